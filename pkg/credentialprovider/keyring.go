@@ -42,12 +42,20 @@ type DockerKeyring interface {
 type BasicDockerKeyring struct {
 	index []string
 	creds map[string][]AuthConfig
+
+	// Source optionally names where the credentials came from, for use in
+	// resolution traces. When empty, "dockercfg keyring" is used.
+	Source string
 }
 
 // providersDockerKeyring is an implementation of DockerKeyring that
 // materializes its dockercfg based on a set of dockerConfigProviders.
 type providersDockerKeyring struct {
 	Providers []DockerConfigProvider
+
+	// Names holds the registered name of each provider, index-aligned with
+	// Providers. It may be empty; it is only used in resolution traces.
+	Names []string
 }
 
 // AuthConfig contains authorization information for connecting to a Registry
@@ -236,12 +244,24 @@ func URLsMatch(globURL *url.URL, targetURL *url.URL) (bool, error) {
 // Multiple credentials may be returned if there are multiple potentially valid credentials
 // available.  This allows for rotation.
 func (dk *BasicDockerKeyring) Lookup(image string) ([]AuthConfig, bool) {
+	return dk.LookupWithTrace(image, nil)
+}
+
+// LookupWithTrace implements the TracingKeyring method, recording the
+// patterns that matched the image on trace.
+func (dk *BasicDockerKeyring) LookupWithTrace(image string, trace *ResolutionTrace) ([]AuthConfig, bool) {
+	source := dk.Source
+	if source == "" {
+		source = "dockercfg keyring"
+	}
+
 	// range over the index as iterating over a map does not provide a predictable ordering
 	ret := []AuthConfig{}
 	for _, k := range dk.index {
 		// both k and image are schemeless URLs because even though schemes are allowed
 		// in the credential configurations, we remove them in Add.
 		if matched, _ := URLsMatchStr(k, image); matched {
+			trace.addStep(TraceStep{Source: source, MatchPattern: k, Matched: true})
 			ret = append(ret, dk.creds[k]...)
 		}
 	}
@@ -253,10 +273,12 @@ func (dk *BasicDockerKeyring) Lookup(image string) ([]AuthConfig, bool) {
 	// Use credentials for the default registry if provided, and appropriate
 	if isDefaultRegistryMatch(image) {
 		if auth, ok := dk.creds[defaultRegistryHost]; ok {
+			trace.addStep(TraceStep{Source: source, MatchPattern: defaultRegistryHost, Matched: true})
 			return auth, true
 		}
 	}
 
+	trace.addStep(TraceStep{Source: source, Matched: false})
 	return []AuthConfig{}, false
 }
 
@@ -272,6 +294,44 @@ func (dk *providersDockerKeyring) Lookup(image string) ([]AuthConfig, bool) {
 	return keyring.Lookup(image)
 }
 
+// LookupWithTrace implements the TracingKeyring method. The returned
+// credentials match Lookup; additionally one step per provider is recorded
+// on trace, including whether the provider's answer was served from a
+// cache.
+func (dk *providersDockerKeyring) LookupWithTrace(image string, trace *ResolutionTrace) ([]AuthConfig, bool) {
+	merged := &BasicDockerKeyring{}
+
+	for i, p := range dk.Providers {
+		source := "provider"
+		if i < len(dk.Names) {
+			source = dk.Names[i]
+		}
+
+		var cfg DockerConfig
+		var cached bool
+		if tracer, ok := p.(TracingDockerConfigProvider); ok {
+			cfg, cached = tracer.ProvideTraced(image)
+		} else {
+			cfg = p.Provide(image)
+		}
+		merged.Add(cfg)
+
+		// Record whether this provider on its own matches the image; the
+		// returned credentials still come from the merged keyring so the
+		// traced lookup behaves exactly like Lookup.
+		providerKeyring := &BasicDockerKeyring{Source: source}
+		providerKeyring.Add(cfg)
+		providerTrace := &ResolutionTrace{}
+		providerKeyring.LookupWithTrace(image, providerTrace)
+		for _, step := range providerTrace.Steps {
+			step.ServedFromCache = cached
+			trace.addStep(step)
+		}
+	}
+
+	return merged.Lookup(image)
+}
+
 // FakeKeyring a fake config credentials
 type FakeKeyring struct {
 	auth []AuthConfig
@@ -290,13 +350,26 @@ type UnionDockerKeyring []DockerKeyring
 // Lookup implements the DockerKeyring method for fetching credentials based on image name.
 // return each credentials
 func (k UnionDockerKeyring) Lookup(image string) ([]AuthConfig, bool) {
+	return k.LookupWithTrace(image, nil)
+}
+
+// LookupWithTrace implements the TracingKeyring method, delegating to each
+// sub-keyring in order and recording their sources on trace.
+func (k UnionDockerKeyring) LookupWithTrace(image string, trace *ResolutionTrace) ([]AuthConfig, bool) {
 	authConfigs := []AuthConfig{}
 	for _, subKeyring := range k {
 		if subKeyring == nil {
 			continue
 		}
 
-		currAuthResults, _ := subKeyring.Lookup(image)
+		var currAuthResults []AuthConfig
+		if tracer, ok := subKeyring.(TracingKeyring); ok {
+			currAuthResults, _ = tracer.LookupWithTrace(image, trace)
+		} else {
+			var found bool
+			currAuthResults, found = subKeyring.Lookup(image)
+			trace.addStep(TraceStep{Source: "keyring", Matched: found})
+		}
 		authConfigs = append(authConfigs, currAuthResults...)
 	}
 
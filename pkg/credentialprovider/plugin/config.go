@@ -123,6 +123,18 @@ func validateCredentialProviderConfig(config *kubeletconfig.CredentialProviderCo
 		if provider.DefaultCacheDuration != nil && provider.DefaultCacheDuration.Duration < 0 {
 			allErrs = append(allErrs, field.Invalid(fieldPath.Child("defaultCacheDuration"), provider.DefaultCacheDuration.Duration, "defaultCacheDuration must be greater than or equal to 0"))
 		}
+
+		if provider.MaxConcurrentExecutions != nil && *provider.MaxConcurrentExecutions <= 0 {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("maxConcurrentExecutions"), *provider.MaxConcurrentExecutions, "maxConcurrentExecutions must be greater than 0"))
+		}
+
+		if provider.CacheDurationOverride != nil && provider.CacheDurationOverride.Duration < 0 {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("cacheDurationOverride"), provider.CacheDurationOverride.Duration, "cacheDurationOverride must be greater than or equal to 0"))
+		}
+
+		if provider.StaleCredentialsWindow != nil && provider.StaleCredentialsWindow.Duration < 0 {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("staleCredentialsWindow"), provider.StaleCredentialsWindow.Duration, "staleCredentialsWindow must be greater than or equal to 0"))
+		}
 	}
 
 	return allErrs
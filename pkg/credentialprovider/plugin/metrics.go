@@ -50,6 +50,67 @@ var (
 		},
 		[]string{"plugin_name"},
 	)
+
+	kubeletCredentialProviderPluginQueueDepth = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      KubeletSubsystem,
+			Name:           "credential_provider_plugin_queue_depth",
+			Help:           "Number of credential provider plugin invocations waiting for an execution slot",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"plugin_name"},
+	)
+
+	kubeletCredentialProviderConfigReloads = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      KubeletSubsystem,
+			Name:           "credential_provider_config_reloads",
+			Help:           "Number of credential provider config reloads, partitioned by result",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	kubeletCredentialProviderPluginWaitDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      KubeletSubsystem,
+			Name:           "credential_provider_plugin_wait_duration",
+			Help:           "Duration in seconds credential provider plugin invocations waited for an execution slot",
+			Buckets:        metrics.DefBuckets,
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"plugin_name"},
+	)
+
+	kubeletCredentialProviderCacheHits = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      KubeletSubsystem,
+			Name:           "credential_provider_cache_hits",
+			Help:           "Number of credential provider invocations served from the in-memory credential cache",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"plugin_name"},
+	)
+
+	kubeletCredentialProviderCacheMisses = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      KubeletSubsystem,
+			Name:           "credential_provider_cache_misses",
+			Help:           "Number of credential provider invocations that missed the in-memory credential cache and invoked the plugin",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"plugin_name"},
+	)
+
+	kubeletCredentialProviderStaleCredentials = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      KubeletSubsystem,
+			Name:           "credential_provider_stale_credentials",
+			Help:           "Number of credential provider invocations that served expired cached credentials because the plugin was failing",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"plugin_name"},
+	)
 )
 
 // registerMetrics registers credential provider metrics.
@@ -57,5 +118,11 @@ func registerMetrics() {
 	registerOnce.Do(func() {
 		legacyregistry.MustRegister(kubeletCredentialProviderPluginErrors)
 		legacyregistry.MustRegister(kubeletCredentialProviderPluginDuration)
+		legacyregistry.MustRegister(kubeletCredentialProviderPluginQueueDepth)
+		legacyregistry.MustRegister(kubeletCredentialProviderPluginWaitDuration)
+		legacyregistry.MustRegister(kubeletCredentialProviderConfigReloads)
+		legacyregistry.MustRegister(kubeletCredentialProviderCacheHits)
+		legacyregistry.MustRegister(kubeletCredentialProviderCacheMisses)
+		legacyregistry.MustRegister(kubeletCredentialProviderStaleCredentials)
 	})
 }
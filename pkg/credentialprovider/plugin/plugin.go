@@ -24,6 +24,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -52,6 +53,10 @@ import (
 const (
 	globalCacheKey     = "global"
 	cachePurgeInterval = time.Minute * 15
+
+	// defaultMaxConcurrentExecutions bounds the number of plugin processes run
+	// concurrently for a provider that does not set maxConcurrentExecutions.
+	defaultMaxConcurrentExecutions = 10
 )
 
 var (
@@ -84,40 +89,60 @@ func RegisterCredentialProviderPlugins(pluginConfigFile, pluginBinDir string) er
 		return fmt.Errorf("error inspecting binary directory %s: %w", pluginBinDir, err)
 	}
 
-	credentialProviderConfig, err := readCredentialProviderConfigFile(pluginConfigFile)
+	providers, names, err := loadPluginProviders(pluginConfigFile, pluginBinDir)
 	if err != nil {
 		return err
 	}
 
+	// Register metrics for credential providers
+	registerMetrics()
+
+	for _, name := range names {
+		credentialprovider.RegisterCredentialProvider(name, providers[name])
+	}
+
+	return nil
+}
+
+// loadPluginProviders reads and validates the credential provider config file
+// and builds a pluginProvider for every configured provider. It returns the
+// providers keyed by name together with the sorted name list.
+func loadPluginProviders(pluginConfigFile, pluginBinDir string) (map[string]*pluginProvider, []string, error) {
+	credentialProviderConfig, err := readCredentialProviderConfigFile(pluginConfigFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	errs := validateCredentialProviderConfig(credentialProviderConfig)
 	if len(errs) > 0 {
-		return fmt.Errorf("failed to validate credential provider config: %v", errs.ToAggregate())
+		return nil, nil, fmt.Errorf("failed to validate credential provider config: %v", errs.ToAggregate())
 	}
 
-	// Register metrics for credential providers
-	registerMetrics()
-
+	providers := make(map[string]*pluginProvider, len(credentialProviderConfig.Providers))
+	names := make([]string, 0, len(credentialProviderConfig.Providers))
 	for _, provider := range credentialProviderConfig.Providers {
 		// Considering Windows binary with suffix ".exe", LookPath() helps to find the correct path.
 		// LookPath() also calls os.Stat().
 		pluginBin, err := exec.LookPath(filepath.Join(pluginBinDir, provider.Name))
 		if err != nil {
 			if errors.Is(err, os.ErrNotExist) || errors.Is(err, exec.ErrNotFound) {
-				return fmt.Errorf("plugin binary executable %s did not exist", pluginBin)
+				return nil, nil, fmt.Errorf("plugin binary executable %s did not exist", pluginBin)
 			}
 
-			return fmt.Errorf("error inspecting binary executable %s: %w", pluginBin, err)
+			return nil, nil, fmt.Errorf("error inspecting binary executable %s: %w", pluginBin, err)
 		}
 
 		plugin, err := newPluginProvider(pluginBinDir, provider)
 		if err != nil {
-			return fmt.Errorf("error initializing plugin provider %s: %w", provider.Name, err)
+			return nil, nil, fmt.Errorf("error initializing plugin provider %s: %w", provider.Name, err)
 		}
 
-		credentialprovider.RegisterCredentialProvider(provider.Name, plugin)
+		providers[provider.Name] = plugin
+		names = append(names, provider.Name)
 	}
+	sort.Strings(names)
 
-	return nil
+	return providers, names, nil
 }
 
 // newPluginProvider returns a new pluginProvider based on the credential provider config.
@@ -135,12 +160,31 @@ func newPluginProvider(pluginBinDir string, provider kubeletconfig.CredentialPro
 
 	clock := clock.RealClock{}
 
+	maxConcurrent := int32(defaultMaxConcurrentExecutions)
+	if provider.MaxConcurrentExecutions != nil {
+		maxConcurrent = *provider.MaxConcurrentExecutions
+	}
+
+	var cacheDurationOverride *time.Duration
+	if provider.CacheDurationOverride != nil {
+		duration := provider.CacheDurationOverride.Duration
+		cacheDurationOverride = &duration
+	}
+
+	var staleCredentialsWindow time.Duration
+	if provider.StaleCredentialsWindow != nil {
+		staleCredentialsWindow = provider.StaleCredentialsWindow.Duration
+	}
+
 	return &pluginProvider{
-		clock:                clock,
-		matchImages:          provider.MatchImages,
-		cache:                cache.NewExpirationStore(cacheKeyFunc, &cacheExpirationPolicy{clock: clock}),
-		defaultCacheDuration: provider.DefaultCacheDuration.Duration,
-		lastCachePurge:       clock.Now(),
+		name:                   provider.Name,
+		clock:                  clock,
+		matchImages:            provider.MatchImages,
+		cache:                  cache.NewExpirationStore(cacheKeyFunc, &cacheExpirationPolicy{clock: clock, staleWindow: staleCredentialsWindow}),
+		defaultCacheDuration:   provider.DefaultCacheDuration.Duration,
+		cacheDurationOverride:  cacheDurationOverride,
+		staleCredentialsWindow: staleCredentialsWindow,
+		lastCachePurge:         clock.Now(),
 		plugin: &execPlugin{
 			name:         provider.Name,
 			apiVersion:   provider.APIVersion,
@@ -149,12 +193,16 @@ func newPluginProvider(pluginBinDir string, provider kubeletconfig.CredentialPro
 			args:         provider.Args,
 			envVars:      provider.Env,
 			environ:      os.Environ,
+			limiter:      newExecutionLimiter(provider.Name, maxConcurrent),
 		},
 	}, nil
 }
 
 // pluginProvider is the plugin-based implementation of the DockerConfigProvider interface.
 type pluginProvider struct {
+	// name is the provider name, used as the plugin_name metrics label.
+	name string
+
 	clock clock.Clock
 
 	sync.Mutex
@@ -172,6 +220,12 @@ type pluginProvider struct {
 	// defaultCacheDuration is the default duration credentials are cached in-memory if the auth plugin
 	// response did not provide a cache duration for credentials.
 	defaultCacheDuration time.Duration
+	// cacheDurationOverride, when non-nil, wins over both the plugin-returned cache
+	// duration and defaultCacheDuration. A zero override disables caching.
+	cacheDurationOverride *time.Duration
+	// staleCredentialsWindow is how long expired cache entries may still be served
+	// when the plugin binary is failing. Zero disables serving stale credentials.
+	staleCredentialsWindow time.Duration
 
 	// plugin is the exec implementation of the credential providing plugin.
 	plugin Plugin
@@ -196,30 +250,44 @@ func cacheKeyFunc(obj interface{}) (string, error) {
 // cacheExpirationPolicy defines implements cache.ExpirationPolicy, determining expiration based on the expiresAt timestamp.
 type cacheExpirationPolicy struct {
 	clock clock.Clock
+	// staleWindow retains entries past expiresAt for this long, so they can
+	// still be served as stale credentials while the plugin is failing.
+	staleWindow time.Duration
 }
 
-// IsExpired returns true if the current time is after cacheEntry.expiresAt, which is determined by the
-// cache duration returned from the credential provider plugin response.
+// IsExpired returns true if the current time is after cacheEntry.expiresAt plus the
+// stale-credentials window. expiresAt is determined by the cache duration returned
+// from the credential provider plugin response unless overridden by the operator.
 func (c *cacheExpirationPolicy) IsExpired(entry *cache.TimestampedEntry) bool {
-	return c.clock.Now().After(entry.Obj.(*cacheEntry).expiresAt)
+	return c.clock.Now().After(entry.Obj.(*cacheEntry).expiresAt.Add(c.staleWindow))
 }
 
 // Provide returns a credentialprovider.DockerConfig based on the credentials returned
 // from cache or the exec plugin.
 func (p *pluginProvider) Provide(image string) credentialprovider.DockerConfig {
+	config, _ := p.ProvideTraced(image)
+	return config
+}
+
+// ProvideTraced implements credentialprovider.TracingDockerConfigProvider,
+// additionally returning whether the credentials were served from the
+// plugin cache instead of a fresh plugin invocation.
+func (p *pluginProvider) ProvideTraced(image string) (credentialprovider.DockerConfig, bool) {
 	if !p.isImageAllowed(image) {
-		return credentialprovider.DockerConfig{}
+		return credentialprovider.DockerConfig{}, false
 	}
 
 	cachedConfig, found, err := p.getCachedCredentials(image)
 	if err != nil {
 		klog.Errorf("Failed to get cached docker config: %v", err)
-		return credentialprovider.DockerConfig{}
+		return credentialprovider.DockerConfig{}, false
 	}
 
 	if found {
-		return cachedConfig
+		kubeletCredentialProviderCacheHits.WithLabelValues(p.name).Inc()
+		return cachedConfig, true
 	}
+	kubeletCredentialProviderCacheMisses.WithLabelValues(p.name).Inc()
 
 	// ExecPlugin is wrapped in single flight to exec plugin once for concurrent same image request.
 	// The caveat here is we don't know cacheKeyType yet, so if cacheKeyType is registry/global and credentials saved in cache
@@ -233,13 +301,18 @@ func (p *pluginProvider) Provide(image string) credentialprovider.DockerConfig {
 
 	if err != nil {
 		klog.Errorf("Failed getting credential from external registry credential provider: %v", err)
-		return credentialprovider.DockerConfig{}
+		if staleConfig, ok := p.getStaleCredentials(image); ok {
+			kubeletCredentialProviderStaleCredentials.WithLabelValues(p.name).Inc()
+			klog.Warningf("Serving stale cached credentials for image %s because credential provider plugin %s is failing", image, p.name)
+			return staleConfig, true
+		}
+		return credentialprovider.DockerConfig{}, false
 	}
 
 	response, ok := res.(*credentialproviderapi.CredentialProviderResponse)
 	if !ok {
 		klog.Errorf("Invalid response type returned by external credential provider")
-		return credentialprovider.DockerConfig{}
+		return credentialprovider.DockerConfig{}, false
 	}
 
 	var cacheKey string
@@ -253,7 +326,7 @@ func (p *pluginProvider) Provide(image string) credentialprovider.DockerConfig {
 		cacheKey = globalCacheKey
 	default:
 		klog.Errorf("credential provider plugin did not return a valid cacheKeyType: %q", cacheKeyType)
-		return credentialprovider.DockerConfig{}
+		return credentialprovider.DockerConfig{}, false
 	}
 
 	dockerConfig := make(credentialprovider.DockerConfig, len(response.Auth))
@@ -264,21 +337,22 @@ func (p *pluginProvider) Provide(image string) credentialprovider.DockerConfig {
 		}
 	}
 
-	// cache duration was explicitly 0 so don't cache this response at all.
-	if response.CacheDuration != nil && response.CacheDuration.Duration == 0 {
-		return dockerConfig
+	// The operator's cacheDurationOverride wins over the plugin-returned cache
+	// duration, which in turn wins over defaultCacheDuration.
+	var cacheDuration time.Duration
+	switch {
+	case p.cacheDurationOverride != nil:
+		cacheDuration = *p.cacheDurationOverride
+	case response.CacheDuration != nil:
+		cacheDuration = response.CacheDuration.Duration
+	default:
+		cacheDuration = p.defaultCacheDuration
 	}
-
-	var expiresAt time.Time
-	// nil cache duration means use the default cache duration
-	if response.CacheDuration == nil {
-		if p.defaultCacheDuration == 0 {
-			return dockerConfig
-		}
-		expiresAt = p.clock.Now().Add(p.defaultCacheDuration)
-	} else {
-		expiresAt = p.clock.Now().Add(response.CacheDuration.Duration)
+	// cache duration of 0 means don't cache this response at all.
+	if cacheDuration == 0 {
+		return dockerConfig, false
 	}
+	expiresAt := p.clock.Now().Add(cacheDuration)
 
 	cachedEntry := &cacheEntry{
 		key:         cacheKey,
@@ -290,7 +364,7 @@ func (p *pluginProvider) Provide(image string) credentialprovider.DockerConfig {
 		klog.Errorf("Error adding auth entry to cache: %v", err)
 	}
 
-	return dockerConfig
+	return dockerConfig, false
 }
 
 // Enabled always returns true since registration of the plugin via kubelet implies it should be enabled.
@@ -309,7 +383,14 @@ func (p *pluginProvider) isImageAllowed(image string) bool {
 	return false
 }
 
-// getCachedCredentials returns a credentialprovider.DockerConfig if cached from the plugin.
+// cacheKeysForImage returns the cache keys an image's credentials may be
+// stored under, from most to least specific.
+func cacheKeysForImage(image string) []string {
+	return []string{image, parseRegistry(image), globalCacheKey}
+}
+
+// getCachedCredentials returns a credentialprovider.DockerConfig if cached from the plugin
+// and still within its cache duration.
 func (p *pluginProvider) getCachedCredentials(image string) (credentialprovider.DockerConfig, bool, error) {
 	p.Lock()
 	if p.clock.Now().After(p.lastCachePurge.Add(cachePurgeInterval)) {
@@ -321,35 +402,44 @@ func (p *pluginProvider) getCachedCredentials(image string) (credentialprovider.
 	}
 	p.Unlock()
 
-	obj, found, err := p.cache.GetByKey(image)
-	if err != nil {
-		return nil, false, err
-	}
-
-	if found {
-		return obj.(*cacheEntry).credentials, true, nil
-	}
-
-	registry := parseRegistry(image)
-	obj, found, err = p.cache.GetByKey(registry)
-	if err != nil {
-		return nil, false, err
-	}
-
-	if found {
-		return obj.(*cacheEntry).credentials, true, nil
+	for _, key := range cacheKeysForImage(image) {
+		obj, found, err := p.cache.GetByKey(key)
+		if err != nil {
+			return nil, false, err
+		}
+		if !found {
+			continue
+		}
+		entry := obj.(*cacheEntry)
+		if p.clock.Now().After(entry.expiresAt) {
+			// Expired, but retained for the stale-credentials window; only
+			// getStaleCredentials may serve it.
+			continue
+		}
+		return entry.credentials, true, nil
 	}
 
-	obj, found, err = p.cache.GetByKey(globalCacheKey)
-	if err != nil {
-		return nil, false, err
-	}
+	return nil, false, nil
+}
 
-	if found {
-		return obj.(*cacheEntry).credentials, true, nil
+// getStaleCredentials returns expired cached credentials that are still within the
+// provider's stale-credentials window. It is the fallback when the plugin binary is
+// failing, so recently working credentials keep image pulls going through a
+// temporary plugin outage.
+func (p *pluginProvider) getStaleCredentials(image string) (credentialprovider.DockerConfig, bool) {
+	if p.staleCredentialsWindow == 0 {
+		return nil, false
+	}
+	for _, key := range cacheKeysForImage(image) {
+		obj, found, err := p.cache.GetByKey(key)
+		if err != nil || !found {
+			continue
+		}
+		// The expiration policy evicts entries once they are past expiresAt
+		// plus the stale window, so any entry still present may be served.
+		return obj.(*cacheEntry).credentials, true
 	}
-
-	return nil, false, nil
+	return nil, false
 }
 
 // Plugin is the interface calling ExecPlugin. This is mainly for testability
@@ -369,6 +459,50 @@ type execPlugin struct {
 	envVars      []kubeletconfig.ExecEnvVar
 	pluginBinDir string
 	environ      func() []string
+	limiter      *executionLimiter
+}
+
+// executionLimiter bounds the number of concurrently executing plugin
+// processes for a provider. Callers that exceed the limit wait on a buffered
+// channel, whose blocked senders are released in FIFO order by the runtime.
+type executionLimiter struct {
+	pluginName string
+	sem        chan struct{}
+}
+
+func newExecutionLimiter(pluginName string, maxConcurrent int32) *executionLimiter {
+	return &executionLimiter{
+		pluginName: pluginName,
+		sem:        make(chan struct{}, maxConcurrent),
+	}
+}
+
+// acquire blocks until an execution slot is free or ctx is cancelled. Queue
+// depth and wait time are only recorded for callers that actually had to wait.
+func (l *executionLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	default:
+	}
+
+	startTime := time.Now()
+	kubeletCredentialProviderPluginQueueDepth.WithLabelValues(l.pluginName).Inc()
+	defer func() {
+		kubeletCredentialProviderPluginQueueDepth.WithLabelValues(l.pluginName).Dec()
+		kubeletCredentialProviderPluginWaitDuration.WithLabelValues(l.pluginName).Observe(time.Since(startTime).Seconds())
+	}()
+
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *executionLimiter) release() {
+	<-l.sem
 }
 
 // ExecPlugin executes the plugin binary with arguments and environment variables specified in CredentialProviderConfig:
@@ -380,6 +514,13 @@ type execPlugin struct {
 func (e *execPlugin) ExecPlugin(ctx context.Context, image string) (*credentialproviderapi.CredentialProviderResponse, error) {
 	klog.V(5).Infof("Getting image %s credentials from external exec plugin %s", image, e.name)
 
+	if e.limiter != nil {
+		if err := e.limiter.acquire(ctx); err != nil {
+			return nil, fmt.Errorf("waiting for execution slot for credential provider plugin %s for image %s: %w", e.name, image, err)
+		}
+		defer e.limiter.release()
+	}
+
 	authRequest := &credentialproviderapi.CredentialProviderRequest{Image: image}
 	data, err := e.encodeRequest(authRequest)
 	if err != nil {
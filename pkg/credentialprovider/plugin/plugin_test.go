@@ -18,9 +18,11 @@ package plugin
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -879,3 +881,283 @@ func validate(expected, actual []string) error {
 
 	return nil
 }
+
+// slowFakeExecPlugin counts invocations and holds each one until released,
+// simulating a plugin binary that takes a long time to respond.
+type slowFakeExecPlugin struct {
+	calls   int32
+	release chan struct{}
+}
+
+func (f *slowFakeExecPlugin) ExecPlugin(ctx context.Context, image string) (*credentialproviderapi.CredentialProviderResponse, error) {
+	atomic.AddInt32(&f.calls, 1)
+	select {
+	case <-f.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &credentialproviderapi.CredentialProviderResponse{
+		CacheKeyType: credentialproviderapi.RegistryPluginCacheKeyType,
+		CacheDuration: &metav1.Duration{
+			Duration: time.Minute,
+		},
+		Auth: map[string]credentialproviderapi.AuthConfig{
+			"test.registry.io": {
+				Username: "user",
+				Password: "password",
+			},
+		},
+	}, nil
+}
+
+func Test_ExecutionLimiterCapsConcurrency(t *testing.T) {
+	const maxConcurrent = 2
+	const callers = 10
+
+	limiter := newExecutionLimiter("test-plugin", maxConcurrent)
+
+	var inFlight, maxInFlight int32
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			if err := limiter.acquire(context.Background()); err != nil {
+				t.Errorf("unexpected acquire error: %v", err)
+				return
+			}
+			defer limiter.release()
+
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+					break
+				}
+			}
+			// Hold the slot long enough for the other callers to queue up.
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&maxInFlight); got > maxConcurrent {
+		t.Errorf("expected at most %d concurrent executions, observed %d", maxConcurrent, got)
+	}
+}
+
+func Test_ExecutionLimiterCancelledWhileQueued(t *testing.T) {
+	limiter := newExecutionLimiter("test-plugin", 1)
+
+	if err := limiter.acquire(context.Background()); err != nil {
+		t.Fatalf("unexpected acquire error: %v", err)
+	}
+	defer limiter.release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() {
+		errCh <- limiter.acquire(ctx)
+	}()
+	cancel()
+
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from queued acquire, got %v", err)
+	}
+}
+
+func Test_ProvideCoalescesConcurrentRequests(t *testing.T) {
+	tclock := clock.RealClock{}
+	slowPlugin := &slowFakeExecPlugin{release: make(chan struct{})}
+
+	pluginProvider := &pluginProvider{
+		clock:          tclock,
+		lastCachePurge: tclock.Now(),
+		matchImages:    []string{"test.registry.io"},
+		cache:          cache.NewExpirationStore(cacheKeyFunc, &cacheExpirationPolicy{clock: tclock}),
+		plugin:         slowPlugin,
+	}
+
+	const callers = 5
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			pluginProvider.Provide("test.registry.io/image")
+		}()
+	}
+
+	// Give the concurrent callers time to reach the singleflight group before
+	// releasing the in-flight plugin execution.
+	time.Sleep(100 * time.Millisecond)
+	close(slowPlugin.release)
+	wg.Wait()
+
+	if calls := atomic.LoadInt32(&slowPlugin.calls); calls != 1 {
+		t.Errorf("expected concurrent requests for the same image to coalesce into 1 plugin execution, got %d", calls)
+	}
+}
+
+// failingExecPlugin simulates a credential provider binary that is
+// temporarily broken.
+type failingExecPlugin struct{}
+
+func (f *failingExecPlugin) ExecPlugin(ctx context.Context, image string) (*credentialproviderapi.CredentialProviderResponse, error) {
+	return nil, errors.New("plugin is failing")
+}
+
+func Test_CacheDurationOverride(t *testing.T) {
+	testcases := []struct {
+		name              string
+		pluginDuration    time.Duration
+		override          time.Duration
+		expectedCacheKeys []string
+	}{
+		{
+			name:              "override wins over an uncacheable plugin response",
+			pluginDuration:    0,
+			override:          time.Hour,
+			expectedCacheKeys: []string{"test.registry.io"},
+		},
+		{
+			name:              "zero override disables caching",
+			pluginDuration:    time.Hour,
+			override:          0,
+			expectedCacheKeys: []string{},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			tclock := clock.RealClock{}
+			override := testcase.override
+			pluginProvider := &pluginProvider{
+				clock:                 tclock,
+				lastCachePurge:        tclock.Now(),
+				matchImages:           []string{"test.registry.io"},
+				cacheDurationOverride: &override,
+				cache:                 cache.NewExpirationStore(cacheKeyFunc, &cacheExpirationPolicy{clock: tclock}),
+				plugin: &fakeExecPlugin{
+					cacheKeyType:  credentialproviderapi.RegistryPluginCacheKeyType,
+					cacheDuration: testcase.pluginDuration,
+					auth: map[string]credentialproviderapi.AuthConfig{
+						"test.registry.io": {
+							Username: "user",
+							Password: "password",
+						},
+					},
+				},
+			}
+
+			expectedDockerConfig := credentialprovider.DockerConfig{
+				"test.registry.io": credentialprovider.DockerConfigEntry{
+					Username: "user",
+					Password: "password",
+				},
+			}
+
+			dockerConfig := pluginProvider.Provide("test.registry.io/foo/bar")
+			if !reflect.DeepEqual(dockerConfig, expectedDockerConfig) {
+				t.Logf("actual docker config: %v", dockerConfig)
+				t.Logf("expected docker config: %v", expectedDockerConfig)
+				t.Fatal("unexpected docker config")
+			}
+
+			cacheKeys := pluginProvider.cache.ListKeys()
+			if !reflect.DeepEqual(cacheKeys, testcase.expectedCacheKeys) {
+				t.Logf("actual cache keys: %v", cacheKeys)
+				t.Logf("expected cache keys: %v", testcase.expectedCacheKeys)
+				t.Error("unexpected cache keys")
+			}
+		})
+	}
+}
+
+func Test_StaleCredentialsFallback(t *testing.T) {
+	tclock := testingclock.NewFakeClock(time.Now())
+	pluginProvider := &pluginProvider{
+		clock:                  tclock,
+		lastCachePurge:         tclock.Now(),
+		matchImages:            []string{"test.registry.io"},
+		staleCredentialsWindow: time.Hour,
+		cache:                  cache.NewExpirationStore(cacheKeyFunc, &cacheExpirationPolicy{clock: tclock, staleWindow: time.Hour}),
+		plugin: &fakeExecPlugin{
+			cacheKeyType:  credentialproviderapi.RegistryPluginCacheKeyType,
+			cacheDuration: time.Minute,
+			auth: map[string]credentialproviderapi.AuthConfig{
+				"test.registry.io": {
+					Username: "user",
+					Password: "password",
+				},
+			},
+		},
+	}
+
+	expectedDockerConfig := credentialprovider.DockerConfig{
+		"test.registry.io": credentialprovider.DockerConfigEntry{
+			Username: "user",
+			Password: "password",
+		},
+	}
+
+	// Prime the cache while the plugin is healthy.
+	dockerConfig := pluginProvider.Provide("test.registry.io/foo/bar")
+	if !reflect.DeepEqual(dockerConfig, expectedDockerConfig) {
+		t.Logf("actual docker config: %v", dockerConfig)
+		t.Logf("expected docker config: %v", expectedDockerConfig)
+		t.Fatal("unexpected docker config")
+	}
+
+	// The plugin starts failing after the credentials expired but within the
+	// stale-credentials window: the stale credentials are served.
+	pluginProvider.plugin = &failingExecPlugin{}
+	tclock.Step(30 * time.Minute)
+	dockerConfig, fromCache := pluginProvider.ProvideTraced("test.registry.io/foo/bar")
+	if !fromCache {
+		t.Error("expected stale credentials to be reported as served from cache")
+	}
+	if !reflect.DeepEqual(dockerConfig, expectedDockerConfig) {
+		t.Logf("actual docker config: %v", dockerConfig)
+		t.Logf("expected docker config: %v", expectedDockerConfig)
+		t.Fatal("expected the stale credentials to be served while the plugin is failing")
+	}
+
+	// Past the stale-credentials window nothing can be served.
+	tclock.Step(2 * time.Hour)
+	dockerConfig = pluginProvider.Provide("test.registry.io/foo/bar")
+	if len(dockerConfig) != 0 {
+		t.Fatalf("expected no credentials past the stale-credentials window, got %v", dockerConfig)
+	}
+}
+
+func Test_StaleCredentialsDisabledByDefault(t *testing.T) {
+	tclock := testingclock.NewFakeClock(time.Now())
+	pluginProvider := &pluginProvider{
+		clock:          tclock,
+		lastCachePurge: tclock.Now(),
+		matchImages:    []string{"test.registry.io"},
+		cache:          cache.NewExpirationStore(cacheKeyFunc, &cacheExpirationPolicy{clock: tclock}),
+		plugin: &fakeExecPlugin{
+			cacheKeyType:  credentialproviderapi.RegistryPluginCacheKeyType,
+			cacheDuration: time.Minute,
+			auth: map[string]credentialproviderapi.AuthConfig{
+				"test.registry.io": {
+					Username: "user",
+					Password: "password",
+				},
+			},
+		},
+	}
+
+	if dockerConfig := pluginProvider.Provide("test.registry.io/foo/bar"); len(dockerConfig) == 0 {
+		t.Fatal("expected credentials from the plugin")
+	}
+
+	pluginProvider.plugin = &failingExecPlugin{}
+	tclock.Step(30 * time.Minute)
+	if dockerConfig := pluginProvider.Provide("test.registry.io/foo/bar"); len(dockerConfig) != 0 {
+		t.Fatalf("expected no credentials with stale serving disabled, got %v", dockerConfig)
+	}
+}
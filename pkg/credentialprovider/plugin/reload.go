@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/credentialprovider"
+)
+
+// reloadingProviderName is the keyring name the reloading plugin registry is
+// registered under. All plugin providers sit behind this single entry so a
+// config reload can add or remove providers without re-registering anything.
+const reloadingProviderName = "credential-provider-plugins"
+
+// RegisterCredentialProviderPluginsWithReload registers external credential
+// provider plugins like RegisterCredentialProviderPlugins, and additionally
+// keeps watching the config file: when it changes, the new config is loaded
+// and replaces the active providers, so new plugins or changed matchImages
+// rules take effect without a kubelet restart. If a reload fails the
+// previously loaded config stays active.
+func RegisterCredentialProviderPluginsWithReload(pluginConfigFile, pluginBinDir string) error {
+	if _, err := os.Stat(pluginBinDir); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("plugin binary directory %s did not exist", pluginBinDir)
+		}
+
+		return fmt.Errorf("error inspecting binary directory %s: %w", pluginBinDir, err)
+	}
+
+	registry := &pluginRegistry{binDir: pluginBinDir}
+	if err := registry.load(pluginConfigFile); err != nil {
+		return err
+	}
+
+	// Register metrics for credential providers
+	registerMetrics()
+
+	credentialprovider.RegisterCredentialProvider(reloadingProviderName, registry)
+	go registry.watch(pluginConfigFile)
+
+	return nil
+}
+
+// pluginRegistry is a DockerConfigProvider fronting the plugin providers
+// loaded from a credential provider config file. The provider set can be
+// swapped atomically, which is what makes config reload possible behind the
+// kubelet's static keyring.
+type pluginRegistry struct {
+	binDir string
+
+	lock      sync.RWMutex
+	names     []string
+	providers map[string]*pluginProvider
+}
+
+// Enabled always returns true: the registry only exists when a credential
+// provider config was supplied to the kubelet.
+func (r *pluginRegistry) Enabled() bool {
+	return true
+}
+
+// Provide merges the credentials of every active provider matching the
+// image. Providers are consulted in name order so the result is
+// deterministic, matching the keyring's ordering of individually registered
+// providers.
+func (r *pluginRegistry) Provide(image string) credentialprovider.DockerConfig {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	merged := credentialprovider.DockerConfig{}
+	for _, name := range r.names {
+		for host, entry := range r.providers[name].Provide(image) {
+			merged[host] = entry
+		}
+	}
+	return merged
+}
+
+// load reads and validates the config file and swaps the active provider
+// set. On error the registry keeps serving the previously loaded providers.
+func (r *pluginRegistry) load(pluginConfigFile string) error {
+	providers, names, err := loadPluginProviders(pluginConfigFile, r.binDir)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.providers = providers
+	r.names = names
+	return nil
+}
+
+// watch reloads the registry whenever the config file changes. The parent
+// directory is watched rather than the file itself so atomic replacement
+// (write to a temporary file, rename over the config) is observed as well.
+func (r *pluginRegistry) watch(pluginConfigFile string) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		klog.ErrorS(err, "Failed to create credential provider config watcher, config reload disabled", "configFile", pluginConfigFile)
+		return
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(pluginConfigFile)); err != nil {
+		klog.ErrorS(err, "Failed to watch credential provider config directory, config reload disabled", "configFile", pluginConfigFile)
+		return
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(pluginConfigFile) {
+				continue
+			}
+			if event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Rename) == 0 {
+				continue
+			}
+			if err := r.load(pluginConfigFile); err != nil {
+				kubeletCredentialProviderConfigReloads.WithLabelValues("error").Inc()
+				klog.ErrorS(err, "Failed to reload credential provider config, keeping previous config", "configFile", pluginConfigFile)
+				continue
+			}
+			kubeletCredentialProviderConfigReloads.WithLabelValues("success").Inc()
+			klog.InfoS("Reloaded credential provider config", "configFile", pluginConfigFile)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			klog.ErrorS(err, "Credential provider config watcher error", "configFile", pluginConfigFile)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// writeReloadTestConfig writes a CredentialProviderConfig naming the given
+// providers and creates a dummy executable for each of them in binDir.
+func writeReloadTestConfig(t *testing.T, configFile, binDir string, providerNames ...string) {
+	t.Helper()
+	config := "kind: CredentialProviderConfig\napiVersion: kubelet.config.k8s.io/v1\nproviders:\n"
+	for _, name := range providerNames {
+		config += fmt.Sprintf(`  - name: %s
+    matchImages:
+    - "registry.io/%s"
+    defaultCacheDuration: 10m
+    apiVersion: credentialprovider.kubelet.k8s.io/v1
+`, name, name)
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("Unexpected error writing plugin binary: %v", err)
+		}
+	}
+	if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatalf("Unexpected error writing config file: %v", err)
+	}
+}
+
+func (r *pluginRegistry) providerNames() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.names
+}
+
+func Test_PluginRegistryLoad(t *testing.T) {
+	binDir := t.TempDir()
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	registry := &pluginRegistry{binDir: binDir}
+
+	writeReloadTestConfig(t, configFile, binDir, "test")
+	if err := registry.load(configFile); err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+	if expected := []string{"test"}; !reflect.DeepEqual(registry.providerNames(), expected) {
+		t.Fatalf("expected providers %v, got %v", expected, registry.providerNames())
+	}
+
+	// A reload picks up added providers; names are sorted.
+	writeReloadTestConfig(t, configFile, binDir, "second", "test")
+	if err := registry.load(configFile); err != nil {
+		t.Fatalf("Unexpected error reloading config: %v", err)
+	}
+	if expected := []string{"second", "test"}; !reflect.DeepEqual(registry.providerNames(), expected) {
+		t.Fatalf("expected providers %v, got %v", expected, registry.providerNames())
+	}
+
+	// A failed reload keeps the previously loaded providers.
+	if err := os.WriteFile(configFile, []byte("not a credential provider config"), 0644); err != nil {
+		t.Fatalf("Unexpected error writing config file: %v", err)
+	}
+	if err := registry.load(configFile); err == nil {
+		t.Fatal("expected error loading invalid config, got none")
+	}
+	if expected := []string{"second", "test"}; !reflect.DeepEqual(registry.providerNames(), expected) {
+		t.Fatalf("expected providers %v after failed reload, got %v", expected, registry.providerNames())
+	}
+}
+
+func Test_PluginRegistryWatch(t *testing.T) {
+	binDir := t.TempDir()
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	registry := &pluginRegistry{binDir: binDir}
+
+	writeReloadTestConfig(t, configFile, binDir, "test")
+	if err := registry.load(configFile); err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+
+	go registry.watch(configFile)
+
+	// The watcher is established asynchronously, so keep replacing the
+	// config file, the way kubelet config updates typically happen (write a
+	// temporary file and rename it over the config), until the reload is
+	// observed.
+	expected := []string{"second", "test"}
+	err := wait.PollUntilContextTimeout(context.Background(), 100*time.Millisecond, 10*time.Second, true, func(ctx context.Context) (bool, error) {
+		tmpFile := configFile + ".tmp"
+		writeReloadTestConfig(t, tmpFile, binDir, "second", "test")
+		if err := os.Rename(tmpFile, configFile); err != nil {
+			return false, err
+		}
+		return reflect.DeepEqual(registry.providerNames(), expected), nil
+	})
+	if err != nil {
+		t.Fatalf("registry was not reloaded after config change, providers: %v", registry.providerNames())
+	}
+}
@@ -50,6 +50,7 @@ func RegisterCredentialProvider(name string, provider DockerConfigProvider) {
 func NewDockerKeyring() DockerKeyring {
 	keyring := &providersDockerKeyring{
 		Providers: make([]DockerConfigProvider, 0),
+		Names:     make([]string, 0),
 	}
 
 	keys := reflect.ValueOf(providers).MapKeys()
@@ -64,6 +65,7 @@ func NewDockerKeyring() DockerKeyring {
 		if provider.Enabled() {
 			klog.V(4).Infof("Registering credential provider: %v", key)
 			keyring.Providers = append(keyring.Providers, provider)
+			keyring.Names = append(keyring.Names, key)
 		}
 	}
 
@@ -91,12 +91,19 @@ func (d *CachingDockerConfigProvider) Enabled() bool {
 
 // Provide implements dockerConfigProvider
 func (d *CachingDockerConfigProvider) Provide(image string) DockerConfig {
+	config, _ := d.ProvideTraced(image)
+	return config
+}
+
+// ProvideTraced implements TracingDockerConfigProvider, additionally
+// returning whether the configuration was served from the cache.
+func (d *CachingDockerConfigProvider) ProvideTraced(image string) (DockerConfig, bool) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
 	// If the cache hasn't expired, return our cache
 	if time.Now().Before(d.expiration) {
-		return d.cacheDockerConfig
+		return d.cacheDockerConfig, true
 	}
 
 	klog.V(2).Infof("Refreshing cache for provider: %v", reflect.TypeOf(d.Provider).String())
@@ -105,5 +112,5 @@ func (d *CachingDockerConfigProvider) Provide(image string) DockerConfig {
 		d.cacheDockerConfig = config
 		d.expiration = time.Now().Add(d.Lifetime)
 	}
-	return config
+	return config, false
 }
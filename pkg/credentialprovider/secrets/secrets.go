@@ -47,7 +47,7 @@ func MakeDockerKeyring(passedSecrets []v1.Secret, defaultKeyring credentialprovi
 	}
 
 	if len(passedCredentials) > 0 {
-		basicKeyring := &credentialprovider.BasicDockerKeyring{}
+		basicKeyring := &credentialprovider.BasicDockerKeyring{Source: "pull secrets"}
 		for _, currCredentials := range passedCredentials {
 			basicKeyring.Add(currCredentials)
 		}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialprovider
+
+// TraceStep records one credential source consulted while resolving
+// credentials for an image.
+type TraceStep struct {
+	// Source identifies the consulted source, e.g. "pull secrets",
+	// ".dockercfg", or the name of a registered credential provider.
+	Source string `json:"source"`
+	// MatchPattern is the keyring pattern that matched the image. It is
+	// empty when the source did not match.
+	MatchPattern string `json:"matchPattern,omitempty"`
+	// Matched records whether this source produced credentials for the
+	// image.
+	Matched bool `json:"matched"`
+	// ServedFromCache records whether the source answered from a cache
+	// instead of a fresh invocation, e.g. an exec plugin cache hit.
+	ServedFromCache bool `json:"servedFromCache,omitempty"`
+}
+
+// ResolutionTrace is a structured record of one credential resolution, used
+// by the kubelet debug endpoint to explain which credentials would be used
+// for an image. It records sources in the order they were consulted and
+// never contains secret values.
+type ResolutionTrace struct {
+	// Image is the image reference the resolution was performed for.
+	Image string `json:"image"`
+	// Steps lists the consulted sources in order.
+	Steps []TraceStep `json:"steps"`
+	// FinalSource is the first source that produced credentials, or empty
+	// when no source matched.
+	FinalSource string `json:"finalSource,omitempty"`
+	// CredentialsFound records whether any source produced credentials.
+	CredentialsFound bool `json:"credentialsFound"`
+}
+
+// addStep appends a step to the trace. It is nil-safe so the lookup paths
+// can record unconditionally.
+func (t *ResolutionTrace) addStep(step TraceStep) {
+	if t == nil {
+		return
+	}
+	t.Steps = append(t.Steps, step)
+}
+
+// finalize derives FinalSource and CredentialsFound from the recorded
+// steps.
+func (t *ResolutionTrace) finalize() {
+	for _, step := range t.Steps {
+		if step.Matched {
+			t.FinalSource = step.Source
+			t.CredentialsFound = true
+			return
+		}
+	}
+}
+
+// TracingKeyring is implemented by keyrings that can explain how a lookup
+// was resolved. LookupWithTrace behaves like Lookup but additionally
+// records the consulted sources on trace.
+type TracingKeyring interface {
+	DockerKeyring
+	LookupWithTrace(image string, trace *ResolutionTrace) ([]AuthConfig, bool)
+}
+
+// TracingDockerConfigProvider is implemented by providers that can report
+// whether a Provide call was served from a cache rather than a fresh
+// invocation.
+type TracingDockerConfigProvider interface {
+	DockerConfigProvider
+	// ProvideTraced behaves like Provide and additionally returns whether
+	// the configuration was served from the provider's cache.
+	ProvideTraced(image string) (DockerConfig, bool)
+}
+
+// TraceResolution resolves credentials for image through keyring and
+// returns the structured trace of the resolution. Keyrings that do not
+// implement TracingKeyring yield a single untraced step.
+func TraceResolution(keyring DockerKeyring, image string) *ResolutionTrace {
+	trace := &ResolutionTrace{Image: image}
+	if tracer, ok := keyring.(TracingKeyring); ok {
+		tracer.LookupWithTrace(image, trace)
+	} else {
+		_, found := keyring.Lookup(image)
+		trace.addStep(TraceStep{Source: "keyring", Matched: found})
+	}
+	trace.finalize()
+	return trace
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialprovider
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// tracedTestProvider is a DockerConfigProvider with a fixed config and a
+// fixed cache-served answer for ProvideTraced.
+type tracedTestProvider struct {
+	config DockerConfig
+	cached bool
+}
+
+func (p *tracedTestProvider) Enabled() bool { return true }
+
+func (p *tracedTestProvider) Provide(image string) DockerConfig {
+	return p.config
+}
+
+func (p *tracedTestProvider) ProvideTraced(image string) (DockerConfig, bool) {
+	return p.config, p.cached
+}
+
+func TestTraceResolution(t *testing.T) {
+	secretsKeyring := &BasicDockerKeyring{Source: "pull secrets"}
+	secretsKeyring.Add(DockerConfig{
+		"registry.test/private": DockerConfigEntry{Username: "secret-user", Password: "secret-pass"},
+	})
+
+	providerKeyring := &providersDockerKeyring{
+		Providers: []DockerConfigProvider{
+			&tracedTestProvider{
+				config: DockerConfig{"registry.test": {Username: "plugin-user", Password: "plugin-pass"}},
+				cached: true,
+			},
+		},
+		Names: []string{"test-plugin"},
+	}
+
+	for _, tc := range []struct {
+		name          string
+		keyring       DockerKeyring
+		image         string
+		expectedFound bool
+		expectedFinal string
+		// expectedSteps is (source, matched, pattern, cached) per step.
+		expectedSteps []TraceStep
+	}{
+		{
+			name:          "pull secret match wins over provider",
+			keyring:       UnionDockerKeyring{secretsKeyring, providerKeyring},
+			image:         "registry.test/private/app:v1",
+			expectedFound: true,
+			expectedFinal: "pull secrets",
+			expectedSteps: []TraceStep{
+				{Source: "pull secrets", MatchPattern: "registry.test/private", Matched: true},
+				{Source: "test-plugin", MatchPattern: "registry.test", Matched: true, ServedFromCache: true},
+			},
+		},
+		{
+			name:          "provider matches when secrets do not",
+			keyring:       UnionDockerKeyring{secretsKeyring, providerKeyring},
+			image:         "registry.test/public/app:v1",
+			expectedFound: true,
+			expectedFinal: "test-plugin",
+			expectedSteps: []TraceStep{
+				{Source: "pull secrets", Matched: false},
+				{Source: "test-plugin", MatchPattern: "registry.test", Matched: true, ServedFromCache: true},
+			},
+		},
+		{
+			name:          "no source matches",
+			keyring:       UnionDockerKeyring{secretsKeyring, providerKeyring},
+			image:         "other.example.com/app:v1",
+			expectedFound: false,
+			expectedSteps: []TraceStep{
+				{Source: "pull secrets", Matched: false},
+				// ServedFromCache reflects where the provider's answer came
+				// from even when it did not match the image.
+				{Source: "test-plugin", Matched: false, ServedFromCache: true},
+			},
+		},
+		{
+			name:          "keyring without trace support yields generic step",
+			keyring:       &FakeKeyring{auth: []AuthConfig{{Username: "u"}}, ok: true},
+			image:         "registry.test/app:v1",
+			expectedFound: true,
+			expectedFinal: "keyring",
+			expectedSteps: []TraceStep{
+				{Source: "keyring", Matched: true},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			trace := TraceResolution(tc.keyring, tc.image)
+
+			if trace.Image != tc.image {
+				t.Errorf("expected image %q, got %q", tc.image, trace.Image)
+			}
+			if trace.CredentialsFound != tc.expectedFound {
+				t.Errorf("expected credentialsFound=%t, got %t", tc.expectedFound, trace.CredentialsFound)
+			}
+			if trace.FinalSource != tc.expectedFinal {
+				t.Errorf("expected finalSource %q, got %q", tc.expectedFinal, trace.FinalSource)
+			}
+			if len(trace.Steps) != len(tc.expectedSteps) {
+				t.Fatalf("expected %d steps, got %+v", len(tc.expectedSteps), trace.Steps)
+			}
+			for i, expected := range tc.expectedSteps {
+				if trace.Steps[i] != expected {
+					t.Errorf("step %d: expected %+v, got %+v", i, expected, trace.Steps[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTraceNeverContainsSecrets(t *testing.T) {
+	keyring := &BasicDockerKeyring{Source: "pull secrets"}
+	keyring.Add(DockerConfig{
+		"registry.test": DockerConfigEntry{Username: "trace-user", Password: "trace-password"},
+	})
+
+	trace := TraceResolution(keyring, "registry.test/app:v1")
+	data, err := json.Marshal(trace)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, secret := range []string{"trace-user", "trace-password"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("serialized trace contains credential material %q: %s", secret, data)
+		}
+	}
+}
@@ -369,6 +369,51 @@ const (
 	// fallback to using it's cgroupDriver option.
 	KubeletCgroupDriverFromCRI featuregate.Feature = "KubeletCgroupDriverFromCRI"
 
+	// owner: @sig-node
+	// alpha: v1.30
+	//
+	// Enables the kubelet to watch the --image-credential-provider-config
+	// file and apply changes to it without a restart, so credential
+	// provider plugins can be added or their matchImages rules rotated on
+	// a live node.
+	KubeletCredentialProviderReload featuregate.Feature = "KubeletCredentialProviderReload"
+
+	// owner: @sig-node
+	// alpha: v1.30
+	//
+	// Enables a prefetch endpoint on the kubelet server that pulls a list
+	// of images with given credentials ahead of time, so latency-sensitive
+	// workloads find their images already cached when their pods are
+	// scheduled.
+	KubeletImagePrefetch featuregate.Feature = "KubeletImagePrefetch"
+
+	// owner: @sig-node
+	// alpha: v1.30
+	//
+	// Enables the kubelet to verify the credentials available for an image
+	// against the registry with a HEAD request on the manifest before
+	// scheduling the pull, so rejected imagePullSecrets and credential
+	// provider output surface as a distinct InvalidImageCredentials waiting
+	// reason instead of a generic pull failure.
+	KubeletImagePullCredentialVerification featuregate.Feature = "KubeletImagePullCredentialVerification"
+
+	// owner: @sig-node
+	// alpha: v1.30
+	//
+	// Enables the kubelet to maintain a rolling, size-bounded JSON report of
+	// image pull activity under its root directory, so that node agents can
+	// aggregate pull statistics without scraping metrics.
+	KubeletImagePullReport featuregate.Feature = "KubeletImagePullReport"
+
+	// owner: @sig-node
+	// alpha: v1.30
+	//
+	// Enables the kubelet to invoke an external image verifier plugin that
+	// approves or rejects resolved image digests before containers are
+	// created from them. Rejections surface as a distinct
+	// ImageVerificationFailed waiting reason.
+	KubeletImageVerification featuregate.Feature = "KubeletImageVerification"
+
 	// owner: @AkihiroSuda
 	// alpha: v1.22
 	//
@@ -395,6 +440,14 @@ const (
 	// alpha: v1.29
 	KubeletSeparateDiskGC featuregate.Feature = "KubeletSeparateDiskGC"
 
+	// owner: @SergeyKanzhelev
+	// alpha: v1.31
+	//
+	// Allow static pods on standalone kubelets to reference imagePullSecrets
+	// by name, resolved from dockerconfigjson files in a node-local directory
+	// instead of the API.
+	KubeletStaticPodImagePullSecrets featuregate.Feature = "KubeletStaticPodImagePullSecrets"
+
 	// owner: @sallyom
 	// kep: https://kep.k8s.io/2832
 	// alpha: v1.25
@@ -1066,6 +1119,16 @@ var defaultKubernetesFeatureGates = map[featuregate.Feature]featuregate.FeatureS
 
 	KubeletCgroupDriverFromCRI: {Default: false, PreRelease: featuregate.Alpha},
 
+	KubeletCredentialProviderReload: {Default: false, PreRelease: featuregate.Alpha},
+
+	KubeletImagePrefetch: {Default: false, PreRelease: featuregate.Alpha},
+
+	KubeletImagePullCredentialVerification: {Default: false, PreRelease: featuregate.Alpha},
+
+	KubeletImagePullReport: {Default: false, PreRelease: featuregate.Alpha},
+
+	KubeletImageVerification: {Default: false, PreRelease: featuregate.Alpha},
+
 	KubeletInUserNamespace: {Default: false, PreRelease: featuregate.Alpha},
 
 	KubeletPodResourcesDynamicResources: {Default: false, PreRelease: featuregate.Alpha},
@@ -1074,6 +1137,8 @@ var defaultKubernetesFeatureGates = map[featuregate.Feature]featuregate.FeatureS
 
 	KubeletSeparateDiskGC: {Default: false, PreRelease: featuregate.Alpha},
 
+	KubeletStaticPodImagePullSecrets: {Default: false, PreRelease: featuregate.Alpha},
+
 	KubeletTracing: {Default: true, PreRelease: featuregate.Beta},
 
 	KubeProxyDrainingTerminatingNodes: {Default: true, PreRelease: featuregate.GA, LockToDefault: true}, // GA in 1.31; remove in 1.33
@@ -22,6 +22,7 @@ package config
 func KubeletConfigurationPathRefs(kc *KubeletConfiguration) []*string {
 	paths := []*string{}
 	paths = append(paths, &kc.StaticPodPath)
+	paths = append(paths, &kc.StaticPodImagePullSecretsDir)
 	paths = append(paths, &kc.Authentication.X509.ClientCAFile)
 	paths = append(paths, &kc.TLSCertFile)
 	paths = append(paths, &kc.TLSPrivateKeyFile)
@@ -152,6 +152,7 @@ var (
 	// KubeletConfiguration fields that contain file paths. If you update this, also update KubeletConfigurationPathRefs!
 	kubeletConfigurationPathFieldPaths = sets.New[string](
 		"StaticPodPath",
+		"StaticPodImagePullSecretsDir",
 		"Authentication.X509.ClientCAFile",
 		"TLSCertFile",
 		"TLSPrivateKeyFile",
@@ -240,8 +241,11 @@ var (
 		"IPTablesMasqueradeBit",
 		"ImageGCHighThresholdPercent",
 		"ImageGCLowThresholdPercent",
+		"ImageGCPolicy",
+		"ImageGCSizeBudgetBytes",
 		"ImageMinimumGCAge.Duration",
 		"ImageMaximumGCAge.Duration",
+		"PinnedImages[*]",
 		"KernelMemcgNotification",
 		"KubeAPIBurst",
 		"KubeAPIQPS",
@@ -280,6 +284,9 @@ var (
 		"SeccompDefault",
 		"SerializeImagePulls",
 		"MaxParallelImagePulls",
+		"MaxParallelImagePullsPerRuntimeClass[*]",
+		"ImagePullMaxBandwidth",
+		"ImagePullRevalidateInterval",
 		"ShowHiddenMetricsForVersion",
 		"ShutdownGracePeriodByPodPriority[*].Priority",
 		"ShutdownGracePeriodByPodPriority[*].ShutdownGracePeriodSeconds",
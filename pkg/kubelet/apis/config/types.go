@@ -41,6 +41,17 @@ const (
 	HairpinNone = "none"
 )
 
+// Valid values for the ImageGCPolicy field.
+const (
+	// ThresholdImageGCPolicy frees images only when disk usage crosses
+	// imageGCHighThresholdPercent, down to imageGCLowThresholdPercent.
+	ThresholdImageGCPolicy = "Threshold"
+	// LeastRecentlyUsedImageGCPolicy keeps unused images within
+	// imageGCSizeBudgetBytes, removing the least recently used images
+	// beyond the budget.
+	LeastRecentlyUsedImageGCPolicy = "LeastRecentlyUsed"
+)
+
 // ResourceChangeDetectionStrategy denotes a mode in which internal
 // managers (secret, configmap) are discovering object changes.
 type ResourceChangeDetectionStrategy string
@@ -105,6 +116,12 @@ type KubeletConfiguration struct {
 	StaticPodURL string
 	// staticPodURLHeader is a map of slices with HTTP headers to use when accessing the podURL
 	StaticPodURLHeader map[string][]string `datapolicy:"token"`
+	// staticPodImagePullSecretsDir is a directory containing dockerconfigjson
+	// files named <secret-name>.json that standalone kubelets use to resolve
+	// imagePullSecrets referenced by static pods. Requires the
+	// KubeletStaticPodImagePullSecrets feature gate and is only honored when
+	// the kubelet has no API server client.
+	StaticPodImagePullSecretsDir string
 	// address is the IP address for the Kubelet to serve on (set to 0.0.0.0
 	// for all interfaces)
 	Address string
@@ -212,6 +229,19 @@ type KubeletConfiguration struct {
 	// image garbage collection is never run. Lowest disk usage to garbage
 	// collect to. The percent is calculated as this field value out of 100.
 	ImageGCLowThresholdPercent int32
+	// imageGCPolicy selects how image garbage collection chooses images to
+	// remove under disk pressure. "Threshold" (the default when empty)
+	// removes least recently used images until disk usage drops below
+	// imageGCLowThresholdPercent. "LeastRecentlyUsed" keeps unused images
+	// within imageGCSizeBudgetBytes, removing the least recently used
+	// images beyond the budget.
+	ImageGCPolicy string
+	// imageGCSizeBudgetBytes is the total size in bytes that unused images
+	// may occupy when imageGCPolicy is "LeastRecentlyUsed".
+	ImageGCSizeBudgetBytes int64
+	// pinnedImages lists image references that are never garbage collected,
+	// in addition to images pinned by the container runtime.
+	PinnedImages []string
 	// How frequently to calculate and cache volume disk usage for all pods
 	VolumeStatsAggPeriod metav1.Duration
 	// KubeletCgroups is the absolute name of cgroups to isolate the kubelet in
@@ -302,6 +332,18 @@ type KubeletConfiguration struct {
 	SerializeImagePulls bool
 	// MaxParallelImagePulls sets the maximum number of image pulls in parallel.
 	MaxParallelImagePulls *int32
+	// MaxParallelImagePullsPerRuntimeClass overrides the pull parallelism for
+	// pods of specific runtime classes, keyed by the runtime handler name. A
+	// value of 1 serializes pulls for that runtime class.
+	MaxParallelImagePullsPerRuntimeClass map[string]int32
+	// ImagePullMaxBandwidth caps the aggregate image pull bandwidth of the
+	// node in bytes per second. A value of 0 disables the cap.
+	ImagePullMaxBandwidth int64
+	// ImagePullRevalidateInterval is how often a locally present image tag is
+	// revalidated against the registry digest when the pull policy is
+	// PullIfNotPresent; the image is pulled again only when the digest
+	// changed. A value of 0 disables revalidation.
+	ImagePullRevalidateInterval metav1.Duration
 	// Map of signal names to quantities that defines hard eviction thresholds. For example: {"memory.available": "300Mi"}.
 	// Some default signals are Linux only: nodefs.inodesFree
 	EvictionHard map[string]string
@@ -641,6 +683,26 @@ type CredentialProvider struct {
 	// to pass argument to the plugin.
 	// +optional
 	Env []ExecEnvVar
+
+	// maxConcurrentExecutions is the maximum number of plugin processes the kubelet
+	// will run concurrently for this provider. Additional invocations are queued in
+	// FIFO order until a slot frees up. If unset, a default limit of 10 is applied.
+	// +optional
+	MaxConcurrentExecutions *int32
+
+	// cacheDurationOverride, when set, overrides both the cache duration returned by
+	// the plugin and defaultCacheDuration for credentials from this provider. This
+	// lets operators tune caching for plugins that return unsuitable durations
+	// without changing the plugin. A value of 0 disables caching entirely.
+	// +optional
+	CacheDurationOverride *metav1.Duration
+
+	// staleCredentialsWindow is how long expired cached credentials may still be
+	// served when the plugin binary is failing, so a temporary plugin outage does
+	// not break image pulls that authenticated successfully before it. Unset or
+	// zero disables serving stale credentials.
+	// +optional
+	StaleCredentialsWindow *metav1.Duration
 }
 
 // ExecEnvVar is used for setting environment variables when executing an exec-based
@@ -78,6 +78,9 @@ func autoConvert_v1alpha1_CredentialProvider_To_config_CredentialProvider(in *v1
 	out.APIVersion = in.APIVersion
 	out.Args = *(*[]string)(unsafe.Pointer(&in.Args))
 	out.Env = *(*[]config.ExecEnvVar)(unsafe.Pointer(&in.Env))
+	out.MaxConcurrentExecutions = (*int32)(unsafe.Pointer(in.MaxConcurrentExecutions))
+	out.CacheDurationOverride = (*v1.Duration)(unsafe.Pointer(in.CacheDurationOverride))
+	out.StaleCredentialsWindow = (*v1.Duration)(unsafe.Pointer(in.StaleCredentialsWindow))
 	return nil
 }
 
@@ -93,6 +96,9 @@ func autoConvert_config_CredentialProvider_To_v1alpha1_CredentialProvider(in *co
 	out.APIVersion = in.APIVersion
 	out.Args = *(*[]string)(unsafe.Pointer(&in.Args))
 	out.Env = *(*[]v1alpha1.ExecEnvVar)(unsafe.Pointer(&in.Env))
+	out.MaxConcurrentExecutions = (*int32)(unsafe.Pointer(in.MaxConcurrentExecutions))
+	out.CacheDurationOverride = (*v1.Duration)(unsafe.Pointer(in.CacheDurationOverride))
+	out.StaleCredentialsWindow = (*v1.Duration)(unsafe.Pointer(in.StaleCredentialsWindow))
 	return nil
 }
 
@@ -190,6 +190,9 @@ func autoConvert_v1beta1_CredentialProvider_To_config_CredentialProvider(in *v1b
 	out.APIVersion = in.APIVersion
 	out.Args = *(*[]string)(unsafe.Pointer(&in.Args))
 	out.Env = *(*[]config.ExecEnvVar)(unsafe.Pointer(&in.Env))
+	out.MaxConcurrentExecutions = (*int32)(unsafe.Pointer(in.MaxConcurrentExecutions))
+	out.CacheDurationOverride = (*v1.Duration)(unsafe.Pointer(in.CacheDurationOverride))
+	out.StaleCredentialsWindow = (*v1.Duration)(unsafe.Pointer(in.StaleCredentialsWindow))
 	return nil
 }
 
@@ -205,6 +208,9 @@ func autoConvert_config_CredentialProvider_To_v1beta1_CredentialProvider(in *con
 	out.APIVersion = in.APIVersion
 	out.Args = *(*[]string)(unsafe.Pointer(&in.Args))
 	out.Env = *(*[]v1beta1.ExecEnvVar)(unsafe.Pointer(&in.Env))
+	out.MaxConcurrentExecutions = (*int32)(unsafe.Pointer(in.MaxConcurrentExecutions))
+	out.CacheDurationOverride = (*v1.Duration)(unsafe.Pointer(in.CacheDurationOverride))
+	out.StaleCredentialsWindow = (*v1.Duration)(unsafe.Pointer(in.StaleCredentialsWindow))
 	return nil
 }
 
@@ -352,6 +358,7 @@ func autoConvert_v1beta1_KubeletConfiguration_To_config_KubeletConfiguration(in
 	out.HTTPCheckFrequency = in.HTTPCheckFrequency
 	out.StaticPodURL = in.StaticPodURL
 	out.StaticPodURLHeader = *(*map[string][]string)(unsafe.Pointer(&in.StaticPodURLHeader))
+	out.StaticPodImagePullSecretsDir = in.StaticPodImagePullSecretsDir
 	out.Address = in.Address
 	out.Port = in.Port
 	out.ReadOnlyPort = in.ReadOnlyPort
@@ -400,6 +407,9 @@ func autoConvert_v1beta1_KubeletConfiguration_To_config_KubeletConfiguration(in
 	if err := v1.Convert_Pointer_int32_To_int32(&in.ImageGCLowThresholdPercent, &out.ImageGCLowThresholdPercent, s); err != nil {
 		return err
 	}
+	out.ImageGCPolicy = in.ImageGCPolicy
+	out.ImageGCSizeBudgetBytes = in.ImageGCSizeBudgetBytes
+	out.PinnedImages = *(*[]string)(unsafe.Pointer(&in.PinnedImages))
 	out.VolumeStatsAggPeriod = in.VolumeStatsAggPeriod
 	out.KubeletCgroups = in.KubeletCgroups
 	out.SystemCgroups = in.SystemCgroups
@@ -446,6 +456,9 @@ func autoConvert_v1beta1_KubeletConfiguration_To_config_KubeletConfiguration(in
 		return err
 	}
 	out.MaxParallelImagePulls = (*int32)(unsafe.Pointer(in.MaxParallelImagePulls))
+	out.MaxParallelImagePullsPerRuntimeClass = *(*map[string]int32)(unsafe.Pointer(&in.MaxParallelImagePullsPerRuntimeClass))
+	out.ImagePullMaxBandwidth = in.ImagePullMaxBandwidth
+	out.ImagePullRevalidateInterval = in.ImagePullRevalidateInterval
 	out.EvictionHard = *(*map[string]string)(unsafe.Pointer(&in.EvictionHard))
 	out.EvictionSoft = *(*map[string]string)(unsafe.Pointer(&in.EvictionSoft))
 	out.EvictionSoftGracePeriod = *(*map[string]string)(unsafe.Pointer(&in.EvictionSoftGracePeriod))
@@ -545,6 +558,7 @@ func autoConvert_config_KubeletConfiguration_To_v1beta1_KubeletConfiguration(in
 	out.HTTPCheckFrequency = in.HTTPCheckFrequency
 	out.StaticPodURL = in.StaticPodURL
 	out.StaticPodURLHeader = *(*map[string][]string)(unsafe.Pointer(&in.StaticPodURLHeader))
+	out.StaticPodImagePullSecretsDir = in.StaticPodImagePullSecretsDir
 	out.Address = in.Address
 	out.Port = in.Port
 	out.ReadOnlyPort = in.ReadOnlyPort
@@ -595,6 +609,9 @@ func autoConvert_config_KubeletConfiguration_To_v1beta1_KubeletConfiguration(in
 	if err := v1.Convert_int32_To_Pointer_int32(&in.ImageGCLowThresholdPercent, &out.ImageGCLowThresholdPercent, s); err != nil {
 		return err
 	}
+	out.ImageGCPolicy = in.ImageGCPolicy
+	out.ImageGCSizeBudgetBytes = in.ImageGCSizeBudgetBytes
+	out.PinnedImages = *(*[]string)(unsafe.Pointer(&in.PinnedImages))
 	out.VolumeStatsAggPeriod = in.VolumeStatsAggPeriod
 	out.KubeletCgroups = in.KubeletCgroups
 	out.SystemCgroups = in.SystemCgroups
@@ -641,6 +658,9 @@ func autoConvert_config_KubeletConfiguration_To_v1beta1_KubeletConfiguration(in
 		return err
 	}
 	out.MaxParallelImagePulls = (*int32)(unsafe.Pointer(in.MaxParallelImagePulls))
+	out.MaxParallelImagePullsPerRuntimeClass = *(*map[string]int32)(unsafe.Pointer(&in.MaxParallelImagePullsPerRuntimeClass))
+	out.ImagePullMaxBandwidth = in.ImagePullMaxBandwidth
+	out.ImagePullRevalidateInterval = in.ImagePullRevalidateInterval
 	out.EvictionHard = *(*map[string]string)(unsafe.Pointer(&in.EvictionHard))
 	out.EvictionSoft = *(*map[string]string)(unsafe.Pointer(&in.EvictionSoft))
 	out.EvictionSoftGracePeriod = *(*map[string]string)(unsafe.Pointer(&in.EvictionSoftGracePeriod))
@@ -85,9 +85,21 @@ func ValidateKubeletConfiguration(kc *kubeletconfig.KubeletConfiguration, featur
 	if kc.ImageGCLowThresholdPercent >= kc.ImageGCHighThresholdPercent {
 		allErrors = append(allErrors, fmt.Errorf("invalid configuration: imageGCLowThresholdPercent (--image-gc-low-threshold) %v must be less than imageGCHighThresholdPercent (--image-gc-high-threshold) %v", kc.ImageGCLowThresholdPercent, kc.ImageGCHighThresholdPercent))
 	}
+	switch kc.ImageGCPolicy {
+	case "", kubeletconfig.ThresholdImageGCPolicy:
+	case kubeletconfig.LeastRecentlyUsedImageGCPolicy:
+		if kc.ImageGCSizeBudgetBytes <= 0 {
+			allErrors = append(allErrors, fmt.Errorf("invalid configuration: imageGCSizeBudgetBytes %v must be positive when imageGCPolicy is %q", kc.ImageGCSizeBudgetBytes, kubeletconfig.LeastRecentlyUsedImageGCPolicy))
+		}
+	default:
+		allErrors = append(allErrors, fmt.Errorf("invalid configuration: imageGCPolicy (--image-gc-policy) %q must be one of: %q or %q", kc.ImageGCPolicy, kubeletconfig.ThresholdImageGCPolicy, kubeletconfig.LeastRecentlyUsedImageGCPolicy))
+	}
 	if kc.ImageMaximumGCAge.Duration != 0 && !localFeatureGate.Enabled(features.ImageMaximumGCAge) {
 		allErrors = append(allErrors, fmt.Errorf("invalid configuration: ImageMaximumGCAge feature gate is required for Kubelet configuration option imageMaximumGCAge"))
 	}
+	if kc.StaticPodImagePullSecretsDir != "" && !localFeatureGate.Enabled(features.KubeletStaticPodImagePullSecrets) {
+		allErrors = append(allErrors, fmt.Errorf("invalid configuration: KubeletStaticPodImagePullSecrets feature gate is required for Kubelet configuration option staticPodImagePullSecretsDir"))
+	}
 	if kc.ImageMaximumGCAge.Duration < 0 {
 		allErrors = append(allErrors, fmt.Errorf("invalid configuration: imageMaximumGCAge %v must not be negative", kc.ImageMaximumGCAge.Duration))
 	}
@@ -139,6 +151,17 @@ func ValidateKubeletConfiguration(kc *kubeletconfig.KubeletConfiguration, featur
 	if kc.SerializeImagePulls && kc.MaxParallelImagePulls != nil && *kc.MaxParallelImagePulls > 1 {
 		allErrors = append(allErrors, fmt.Errorf("invalid configuration: maxParallelImagePulls cannot be larger than 1 unless SerializeImagePulls (--serialize-image-pulls) is set to false"))
 	}
+	for handler, limit := range kc.MaxParallelImagePullsPerRuntimeClass {
+		if limit < 1 {
+			allErrors = append(allErrors, fmt.Errorf("invalid configuration: maxParallelImagePullsPerRuntimeClass[%q] %v must be a positive number", handler, limit))
+		}
+	}
+	if kc.ImagePullMaxBandwidth < 0 {
+		allErrors = append(allErrors, fmt.Errorf("invalid configuration: imagePullMaxBandwidth %v must not be a negative number", kc.ImagePullMaxBandwidth))
+	}
+	if kc.ImagePullRevalidateInterval.Duration < 0 {
+		allErrors = append(allErrors, fmt.Errorf("invalid configuration: imagePullRevalidateInterval %v must not be a negative duration", kc.ImagePullRevalidateInterval.Duration))
+	}
 	if kc.ServerTLSBootstrap && !localFeatureGate.Enabled(features.RotateKubeletServerCertificate) {
 		allErrors = append(allErrors, fmt.Errorf("invalid configuration: serverTLSBootstrap %v requires feature gate RotateKubeletServerCertificate", kc.ServerTLSBootstrap))
 	}
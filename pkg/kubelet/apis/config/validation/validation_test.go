@@ -290,6 +290,13 @@ func TestValidateKubeletConfiguration(t *testing.T) {
 			return conf
 		},
 		errMsg: "invalid configuration: maxParallelImagePulls cannot be larger than 1 unless SerializeImagePulls (--serialize-image-pulls) is set to false",
+	}, {
+		name: "invalid MaxParallelImagePullsPerRuntimeClass",
+		configure: func(conf *kubeletconfig.KubeletConfiguration) *kubeletconfig.KubeletConfiguration {
+			conf.MaxParallelImagePullsPerRuntimeClass = map[string]int32{"gvisor": 0}
+			return conf
+		},
+		errMsg: "invalid configuration: maxParallelImagePullsPerRuntimeClass[\"gvisor\"] 0 must be a positive number",
 	}, {
 		name: "valid MaxParallelImagePulls and SerializeImagePulls combination",
 		configure: func(conf *kubeletconfig.KubeletConfiguration) *kubeletconfig.KubeletConfiguration {
@@ -549,6 +556,20 @@ func TestValidateKubeletConfiguration(t *testing.T) {
 			return conf
 		},
 		errMsg: "invalid configuration: imageMaximumGCAge 1ns must be greater than imageMinimumGCAge 2ns",
+	}, {
+		name: "imageGCPolicy must be a known policy",
+		configure: func(conf *kubeletconfig.KubeletConfiguration) *kubeletconfig.KubeletConfiguration {
+			conf.ImageGCPolicy = "NotAPolicy"
+			return conf
+		},
+		errMsg: "invalid configuration: imageGCPolicy (--image-gc-policy) \"NotAPolicy\" must be one of: \"Threshold\" or \"LeastRecentlyUsed\"",
+	}, {
+		name: "imageGCSizeBudgetBytes is required for the LeastRecentlyUsed policy",
+		configure: func(conf *kubeletconfig.KubeletConfiguration) *kubeletconfig.KubeletConfiguration {
+			conf.ImageGCPolicy = kubeletconfig.LeastRecentlyUsedImageGCPolicy
+			return conf
+		},
+		errMsg: "invalid configuration: imageGCSizeBudgetBytes 0 must be positive when imageGCPolicy is \"LeastRecentlyUsed\"",
 	}, {
 		name: "containerLogMaxWorkers must be greater than or equal to 1",
 		configure: func(conf *kubeletconfig.KubeletConfiguration) *kubeletconfig.KubeletConfiguration {
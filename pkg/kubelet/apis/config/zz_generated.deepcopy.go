@@ -51,6 +51,21 @@ func (in *CredentialProvider) DeepCopyInto(out *CredentialProvider) {
 		*out = make([]ExecEnvVar, len(*in))
 		copy(*out, *in)
 	}
+	if in.MaxConcurrentExecutions != nil {
+		in, out := &in.MaxConcurrentExecutions, &out.MaxConcurrentExecutions
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CacheDurationOverride != nil {
+		in, out := &in.CacheDurationOverride, &out.CacheDurationOverride
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.StaleCredentialsWindow != nil {
+		in, out := &in.StaleCredentialsWindow, &out.StaleCredentialsWindow
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	return
 }
 
@@ -203,6 +218,11 @@ func (in *KubeletConfiguration) DeepCopyInto(out *KubeletConfiguration) {
 	out.NodeStatusReportFrequency = in.NodeStatusReportFrequency
 	out.ImageMinimumGCAge = in.ImageMinimumGCAge
 	out.ImageMaximumGCAge = in.ImageMaximumGCAge
+	if in.PinnedImages != nil {
+		in, out := &in.PinnedImages, &out.PinnedImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	out.VolumeStatsAggPeriod = in.VolumeStatsAggPeriod
 	if in.CPUManagerPolicyOptions != nil {
 		in, out := &in.CPUManagerPolicyOptions, &out.CPUManagerPolicyOptions
@@ -233,6 +253,14 @@ func (in *KubeletConfiguration) DeepCopyInto(out *KubeletConfiguration) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.MaxParallelImagePullsPerRuntimeClass != nil {
+		in, out := &in.MaxParallelImagePullsPerRuntimeClass, &out.MaxParallelImagePullsPerRuntimeClass
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.ImagePullRevalidateInterval = in.ImagePullRevalidateInterval
 	if in.EvictionHard != nil {
 		in, out := &in.EvictionHard, &out.EvictionHard
 		*out = make(map[string]string, len(*in))
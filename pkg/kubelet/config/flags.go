@@ -44,6 +44,13 @@ type ContainerRuntimeOptions struct {
 	// specified in imageCredentialProviderConfigFile.
 	// +optional
 	ImageCredentialProviderBinDir string
+
+	// ImageVerifierBin is the path to an image verifier plugin binary. When set and the
+	// KubeletImageVerification feature gate is enabled, the kubelet invokes the plugin for
+	// every resolved image digest and refuses to create containers from images the plugin
+	// rejects.
+	// +optional
+	ImageVerifierBin string
 }
 
 // AddFlags adds flags to the container runtime, according to ContainerRuntimeOptions.
@@ -56,4 +63,7 @@ func (s *ContainerRuntimeOptions) AddFlags(fs *pflag.FlagSet) {
 	// Image credential provider settings.
 	fs.StringVar(&s.ImageCredentialProviderConfigFile, "image-credential-provider-config", s.ImageCredentialProviderConfigFile, "The path to the credential provider plugin config file.")
 	fs.StringVar(&s.ImageCredentialProviderBinDir, "image-credential-provider-bin-dir", s.ImageCredentialProviderBinDir, "The path to the directory where credential provider plugin binaries are located.")
+
+	// Image verifier settings.
+	fs.StringVar(&s.ImageVerifierBin, "image-verifier-bin", s.ImageVerifierBin, "The path to an image verifier plugin binary, invoked for every resolved image digest before a container is created from it. Requires the KubeletImageVerification feature gate.")
 }
@@ -65,6 +65,59 @@ type ImageStats struct {
 	TotalStorageBytes uint64
 }
 
+// ImagePullProgress describes an in-flight image pull.
+type ImagePullProgress struct {
+	// Image is the image reference from the container spec.
+	Image string
+	// StartTime is when the pull started.
+	StartTime time.Time
+	// BytesPulled is an estimate of the bytes fetched so far, derived from
+	// the growth of the runtime's image storage since the pull started. The
+	// storage is shared by all concurrent pulls, so this is an upper bound.
+	BytesPulled int64
+}
+
+// ImagePullProgressProvider is implemented by runtimes that can report the
+// progress of in-flight image pulls. It is optional; callers discover it via
+// a type assertion.
+type ImagePullProgressProvider interface {
+	// PullProgress returns the progress of an in-flight pull of the given
+	// image, if one is in progress.
+	PullProgress(ctx context.Context, image string) (*ImagePullProgress, bool)
+}
+
+// ImagePrefetchSpec names one image to pull ahead of pod scheduling,
+// together with the pull secrets to pull it with.
+type ImagePrefetchSpec struct {
+	// Image is the image reference to pull, as it would appear in a
+	// container spec.
+	Image string
+	// PullSecrets are the secrets to use for the pull, in the same format
+	// as a pod's imagePullSecrets.
+	PullSecrets []v1.Secret
+}
+
+// ImagePrefetchResult reports the outcome of prefetching one image.
+type ImagePrefetchResult struct {
+	// Image is the image reference from the spec.
+	Image string
+	// ImageRef identifies the image in the runtime once it is present.
+	ImageRef string
+	// Err is the pull error, if the prefetch failed.
+	Err error
+}
+
+// ImagePrefetcher is implemented by runtimes that can pull a set of
+// images ahead of pod scheduling, so the images are already cached when
+// pods referencing them are created. It is optional; callers discover it
+// via a type assertion.
+type ImagePrefetcher interface {
+	// PrefetchImages pulls the given images and returns one result per
+	// spec, in order. Images already present are reported as successes
+	// without a pull.
+	PrefetchImages(ctx context.Context, specs []ImagePrefetchSpec) []ImagePrefetchResult
+}
+
 // Runtime interface defines the interfaces that should be implemented
 // by a container runtime.
 // Thread safety is required from implementations of this interface.
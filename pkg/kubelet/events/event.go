@@ -44,6 +44,8 @@ const (
 	FailedToInspectImage    = "InspectFailed"
 	ErrImageNeverPullPolicy = "ErrImageNeverPull"
 	BackOffPullImage        = "BackOff"
+	FailedImageVerification = "FailedImageVerification"
+	RegistryRateLimited     = "RegistryRateLimited"
 )
 
 // kubelet event reason list
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	kubecontainer "k8s.io/kubernetes/pkg/kubelet/container"
+	"k8s.io/kubernetes/pkg/kubelet/metrics"
+	"k8s.io/utils/clock"
+)
+
+// throttleImagePullBandwidth wraps kubecontainer.ImageService to cap the
+// aggregate image pull bandwidth of the node. The kubelet does not see the
+// image bytes itself, so the cap works by accounting: each completed pull is
+// charged for the size of the image, and while the charged bytes exceed what
+// the configured rate allows, subsequent pulls are delayed until the average
+// falls back under the cap. maxBandwidth is in bytes per second; 0 disables
+// the cap.
+func throttleImagePullBandwidth(imageService kubecontainer.ImageService, maxBandwidth int64) kubecontainer.ImageService {
+	if maxBandwidth <= 0 {
+		return imageService
+	}
+	return &bandwidthLimitedImageService{
+		ImageService: imageService,
+		limiter:      newPullBandwidthLimiter(clock.RealClock{}, maxBandwidth),
+	}
+}
+
+type bandwidthLimitedImageService struct {
+	kubecontainer.ImageService
+	limiter *pullBandwidthLimiter
+}
+
+func (bs *bandwidthLimitedImageService) PullImage(ctx context.Context, image kubecontainer.ImageSpec, secrets []v1.Secret, podSandboxConfig *runtimeapi.PodSandboxConfig) (string, error) {
+	waited, err := bs.limiter.wait(ctx)
+	if err != nil {
+		return "", fmt.Errorf("waiting for image pull bandwidth: %w", err)
+	}
+	if waited > 0 {
+		metrics.ImagePullBandwidthThrottled.Inc()
+		metrics.ImagePullBandwidthThrottleWait.Observe(waited.Seconds())
+	}
+	imageRef, err := bs.ImageService.PullImage(ctx, image, secrets, podSandboxConfig)
+	if err != nil {
+		return "", err
+	}
+	if size, err := bs.ImageService.GetImageSize(ctx, image); err == nil {
+		bs.limiter.charge(int64(size))
+	}
+	return imageRef, nil
+}
+
+// pullBandwidthLimiter is a token bucket over bytes that allows overdraft:
+// pulls are never split or aborted, so a pull may drive the debt arbitrarily
+// high, and later pulls wait until enough time has passed to pay it back at
+// the configured rate.
+type pullBandwidthLimiter struct {
+	clock          clock.Clock
+	bytesPerSecond int64
+
+	mu sync.Mutex
+	// debt is the number of charged bytes not yet covered by elapsed time.
+	debt int64
+	// last is when debt was last reconciled against the elapsed time.
+	last time.Time
+}
+
+func newPullBandwidthLimiter(c clock.Clock, bytesPerSecond int64) *pullBandwidthLimiter {
+	return &pullBandwidthLimiter{
+		clock:          c,
+		bytesPerSecond: bytesPerSecond,
+	}
+}
+
+// reconcileLocked pays back debt for the time elapsed since the last
+// reconciliation. Callers must hold mu.
+func (l *pullBandwidthLimiter) reconcileLocked(now time.Time) {
+	if !l.last.IsZero() {
+		repaid := int64(now.Sub(l.last).Seconds() * float64(l.bytesPerSecond))
+		if repaid >= l.debt {
+			l.debt = 0
+		} else {
+			l.debt -= repaid
+		}
+	}
+	l.last = now
+}
+
+// delay returns how long a pull starting now has to wait for the outstanding
+// debt to be repaid.
+func (l *pullBandwidthLimiter) delay() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reconcileLocked(l.clock.Now())
+	if l.debt == 0 {
+		return 0
+	}
+	return time.Duration(float64(l.debt) / float64(l.bytesPerSecond) * float64(time.Second))
+}
+
+// charge records that bytes were transferred, delaying future pulls.
+func (l *pullBandwidthLimiter) charge(bytes int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reconcileLocked(l.clock.Now())
+	l.debt += bytes
+}
+
+// wait blocks until the outstanding debt is repaid or the context is
+// canceled, and returns how long it waited.
+func (l *pullBandwidthLimiter) wait(ctx context.Context) (time.Duration, error) {
+	var waited time.Duration
+	for {
+		d := l.delay()
+		if d == 0 {
+			return waited, nil
+		}
+		timer := l.clock.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return waited, ctx.Err()
+		case <-timer.C():
+		}
+		waited += d
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kubecontainer "k8s.io/kubernetes/pkg/kubelet/container"
+	ctest "k8s.io/kubernetes/pkg/kubelet/container/testing"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestPullBandwidthLimiterDelay(t *testing.T) {
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	limiter := newPullBandwidthLimiter(fakeClock, 1000)
+
+	assert.Equal(t, time.Duration(0), limiter.delay(), "a fresh limiter should not delay")
+
+	// A pull twice the per-second budget delays the next pull by two
+	// seconds, paid back as time passes.
+	limiter.charge(2000)
+	assert.Equal(t, 2*time.Second, limiter.delay())
+	fakeClock.Step(time.Second)
+	assert.Equal(t, time.Second, limiter.delay())
+	fakeClock.Step(2 * time.Second)
+	assert.Equal(t, time.Duration(0), limiter.delay(), "fully repaid debt should not delay")
+
+	// Debt does not accumulate credit: a long idle period does not allow a
+	// burst above the cap afterwards.
+	fakeClock.Step(time.Hour)
+	limiter.charge(3000)
+	assert.Equal(t, 3*time.Second, limiter.delay())
+}
+
+func TestPullBandwidthLimiterWaitCanceled(t *testing.T) {
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	limiter := newPullBandwidthLimiter(fakeClock, 1)
+	limiter.charge(1 << 40)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := limiter.wait(ctx)
+		done <- err
+	}()
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestThrottleImagePullBandwidthDisabled(t *testing.T) {
+	fakeRuntime := &ctest.FakeRuntime{T: t}
+	assert.Equal(t, kubecontainer.ImageService(fakeRuntime), throttleImagePullBandwidth(fakeRuntime, 0),
+		"a zero cap should leave the image service unwrapped")
+}
@@ -80,6 +80,17 @@ type ImageGCManager interface {
 	DeleteUnusedImages(ctx context.Context) error
 }
 
+// Valid values for ImageGCPolicy.Type.
+const (
+	// ImageGCPolicyThreshold frees images only when disk usage crosses
+	// HighThresholdPercent, down to LowThresholdPercent.
+	ImageGCPolicyThreshold = "Threshold"
+	// ImageGCPolicyLeastRecentlyUsed keeps unused images within
+	// SizeBudgetBytes, removing the least recently used images beyond the
+	// budget.
+	ImageGCPolicyLeastRecentlyUsed = "LeastRecentlyUsed"
+)
+
 // ImageGCPolicy is a policy for garbage collecting images. Policy defines an allowed band in
 // which garbage collection will be run.
 type ImageGCPolicy struct {
@@ -98,6 +109,20 @@ type ImageGCPolicy struct {
 	// Currently gated by MaximumImageGCAge feature gate and Kubelet configuration.
 	// If 0, the feature is disabled.
 	MaxAge time.Duration
+
+	// Type selects how garbage collection chooses images to remove under
+	// disk pressure. Empty defaults to ImageGCPolicyThreshold.
+	Type string
+
+	// SizeBudgetBytes is the total size unused images may occupy when Type
+	// is ImageGCPolicyLeastRecentlyUsed. Ignored otherwise.
+	SizeBudgetBytes int64
+
+	// PinnedImages lists image references that are never garbage
+	// collected, in addition to images pinned by the runtime. A reference
+	// matches an image when it equals one of the image's repo tags or repo
+	// digests.
+	PinnedImages []string
 }
 
 type realImageGCManager struct {
@@ -128,6 +153,10 @@ type realImageGCManager struct {
 	// imageCache is the cache of latest image list.
 	imageCache imageCache
 
+	// pinnedByPolicy is the set of image references from the policy's pin
+	// list, matched against image repo tags and digests.
+	pinnedByPolicy sets.Set[string]
+
 	// tracer for recording spans
 	tracer trace.Tracer
 }
@@ -192,15 +221,25 @@ func NewImageGCManager(runtime container.Runtime, statsProvider StatsProvider, r
 	if policy.LowThresholdPercent > policy.HighThresholdPercent {
 		return nil, fmt.Errorf("LowThresholdPercent %d can not be higher than HighThresholdPercent %d", policy.LowThresholdPercent, policy.HighThresholdPercent)
 	}
+	switch policy.Type {
+	case "", ImageGCPolicyThreshold:
+	case ImageGCPolicyLeastRecentlyUsed:
+		if policy.SizeBudgetBytes <= 0 {
+			return nil, fmt.Errorf("SizeBudgetBytes %d must be positive when the policy type is %q", policy.SizeBudgetBytes, ImageGCPolicyLeastRecentlyUsed)
+		}
+	default:
+		return nil, fmt.Errorf("invalid policy type %q, must be %q or %q", policy.Type, ImageGCPolicyThreshold, ImageGCPolicyLeastRecentlyUsed)
+	}
 	tracer := tracerProvider.Tracer(instrumentationScope)
 	im := &realImageGCManager{
-		runtime:       runtime,
-		policy:        policy,
-		imageRecords:  make(map[string]*imageRecord),
-		statsProvider: statsProvider,
-		recorder:      recorder,
-		nodeRef:       nodeRef,
-		tracer:        tracer,
+		runtime:        runtime,
+		policy:         policy,
+		imageRecords:   make(map[string]*imageRecord),
+		statsProvider:  statsProvider,
+		recorder:       recorder,
+		nodeRef:        nodeRef,
+		tracer:         tracer,
+		pinnedByPolicy: sets.New(policy.PinnedImages...),
 	}
 
 	return im, nil
@@ -293,8 +332,9 @@ func (im *realImageGCManager) detectImages(ctx context.Context, detectTime time.
 		klog.V(5).InfoS("Image ID has size", "imageID", imageKey, "size", image.Size)
 		im.imageRecords[imageKey].size = image.Size
 
-		klog.V(5).InfoS("Image ID is pinned", "imageID", imageKey, "pinned", image.Pinned)
-		im.imageRecords[imageKey].pinned = image.Pinned
+		pinned := image.Pinned || im.isPinnedByPolicy(image)
+		klog.V(5).InfoS("Image ID is pinned", "imageID", imageKey, "pinned", pinned)
+		im.imageRecords[imageKey].pinned = pinned
 	}
 
 	// Remove old images from our records.
@@ -308,6 +348,25 @@ func (im *realImageGCManager) detectImages(ctx context.Context, detectTime time.
 	return imagesInUse, nil
 }
 
+// isPinnedByPolicy reports whether the image matches the policy's pin list,
+// i.e. one of its repo tags or repo digests is listed there.
+func (im *realImageGCManager) isPinnedByPolicy(image container.Image) bool {
+	if im.pinnedByPolicy.Len() == 0 {
+		return false
+	}
+	for _, tag := range image.RepoTags {
+		if im.pinnedByPolicy.Has(tag) {
+			return true
+		}
+	}
+	for _, digest := range image.RepoDigests {
+		if im.pinnedByPolicy.Has(digest) {
+			return true
+		}
+	}
+	return false
+}
+
 func (im *realImageGCManager) GarbageCollect(ctx context.Context, beganGC time.Time) error {
 	ctx, otelSpan := im.tracer.Start(ctx, "Images/GarbageCollect")
 	defer otelSpan.End()
@@ -323,6 +382,12 @@ func (im *realImageGCManager) GarbageCollect(ctx context.Context, beganGC time.T
 		return err
 	}
 
+	// The LRU policy frees against its size budget instead of disk usage
+	// thresholds, so it needs no filesystem stats.
+	if im.policy.Type == ImageGCPolicyLeastRecentlyUsed {
+		return im.freeOverSizeBudget(ctx, freeTime, images)
+	}
+
 	// Get disk usage on disk holding images.
 	fsStats, _, err := im.statsProvider.ImageFsStats(ctx)
 	if err != nil {
@@ -369,6 +434,32 @@ func (im *realImageGCManager) GarbageCollect(ctx context.Context, beganGC time.T
 	return nil
 }
 
+// freeOverSizeBudget removes least recently used images until the unused
+// images fit within the policy's size budget. Pinned and in-use images are
+// already excluded from the candidate list and never count against the
+// budget.
+func (im *realImageGCManager) freeOverSizeBudget(ctx context.Context, freeTime time.Time, images []evictionInfo) error {
+	var totalSize int64
+	for _, image := range images {
+		totalSize += image.size
+	}
+	if totalSize <= im.policy.SizeBudgetBytes {
+		return nil
+	}
+	amountToFree := totalSize - im.policy.SizeBudgetBytes
+	klog.InfoS("Unused images exceed the size budget, trying to free least recently used images", "totalSize", totalSize, "sizeBudget", im.policy.SizeBudgetBytes, "amountToFree", amountToFree)
+	freed, err := im.freeSpace(ctx, amountToFree, freeTime, images)
+	if err != nil {
+		return err
+	}
+	if freed < amountToFree {
+		err := fmt.Errorf("Failed to garbage collect required amount of images. Attempted to free %d bytes, but only found %d bytes eligible to free.", amountToFree, freed)
+		im.recorder.Eventf(im.nodeRef, v1.EventTypeWarning, events.FreeDiskSpaceFailed, err.Error())
+		return err
+	}
+	return nil
+}
+
 func (im *realImageGCManager) freeOldImages(ctx context.Context, images []evictionInfo, freeTime, beganGC time.Time) ([]evictionInfo, error) {
 	if im.policy.MaxAge == 0 {
 		return images, nil
@@ -28,6 +28,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	noopoteltrace "go.opentelemetry.io/otel/trace/noop"
+	"k8s.io/apimachinery/pkg/util/sets"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/client-go/tools/record"
 	featuregatetesting "k8s.io/component-base/featuregate/testing"
@@ -46,12 +47,13 @@ var sandboxImage = "registry.k8s.io/pause-amd64:latest"
 func newRealImageGCManager(policy ImageGCPolicy, mockStatsProvider stats.Provider) (*realImageGCManager, *containertest.FakeRuntime) {
 	fakeRuntime := &containertest.FakeRuntime{}
 	return &realImageGCManager{
-		runtime:       fakeRuntime,
-		policy:        policy,
-		imageRecords:  make(map[string]*imageRecord),
-		statsProvider: mockStatsProvider,
-		recorder:      &record.FakeRecorder{},
-		tracer:        noopoteltrace.NewTracerProvider().Tracer(""),
+		runtime:        fakeRuntime,
+		policy:         policy,
+		imageRecords:   make(map[string]*imageRecord),
+		statsProvider:  mockStatsProvider,
+		recorder:       &record.FakeRecorder{},
+		tracer:         noopoteltrace.NewTracerProvider().Tracer(""),
+		pinnedByPolicy: sets.New(policy.PinnedImages...),
 	}, fakeRuntime
 }
 
@@ -383,6 +385,51 @@ func TestAllPinnedImages(t *testing.T) {
 	getImagesAndFreeSpace(ctx, t, assert, manager, fakeRuntime, 2048, 0, 2, time.Now())
 }
 
+func TestDoNotDeleteImagePinnedByPolicy(t *testing.T) {
+	ctx := context.Background()
+	mockStatsProvider := statstest.NewMockProvider(t)
+
+	policy := ImageGCPolicy{
+		PinnedImages: []string{"registry.test/critical:v1"},
+	}
+	manager, fakeRuntime := newRealImageGCManager(policy, mockStatsProvider)
+	fakeRuntime.ImageList = []container.Image{
+		{
+			ID:       "1",
+			Size:     1024,
+			RepoTags: []string{"registry.test/critical:v1"},
+		},
+		{
+			ID:   "2",
+			Size: 1024,
+		},
+	}
+
+	assert := assert.New(t)
+	getImagesAndFreeSpace(ctx, t, assert, manager, fakeRuntime, 4096, 1024, 1, time.Now())
+}
+
+func TestDoNotDeleteImagePinnedByPolicyDigest(t *testing.T) {
+	ctx := context.Background()
+	mockStatsProvider := statstest.NewMockProvider(t)
+
+	policy := ImageGCPolicy{
+		PinnedImages: []string{"registry.test/critical@sha256:0000000000000000000000000000000000000000000000000000000000000000"},
+	}
+	manager, fakeRuntime := newRealImageGCManager(policy, mockStatsProvider)
+	fakeRuntime.ImageList = []container.Image{
+		{
+			ID:          "1",
+			Size:        1024,
+			RepoDigests: []string{"registry.test/critical@sha256:0000000000000000000000000000000000000000000000000000000000000000"},
+		},
+	}
+
+	err := manager.DeleteUnusedImages(ctx)
+	require.NoError(t, err)
+	assert.Len(t, fakeRuntime.ImageList, 1)
+}
+
 func TestDetectImagesContainerStopped(t *testing.T) {
 	ctx := context.Background()
 	mockStatsProvider := statstest.NewMockProvider(t)
@@ -692,6 +739,60 @@ func TestGarbageCollectNotEnoughFreed(t *testing.T) {
 	assert.NotNil(t, manager.GarbageCollect(ctx, time.Now()))
 }
 
+func TestGarbageCollectLRUOverBudget(t *testing.T) {
+	ctx := context.Background()
+	policy := ImageGCPolicy{
+		Type:            ImageGCPolicyLeastRecentlyUsed,
+		SizeBudgetBytes: 1000,
+	}
+	// The LRU policy never consults filesystem stats, so no expectation is
+	// registered on the provider.
+	mockStatsProvider := statstest.NewMockProvider(t)
+	manager, fakeRuntime := newRealImageGCManager(policy, mockStatsProvider)
+
+	fakeRuntime.ImageList = []container.Image{
+		makeImage(0, 500),
+		makeImage(1, 500),
+		makeImage(2, 500),
+	}
+
+	assert.NoError(t, manager.GarbageCollect(ctx, time.Now()))
+	assert.Len(t, fakeRuntime.ImageList, 2)
+}
+
+func TestGarbageCollectLRUWithinBudget(t *testing.T) {
+	ctx := context.Background()
+	policy := ImageGCPolicy{
+		Type:            ImageGCPolicyLeastRecentlyUsed,
+		SizeBudgetBytes: 2000,
+	}
+	mockStatsProvider := statstest.NewMockProvider(t)
+	manager, fakeRuntime := newRealImageGCManager(policy, mockStatsProvider)
+
+	fakeRuntime.ImageList = []container.Image{
+		makeImage(0, 500),
+		makeImage(1, 500),
+		makeImage(2, 500),
+	}
+
+	assert.NoError(t, manager.GarbageCollect(ctx, time.Now()))
+	assert.Len(t, fakeRuntime.ImageList, 3)
+}
+
+func TestNewImageGCManagerPolicyType(t *testing.T) {
+	mockStatsProvider := statstest.NewMockProvider(t)
+	fakeRuntime := &containertest.FakeRuntime{}
+
+	_, err := NewImageGCManager(fakeRuntime, mockStatsProvider, &record.FakeRecorder{}, nil, ImageGCPolicy{Type: "NotAPolicy"}, noopoteltrace.NewTracerProvider())
+	require.ErrorContains(t, err, "invalid policy type")
+
+	_, err = NewImageGCManager(fakeRuntime, mockStatsProvider, &record.FakeRecorder{}, nil, ImageGCPolicy{Type: ImageGCPolicyLeastRecentlyUsed}, noopoteltrace.NewTracerProvider())
+	require.ErrorContains(t, err, "SizeBudgetBytes")
+
+	_, err = NewImageGCManager(fakeRuntime, mockStatsProvider, &record.FakeRecorder{}, nil, ImageGCPolicy{Type: ImageGCPolicyLeastRecentlyUsed, SizeBudgetBytes: 1024}, noopoteltrace.NewTracerProvider())
+	require.NoError(t, err)
+}
+
 func TestGarbageCollectImageNotOldEnough(t *testing.T) {
 	ctx := context.Background()
 	policy := ImageGCPolicy{
@@ -18,8 +18,10 @@ package images
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -28,10 +30,15 @@ import (
 	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/klog/v2"
 
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
 	crierrors "k8s.io/cri-api/pkg/errors"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+	"k8s.io/kubernetes/pkg/credentialprovider"
+	"k8s.io/kubernetes/pkg/features"
 	kubecontainer "k8s.io/kubernetes/pkg/kubelet/container"
 	"k8s.io/kubernetes/pkg/kubelet/events"
+	"k8s.io/kubernetes/pkg/kubelet/images/pullreport"
 	"k8s.io/kubernetes/pkg/kubelet/metrics"
 	"k8s.io/kubernetes/pkg/util/parsers"
 )
@@ -50,12 +57,67 @@ type imageManager struct {
 	puller imagePuller
 
 	podPullingTimeRecorder ImagePodPullingTimeRecorder
+
+	// preflight verifies the available pull credentials against the
+	// registry before a pull is scheduled. It is nil unless the
+	// KubeletImagePullCredentialVerification feature gate is enabled.
+	preflight credentialPreflightFunc
+
+	// verifier approves or rejects resolved image digests before containers
+	// may be created from them. It is nil unless the KubeletImageVerification
+	// feature gate is enabled and a verifier plugin is configured; when set,
+	// verification fails closed.
+	verifier imageVerifyFunc
+
+	// manifests deduplicates manifest resolution across the consumers that
+	// run around a pull (prefetch, size estimation, platform checks). It is
+	// nil until a resolver is installed via setManifestResolver.
+	manifests *manifestCache
+
+	// registryLabels caps the cardinality of the per-registry pull metrics.
+	registryLabels *registryLabelTracker
+
+	// report records completed and failed pulls in the on-disk image pull
+	// report. It is nil unless the KubeletImagePullReport feature gate is
+	// enabled.
+	report *pullreport.Writer
+
+	// pullsLock guards inflightPulls, the pulls currently in flight keyed
+	// by the container spec's image reference.
+	pullsLock     sync.Mutex
+	inflightPulls map[string]*inflightPull
+
+	// attemptsLock guards pullAttempts, the consecutive failed pull attempts
+	// keyed by the same key as backOff, used to enforce the per-pod retry
+	// policy read from the pod's annotations, and rateLimitedUntil, the
+	// registry-imposed earliest next attempt times from Retry-After.
+	attemptsLock     sync.Mutex
+	pullAttempts     map[string]pullAttemptRecord
+	rateLimitedUntil map[string]time.Time
+
+	// presentLock guards presentEvents, the last time the present branch
+	// was taken per pod/image pair, keyed by the same key as backOff.
+	// Restarting containers hit the present branch on every sync; the
+	// "already present" event is emitted once per pair and downgraded to a
+	// log line afterwards. Entries are refreshed on every sync, so pairs
+	// belonging to deleted pods age out after presentEventsTTL.
+	presentLock   sync.Mutex
+	presentEvents map[string]time.Time
+
+	// revalidateInterval is how often a locally present tag is revalidated
+	// against the registry digest under PullIfNotPresent. Zero disables
+	// revalidation. revalidateLock guards revalidated, the last revalidation
+	// times keyed by image reference.
+	revalidateInterval time.Duration
+	revalidateLock     sync.Mutex
+	revalidated        map[string]time.Time
 }
 
 var _ ImageManager = &imageManager{}
 
 // NewImageManager instantiates a new ImageManager object.
-func NewImageManager(recorder record.EventRecorder, imageService kubecontainer.ImageService, imageBackOff *flowcontrol.Backoff, serialized bool, maxParallelImagePulls *int32, qps float32, burst int, podPullingTimeRecorder ImagePodPullingTimeRecorder) ImageManager {
+func NewImageManager(recorder record.EventRecorder, imageService kubecontainer.ImageService, imageBackOff *flowcontrol.Backoff, serialized bool, maxParallelImagePulls *int32, maxParallelImagePullsPerRuntimeClass map[string]int32, qps float32, burst int, maxPullBandwidth int64, tagRevalidateInterval time.Duration, podPullingTimeRecorder ImagePodPullingTimeRecorder, pullReportDir string, imageVerifierBin string) ImageManager {
+	imageService = throttleImagePullBandwidth(imageService, maxPullBandwidth)
 	imageService = throttleImagePulling(imageService, qps, burst)
 
 	var puller imagePuller
@@ -64,30 +126,100 @@ func NewImageManager(recorder record.EventRecorder, imageService kubecontainer.I
 	} else {
 		puller = newParallelImagePuller(imageService, maxParallelImagePulls)
 	}
-	return &imageManager{
+	if len(maxParallelImagePullsPerRuntimeClass) > 0 {
+		puller = newPerRuntimeClassImagePuller(imageService, puller, maxParallelImagePullsPerRuntimeClass)
+	}
+	var report *pullreport.Writer
+	if pullReportDir != "" && utilfeature.DefaultFeatureGate.Enabled(features.KubeletImagePullReport) {
+		report = pullreport.NewWriter(pullReportDir, pullreport.DefaultMaxSizeBytes)
+	}
+	var preflight credentialPreflightFunc
+	if utilfeature.DefaultFeatureGate.Enabled(features.KubeletImagePullCredentialVerification) {
+		preflight = newRegistryCredentialPreflight(credentialprovider.NewDockerKeyring()).check
+	}
+	var verifier imageVerifyFunc
+	if imageVerifierBin != "" && utilfeature.DefaultFeatureGate.Enabled(features.KubeletImageVerification) {
+		verifier = newExecImageVerifier(imageVerifierBin).verify
+	}
+	m := &imageManager{
 		recorder:               recorder,
 		imageService:           imageService,
 		backOff:                imageBackOff,
 		puller:                 puller,
 		podPullingTimeRecorder: podPullingTimeRecorder,
+		preflight:              preflight,
+		verifier:               verifier,
+		registryLabels:         newRegistryLabelTracker(),
+		report:                 report,
+		inflightPulls:          map[string]*inflightPull{},
+		pullAttempts:           map[string]pullAttemptRecord{},
+		rateLimitedUntil:       map[string]time.Time{},
+		presentEvents:          map[string]time.Time{},
+		revalidateInterval:     tagRevalidateInterval,
+		revalidated:            map[string]time.Time{},
+	}
+	if tagRevalidateInterval > 0 {
+		m.setManifestResolver(newRegistryDigestResolver(credentialprovider.NewDockerKeyring()).resolve)
 	}
+	return m
+}
+
+// setManifestResolver installs the registry manifest resolver shared by all
+// resolution consumers. Consumers go through ResolveManifest so concurrent
+// requests for the same image and credentials collapse into one registry
+// round trip.
+func (m *imageManager) setManifestResolver(resolve resolveManifestFunc) {
+	m.manifests = newManifestCache(m.backOff.Clock, defaultManifestCacheTTL, resolve)
+}
+
+// ResolveManifest resolves the manifest for the given image reference
+// through the shared single-flight cache. It returns an error if no
+// resolver is installed.
+func (m *imageManager) ResolveManifest(ctx context.Context, imageRef, credentialHash string) (ManifestResolution, error) {
+	if m.manifests == nil {
+		return ManifestResolution{}, fmt.Errorf("no manifest resolver installed")
+	}
+	return m.manifests.Resolve(ctx, imageRef, credentialHash)
 }
 
 // shouldPullImage returns whether we should pull an image according to
-// the presence and pull policy of the image.
-func shouldPullImage(container *v1.Container, imagePresent bool) bool {
-	if container.ImagePullPolicy == v1.PullNever {
+// the presence of the image and the given pull policy.
+func shouldPullImage(pullPolicy v1.PullPolicy, imagePresent bool) bool {
+	if pullPolicy == v1.PullNever {
 		return false
 	}
 
-	if container.ImagePullPolicy == v1.PullAlways ||
-		(container.ImagePullPolicy == v1.PullIfNotPresent && (!imagePresent)) {
+	if pullPolicy == v1.PullAlways ||
+		(pullPolicy == v1.PullIfNotPresent && (!imagePresent)) {
 		return true
 	}
 
 	return false
 }
 
+// effectivePullPolicy returns the pull policy to apply for container's image,
+// taking the rest of the pod into account. When several containers in the pod
+// share an image with conflicting pull policies, Always wins for the shared
+// pull decision: without this, whether the image is re-pulled would depend on
+// which container happens to sync first, and a container requesting Always
+// could be satisfied by a stale image pulled for an IfNotPresent sibling. A
+// container's own Never policy is preserved: it never triggers a pull itself;
+// a sibling with a stronger policy performs the pull instead.
+func effectivePullPolicy(pod *v1.Pod, container *v1.Container) v1.PullPolicy {
+	policy := container.ImagePullPolicy
+	if policy == v1.PullNever || policy == v1.PullAlways {
+		return policy
+	}
+	podutil.VisitContainers(&pod.Spec, podutil.AllContainers, func(other *v1.Container, _ podutil.ContainerType) bool {
+		if other.Image == container.Image && other.ImagePullPolicy == v1.PullAlways {
+			policy = v1.PullAlways
+			return false
+		}
+		return true
+	})
+	return policy
+}
+
 // records an event using ref, event msg.  log to glog using prefix, msg, logFn
 func (m *imageManager) logIt(ref *v1.ObjectReference, eventtype, event, prefix, msg string, logFn func(args ...interface{})) {
 	if ref != nil {
@@ -136,10 +268,25 @@ func (m *imageManager) EnsureImageExists(ctx context.Context, pod *v1.Pod, conta
 	}
 
 	present := imageRef != ""
-	if !shouldPullImage(container, present) {
+	pullPolicy := effectivePullPolicy(pod, container)
+	// A present tag may still warrant a pull when revalidation finds the
+	// registry now serves a different digest for it.
+	staleTag := present && pullPolicy == v1.PullIfNotPresent && m.tagIsStale(ctx, spec, image)
+	if !staleTag && !shouldPullImage(pullPolicy, present) {
 		if present {
-			msg := fmt.Sprintf("Container image %q already present on machine", container.Image)
-			m.logIt(ref, v1.EventTypeNormal, events.PulledImage, logPrefix, msg, klog.Info)
+			// An image that never leaves the node still has to pass
+			// verification before a container may be created from it.
+			if verifyErr := m.verifyImage(ctx, pod, image, imageRef); verifyErr != nil {
+				msg := fmt.Sprintf("Image %q failed verification: %v", container.Image, verifyErr)
+				m.logIt(ref, v1.EventTypeWarning, events.FailedImageVerification, logPrefix, msg, klog.Warning)
+				return "", msg, ErrImageVerificationFailed
+			}
+			if m.firstPresentEvent(fmt.Sprintf("%s_%s", pod.UID, container.Image)) {
+				msg := fmt.Sprintf("Container image %q already present on machine", container.Image)
+				m.logIt(ref, v1.EventTypeNormal, events.PulledImage, logPrefix, msg, klog.Info)
+			} else {
+				klog.V(4).InfoS("Container image already present on machine", "image", container.Image, "pod", klog.KObj(pod))
+			}
 			return imageRef, "", nil
 		}
 		msg := fmt.Sprintf("Container image %q is not present with pull policy of Never", container.Image)
@@ -147,34 +294,141 @@ func (m *imageManager) EnsureImageExists(ctx context.Context, pod *v1.Pod, conta
 		return "", msg, ErrImageNeverPull
 	}
 
+	policy := pullRetryPolicyForPod(pod)
 	backOffKey := fmt.Sprintf("%s_%s", pod.UID, container.Image)
-	if m.backOff.IsInBackOffSinceUpdate(backOffKey, m.backOff.Clock.Now()) {
+	if policy.maxAttempts > 0 && m.failedPullAttempts(backOffKey) >= policy.maxAttempts {
+		msg := fmt.Sprintf("Exceeded the configured limit of %d pull attempts for image %q", policy.maxAttempts, container.Image)
+		m.logIt(ref, v1.EventTypeNormal, events.BackOffPullImage, logPrefix, msg, klog.Info)
+		return "", msg, ErrImagePullBackOff
+	}
+	if m.backOff.IsInBackOffSinceUpdate(backOffKey, m.backOff.Clock.Now()) && !m.backoffCapElapsed(backOffKey, policy) {
 		msg := fmt.Sprintf("Back-off pulling image %q", container.Image)
 		m.logIt(ref, v1.EventTypeNormal, events.BackOffPullImage, logPrefix, msg, klog.Info)
 		return "", msg, ErrImagePullBackOff
 	}
+	if until, held := m.rateLimitedDeadline(backOffKey); held {
+		if m.backOff.Clock.Now().Before(until) {
+			msg := fmt.Sprintf("Registry rate limited, delaying next pull of %q until %s", container.Image, until.Format(time.RFC3339))
+			m.logIt(ref, v1.EventTypeNormal, events.BackOffPullImage, logPrefix, msg, klog.Info)
+			return "", msg, ErrImagePullBackOff
+		}
+		m.clearRateLimitedDeadline(backOffKey)
+	}
+	if m.preflight != nil {
+		if preflightErr := m.preflight(ctx, image, pullSecrets); preflightErr != nil {
+			if errors.Is(preflightErr, ErrInvalidImageCredentials) {
+				msg := fmt.Sprintf("Failed to pull image %q: %v", container.Image, preflightErr)
+				m.logIt(ref, v1.EventTypeWarning, events.FailedToPullImage, logPrefix, msg, klog.Warning)
+				m.backOff.Next(backOffKey, m.backOff.Clock.Now())
+				m.recordFailedPullAttempt(backOffKey)
+				metrics.ImageRegistryPullFailures.WithLabelValues(m.registryLabels.label(registryHostForImage(image)), pullFailureAuth).Inc()
+				m.reportPull(pullreport.Record{Image: image, Succeeded: false, Reason: ErrInvalidImageCredentials.Error()})
+				return "", msg, ErrInvalidImageCredentials
+			}
+			// An inconclusive check (unreachable registry, unexpected
+			// response) must not block the pull.
+			klog.V(4).InfoS("Image credential preflight was inconclusive, proceeding with the pull", "image", container.Image, "err", preflightErr)
+		}
+	}
 	m.podPullingTimeRecorder.RecordImageStartedPulling(pod.UID)
 	m.logIt(ref, v1.EventTypeNormal, events.PullingImage, logPrefix, fmt.Sprintf("Pulling image %q", container.Image), klog.Info)
 	startTime := time.Now()
+	m.startPullProgress(ctx, container.Image)
+	defer m.finishPullProgress(container.Image)
+	pullCtx, cancel := policy.attemptContext(ctx)
+	defer cancel()
 	pullChan := make(chan pullResult)
-	m.puller.pullImage(ctx, spec, pullSecrets, pullChan, podSandboxConfig)
+	m.puller.pullImage(pullCtx, spec, pullPriorityForContainer(pod, container.Name), pullSecrets, pullChan, podSandboxConfig)
 	imagePullResult := <-pullChan
 	if imagePullResult.err != nil {
 		m.logIt(ref, v1.EventTypeWarning, events.FailedToPullImage, logPrefix, fmt.Sprintf("Failed to pull image %q: %v", container.Image, imagePullResult.err), klog.Warning)
 		m.backOff.Next(backOffKey, m.backOff.Clock.Now())
+		m.recordFailedPullAttempt(backOffKey)
+		metrics.ImageRegistryPullFailures.WithLabelValues(m.registryLabels.label(registryHostForImage(image)), classifyPullError(imagePullResult.err)).Inc()
 
 		msg, err := evalCRIPullErr(container, imagePullResult.err)
+		if errors.Is(err, ErrRegistryRateLimited) {
+			m.logIt(ref, v1.EventTypeWarning, events.RegistryRateLimited, logPrefix, msg, klog.Warning)
+			if retryAfter, ok := parseRetryAfter(imagePullResult.err.Error()); ok {
+				m.setRateLimitedDeadline(backOffKey, m.backOff.Clock.Now().Add(retryAfter))
+			}
+		}
+		m.reportPull(pullreport.Record{Image: image, Succeeded: false, Reason: err.Error()})
 		return "", msg, err
 	}
 	m.podPullingTimeRecorder.RecordImageFinishedPulling(pod.UID)
+	if m.manifests != nil {
+		// The pulled content supersedes anything resolved before the pull.
+		m.manifests.Invalidate(image)
+	}
+	if verifyErr := m.verifyImage(ctx, pod, image, imagePullResult.imageRef); verifyErr != nil {
+		msg := fmt.Sprintf("Image %q failed verification: %v", container.Image, verifyErr)
+		m.logIt(ref, v1.EventTypeWarning, events.FailedImageVerification, logPrefix, msg, klog.Warning)
+		// The rejection is unlikely to resolve on an immediate retry, so it
+		// backs off like a failed pull.
+		m.backOff.Next(backOffKey, m.backOff.Clock.Now())
+		m.recordFailedPullAttempt(backOffKey)
+		m.reportPull(pullreport.Record{Image: image, Succeeded: false, Reason: ErrImageVerificationFailed.Error()})
+		return "", msg, ErrImageVerificationFailed
+	}
 	imagePullDuration := time.Since(startTime).Truncate(time.Millisecond)
 	m.logIt(ref, v1.EventTypeNormal, events.PulledImage, logPrefix, fmt.Sprintf("Successfully pulled image %q in %v (%v including waiting). Image size: %v bytes.",
 		container.Image, imagePullResult.pullDuration.Truncate(time.Millisecond), imagePullDuration, imagePullResult.imageSize), klog.Info)
 	metrics.ImagePullDuration.WithLabelValues(metrics.GetImageSizeBucket(imagePullResult.imageSize)).Observe(imagePullDuration.Seconds())
+	registry := m.registryLabels.label(registryHostForImage(image))
+	metrics.ImageRegistryPullDuration.WithLabelValues(registry).Observe(imagePullDuration.Seconds())
+	metrics.ImageRegistryPulledBytes.WithLabelValues(registry).Add(float64(imagePullResult.imageSize))
+	m.reportPull(pullreport.Record{Image: image, Succeeded: true, SizeBytes: int64(imagePullResult.imageSize)})
 	m.backOff.GC()
+	m.clearPullAttempts(backOffKey)
+	m.clearRateLimitedDeadline(backOffKey)
+	m.clearPresentEvent(backOffKey)
 	return imagePullResult.imageRef, "", nil
 }
 
+// presentEventsTTL is how long a present-branch record is retained after it
+// was last refreshed. Active pods refresh their records on every sync, so
+// only pairs no longer syncing — deleted pods — expire.
+const presentEventsTTL = time.Hour
+
+// firstPresentEvent notes that the present branch was taken for the given
+// key and reports whether the "already present on machine" event still has
+// to be emitted for it. Records not refreshed within presentEventsTTL are
+// pruned along the way.
+func (m *imageManager) firstPresentEvent(key string) bool {
+	now := m.backOff.Clock.Now()
+	m.presentLock.Lock()
+	defer m.presentLock.Unlock()
+	for recorded, last := range m.presentEvents {
+		if now.Sub(last) > presentEventsTTL {
+			delete(m.presentEvents, recorded)
+		}
+	}
+	_, emitted := m.presentEvents[key]
+	m.presentEvents[key] = now
+	return !emitted
+}
+
+// clearPresentEvent forgets the emitted "already present on machine" event
+// for the given key, so a fresh pull is followed by a fresh event.
+func (m *imageManager) clearPresentEvent(key string) {
+	m.presentLock.Lock()
+	defer m.presentLock.Unlock()
+	delete(m.presentEvents, key)
+}
+
+// reportPull records the outcome of a pull attempt in the on-disk image pull
+// report, when enabled. Failures to write the report are logged but never
+// affect the pull itself.
+func (m *imageManager) reportPull(record pullreport.Record) {
+	if m.report == nil {
+		return
+	}
+	if err := m.report.Record(record); err != nil {
+		klog.ErrorS(err, "Failed to record image pull report entry", "image", record.Image)
+	}
+}
+
 func evalCRIPullErr(container *v1.Container, err error) (errMsg string, errRes error) {
 	// Error assertions via errors.Is is not supported by gRPC (remote runtime) errors right now.
 	// See https://github.com/grpc/grpc-go/issues/3616
@@ -202,10 +456,48 @@ func evalCRIPullErr(container *v1.Container, err error) (errMsg string, errRes e
 		return errMsg, crierrors.ErrSignatureValidationFailed
 	}
 
+	if isRegistryRateLimitErr(err) {
+		errMsg = fmt.Sprintf(
+			"image pull failed for %s because the registry rate limited the request: %v",
+			container.Image,
+			err,
+		)
+		return errMsg, ErrRegistryRateLimited
+	}
+
+	if isHTMLResponseErr(err) {
+		errMsg = fmt.Sprintf(
+			"image pull failed for %s because the registry returned an HTML page instead of a registry API response; the node is likely behind a captive portal or an SSO proxy intercepted the request: %v",
+			container.Image,
+			err,
+		)
+		return errMsg, ErrImagePullHTMLResponse
+	}
+
 	// Fallback for no specific error
 	return err.Error(), ErrImagePull
 }
 
+// isHTMLResponseErr detects pull errors caused by the registry endpoint
+// replying with an HTML document, e.g. a captive portal or SSO proxy login
+// page. Runtimes surface these either by quoting the body or by failing to
+// decode it as JSON.
+func isHTMLResponseErr(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{
+		"<!doctype html",
+		"<html",
+		"content-type: text/html",
+		"mime type text/html",
+		"invalid character '<' looking for beginning of value",
+	} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
 // applyDefaultImageTag parses a docker image string, if it doesn't contain any tag or digest,
 // a default tag will be applied.
 func applyDefaultImageTag(image string) (string, error) {
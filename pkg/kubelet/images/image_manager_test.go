@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -36,6 +37,7 @@ import (
 	"k8s.io/kubernetes/pkg/features"
 	. "k8s.io/kubernetes/pkg/kubelet/container"
 	ctest "k8s.io/kubernetes/pkg/kubelet/container/testing"
+	"k8s.io/kubernetes/pkg/kubelet/images/pullreport"
 	testingclock "k8s.io/utils/clock/testing"
 	utilpointer "k8s.io/utils/pointer"
 )
@@ -69,7 +71,7 @@ func pullerTestCases() []pullerTestCase {
 			qps:            0.0,
 			burst:          0,
 			expected: []pullerExpects{
-				{[]string{"GetImageRef", "PullImage", "GetImageSize"}, nil, true, true},
+				{[]string{"GetImageRef", "ImageStats", "PullImage", "GetImageSize"}, nil, true, true},
 			}},
 
 		{ // image present, don't pull
@@ -94,9 +96,9 @@ func pullerTestCases() []pullerTestCase {
 			qps:        0.0,
 			burst:      0,
 			expected: []pullerExpects{
-				{[]string{"GetImageRef", "PullImage", "GetImageSize"}, nil, true, true},
-				{[]string{"GetImageRef", "PullImage", "GetImageSize"}, nil, true, true},
-				{[]string{"GetImageRef", "PullImage", "GetImageSize"}, nil, true, true},
+				{[]string{"GetImageRef", "ImageStats", "PullImage", "GetImageSize"}, nil, true, true},
+				{[]string{"GetImageRef", "ImageStats", "PullImage", "GetImageSize"}, nil, true, true},
+				{[]string{"GetImageRef", "ImageStats", "PullImage", "GetImageSize"}, nil, true, true},
 			}},
 		// missing image, error PullNever
 		{containerImage: "missing_image",
@@ -133,10 +135,10 @@ func pullerTestCases() []pullerTestCase {
 			qps:        0.0,
 			burst:      0,
 			expected: []pullerExpects{
-				{[]string{"GetImageRef", "PullImage"}, ErrImagePull, true, false},
-				{[]string{"GetImageRef", "PullImage"}, ErrImagePull, true, false},
+				{[]string{"GetImageRef", "ImageStats", "PullImage"}, ErrImagePull, true, false},
+				{[]string{"GetImageRef", "ImageStats", "PullImage"}, ErrImagePull, true, false},
 				{[]string{"GetImageRef"}, ErrImagePullBackOff, false, false},
-				{[]string{"GetImageRef", "PullImage"}, ErrImagePull, true, false},
+				{[]string{"GetImageRef", "ImageStats", "PullImage"}, ErrImagePull, true, false},
 				{[]string{"GetImageRef"}, ErrImagePullBackOff, false, false},
 				{[]string{"GetImageRef"}, ErrImagePullBackOff, false, false},
 			}},
@@ -149,9 +151,9 @@ func pullerTestCases() []pullerTestCase {
 			qps:        400.0,
 			burst:      600,
 			expected: []pullerExpects{
-				{[]string{"GetImageRef", "PullImage", "GetImageSize"}, nil, true, true},
-				{[]string{"GetImageRef", "PullImage", "GetImageSize"}, nil, true, true},
-				{[]string{"GetImageRef", "PullImage", "GetImageSize"}, nil, true, true},
+				{[]string{"GetImageRef", "ImageStats", "PullImage", "GetImageSize"}, nil, true, true},
+				{[]string{"GetImageRef", "ImageStats", "PullImage", "GetImageSize"}, nil, true, true},
+				{[]string{"GetImageRef", "ImageStats", "PullImage", "GetImageSize"}, nil, true, true},
 			}},
 		// image present, non-zero qps, try to pull when qps exceeded
 		{containerImage: "present_image",
@@ -162,8 +164,8 @@ func pullerTestCases() []pullerTestCase {
 			qps:        2000.0,
 			burst:      0,
 			expected: []pullerExpects{
-				{[]string{"GetImageRef"}, ErrImagePull, true, false},
-				{[]string{"GetImageRef"}, ErrImagePull, true, false},
+				{[]string{"GetImageRef", "ImageStats"}, ErrImagePull, true, false},
+				{[]string{"GetImageRef", "ImageStats"}, ErrImagePull, true, false},
 				{[]string{"GetImageRef"}, ErrImagePullBackOff, false, false},
 			}},
 		// error case if image name fails validation due to invalid reference format
@@ -247,7 +249,7 @@ func pullerTestEnv(t *testing.T, c pullerTestCase, serialized bool, maxParallelI
 
 	fakePodPullingTimeRecorder = &mockPodPullingTimeRecorder{}
 
-	puller = NewImageManager(fakeRecorder, fakeRuntime, backOff, serialized, maxParallelImagePulls, c.qps, c.burst, fakePodPullingTimeRecorder)
+	puller = NewImageManager(fakeRecorder, fakeRuntime, backOff, serialized, maxParallelImagePulls, nil, c.qps, c.burst, 0, 0, fakePodPullingTimeRecorder, "", "")
 	return
 }
 
@@ -315,6 +317,59 @@ func TestSerializedPuller(t *testing.T) {
 	}
 }
 
+func TestPresentImageEventDeduplicated(t *testing.T) {
+	ctx := context.Background()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test_pod",
+			Namespace:       "test-ns",
+			UID:             "bar",
+			ResourceVersion: "42",
+		}}
+	container := &v1.Container{
+		Name:            "container_name",
+		Image:           "present_image",
+		ImagePullPolicy: v1.PullIfNotPresent,
+	}
+
+	backOff := flowcontrol.NewBackOff(time.Second, time.Minute)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	backOff.Clock = fakeClock
+	fakeRuntime := &ctest.FakeRuntime{T: t, ImageList: []Image{{ID: "present_image:latest"}}}
+	fakeRecorder := record.NewFakeRecorder(16)
+	puller := NewImageManager(fakeRecorder, fakeRuntime, backOff, false, nil, nil, 0, 0, 0, 0, &mockPodPullingTimeRecorder{}, "", "")
+
+	countPresentEvents := func() int {
+		presentEvents := 0
+	drain:
+		for {
+			select {
+			case event := <-fakeRecorder.Events:
+				if strings.Contains(event, "already present on machine") {
+					presentEvents++
+				}
+			default:
+				break drain
+			}
+		}
+		return presentEvents
+	}
+
+	// Restarting containers resolve the present image on every sync; only
+	// the first resolution may emit the "already present" event.
+	for i := 0; i < 3; i++ {
+		_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, countPresentEvents(), "expected exactly one 'already present' event across repeated syncs")
+
+	// A pair no longer syncing ages out, so a much later sync emits anew.
+	fakeClock.Step(2 * presentEventsTTL)
+	_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, countPresentEvents(), "expected the event again after the record expired")
+}
+
 func TestApplyDefaultImageTag(t *testing.T) {
 	for _, testCase := range []struct {
 		testName string
@@ -356,7 +411,7 @@ func TestPullAndListImageWithPodAnnotations(t *testing.T) {
 		inspectErr:     nil,
 		pullerErr:      nil,
 		expected: []pullerExpects{
-			{[]string{"GetImageRef", "PullImage", "GetImageSize"}, nil, true, true},
+			{[]string{"GetImageRef", "ImageStats", "PullImage", "GetImageSize"}, nil, true, true},
 		}}
 
 	useSerializedEnv := true
@@ -412,7 +467,7 @@ func TestPullAndListImageWithRuntimeHandlerInImageCriAPIFeatureGate(t *testing.T
 		inspectErr:     nil,
 		pullerErr:      nil,
 		expected: []pullerExpects{
-			{[]string{"GetImageRef", "PullImage", "GetImageSize"}, nil, true, true},
+			{[]string{"GetImageRef", "ImageStats", "PullImage", "GetImageSize"}, nil, true, true},
 		}}
 
 	useSerializedEnv := true
@@ -479,14 +534,22 @@ func TestMaxParallelImagePullsLimit(t *testing.T) {
 	fakeRuntime.T = t
 	fakeClock.Step(time.Second)
 
+	// Use a distinct image per request so the in-flight deduplication does
+	// not coalesce them; this test is about the parallelism limit.
+	containerForImage := func(i int) *v1.Container {
+		c := *container
+		c.Image = fmt.Sprintf("%s_%d", container.Image, i)
+		return &c
+	}
+
 	// First 5 EnsureImageExists should result in runtime calls
 	for i := 0; i < maxParallelImagePulls; i++ {
 		wg.Add(1)
-		go func() {
-			_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+		go func(i int) {
+			_, _, err := puller.EnsureImageExists(ctx, pod, containerForImage(i), nil, nil, "")
 			assert.Nil(t, err)
 			wg.Done()
-		}()
+		}(i)
 	}
 	time.Sleep(1 * time.Second)
 	fakeRuntime.AssertCallCounts("PullImage", 5)
@@ -494,11 +557,11 @@ func TestMaxParallelImagePullsLimit(t *testing.T) {
 	// Next two EnsureImageExists should be blocked because maxParallelImagePulls is hit
 	for i := 0; i < 2; i++ {
 		wg.Add(1)
-		go func() {
-			_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+		go func(i int) {
+			_, _, err := puller.EnsureImageExists(ctx, pod, containerForImage(maxParallelImagePulls+i), nil, nil, "")
 			assert.Nil(t, err)
 			wg.Done()
-		}()
+		}(i)
 	}
 	time.Sleep(1 * time.Second)
 	fakeRuntime.AssertCallCounts("PullImage", 5)
@@ -515,6 +578,314 @@ func TestMaxParallelImagePullsLimit(t *testing.T) {
 	fakeRuntime.AssertCallCounts("PullImage", 7)
 }
 
+func TestPerRuntimeClassImagePullLimits(t *testing.T) {
+	ctx := context.Background()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test_pod",
+			Namespace:       "test-ns",
+			UID:             "bar",
+			ResourceVersion: "42",
+		}}
+
+	backOff := flowcontrol.NewBackOff(time.Second, time.Minute)
+	backOff.Clock = testingclock.NewFakeClock(time.Now())
+	fakeRuntime := &ctest.FakeRuntime{T: t, BlockImagePulls: true}
+	// Pulls for the "gpu" runtime class are serialized; every other runtime
+	// class keeps the unlimited node-wide default.
+	puller := NewImageManager(&record.FakeRecorder{}, fakeRuntime, backOff, false, nil, map[string]int32{"gpu": 1}, 0, 0, 0, 0, &mockPodPullingTimeRecorder{}, "", "")
+
+	containerForImage := func(i int) *v1.Container {
+		return &v1.Container{
+			Name:            "container_name",
+			Image:           fmt.Sprintf("missing_image_%d", i),
+			ImagePullPolicy: v1.PullAlways,
+		}
+	}
+
+	var wg sync.WaitGroup
+	// Two pulls for the serialized runtime class: only the first reaches the
+	// runtime, the second waits in the serial puller's queue.
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			_, _, err := puller.EnsureImageExists(ctx, pod, containerForImage(i), nil, nil, "gpu")
+			assert.Nil(t, err)
+			wg.Done()
+		}(i)
+	}
+	time.Sleep(1 * time.Second)
+	fakeRuntime.AssertCallCounts("PullImage", 1)
+
+	// Two pulls for a runtime class without an override run in parallel
+	// through the default puller, unaffected by the serialized class.
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			_, _, err := puller.EnsureImageExists(ctx, pod, containerForImage(2+i), nil, nil, "")
+			assert.Nil(t, err)
+			wg.Done()
+		}(i)
+	}
+	time.Sleep(1 * time.Second)
+	fakeRuntime.AssertCallCounts("PullImage", 3)
+
+	fakeRuntime.UnblockImagePulls(4)
+	wg.Wait()
+	fakeRuntime.AssertCallCounts("PullImage", 4)
+}
+
+func TestEnsureImageExistsCredentialPreflight(t *testing.T) {
+	ctx := context.Background()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test_pod",
+			Namespace:       "test-ns",
+			UID:             "bar",
+			ResourceVersion: "42",
+		}}
+	c := pullerTestCase{containerImage: "missing_image", policy: v1.PullIfNotPresent}
+	puller, fakeClock, fakeRuntime, container, _ := pullerTestEnv(t, c, false, nil)
+	m := puller.(*imageManager)
+
+	// A definitive rejection surfaces InvalidImageCredentials without
+	// scheduling the pull.
+	m.preflight = func(ctx context.Context, image string, pullSecrets []v1.Secret) error {
+		return fmt.Errorf("registry rejected all credentials: %w", ErrInvalidImageCredentials)
+	}
+	fakeRuntime.CalledFunctions = nil
+	_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.Equal(t, ErrInvalidImageCredentials, err)
+	fakeRuntime.AssertCalls([]string{"GetImageRef"})
+
+	// The rejection counts against the pull backoff like a failed pull.
+	_, _, err = puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.Equal(t, ErrImagePullBackOff, err)
+
+	// An inconclusive preflight must not block the pull.
+	m.preflight = func(ctx context.Context, image string, pullSecrets []v1.Secret) error {
+		return errors.New("connection refused")
+	}
+	fakeClock.Step(time.Hour)
+	fakeRuntime.CalledFunctions = nil
+	_, _, err = puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.NoError(t, err)
+	fakeRuntime.AssertCalls([]string{"GetImageRef", "ImageStats", "PullImage", "GetImageSize"})
+}
+
+func TestPrefetchImages(t *testing.T) {
+	ctx := context.Background()
+	c := pullerTestCase{containerImage: "missing_image", policy: v1.PullIfNotPresent}
+	puller, _, fakeRuntime, _, _ := pullerTestEnv(t, c, true, nil)
+
+	results := puller.PrefetchImages(ctx, []ImagePrefetchSpec{
+		{Image: "missing_image:latest"},
+		{Image: "present_image:latest"},
+		{Image: "invalid image"},
+	})
+	assert.Len(t, results, 3)
+
+	// The missing image is pulled.
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "missing_image:latest", results[0].ImageRef)
+
+	// The present image is reported without a pull.
+	assert.NoError(t, results[1].Err)
+	assert.Equal(t, "present_image:latest", results[1].ImageRef)
+
+	// The invalid image name fails without touching the runtime.
+	assert.Error(t, results[2].Err)
+
+	fakeRuntime.AssertCallCounts("PullImage", 1)
+
+	// Pull failures are reported per image.
+	fakeRuntime.Err = errors.New("registry is down")
+	results = puller.PrefetchImages(ctx, []ImagePrefetchSpec{{Image: "another_image:latest"}})
+	assert.Len(t, results, 1)
+	assert.ErrorContains(t, results[0].Err, "registry is down")
+}
+
+func TestEffectivePullPolicy(t *testing.T) {
+	sharedImage := "shared_image"
+	makeContainer := func(name, image string, policy v1.PullPolicy) v1.Container {
+		return v1.Container{Name: name, Image: image, ImagePullPolicy: policy}
+	}
+
+	for _, tc := range []struct {
+		name           string
+		initContainers []v1.Container
+		containers     []v1.Container
+		forContainer   string
+		expected       v1.PullPolicy
+	}{
+		{
+			name: "single container keeps its policy",
+			containers: []v1.Container{
+				makeContainer("a", sharedImage, v1.PullIfNotPresent),
+			},
+			forContainer: "a",
+			expected:     v1.PullIfNotPresent,
+		},
+		{
+			name: "Always sibling upgrades IfNotPresent",
+			containers: []v1.Container{
+				makeContainer("a", sharedImage, v1.PullIfNotPresent),
+				makeContainer("b", sharedImage, v1.PullAlways),
+			},
+			forContainer: "a",
+			expected:     v1.PullAlways,
+		},
+		{
+			name: "ordering does not matter",
+			containers: []v1.Container{
+				makeContainer("b", sharedImage, v1.PullAlways),
+				makeContainer("a", sharedImage, v1.PullIfNotPresent),
+			},
+			forContainer: "a",
+			expected:     v1.PullAlways,
+		},
+		{
+			name: "Always container keeps Always",
+			containers: []v1.Container{
+				makeContainer("a", sharedImage, v1.PullAlways),
+				makeContainer("b", sharedImage, v1.PullIfNotPresent),
+			},
+			forContainer: "a",
+			expected:     v1.PullAlways,
+		},
+		{
+			name: "Never is preserved despite Always sibling",
+			containers: []v1.Container{
+				makeContainer("a", sharedImage, v1.PullNever),
+				makeContainer("b", sharedImage, v1.PullAlways),
+			},
+			forContainer: "a",
+			expected:     v1.PullNever,
+		},
+		{
+			name: "different image does not upgrade",
+			containers: []v1.Container{
+				makeContainer("a", sharedImage, v1.PullIfNotPresent),
+				makeContainer("b", "other_image", v1.PullAlways),
+			},
+			forContainer: "a",
+			expected:     v1.PullIfNotPresent,
+		},
+		{
+			name: "Always init container upgrades app container",
+			initContainers: []v1.Container{
+				makeContainer("init", sharedImage, v1.PullAlways),
+			},
+			containers: []v1.Container{
+				makeContainer("a", sharedImage, v1.PullIfNotPresent),
+			},
+			forContainer: "a",
+			expected:     v1.PullAlways,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test_pod", Namespace: "test-ns"},
+				Spec: v1.PodSpec{
+					InitContainers: tc.initContainers,
+					Containers:     tc.containers,
+				},
+			}
+			var container *v1.Container
+			for i := range pod.Spec.Containers {
+				if pod.Spec.Containers[i].Name == tc.forContainer {
+					container = &pod.Spec.Containers[i]
+				}
+			}
+			assert.Equal(t, tc.expected, effectivePullPolicy(pod, container))
+		})
+	}
+}
+
+func TestPullerCoalescesDuplicateRequests(t *testing.T) {
+	for _, serialized := range []bool{true, false} {
+		t.Run(fmt.Sprintf("serialized=%t", serialized), func(t *testing.T) {
+			ctx := context.Background()
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "test_pod",
+					Namespace:       "test-ns",
+					UID:             "bar",
+					ResourceVersion: "42",
+				}}
+			c := pullerTestCase{
+				containerImage: "missing_image",
+				policy:         v1.PullAlways,
+			}
+			puller, fakeClock, fakeRuntime, container, _ := pullerTestEnv(t, c, serialized, nil)
+			fakeRuntime.BlockImagePulls = true
+			fakeRuntime.CalledFunctions = nil
+			fakeClock.Step(time.Second)
+
+			// Two duplicate requests for the same image and credentials must
+			// share one runtime pull, even with an Always pull policy: the
+			// shared pull is still in flight, so its result is fresh.
+			var wg sync.WaitGroup
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+					assert.Nil(t, err)
+				}()
+			}
+			// Let both requests join the in-flight pull before releasing it.
+			time.Sleep(1 * time.Second)
+			fakeRuntime.UnblockImagePulls(1)
+			wg.Wait()
+			fakeRuntime.AssertCallCounts("PullImage", 1)
+		})
+	}
+}
+
+func TestImagePullReport(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.KubeletImagePullReport, true)
+	ctx := context.Background()
+	dir := t.TempDir()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test_pod",
+			Namespace:       "test-ns",
+			UID:             "bar",
+			ResourceVersion: "42",
+		}}
+	container := &v1.Container{
+		Name:            "container_name",
+		Image:           "missing_image",
+		ImagePullPolicy: v1.PullAlways,
+	}
+	backOff := flowcontrol.NewBackOff(time.Second, time.Minute)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	backOff.Clock = fakeClock
+	fakeRuntime := &ctest.FakeRuntime{T: t}
+	puller := NewImageManager(&record.FakeRecorder{}, fakeRuntime, backOff, false, nil, nil, 0, 0, 0, 0, &mockPodPullingTimeRecorder{}, dir, "")
+
+	_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.Nil(t, err)
+
+	fakeRuntime.Err = errors.New("404 image not found")
+	fakeClock.Step(time.Hour)
+	_, _, err = puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.Equal(t, ErrImagePull, err)
+
+	records, err := pullreport.ReadDir(dir)
+	assert.Nil(t, err)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, pullreport.SchemaVersion, records[0].SchemaVersion)
+		assert.Equal(t, "missing_image:latest", records[0].Image)
+		assert.Equal(t, "docker.io", records[0].Registry)
+		assert.True(t, records[0].Succeeded)
+		assert.Equal(t, "missing_image:latest", records[1].Image)
+		assert.False(t, records[1].Succeeded)
+		assert.Equal(t, ErrImagePull.Error(), records[1].Reason)
+	}
+}
+
 func TestEvalCRIPullErr(t *testing.T) {
 	t.Parallel()
 	for _, tc := range []struct {
@@ -562,6 +933,22 @@ func TestEvalCRIPullErr(t *testing.T) {
 				assert.Equal(t, msg, "image pull failed for test because the signature validation failed: bar")
 			},
 		},
+		{
+			name:  "registry response is an HTML login page",
+			input: errors.New(`failed to resolve reference: unexpected response: <!DOCTYPE HTML><html><head><title>Sign in</title></head></html>`),
+			assert: func(msg string, err error) {
+				assert.ErrorIs(t, err, ErrImagePullHTMLResponse)
+				assert.Contains(t, msg, "captive portal")
+			},
+		},
+		{
+			name:  "registry response body failed to decode as JSON",
+			input: errors.New(`failed to fetch manifest: invalid character '<' looking for beginning of value`),
+			assert: func(msg string, err error) {
+				assert.ErrorIs(t, err, ErrImagePullHTMLResponse)
+				assert.Contains(t, msg, "HTML page instead of a registry API response")
+			},
+		},
 	} {
 		testInput := tc.input
 		testAssert := tc.assert
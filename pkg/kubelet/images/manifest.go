@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"k8s.io/kubernetes/pkg/kubelet/metrics"
+	"k8s.io/utils/clock"
+)
+
+// defaultManifestCacheTTL bounds how long a resolved manifest is reused.
+// The cache only needs to bridge the consumers of a single pod start (size
+// estimation, platform checks, the pull itself), so the TTL is short.
+const defaultManifestCacheTTL = 30 * time.Second
+
+// ManifestResolution is the registry metadata shared between resolution
+// consumers for one image reference.
+type ManifestResolution struct {
+	// Digest is the resolved manifest digest.
+	Digest string
+	// SizeBytes is the compressed size of the image as reported by the
+	// manifest, or 0 if the registry did not report it.
+	SizeBytes int64
+}
+
+// resolveManifestFunc fetches the manifest for an image reference from the
+// registry using the credentials identified by credentialHash.
+type resolveManifestFunc func(ctx context.Context, imageRef, credentialHash string) (ManifestResolution, error)
+
+// manifestCache deduplicates manifest resolution across the consumers that
+// run around an image pull (prefetch, size estimation, the pull itself).
+// Concurrent requests for the same (image ref, credential hash) share a
+// single registry round trip, and completed resolutions are reused until
+// the TTL elapses or the image is pulled.
+type manifestCache struct {
+	clock   clock.Clock
+	ttl     time.Duration
+	resolve resolveManifestFunc
+
+	group singleflight.Group
+
+	lock    sync.Mutex
+	entries map[string]manifestCacheEntry
+}
+
+type manifestCacheEntry struct {
+	resolution ManifestResolution
+	imageRef   string
+	expiresAt  time.Time
+}
+
+func newManifestCache(clock clock.Clock, ttl time.Duration, resolve resolveManifestFunc) *manifestCache {
+	return &manifestCache{
+		clock:   clock,
+		ttl:     ttl,
+		resolve: resolve,
+		entries: map[string]manifestCacheEntry{},
+	}
+}
+
+func manifestCacheKey(imageRef, credentialHash string) string {
+	return fmt.Sprintf("%s|%s", imageRef, credentialHash)
+}
+
+// Resolve returns the manifest for the given image reference, contacting
+// the registry at most once per cache window regardless of how many
+// consumers ask concurrently.
+func (c *manifestCache) Resolve(ctx context.Context, imageRef, credentialHash string) (ManifestResolution, error) {
+	key := manifestCacheKey(imageRef, credentialHash)
+
+	c.lock.Lock()
+	entry, found := c.entries[key]
+	if found && c.clock.Now().Before(entry.expiresAt) {
+		c.lock.Unlock()
+		metrics.ImageManifestResolutionCacheHits.Inc()
+		return entry.resolution, nil
+	}
+	c.lock.Unlock()
+
+	res, err, _ := c.group.Do(key, func() (interface{}, error) {
+		metrics.ImageManifestResolutions.Inc()
+		resolution, err := c.resolve(ctx, imageRef, credentialHash)
+		if err != nil {
+			return ManifestResolution{}, err
+		}
+		c.lock.Lock()
+		c.entries[key] = manifestCacheEntry{
+			resolution: resolution,
+			imageRef:   imageRef,
+			expiresAt:  c.clock.Now().Add(c.ttl),
+		}
+		c.lock.Unlock()
+		return resolution, nil
+	})
+	if err != nil {
+		return ManifestResolution{}, err
+	}
+	return res.(ManifestResolution), nil
+}
+
+// Invalidate drops all cached resolutions for the given image reference,
+// regardless of credentials. It is called when a pull completes, since the
+// pulled content supersedes anything resolved beforehand.
+func (c *manifestCache) Invalidate(imageRef string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for key, entry := range c.entries {
+		if entry.imageRef == imageRef {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestManifestCacheSingleFlight(t *testing.T) {
+	var fetches int32
+	release := make(chan struct{})
+	cache := newManifestCache(testingclock.NewFakeClock(time.Now()), defaultManifestCacheTTL,
+		func(ctx context.Context, imageRef, credentialHash string) (ManifestResolution, error) {
+			atomic.AddInt32(&fetches, 1)
+			<-release
+			return ManifestResolution{Digest: "sha256:abc", SizeBytes: 42}, nil
+		})
+
+	const consumers = 5
+	var wg sync.WaitGroup
+	wg.Add(consumers)
+	for i := 0; i < consumers; i++ {
+		go func() {
+			defer wg.Done()
+			resolution, err := cache.Resolve(context.Background(), "registry.test/app:v1", "cred-hash")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if resolution.Digest != "sha256:abc" {
+				t.Errorf("unexpected digest %q", resolution.Digest)
+			}
+		}()
+	}
+
+	// Let the concurrent consumers pile up on the in-flight resolution
+	// before releasing it.
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Errorf("expected concurrent consumers to share 1 manifest fetch, got %d", got)
+	}
+}
+
+func TestManifestCacheTTLAndInvalidation(t *testing.T) {
+	var fetches int32
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	cache := newManifestCache(fakeClock, defaultManifestCacheTTL,
+		func(ctx context.Context, imageRef, credentialHash string) (ManifestResolution, error) {
+			atomic.AddInt32(&fetches, 1)
+			return ManifestResolution{Digest: "sha256:abc"}, nil
+		})
+
+	resolve := func() {
+		t.Helper()
+		if _, err := cache.Resolve(context.Background(), "registry.test/app:v1", "cred-hash"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	resolve()
+	resolve()
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Fatalf("expected second resolution to be served from cache, got %d fetches", got)
+	}
+
+	// A different credential hash must not share the cached entry: the
+	// registry may answer differently per credential.
+	if _, err := cache.Resolve(context.Background(), "registry.test/app:v1", "other-cred"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&fetches); got != 2 {
+		t.Fatalf("expected a separate fetch for different credentials, got %d fetches", got)
+	}
+
+	// Invalidation on pull completion drops entries for all credentials.
+	cache.Invalidate("registry.test/app:v1")
+	resolve()
+	if got := atomic.LoadInt32(&fetches); got != 3 {
+		t.Fatalf("expected a fresh fetch after invalidation, got %d fetches", got)
+	}
+
+	// Expiry after the TTL elapses.
+	fakeClock.Step(defaultManifestCacheTTL + time.Second)
+	resolve()
+	if got := atomic.LoadInt32(&fetches); got != 4 {
+		t.Fatalf("expected a fresh fetch after TTL expiry, got %d fetches", got)
+	}
+}
+
+func TestManifestCacheError(t *testing.T) {
+	var fetches int32
+	cache := newManifestCache(testingclock.NewFakeClock(time.Now()), defaultManifestCacheTTL,
+		func(ctx context.Context, imageRef, credentialHash string) (ManifestResolution, error) {
+			atomic.AddInt32(&fetches, 1)
+			return ManifestResolution{}, errors.New("registry unavailable")
+		})
+
+	if _, err := cache.Resolve(context.Background(), "registry.test/app:v1", "cred-hash"); err == nil {
+		t.Fatal("expected resolution error")
+	}
+	// Errors must not be cached.
+	if _, err := cache.Resolve(context.Background(), "registry.test/app:v1", "cred-hash"); err == nil {
+		t.Fatal("expected resolution error")
+	}
+	if got := atomic.LoadInt32(&fetches); got != 2 {
+		t.Errorf("expected errors not to be cached, got %d fetches", got)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	kubecontainer "k8s.io/kubernetes/pkg/kubelet/container"
+	"k8s.io/kubernetes/pkg/kubelet/images/pullreport"
+	"k8s.io/kubernetes/pkg/kubelet/metrics"
+)
+
+// PrefetchImages pulls the given images ahead of pod scheduling. Each image
+// is pulled with the secrets from its spec, outside any pod context: there
+// is no pod-level backoff to honor and no events to emit, but the pulls
+// share the puller with pod-driven pulls, so the configured serialization
+// and parallelism limits apply, and they feed the same metrics and pull
+// report.
+func (m *imageManager) PrefetchImages(ctx context.Context, specs []kubecontainer.ImagePrefetchSpec) []kubecontainer.ImagePrefetchResult {
+	results := make([]kubecontainer.ImagePrefetchResult, 0, len(specs))
+	for _, spec := range specs {
+		results = append(results, m.prefetchImage(ctx, spec))
+	}
+	return results
+}
+
+func (m *imageManager) prefetchImage(ctx context.Context, spec kubecontainer.ImagePrefetchSpec) kubecontainer.ImagePrefetchResult {
+	result := kubecontainer.ImagePrefetchResult{Image: spec.Image}
+
+	image, err := applyDefaultImageTag(spec.Image)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to apply default image tag %q: %w", spec.Image, err)
+		return result
+	}
+	imageSpec := kubecontainer.ImageSpec{Image: image}
+
+	imageRef, err := m.imageService.GetImageRef(ctx, imageSpec)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to inspect image %q: %w", spec.Image, err)
+		return result
+	}
+	if imageRef != "" {
+		// Already present, nothing to pull.
+		result.ImageRef = imageRef
+		return result
+	}
+
+	klog.InfoS("Prefetching image", "image", image)
+	startTime := time.Now()
+	m.startPullProgress(ctx, image)
+	defer m.finishPullProgress(image)
+	pullChan := make(chan pullResult)
+	m.puller.pullImage(ctx, imageSpec, defaultPullPriority, spec.PullSecrets, pullChan, nil)
+	imagePullResult := <-pullChan
+	if imagePullResult.err != nil {
+		klog.ErrorS(imagePullResult.err, "Failed to prefetch image", "image", image)
+		metrics.ImageRegistryPullFailures.WithLabelValues(m.registryLabels.label(registryHostForImage(image)), classifyPullError(imagePullResult.err)).Inc()
+		m.reportPull(pullreport.Record{Image: image, Succeeded: false, Reason: imagePullResult.err.Error()})
+		result.Err = imagePullResult.err
+		return result
+	}
+	imagePullDuration := time.Since(startTime).Truncate(time.Millisecond)
+	klog.InfoS("Successfully prefetched image", "image", image, "duration", imagePullDuration, "size", imagePullResult.imageSize)
+	registry := m.registryLabels.label(registryHostForImage(image))
+	metrics.ImageRegistryPullDuration.WithLabelValues(registry).Observe(imagePullDuration.Seconds())
+	metrics.ImageRegistryPulledBytes.WithLabelValues(registry).Add(float64(imagePullResult.imageSize))
+	m.reportPull(pullreport.Record{Image: image, Succeeded: true, SizeBytes: int64(imagePullResult.imageSize)})
+	result.ImageRef = imagePullResult.imageRef
+	return result
+}
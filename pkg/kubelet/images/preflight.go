@@ -0,0 +1,251 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/credentialprovider"
+	credentialprovidersecrets "k8s.io/kubernetes/pkg/credentialprovider/secrets"
+	"k8s.io/kubernetes/pkg/util/parsers"
+)
+
+// preflightTimeout bounds each registry round trip of the credential
+// preflight. The check runs in the pod sync path, so a slow registry must
+// not hold up the worker longer than a pull attempt would.
+const preflightTimeout = 10 * time.Second
+
+// credentialPreflightFunc verifies the credentials available for an image
+// against its registry before a pull is scheduled. It returns an error
+// wrapping ErrInvalidImageCredentials when the registry definitively
+// rejected every available credential; any other error means the check was
+// inconclusive and the pull proceeds normally.
+type credentialPreflightFunc func(ctx context.Context, image string, pullSecrets []v1.Secret) error
+
+// manifestAcceptTypes is the Accept header sent with preflight manifest
+// requests, covering the manifest and index media types a pull would ask
+// for.
+var manifestAcceptTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+// registryCredentialPreflight validates image pull credentials with a HEAD
+// request on the image's manifest, following one Bearer token challenge
+// when the registry issues one. Only a 401 or 403 for every available
+// credential marks the credentials invalid; anonymous pulls, short image
+// names without an explicit registry, unreachable registries and
+// unexpected responses all leave the outcome to the pull itself.
+type registryCredentialPreflight struct {
+	client  *http.Client
+	keyring credentialprovider.DockerKeyring
+}
+
+func newRegistryCredentialPreflight(keyring credentialprovider.DockerKeyring) *registryCredentialPreflight {
+	return &registryCredentialPreflight{
+		client:  &http.Client{Timeout: preflightTimeout},
+		keyring: keyring,
+	}
+}
+
+// check implements credentialPreflightFunc on top of the node-level keyring
+// merged with the pod's pull secrets, mirroring the credential resolution
+// the pull itself performs.
+func (p *registryCredentialPreflight) check(ctx context.Context, image string, pullSecrets []v1.Secret) error {
+	repo, tag, digest, err := parsers.ParseImageName(image)
+	if err != nil {
+		return err
+	}
+	keyring, err := credentialprovidersecrets.MakeDockerKeyring(pullSecrets, p.keyring)
+	if err != nil {
+		return err
+	}
+	creds, withCredentials := keyring.Lookup(repo)
+	if !withCredentials {
+		// An anonymous pull has no credentials to verify.
+		return nil
+	}
+	manifestURL, ok := manifestURL(repo, tag, digest)
+	if !ok {
+		// Short names without an explicit registry host are completed by
+		// the runtime, so the kubelet does not know which registry to ask.
+		return nil
+	}
+	for _, cred := range creds {
+		rejected, err := p.checkCredential(ctx, repo, manifestURL, cred)
+		if err != nil {
+			return err
+		}
+		if !rejected {
+			return nil
+		}
+	}
+	return fmt.Errorf("registry rejected all %d available credentials for %q: %w", len(creds), repo, ErrInvalidImageCredentials)
+}
+
+// checkCredential reports whether the registry definitively rejected the
+// credential. Any status other than 401 and 403 counts as accepted: whether
+// the manifest actually exists is the pull's problem.
+func (p *registryCredentialPreflight) checkCredential(ctx context.Context, repo, manifestURL string, cred credentialprovider.AuthConfig) (rejected bool, err error) {
+	authorization := authorizationHeader(cred)
+	if authorization == "" {
+		// Nothing the preflight can present, e.g. an identity token meant
+		// for the runtime's own token exchange.
+		return false, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestAcceptTypes, ", "))
+	req.Header.Set("Authorization", authorization)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden {
+		return false, nil
+	}
+	// Bearer registries answer 401 on the manifest endpoint until a token
+	// has been obtained, so the credential verdict comes from the token
+	// endpoint named in the challenge instead.
+	if realm, service, ok := parseBearerChallenge(resp.Header.Get("WWW-Authenticate")); ok {
+		return p.checkBearerToken(ctx, repo, realm, service, authorization)
+	}
+	return true, nil
+}
+
+// checkBearerToken asks the token endpoint from a Bearer challenge for a
+// pull token using the credential, which is how Bearer registries report
+// whether a credential is valid.
+func (p *registryCredentialPreflight) checkBearerToken(ctx context.Context, repo, realm, service, authorization string) (rejected bool, err error) {
+	tokenURL, err := url.Parse(realm)
+	if err != nil {
+		return false, err
+	}
+	query := tokenURL.Query()
+	if service != "" {
+		query.Set("service", service)
+	}
+	if _, repoPath, found := strings.Cut(repo, "/"); found {
+		query.Set("scope", fmt.Sprintf("repository:%s:pull", repoPath))
+	}
+	tokenURL.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", authorization)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden, nil
+}
+
+// manifestURL builds the registry API URL for the image's manifest. It
+// reports false when the image has no explicit registry host. Loopback
+// registries are addressed over plain HTTP, matching how runtimes treat
+// them as insecure by default; everything else uses HTTPS.
+func manifestURL(repo, tag, digest string) (string, bool) {
+	host, remainder, found := strings.Cut(repo, "/")
+	if !found || !isRegistryHost(host) {
+		return "", false
+	}
+	reference := digest
+	if reference == "" {
+		reference = tag
+	}
+	if reference == "" {
+		reference = "latest"
+	}
+	scheme := "https"
+	if isLoopbackHost(host) {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, host, remainder, reference), true
+}
+
+// isRegistryHost reports whether the first component of a repository is an
+// explicit registry host rather than a namespace of a short name, using the
+// same heuristic as docker reference parsing: a dot, a port or "localhost".
+func isRegistryHost(host string) bool {
+	return strings.Contains(host, ".") || strings.Contains(host, ":") || host == "localhost"
+}
+
+// isLoopbackHost reports whether the registry host resolves to the local
+// machine, with or without a port.
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// authorizationHeader returns the Authorization header value for the
+// credential, or "" when the credential cannot be presented directly.
+func authorizationHeader(cred credentialprovider.AuthConfig) string {
+	switch {
+	case cred.RegistryToken != "":
+		return "Bearer " + cred.RegistryToken
+	case cred.Auth != "":
+		return "Basic " + cred.Auth
+	case cred.Username != "" || cred.Password != "":
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(cred.Username+":"+cred.Password))
+	}
+	return ""
+}
+
+// parseBearerChallenge extracts the realm and service from a Bearer
+// WWW-Authenticate challenge. It reports false for other schemes and for
+// challenges without a realm.
+func parseBearerChallenge(header string) (realm, service string, ok bool) {
+	scheme, params, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", "", false
+	}
+	for _, param := range strings.Split(params, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		switch strings.ToLower(key) {
+		case "realm":
+			realm = value
+		case "service":
+			service = value
+		}
+	}
+	return realm, service, realm != ""
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/kubernetes/pkg/credentialprovider"
+)
+
+// preflightKeyringFor returns a keyring holding a single basic-auth
+// credential for the given registry host.
+func preflightKeyringFor(host, username, password string) credentialprovider.DockerKeyring {
+	keyring := &credentialprovider.BasicDockerKeyring{}
+	keyring.Add(credentialprovider.DockerConfig{
+		host: credentialprovider.DockerConfigEntry{Username: username, Password: password},
+	})
+	return keyring
+}
+
+func TestRegistryCredentialPreflightBasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("expected a HEAD request, got %s", r.Method)
+		}
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	host := server.Listener.Addr().String()
+	image := host + "/app:v1"
+
+	accepted := newRegistryCredentialPreflight(preflightKeyringFor(host, "user", "secret"))
+	if err := accepted.check(context.Background(), image, nil); err != nil {
+		t.Errorf("expected accepted credentials to pass, got %v", err)
+	}
+
+	rejected := newRegistryCredentialPreflight(preflightKeyringFor(host, "user", "wrong"))
+	if err := rejected.check(context.Background(), image, nil); !errors.Is(err, ErrInvalidImageCredentials) {
+		t.Errorf("expected ErrInvalidImageCredentials for rejected credentials, got %v", err)
+	}
+}
+
+func TestRegistryCredentialPreflightBearerChallenge(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	host := server.Listener.Addr().String()
+
+	mux.HandleFunc("/v2/app/manifests/v1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="http://`+host+`/token",service="registry.test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("scope"); got != "repository:app:pull" {
+			t.Errorf("unexpected token scope %q", got)
+		}
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	image := host + "/app:v1"
+	accepted := newRegistryCredentialPreflight(preflightKeyringFor(host, "user", "secret"))
+	if err := accepted.check(context.Background(), image, nil); err != nil {
+		t.Errorf("expected token endpoint to accept the credentials, got %v", err)
+	}
+
+	rejected := newRegistryCredentialPreflight(preflightKeyringFor(host, "user", "wrong"))
+	if err := rejected.check(context.Background(), image, nil); !errors.Is(err, ErrInvalidImageCredentials) {
+		t.Errorf("expected ErrInvalidImageCredentials from the token endpoint, got %v", err)
+	}
+}
+
+func TestRegistryCredentialPreflightInconclusive(t *testing.T) {
+	// A missing manifest still proves the credential was accepted.
+	notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer notFound.Close()
+	host := notFound.Listener.Addr().String()
+	preflight := newRegistryCredentialPreflight(preflightKeyringFor(host, "user", "secret"))
+	if err := preflight.check(context.Background(), host+"/app:v1", nil); err != nil {
+		t.Errorf("expected a 404 after auth to pass the preflight, got %v", err)
+	}
+
+	// An unreachable registry is inconclusive, not a credential rejection.
+	unreachable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	unreachableHost := unreachable.Listener.Addr().String()
+	unreachable.Close()
+	preflight = newRegistryCredentialPreflight(preflightKeyringFor(unreachableHost, "user", "secret"))
+	err := preflight.check(context.Background(), unreachableHost+"/app:v1", nil)
+	if err == nil {
+		t.Error("expected an error for an unreachable registry")
+	}
+	if errors.Is(err, ErrInvalidImageCredentials) {
+		t.Errorf("expected an unreachable registry to be inconclusive, got %v", err)
+	}
+
+	// No credentials for the registry means nothing to verify.
+	preflight = newRegistryCredentialPreflight(&credentialprovider.BasicDockerKeyring{})
+	if err := preflight.check(context.Background(), "registry.test/app:v1", nil); err != nil {
+		t.Errorf("expected an anonymous pull to pass the preflight, got %v", err)
+	}
+}
+
+func TestManifestURL(t *testing.T) {
+	for _, tc := range []struct {
+		repo, tag, digest string
+		expected          string
+		ok                bool
+	}{
+		{repo: "registry.test/ns/app", tag: "v1", expected: "https://registry.test/v2/ns/app/manifests/v1", ok: true},
+		{repo: "registry.test:5000/app", digest: "sha256:abc", expected: "https://registry.test:5000/v2/app/manifests/sha256:abc", ok: true},
+		{repo: "localhost:5000/app", tag: "v1", expected: "http://localhost:5000/v2/app/manifests/v1", ok: true},
+		{repo: "127.0.0.1:5000/app", tag: "v1", expected: "http://127.0.0.1:5000/v2/app/manifests/v1", ok: true},
+		// Short names have no explicit registry to ask.
+		{repo: "busybox", tag: "latest"},
+		{repo: "library/busybox", tag: "latest"},
+	} {
+		url, ok := manifestURL(tc.repo, tc.tag, tc.digest)
+		if ok != tc.ok {
+			t.Errorf("manifestURL(%q): expected ok=%v, got %v", tc.repo, tc.ok, ok)
+			continue
+		}
+		if url != tc.expected {
+			t.Errorf("manifestURL(%q): expected %q, got %q", tc.repo, tc.expected, url)
+		}
+	}
+}
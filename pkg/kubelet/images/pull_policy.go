@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// Pods can tune how the kubelet retries their image pulls through these
+// annotations, on top of the kubelet's global pull backoff. This lets jobs
+// pulling multi-gigabyte images configure longer per-attempt timeouts while
+// small services fail fast.
+const (
+	// ImagePullAttemptTimeoutAnnotation bounds each individual pull attempt
+	// for the pod's images. The value is a Go duration, e.g. "10m". Zero or
+	// absent means attempts are only bounded by the caller's context.
+	ImagePullAttemptTimeoutAnnotation = "kubelet.kubernetes.io/image-pull-attempt-timeout"
+
+	// ImagePullMaxAttemptsAnnotation caps how many times the kubelet attempts
+	// to pull an image for the pod before giving up with ImagePullBackOff.
+	// The value is a positive integer. Absent means unlimited attempts.
+	ImagePullMaxAttemptsAnnotation = "kubelet.kubernetes.io/image-pull-max-attempts"
+
+	// ImagePullBackoffCapAnnotation caps the delay between pull attempts for
+	// the pod's images. The value is a Go duration. Once this much time has
+	// passed since the last attempt, a new attempt is allowed even if the
+	// global backoff would still reject it.
+	ImagePullBackoffCapAnnotation = "kubelet.kubernetes.io/image-pull-backoff-cap"
+)
+
+// pullRetryPolicy is the per-pod override of the image manager's global pull
+// retry behavior. The zero value applies no override.
+type pullRetryPolicy struct {
+	// attemptTimeout bounds each individual pull attempt; zero means no
+	// per-attempt timeout.
+	attemptTimeout time.Duration
+	// maxAttempts caps the number of pull attempts; zero means unlimited.
+	maxAttempts int
+	// backoffCap caps the delay the global backoff can impose between
+	// attempts; zero means the global backoff applies unmodified.
+	backoffCap time.Duration
+}
+
+// pullRetryPolicyForPod reads the pull retry policy from the pod's
+// annotations. Invalid values are logged and ignored rather than returned as
+// errors, so a malformed annotation can never block the pull path.
+func pullRetryPolicyForPod(pod *v1.Pod) pullRetryPolicy {
+	var policy pullRetryPolicy
+	if value, ok := pod.Annotations[ImagePullAttemptTimeoutAnnotation]; ok {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			policy.attemptTimeout = d
+		} else {
+			klog.InfoS("Ignoring invalid image pull attempt timeout annotation", "pod", klog.KObj(pod), "value", value)
+		}
+	}
+	if value, ok := pod.Annotations[ImagePullMaxAttemptsAnnotation]; ok {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			policy.maxAttempts = n
+		} else {
+			klog.InfoS("Ignoring invalid image pull max attempts annotation", "pod", klog.KObj(pod), "value", value)
+		}
+	}
+	if value, ok := pod.Annotations[ImagePullBackoffCapAnnotation]; ok {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			policy.backoffCap = d
+		} else {
+			klog.InfoS("Ignoring invalid image pull backoff cap annotation", "pod", klog.KObj(pod), "value", value)
+		}
+	}
+	return policy
+}
+
+// attemptContext derives the context for a single pull attempt, applying the
+// policy's per-attempt timeout when one is configured.
+func (p pullRetryPolicy) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.attemptTimeout > 0 {
+		return context.WithTimeout(ctx, p.attemptTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// pullAttemptRecord tracks the failed pull attempts behind one backoff key so
+// per-pod attempt limits and backoff caps can be enforced.
+type pullAttemptRecord struct {
+	count int
+	last  time.Time
+}
+
+// pullAttemptsTTL is how long a failed-attempt record is retained after its
+// last update. It comfortably exceeds the global backoff's maximum delay, so
+// records only expire once the corresponding backoff entry is long dead.
+const pullAttemptsTTL = time.Hour
+
+// failedPullAttempts returns the number of consecutive failed pull attempts
+// recorded for the given backoff key.
+func (m *imageManager) failedPullAttempts(backOffKey string) int {
+	m.attemptsLock.Lock()
+	defer m.attemptsLock.Unlock()
+	return m.pullAttempts[backOffKey].count
+}
+
+// recordFailedPullAttempt notes a failed pull attempt for the given backoff
+// key. Expired records are pruned along the way, so the map shrinks even on
+// nodes where pulls only ever fail.
+func (m *imageManager) recordFailedPullAttempt(backOffKey string) {
+	m.attemptsLock.Lock()
+	defer m.attemptsLock.Unlock()
+	record := m.pullAttempts[backOffKey]
+	record.count++
+	record.last = m.backOff.Clock.Now()
+	m.pullAttempts[backOffKey] = record
+	m.prunePullAttemptsLocked(record.last)
+}
+
+// clearPullAttempts forgets the failed attempts for the given backoff key.
+// Called after a successful pull, mirroring how the global backoff is GCed.
+func (m *imageManager) clearPullAttempts(backOffKey string) {
+	m.attemptsLock.Lock()
+	defer m.attemptsLock.Unlock()
+	delete(m.pullAttempts, backOffKey)
+	m.prunePullAttemptsLocked(m.backOff.Clock.Now())
+}
+
+// prunePullAttemptsLocked drops records that have not been updated within
+// pullAttemptsTTL. The caller must hold attemptsLock.
+func (m *imageManager) prunePullAttemptsLocked(now time.Time) {
+	for key, record := range m.pullAttempts {
+		if now.Sub(record.last) > pullAttemptsTTL {
+			delete(m.pullAttempts, key)
+		}
+	}
+}
+
+// backoffCapElapsed reports whether the policy's backoff cap has passed since
+// the last recorded pull attempt for the given backoff key, in which case a
+// new attempt is allowed even while the global backoff is still in effect.
+func (m *imageManager) backoffCapElapsed(backOffKey string, policy pullRetryPolicy) bool {
+	if policy.backoffCap == 0 {
+		return false
+	}
+	m.attemptsLock.Lock()
+	defer m.attemptsLock.Unlock()
+	record, ok := m.pullAttempts[backOffKey]
+	if !ok {
+		return false
+	}
+	return m.backOff.Clock.Since(record.last) >= policy.backoffCap
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPullRetryPolicyForPod(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		expected    pullRetryPolicy
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name: "all annotations set",
+			annotations: map[string]string{
+				ImagePullAttemptTimeoutAnnotation: "10m",
+				ImagePullMaxAttemptsAnnotation:    "3",
+				ImagePullBackoffCapAnnotation:     "30s",
+			},
+			expected: pullRetryPolicy{
+				attemptTimeout: 10 * time.Minute,
+				maxAttempts:    3,
+				backoffCap:     30 * time.Second,
+			},
+		},
+		{
+			name: "invalid values are ignored",
+			annotations: map[string]string{
+				ImagePullAttemptTimeoutAnnotation: "not-a-duration",
+				ImagePullMaxAttemptsAnnotation:    "-1",
+				ImagePullBackoffCapAnnotation:     "0s",
+			},
+		},
+		{
+			name: "partially set",
+			annotations: map[string]string{
+				ImagePullMaxAttemptsAnnotation: "1",
+			},
+			expected: pullRetryPolicy{maxAttempts: 1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test_pod",
+					Namespace:   "test-ns",
+					Annotations: tc.annotations,
+				}}
+			assert.Equal(t, tc.expected, pullRetryPolicyForPod(pod))
+		})
+	}
+}
+
+func TestPullMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test_pod",
+			Namespace:       "test-ns",
+			UID:             "bar",
+			ResourceVersion: "42",
+			Annotations: map[string]string{
+				ImagePullMaxAttemptsAnnotation: "2",
+			},
+		}}
+	c := pullerTestCase{
+		containerImage: "missing_image",
+		policy:         v1.PullAlways,
+		pullerErr:      errors.New("404"),
+	}
+	puller, fakeClock, fakeRuntime, container, _ := pullerTestEnv(t, c, true, nil)
+
+	// The first two attempts reach the runtime and fail.
+	for attempt := 0; attempt < 2; attempt++ {
+		fakeRuntime.CalledFunctions = nil
+		// Step the clock far enough that the global backoff has expired.
+		fakeClock.Step(time.Minute)
+		_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+		fakeRuntime.AssertCalls([]string{"GetImageRef", "ImageStats", "PullImage"})
+		assert.Equal(t, ErrImagePull, err)
+	}
+
+	// The attempt limit is reached: no further pulls, even though the global
+	// backoff has long expired.
+	fakeRuntime.CalledFunctions = nil
+	fakeClock.Step(time.Hour)
+	_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	fakeRuntime.AssertCalls([]string{"GetImageRef"})
+	assert.Equal(t, ErrImagePullBackOff, err)
+}
+
+func TestPullBackoffCap(t *testing.T) {
+	ctx := context.Background()
+	podTemplate := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test_pod",
+			Namespace:       "test-ns",
+			UID:             "bar",
+			ResourceVersion: "42",
+		}}
+	c := pullerTestCase{
+		containerImage: "missing_image",
+		policy:         v1.PullAlways,
+		pullerErr:      errors.New("404"),
+	}
+
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		// expected outcome of a retry while the global backoff (1s in this
+		// test environment) is still in effect, 600ms after the first
+		// failure.
+		expectedCalls []string
+		expectedErr   error
+	}{
+		{
+			name:          "global backoff applies without a cap",
+			expectedCalls: []string{"GetImageRef"},
+			expectedErr:   ErrImagePullBackOff,
+		},
+		{
+			name: "an elapsed cap overrides the global backoff",
+			annotations: map[string]string{
+				ImagePullBackoffCapAnnotation: "500ms",
+			},
+			expectedCalls: []string{"GetImageRef", "ImageStats", "PullImage"},
+			expectedErr:   ErrImagePull,
+		},
+		{
+			name: "a cap that has not elapsed keeps the backoff",
+			annotations: map[string]string{
+				ImagePullBackoffCapAnnotation: "800ms",
+			},
+			expectedCalls: []string{"GetImageRef"},
+			expectedErr:   ErrImagePullBackOff,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := podTemplate.DeepCopy()
+			pod.Annotations = tc.annotations
+			puller, fakeClock, fakeRuntime, container, _ := pullerTestEnv(t, c, true, nil)
+
+			_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+			assert.Equal(t, ErrImagePull, err)
+
+			fakeRuntime.CalledFunctions = nil
+			fakeClock.Step(600 * time.Millisecond)
+			_, _, err = puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+			fakeRuntime.AssertCalls(tc.expectedCalls)
+			assert.Equal(t, tc.expectedErr, err)
+		})
+	}
+}
+
+func TestPullAttemptTimeout(t *testing.T) {
+	ctx := context.Background()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test_pod",
+			Namespace:       "test-ns",
+			UID:             "bar",
+			ResourceVersion: "42",
+			Annotations: map[string]string{
+				ImagePullAttemptTimeoutAnnotation: "100ms",
+			},
+		}}
+	c := pullerTestCase{
+		containerImage: "missing_image",
+		policy:         v1.PullAlways,
+		pullerErr:      errors.New("pull aborted"),
+	}
+	puller, _, fakeRuntime, container, _ := pullerTestEnv(t, c, true, nil)
+	// The pull blocks until its context is canceled; only the per-attempt
+	// timeout can release it.
+	fakeRuntime.BlockImagePulls = true
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.Equal(t, ErrImagePull, err)
+	case <-time.After(30 * time.Second):
+		t.Fatalf("expected the per-attempt timeout to abort the blocked pull")
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"container/heap"
+	"strconv"
+	"strings"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// ImagePullPrioritiesAnnotation ranks the pod's containers for image pulling
+// when pulls have to wait on each other, i.e. when pull parallelism is
+// limited or pulls are serialized. The value is a comma-separated list of
+// "containerName=priority" entries with integer priorities; higher
+// priorities are pulled first. Containers without an entry have priority 0.
+// This lets sidecar-heavy pods pull small sidecar images ahead of a
+// multi-gigabyte main image instead of being queued behind it.
+const ImagePullPrioritiesAnnotation = "kubelet.kubernetes.io/image-pull-priorities"
+
+// defaultPullPriority is the priority of pulls without an explicit one:
+// containers not listed in the annotation, pods without the annotation, and
+// background pulls such as prefetches.
+const defaultPullPriority = 0
+
+// pullPriorityForContainer reads the named container's pull priority from the
+// pod's annotations. Invalid entries are logged and ignored rather than
+// returned as errors, so a malformed annotation can never block the pull
+// path.
+func pullPriorityForContainer(pod *v1.Pod, containerName string) int {
+	value, ok := pod.Annotations[ImagePullPrioritiesAnnotation]
+	if !ok {
+		return defaultPullPriority
+	}
+	for _, entry := range strings.Split(value, ",") {
+		name, priority, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || name != containerName {
+			continue
+		}
+		n, err := strconv.Atoi(priority)
+		if err != nil {
+			klog.InfoS("Ignoring invalid image pull priority annotation entry", "pod", klog.KObj(pod), "entry", entry)
+			return defaultPullPriority
+		}
+		return n
+	}
+	return defaultPullPriority
+}
+
+// pullQueueItem is an entry in a priority-ordered pull queue: the serial
+// puller queues whole requests, the parallel puller queues goroutines waiting
+// for a token. Among equal priorities, arrival order wins.
+type pullQueueItem struct {
+	priority int
+	seq      uint64
+
+	// request is the queued pull for the serial puller's queue.
+	request *imagePullRequest
+	// ready is closed to grant a token to a waiter in priorityTokens.
+	ready chan struct{}
+}
+
+// pullQueue implements heap.Interface ordering by descending priority, then
+// ascending arrival order.
+type pullQueue []*pullQueueItem
+
+func (q pullQueue) Len() int { return len(q) }
+func (q pullQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+func (q pullQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pullQueue) Push(x interface{}) { *q = append(*q, x.(*pullQueueItem)) }
+func (q *pullQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return item
+}
+
+// pullRequestQueue hands queued pull requests to the serial puller's worker
+// highest priority first. It is bounded like the channel it replaces: push
+// blocks while the queue is full, pop blocks while it is empty.
+type pullRequestQueue struct {
+	lock     sync.Mutex
+	nonEmpty *sync.Cond
+	notFull  *sync.Cond
+	limit    int
+	seq      uint64
+	requests pullQueue
+}
+
+func newPullRequestQueue(limit int) *pullRequestQueue {
+	q := &pullRequestQueue{limit: limit}
+	q.nonEmpty = sync.NewCond(&q.lock)
+	q.notFull = sync.NewCond(&q.lock)
+	return q
+}
+
+func (q *pullRequestQueue) push(request *imagePullRequest) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for q.requests.Len() >= q.limit {
+		q.notFull.Wait()
+	}
+	heap.Push(&q.requests, &pullQueueItem{priority: request.priority, seq: q.seq, request: request})
+	q.seq++
+	q.nonEmpty.Signal()
+}
+
+func (q *pullRequestQueue) pop() *imagePullRequest {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for q.requests.Len() == 0 {
+		q.nonEmpty.Wait()
+	}
+	item := heap.Pop(&q.requests).(*pullQueueItem)
+	q.notFull.Signal()
+	return item.request
+}
+
+// priorityTokens bounds pull parallelism like a counting semaphore, except
+// that a freed slot goes to the highest-priority waiter rather than to
+// whichever goroutine wins the race for it.
+type priorityTokens struct {
+	lock    sync.Mutex
+	free    int
+	seq     uint64
+	waiters pullQueue
+}
+
+func newPriorityTokens(limit int) *priorityTokens {
+	return &priorityTokens{free: limit}
+}
+
+// acquire blocks until a token is available. Tokens are granted to waiters in
+// priority order.
+func (t *priorityTokens) acquire(priority int) {
+	t.lock.Lock()
+	if t.free > 0 {
+		t.free--
+		t.lock.Unlock()
+		return
+	}
+	waiter := &pullQueueItem{priority: priority, seq: t.seq, ready: make(chan struct{})}
+	t.seq++
+	heap.Push(&t.waiters, waiter)
+	t.lock.Unlock()
+	<-waiter.ready
+}
+
+// release returns a token, handing it directly to the highest-priority waiter
+// if any.
+func (t *priorityTokens) release() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.waiters.Len() > 0 {
+		waiter := heap.Pop(&t.waiters).(*pullQueueItem)
+		close(waiter.ready)
+		return
+	}
+	t.free++
+}
+
+// waiting returns the number of goroutines blocked in acquire.
+func (t *priorityTokens) waiting() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.waiters.Len()
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	kubecontainer "k8s.io/kubernetes/pkg/kubelet/container"
+)
+
+func TestPullPriorityForContainer(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		annotation string
+		container  string
+		expected   int
+	}{
+		{
+			name:      "no annotation",
+			container: "main",
+		},
+		{
+			name:       "listed container",
+			annotation: "sidecar=100,main=-10",
+			container:  "sidecar",
+			expected:   100,
+		},
+		{
+			name:       "negative priority",
+			annotation: "sidecar=100,main=-10",
+			container:  "main",
+			expected:   -10,
+		},
+		{
+			name:       "unlisted container",
+			annotation: "sidecar=100",
+			container:  "main",
+		},
+		{
+			name:       "spaces around entries",
+			annotation: "sidecar=100, main=5",
+			container:  "main",
+			expected:   5,
+		},
+		{
+			name:       "invalid priority is ignored",
+			annotation: "main=first",
+			container:  "main",
+		},
+		{
+			name:       "malformed entry is skipped",
+			annotation: "garbage,main=7",
+			container:  "main",
+			expected:   7,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test_pod",
+					Namespace: "test-ns",
+				}}
+			if tc.annotation != "" {
+				pod.Annotations = map[string]string{ImagePullPrioritiesAnnotation: tc.annotation}
+			}
+			assert.Equal(t, tc.expected, pullPriorityForContainer(pod, tc.container))
+		})
+	}
+}
+
+// gatedImageService reports each pull on started as it begins and holds it
+// until a value is sent on proceed, so tests can control the order in which
+// queued pulls are serviced.
+type gatedImageService struct {
+	kubecontainer.ImageService
+	started chan string
+	proceed chan struct{}
+}
+
+func newGatedImageService() *gatedImageService {
+	return &gatedImageService{
+		started: make(chan string, maxImagePullRequests),
+		proceed: make(chan struct{}),
+	}
+}
+
+func (s *gatedImageService) PullImage(ctx context.Context, spec kubecontainer.ImageSpec, pullSecrets []v1.Secret, podSandboxConfig *runtimeapi.PodSandboxConfig) (string, error) {
+	s.started <- spec.Image
+	<-s.proceed
+	return spec.Image, nil
+}
+
+func (s *gatedImageService) GetImageSize(ctx context.Context, spec kubecontainer.ImageSpec) (uint64, error) {
+	return 0, nil
+}
+
+// nextStarted waits for the next pull to begin and returns its image.
+func (s *gatedImageService) nextStarted(t *testing.T) string {
+	t.Helper()
+	select {
+	case image := <-s.started:
+		return image
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for a pull to start")
+		return ""
+	}
+}
+
+func TestSerialImagePullerHonorsPriority(t *testing.T) {
+	ctx := context.Background()
+	service := newGatedImageService()
+	puller := newSerialImagePuller(service)
+
+	pull := func(image string, priority int) chan pullResult {
+		pullChan := make(chan pullResult, 1)
+		puller.pullImage(ctx, kubecontainer.ImageSpec{Image: image}, priority, nil, pullChan, nil)
+		return pullChan
+	}
+
+	// The first pull occupies the worker; the rest queue up behind it and
+	// must be serviced highest priority first, FIFO among equals.
+	first := pull("first", 0)
+	assert.Equal(t, "first", service.nextStarted(t))
+	queued := []chan pullResult{
+		pull("low", 0),
+		pull("high", 10),
+		pull("medium", 5),
+		pull("high-again", 10),
+	}
+	service.proceed <- struct{}{}
+	for _, expected := range []string{"high", "high-again", "medium", "low"} {
+		assert.Equal(t, expected, service.nextStarted(t))
+		service.proceed <- struct{}{}
+	}
+
+	result := <-first
+	require.NoError(t, result.err)
+	assert.Equal(t, "first", result.imageRef)
+	for _, pullChan := range queued {
+		result := <-pullChan
+		require.NoError(t, result.err)
+	}
+}
+
+func TestParallelImagePullerHonorsPriority(t *testing.T) {
+	ctx := context.Background()
+	service := newGatedImageService()
+	limit := int32(1)
+	puller := newParallelImagePuller(service, &limit).(*parallelImagePuller)
+
+	pull := func(image string, priority int) chan pullResult {
+		pullChan := make(chan pullResult, 1)
+		puller.pullImage(ctx, kubecontainer.ImageSpec{Image: image}, priority, nil, pullChan, nil)
+		return pullChan
+	}
+
+	first := pull("first", 0)
+	assert.Equal(t, "first", service.nextStarted(t))
+	queued := []chan pullResult{
+		pull("low", 0),
+		pull("high", 10),
+	}
+	// The queued pulls run in their own goroutines; wait until both block on
+	// the token before releasing it, so the grant order is deterministic.
+	require.Eventually(t, func() bool {
+		return puller.tokens.waiting() == 2
+	}, 10*time.Second, 10*time.Millisecond)
+	service.proceed <- struct{}{}
+	for _, expected := range []string{"high", "low"} {
+		assert.Equal(t, expected, service.nextStarted(t))
+		service.proceed <- struct{}{}
+	}
+
+	result := <-first
+	require.NoError(t, result.err)
+	for _, pullChan := range queued {
+		result := <-pullChan
+		require.NoError(t, result.err)
+	}
+}
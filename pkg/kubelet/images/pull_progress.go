@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"time"
+
+	kubecontainer "k8s.io/kubernetes/pkg/kubelet/container"
+)
+
+// The CRI pull call is a single blocking RPC without progress reporting, so
+// the image manager estimates pull progress instead: when a pull starts it
+// samples the runtime's total image storage, and while the pull is in flight
+// the growth since that baseline is reported as bytes pulled. The storage is
+// shared by all concurrent pulls, making the estimate an upper bound, which
+// is good enough to distinguish a large pull making progress from one that
+// is stuck.
+
+// inflightPull tracks one image currently being pulled. Multiple containers
+// may wait on the same pull, so entries are reference counted.
+type inflightPull struct {
+	count     int
+	startTime time.Time
+	// baseline is the runtime's total image storage when the pull started.
+	// baselineValid is false when the runtime could not report it.
+	baseline      int64
+	baselineValid bool
+}
+
+// startPullProgress records that a pull of the given image has started.
+// Every call must be paired with finishPullProgress.
+func (m *imageManager) startPullProgress(ctx context.Context, image string) {
+	var baseline int64
+	baselineValid := false
+	if stats, err := m.imageService.ImageStats(ctx); err == nil && stats != nil {
+		baseline = int64(stats.TotalStorageBytes)
+		baselineValid = true
+	}
+
+	m.pullsLock.Lock()
+	defer m.pullsLock.Unlock()
+	if pull, ok := m.inflightPulls[image]; ok {
+		pull.count++
+		return
+	}
+	m.inflightPulls[image] = &inflightPull{
+		count:         1,
+		startTime:     time.Now(),
+		baseline:      baseline,
+		baselineValid: baselineValid,
+	}
+}
+
+// finishPullProgress records that a pull of the given image has finished,
+// successfully or not.
+func (m *imageManager) finishPullProgress(image string) {
+	m.pullsLock.Lock()
+	defer m.pullsLock.Unlock()
+	pull, ok := m.inflightPulls[image]
+	if !ok {
+		return
+	}
+	pull.count--
+	if pull.count <= 0 {
+		delete(m.inflightPulls, image)
+	}
+}
+
+// PullProgress returns the progress of an in-flight pull of the given image,
+// if one is in progress.
+func (m *imageManager) PullProgress(ctx context.Context, image string) (*kubecontainer.ImagePullProgress, bool) {
+	m.pullsLock.Lock()
+	pull, ok := m.inflightPulls[image]
+	if !ok {
+		m.pullsLock.Unlock()
+		return nil, false
+	}
+	progress := &kubecontainer.ImagePullProgress{
+		Image:     image,
+		StartTime: pull.startTime,
+	}
+	baseline, baselineValid := pull.baseline, pull.baselineValid
+	m.pullsLock.Unlock()
+
+	if baselineValid {
+		if stats, err := m.imageService.ImageStats(ctx); err == nil && stats != nil {
+			if pulled := int64(stats.TotalStorageBytes) - baseline; pulled > 0 {
+				progress.BytesPulled = pulled
+			}
+		}
+	}
+	return progress, true
+}
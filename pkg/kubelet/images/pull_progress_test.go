@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "k8s.io/kubernetes/pkg/kubelet/container"
+	ctest "k8s.io/kubernetes/pkg/kubelet/container/testing"
+)
+
+// imageStatsRuntime serves controllable image storage totals, so the
+// progress estimate derived from storage growth can be asserted.
+type imageStatsRuntime struct {
+	*ctest.FakeRuntime
+	totalStorageBytes uint64
+}
+
+func (r *imageStatsRuntime) ImageStats(_ context.Context) (*ImageStats, error) {
+	return &ImageStats{TotalStorageBytes: r.totalStorageBytes}, nil
+}
+
+func TestPullProgress(t *testing.T) {
+	ctx := context.Background()
+	fakeRuntime := &imageStatsRuntime{FakeRuntime: &ctest.FakeRuntime{T: t}, totalStorageBytes: 1000}
+	m := &imageManager{
+		imageService:  fakeRuntime,
+		inflightPulls: map[string]*inflightPull{},
+	}
+	const image = "registry.test/app:v1"
+
+	if _, ok := m.PullProgress(ctx, image); ok {
+		t.Fatalf("expected no progress before the pull starts")
+	}
+
+	m.startPullProgress(ctx, image)
+	// A second container waiting on the same image refcounts the entry.
+	m.startPullProgress(ctx, image)
+
+	fakeRuntime.totalStorageBytes = 1500
+	progress, ok := m.PullProgress(ctx, image)
+	if !ok {
+		t.Fatalf("expected progress for an in-flight pull")
+	}
+	if progress.Image != image {
+		t.Errorf("expected image %q, got %q", image, progress.Image)
+	}
+	if progress.BytesPulled != 500 {
+		t.Errorf("expected 500 bytes pulled, got %d", progress.BytesPulled)
+	}
+	if progress.StartTime.IsZero() {
+		t.Errorf("expected a start time")
+	}
+
+	// Storage shrinking below the baseline (e.g. concurrent image GC) must
+	// not yield a negative estimate.
+	fakeRuntime.totalStorageBytes = 800
+	progress, ok = m.PullProgress(ctx, image)
+	if !ok {
+		t.Fatalf("expected progress for an in-flight pull")
+	}
+	if progress.BytesPulled != 0 {
+		t.Errorf("expected 0 bytes pulled when storage shrank, got %d", progress.BytesPulled)
+	}
+
+	m.finishPullProgress(image)
+	if _, ok := m.PullProgress(ctx, image); !ok {
+		t.Fatalf("expected progress while the other pull of the image is still in flight")
+	}
+	m.finishPullProgress(image)
+	if _, ok := m.PullProgress(ctx, image); ok {
+		t.Fatalf("expected no progress after all pulls finished")
+	}
+}
+
+func TestPullProgressDuringPull(t *testing.T) {
+	ctx := context.Background()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test_pod",
+			Namespace:       "test-ns",
+			UID:             "bar",
+			ResourceVersion: "42",
+		}}
+	c := pullerTestCase{
+		containerImage: "missing_image",
+		policy:         v1.PullAlways,
+	}
+	puller, _, fakeRuntime, container, _ := pullerTestEnv(t, c, true, nil)
+	fakeRuntime.BlockImagePulls = true
+
+	pullDone := make(chan struct{})
+	go func() {
+		defer close(pullDone)
+		puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	}()
+
+	// Wait for the pull to be in flight.
+	var progressSeen bool
+	for i := 0; i < 1000 && !progressSeen; i++ {
+		_, progressSeen = puller.PullProgress(ctx, container.Image)
+		if !progressSeen {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if !progressSeen {
+		t.Fatalf("expected progress to be reported while the pull is blocked")
+	}
+
+	fakeRuntime.UnblockImagePulls(1)
+	<-pullDone
+	if _, ok := puller.PullProgress(ctx, container.Image); ok {
+		t.Errorf("expected no progress after the pull completed")
+	}
+}
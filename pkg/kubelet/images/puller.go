@@ -18,6 +18,10 @@ package images
 
 import (
 	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -34,28 +38,95 @@ type pullResult struct {
 }
 
 type imagePuller interface {
-	pullImage(context.Context, kubecontainer.ImageSpec, []v1.Secret, chan<- pullResult, *runtimeapi.PodSandboxConfig)
+	// pullImage pulls the image and delivers the result to pullChan. When
+	// pulls have to wait on each other, waiting pulls are serviced highest
+	// priority first rather than in arrival order.
+	pullImage(ctx context.Context, spec kubecontainer.ImageSpec, priority int, pullSecrets []v1.Secret, pullChan chan<- pullResult, podSandboxConfig *runtimeapi.PodSandboxConfig)
 }
 
-var _, _ imagePuller = &parallelImagePuller{}, &serialImagePuller{}
+var _, _, _ imagePuller = &parallelImagePuller{}, &serialImagePuller{}, &perRuntimeClassImagePuller{}
+
+// inFlightPulls coalesces duplicate pull requests for the same image and
+// credentials into a single pull. Joining a pull that has not finished yet is
+// safe for every pull policy, including Always: the shared pull completes
+// after the duplicate request was made, so its result cannot be stale.
+// Completed pulls are never reused; a request arriving after the pull
+// finished starts a fresh one.
+type inFlightPulls struct {
+	lock    sync.Mutex
+	waiting map[string][]chan<- pullResult
+}
+
+func newInFlightPulls() *inFlightPulls {
+	return &inFlightPulls{waiting: map[string][]chan<- pullResult{}}
+}
+
+// join registers pullChan to receive the result for key. It returns true if
+// the caller is the first requester and must perform the pull.
+func (ip *inFlightPulls) join(key string, pullChan chan<- pullResult) bool {
+	ip.lock.Lock()
+	defer ip.lock.Unlock()
+	_, inFlight := ip.waiting[key]
+	ip.waiting[key] = append(ip.waiting[key], pullChan)
+	return !inFlight
+}
+
+// finish delivers result to every requester registered for key and removes
+// the in-flight entry.
+func (ip *inFlightPulls) finish(key string, result pullResult) {
+	ip.lock.Lock()
+	channels := ip.waiting[key]
+	delete(ip.waiting, key)
+	ip.lock.Unlock()
+	for _, ch := range channels {
+		ch <- result
+	}
+}
+
+// pullKey identifies a pull for deduplication purposes. Requests with
+// different credentials never share a pull, since the registry may answer
+// them differently.
+func pullKey(spec kubecontainer.ImageSpec, pullSecrets []v1.Secret) string {
+	hash := fnv.New64a()
+	for _, secret := range pullSecrets {
+		fmt.Fprintf(hash, "%s/%s:", secret.Namespace, secret.Name)
+		keys := make([]string, 0, len(secret.Data))
+		for k := range secret.Data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			hash.Write([]byte(k))
+			hash.Write(secret.Data[k])
+		}
+	}
+	return fmt.Sprintf("%s|%s|%x", spec.Image, spec.RuntimeHandler, hash.Sum64())
+}
 
 type parallelImagePuller struct {
 	imageService kubecontainer.ImageService
-	tokens       chan struct{}
+	tokens       *priorityTokens
+	inFlight     *inFlightPulls
 }
 
 func newParallelImagePuller(imageService kubecontainer.ImageService, maxParallelImagePulls *int32) imagePuller {
 	if maxParallelImagePulls == nil || *maxParallelImagePulls < 1 {
-		return &parallelImagePuller{imageService, nil}
+		return &parallelImagePuller{imageService, nil, newInFlightPulls()}
 	}
-	return &parallelImagePuller{imageService, make(chan struct{}, *maxParallelImagePulls)}
+	return &parallelImagePuller{imageService, newPriorityTokens(int(*maxParallelImagePulls)), newInFlightPulls()}
 }
 
-func (pip *parallelImagePuller) pullImage(ctx context.Context, spec kubecontainer.ImageSpec, pullSecrets []v1.Secret, pullChan chan<- pullResult, podSandboxConfig *runtimeapi.PodSandboxConfig) {
+func (pip *parallelImagePuller) pullImage(ctx context.Context, spec kubecontainer.ImageSpec, priority int, pullSecrets []v1.Secret, pullChan chan<- pullResult, podSandboxConfig *runtimeapi.PodSandboxConfig) {
+	key := pullKey(spec, pullSecrets)
+	if !pip.inFlight.join(key, pullChan) {
+		// A pull for the same image and credentials is already in flight;
+		// its result will be delivered to pullChan as well.
+		return
+	}
 	go func() {
 		if pip.tokens != nil {
-			pip.tokens <- struct{}{}
-			defer func() { <-pip.tokens }()
+			pip.tokens.acquire(priority)
+			defer pip.tokens.release()
 		}
 		startTime := time.Now()
 		imageRef, err := pip.imageService.PullImage(ctx, spec, pullSecrets, podSandboxConfig)
@@ -64,12 +135,12 @@ func (pip *parallelImagePuller) pullImage(ctx context.Context, spec kubecontaine
 			// Getting the image size with best effort, ignoring the error.
 			size, _ = pip.imageService.GetImageSize(ctx, spec)
 		}
-		pullChan <- pullResult{
+		pip.inFlight.finish(key, pullResult{
 			imageRef:     imageRef,
 			imageSize:    size,
 			err:          err,
 			pullDuration: time.Since(startTime),
-		}
+		})
 	}()
 }
 
@@ -78,35 +149,79 @@ const maxImagePullRequests = 10
 
 type serialImagePuller struct {
 	imageService kubecontainer.ImageService
-	pullRequests chan *imagePullRequest
+	pullRequests *pullRequestQueue
+	inFlight     *inFlightPulls
 }
 
 func newSerialImagePuller(imageService kubecontainer.ImageService) imagePuller {
-	imagePuller := &serialImagePuller{imageService, make(chan *imagePullRequest, maxImagePullRequests)}
+	imagePuller := &serialImagePuller{imageService, newPullRequestQueue(maxImagePullRequests), newInFlightPulls()}
 	go wait.Until(imagePuller.processImagePullRequests, time.Second, wait.NeverStop)
 	return imagePuller
 }
 
 type imagePullRequest struct {
 	ctx              context.Context
+	key              string
 	spec             kubecontainer.ImageSpec
+	priority         int
 	pullSecrets      []v1.Secret
-	pullChan         chan<- pullResult
 	podSandboxConfig *runtimeapi.PodSandboxConfig
 }
 
-func (sip *serialImagePuller) pullImage(ctx context.Context, spec kubecontainer.ImageSpec, pullSecrets []v1.Secret, pullChan chan<- pullResult, podSandboxConfig *runtimeapi.PodSandboxConfig) {
-	sip.pullRequests <- &imagePullRequest{
+func (sip *serialImagePuller) pullImage(ctx context.Context, spec kubecontainer.ImageSpec, priority int, pullSecrets []v1.Secret, pullChan chan<- pullResult, podSandboxConfig *runtimeapi.PodSandboxConfig) {
+	key := pullKey(spec, pullSecrets)
+	if !sip.inFlight.join(key, pullChan) {
+		// A pull for the same image and credentials is already queued or in
+		// flight; its result will be delivered to pullChan as well.
+		return
+	}
+	sip.pullRequests.push(&imagePullRequest{
 		ctx:              ctx,
+		key:              key,
 		spec:             spec,
+		priority:         priority,
 		pullSecrets:      pullSecrets,
-		pullChan:         pullChan,
 		podSandboxConfig: podSandboxConfig,
+	})
+}
+
+// perRuntimeClassImagePuller routes each pull to a puller dedicated to the
+// image's runtime handler, so pull parallelism can be bounded per runtime
+// class. Pulls for runtime classes without an override go to the node-wide
+// default puller. Deduplication is unaffected: pullKey includes the runtime
+// handler, so requests routed to different pullers never share a key.
+type perRuntimeClassImagePuller struct {
+	defaultPuller imagePuller
+	pullers       map[string]imagePuller
+}
+
+// newPerRuntimeClassImagePuller builds a puller per runtime handler from the
+// configured parallelism limits. A limit of 1 serializes pulls for that
+// handler; higher limits bound its parallelism.
+func newPerRuntimeClassImagePuller(imageService kubecontainer.ImageService, defaultPuller imagePuller, limits map[string]int32) imagePuller {
+	pullers := make(map[string]imagePuller, len(limits))
+	for handler, limit := range limits {
+		if limit == 1 {
+			pullers[handler] = newSerialImagePuller(imageService)
+			continue
+		}
+		limit := limit
+		pullers[handler] = newParallelImagePuller(imageService, &limit)
+	}
+	return &perRuntimeClassImagePuller{defaultPuller: defaultPuller, pullers: pullers}
+}
+
+func (prc *perRuntimeClassImagePuller) pullImage(ctx context.Context, spec kubecontainer.ImageSpec, priority int, pullSecrets []v1.Secret, pullChan chan<- pullResult, podSandboxConfig *runtimeapi.PodSandboxConfig) {
+	if puller, ok := prc.pullers[spec.RuntimeHandler]; ok {
+		puller.pullImage(ctx, spec, priority, pullSecrets, pullChan, podSandboxConfig)
+		return
 	}
+	prc.defaultPuller.pullImage(ctx, spec, priority, pullSecrets, pullChan, podSandboxConfig)
 }
 
 func (sip *serialImagePuller) processImagePullRequests() {
-	for pullRequest := range sip.pullRequests {
+	for {
+		pullRequest := sip.pullRequests.pop()
 		startTime := time.Now()
 		imageRef, err := sip.imageService.PullImage(pullRequest.ctx, pullRequest.spec, pullRequest.pullSecrets, pullRequest.podSandboxConfig)
 		var size uint64
@@ -114,12 +229,12 @@ func (sip *serialImagePuller) processImagePullRequests() {
 			// Getting the image size with best effort, ignoring the error.
 			size, _ = sip.imageService.GetImageSize(pullRequest.ctx, pullRequest.spec)
 		}
-		pullRequest.pullChan <- pullResult{
+		sip.inFlight.finish(pullRequest.key, pullResult{
 			imageRef:  imageRef,
 			imageSize: size,
 			err:       err,
 			// Note: pullDuration includes credential resolution and getting the image size.
 			pullDuration: time.Since(startTime),
-		}
+		})
 	}
 }
@@ -0,0 +1,191 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pullreport implements the per-node image pull report written by
+// the kubelet under its root directory when the KubeletImagePullReport
+// feature gate is enabled. The report is a newline-delimited JSON file: one
+// record per pull attempt, each carrying a schema version so consumers can
+// detect format changes. The file is size-bounded; when an append would
+// exceed the bound, the current file is rotated to a single ".1" backup.
+// The package also contains the parser node agents use to consume the
+// report.
+package pullreport
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/util/parsers"
+)
+
+const (
+	// SchemaVersion identifies the format of the records in the report
+	// file. It must be bumped whenever a field changes meaning or is
+	// removed; adding optional fields is backward compatible.
+	SchemaVersion = "v1"
+
+	// ReportFileName is the name of the report file under the kubelet root
+	// directory.
+	ReportFileName = "image_pull_report.json"
+
+	// rotatedReportFileName is the name of the single rotated backup kept
+	// next to the report file.
+	rotatedReportFileName = ReportFileName + ".1"
+
+	// DefaultMaxSizeBytes bounds the size of the report file. Together
+	// with the single rotated backup, the report occupies at most twice
+	// this amount of disk.
+	DefaultMaxSizeBytes = 1 << 20
+)
+
+// Record describes one image pull attempt.
+type Record struct {
+	// SchemaVersion is the version of the record format, currently "v1".
+	SchemaVersion string `json:"schemaVersion"`
+	// Timestamp is the completion time of the pull attempt.
+	Timestamp metav1.Time `json:"timestamp"`
+	// Image is the image reference that was pulled, with the default tag
+	// applied.
+	Image string `json:"image"`
+	// Registry is the registry host the image was pulled from.
+	Registry string `json:"registry"`
+	// Succeeded reports whether the pull attempt succeeded.
+	Succeeded bool `json:"succeeded"`
+	// SizeBytes is the size of the pulled image. It is only set for
+	// successful pulls of runtimes which report image sizes.
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+	// Reason classifies the failure for failed pulls, e.g. "ErrImagePull".
+	Reason string `json:"reason,omitempty"`
+}
+
+// RegistryOf returns the registry host serving the given image reference,
+// after docker-style normalization (e.g. "busybox" resolves to "docker.io").
+// It returns an empty string if the reference cannot be parsed.
+func RegistryOf(image string) string {
+	repoToPull, _, _, err := parsers.ParseImageName(image)
+	if err != nil {
+		return ""
+	}
+	if host, _, ok := strings.Cut(repoToPull, "/"); ok {
+		return host
+	}
+	return repoToPull
+}
+
+// Writer appends records to the report file, rotating it when it grows past
+// the configured bound. It is safe for concurrent use.
+type Writer struct {
+	lock         sync.Mutex
+	path         string
+	rotatedPath  string
+	maxSizeBytes int64
+}
+
+// NewWriter returns a Writer maintaining the report file in the given
+// directory, typically the kubelet root directory.
+func NewWriter(dir string, maxSizeBytes int64) *Writer {
+	return &Writer{
+		path:         filepath.Join(dir, ReportFileName),
+		rotatedPath:  filepath.Join(dir, rotatedReportFileName),
+		maxSizeBytes: maxSizeBytes,
+	}
+}
+
+// Record appends the given record to the report. The schema version is
+// always overwritten and a zero timestamp is filled in with the current
+// time.
+func (w *Writer) Record(record Record) error {
+	record.SchemaVersion = SchemaVersion
+	if record.Timestamp.IsZero() {
+		record.Timestamp = metav1.Now()
+	}
+	if record.Registry == "" {
+		record.Registry = RegistryOf(record.Image)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode image pull report record: %w", err)
+	}
+	data = append(data, '\n')
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if info, err := os.Stat(w.path); err == nil && info.Size()+int64(len(data)) > w.maxSizeBytes {
+		if err := os.Rename(w.path, w.rotatedPath); err != nil {
+			return fmt.Errorf("failed to rotate image pull report: %w", err)
+		}
+	}
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Parse reads records from r. Lines which cannot be decoded, e.g. a record
+// truncated by an unclean shutdown, are skipped. Records with an unknown
+// schema version are returned as-is; distinguishing versions is left to the
+// consumer.
+func Parse(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// ReadDir reads the report from the given directory, returning the records
+// of the rotated backup (if any) followed by those of the current file, i.e.
+// in the order they were written. A missing report yields no records.
+func ReadDir(dir string) ([]Record, error) {
+	var records []Record
+	for _, name := range []string{rotatedReportFileName, ReportFileName} {
+		f, err := os.Open(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := Parse(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, parsed...)
+	}
+	return records, nil
+}
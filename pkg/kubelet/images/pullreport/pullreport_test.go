@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pullreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWriterRotation(t *testing.T) {
+	dir := t.TempDir()
+	// Each record serializes to roughly 150 bytes; a 1 KiB bound forces
+	// several rotations over 50 records.
+	w := NewWriter(dir, 1024)
+
+	for i := 0; i < 50; i++ {
+		if err := w.Record(Record{Image: fmt.Sprintf("registry.test/app-%d:latest", i), Succeeded: true, SizeBytes: 1024}); err != nil {
+			t.Fatalf("unexpected error recording entry %d: %v", i, err)
+		}
+	}
+
+	for _, name := range []string{ReportFileName, rotatedReportFileName} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+		if info.Size() > 1024 {
+			t.Errorf("%s exceeds the configured bound: %d bytes", name, info.Size())
+		}
+	}
+
+	records, err := ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error reading report: %v", err)
+	}
+	if len(records) == 0 || len(records) >= 50 {
+		t.Fatalf("expected rotation to retain a strict subset of the 50 records, got %d", len(records))
+	}
+	// The retained records must be the most recent ones, in write order.
+	firstRetained := 50 - len(records)
+	for i, record := range records {
+		expected := fmt.Sprintf("registry.test/app-%d:latest", firstRetained+i)
+		if record.Image != expected {
+			t.Errorf("record %d: expected image %q, got %q", i, expected, record.Image)
+		}
+	}
+}
+
+func TestSchemaStability(t *testing.T) {
+	dir := t.TempDir()
+	w := NewWriter(dir, DefaultMaxSizeBytes)
+	if err := w.Record(Record{Image: "registry.test/app:v1", Succeeded: false, Reason: "ErrImagePull"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ReportFileName))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("report line is not valid JSON: %v", err)
+	}
+	if fields["schemaVersion"] != SchemaVersion {
+		t.Errorf("expected schemaVersion %q, got %v", SchemaVersion, fields["schemaVersion"])
+	}
+	// The serialized field names are the consumer-facing schema; renaming
+	// or removing one requires a new SchemaVersion.
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	expected := []string{"image", "reason", "registry", "schemaVersion", "succeeded", "timestamp"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected serialized fields %v, got %v", expected, names)
+	}
+}
+
+func TestParseSkipsTruncatedLines(t *testing.T) {
+	dir := t.TempDir()
+	w := NewWriter(dir, DefaultMaxSizeBytes)
+	if err := w.Record(Record{Image: "registry.test/app:v1", Succeeded: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Simulate a record truncated by an unclean shutdown.
+	f, err := os.OpenFile(filepath.Join(dir, ReportFileName), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := f.WriteString(`{"schemaVersion":"v1","image":"registry.te`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.Close()
+
+	records, err := ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 1 || records[0].Image != "registry.test/app:v1" {
+		t.Errorf("expected the single intact record, got %+v", records)
+	}
+}
+
+func TestRegistryOf(t *testing.T) {
+	for image, expected := range map[string]string{
+		"busybox":                        "docker.io",
+		"registry.test/app:v1":           "registry.test",
+		"registry.test:5000/ns/app":      "registry.test:5000",
+		"registry.k8s.io/pause:3.9":      "registry.k8s.io",
+		strings.Repeat("!", 3) + "::bad": "",
+	} {
+		if got := RegistryOf(image); got != expected {
+			t.Errorf("RegistryOf(%q): expected %q, got %q", image, expected, got)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRetryAfter caps how long a registry-supplied Retry-After delay can hold
+// back the next pull attempt, so a misbehaving registry cannot park an image
+// indefinitely.
+const maxRetryAfter = 10 * time.Minute
+
+// isRegistryRateLimitErr reports whether a pull error indicates the registry
+// rate limited the request. Runtimes do not surface typed errors over the
+// CRI, so this works on the well-known message fragments registries emit for
+// HTTP 429 responses. The status code is only matched in its contextual
+// forms: a bare "429" would also match sha256 digests quoted in unrelated
+// pull errors.
+func isRegistryRateLimitErr(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{
+		"toomanyrequests",
+		"too many requests",
+		"rate limit",
+		"status code 429",
+		"code = 429",
+	} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfterPattern extracts the seconds value of a Retry-After header quoted
+// in a pull error message.
+var retryAfterPattern = regexp.MustCompile(`(?i)retry[- ]after[:= ]+(\d+)`)
+
+// parseRetryAfter extracts the registry-supplied Retry-After delay from a
+// pull error message, when the runtime quoted it. The result is capped at
+// maxRetryAfter.
+func parseRetryAfter(msg string) (time.Duration, bool) {
+	match := retryAfterPattern.FindStringSubmatch(msg)
+	if match == nil {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(match[1])
+	if err != nil || seconds <= 0 {
+		return 0, false
+	}
+	delay := time.Duration(seconds) * time.Second
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+	return delay, true
+}
+
+// rateLimitedDeadline returns the registry-imposed earliest time of the next
+// pull attempt for the given backoff key, if one is recorded.
+func (m *imageManager) rateLimitedDeadline(key string) (time.Time, bool) {
+	m.attemptsLock.Lock()
+	defer m.attemptsLock.Unlock()
+	until, ok := m.rateLimitedUntil[key]
+	return until, ok
+}
+
+// setRateLimitedDeadline records the earliest time of the next pull attempt
+// for the given backoff key, as demanded by the registry's Retry-After.
+// Deadlines that have already passed no longer gate anything and are pruned
+// along the way, so pods deleted while rate limited do not leave entries
+// behind.
+func (m *imageManager) setRateLimitedDeadline(key string, until time.Time) {
+	m.attemptsLock.Lock()
+	defer m.attemptsLock.Unlock()
+	now := m.backOff.Clock.Now()
+	for recorded, deadline := range m.rateLimitedUntil {
+		if now.After(deadline) {
+			delete(m.rateLimitedUntil, recorded)
+		}
+	}
+	m.rateLimitedUntil[key] = until
+}
+
+// clearRateLimitedDeadline drops the recorded deadline, after a successful
+// pull or once it has passed.
+func (m *imageManager) clearRateLimitedDeadline(key string) {
+	m.attemptsLock.Lock()
+	defer m.attemptsLock.Unlock()
+	delete(m.rateLimitedUntil, key)
+}
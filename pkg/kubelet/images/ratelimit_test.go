@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsRegistryRateLimitErr(t *testing.T) {
+	for _, tc := range []struct {
+		msg         string
+		rateLimited bool
+	}{
+		{"toomanyrequests: You have reached your pull rate limit", true},
+		{"unexpected status code 429 Too Many Requests", true},
+		{"429 Too Many Requests", true},
+		{"registry rate limit exceeded", true},
+		{"manifest unknown", false},
+		{"unauthorized: authentication required", false},
+		// A digest happening to contain "429" must not classify the error.
+		{"manifest sha256:6a429a73ce7f269ff1bd12a0b0f1d4c2e44298fc1c149afbf4c8996fb92427ae not found", false},
+	} {
+		assert.Equal(t, tc.rateLimited, isRegistryRateLimitErr(errors.New(tc.msg)), "message: %q", tc.msg)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		msg      string
+		expected time.Duration
+		found    bool
+	}{
+		{
+			name:     "header style",
+			msg:      "429 Too Many Requests, Retry-After: 120",
+			expected: 2 * time.Minute,
+			found:    true,
+		},
+		{
+			name:     "lower case prose",
+			msg:      "toomanyrequests: retry after 30 seconds",
+			expected: 30 * time.Second,
+			found:    true,
+		},
+		{
+			name:     "capped at the maximum",
+			msg:      "Retry-After: 86400",
+			expected: maxRetryAfter,
+			found:    true,
+		},
+		{
+			name: "no delay quoted",
+			msg:  "toomanyrequests: You have reached your pull rate limit",
+		},
+		{
+			name: "zero seconds",
+			msg:  "Retry-After: 0",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			delay, found := parseRetryAfter(tc.msg)
+			assert.Equal(t, tc.found, found)
+			assert.Equal(t, tc.expected, delay)
+		})
+	}
+}
+
+func TestEnsureImageExistsHonorsRetryAfter(t *testing.T) {
+	ctx := context.Background()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test_pod",
+			Namespace:       "test-ns",
+			UID:             "bar",
+			ResourceVersion: "42",
+		}}
+
+	c := pullerTestCase{
+		containerImage: "missing_image",
+		policy:         v1.PullIfNotPresent,
+		pullerErr:      errors.New("toomanyrequests: rate limit exceeded, Retry-After: 120"),
+	}
+	puller, fakeClock, fakeRuntime, container, _ := pullerTestEnv(t, c, false, nil)
+
+	_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.Equal(t, ErrRegistryRateLimited, err)
+
+	// Well past the ordinary pull backoff but still inside the
+	// registry-demanded two minutes: the pull is held back.
+	fakeClock.Step(90 * time.Second)
+	fakeRuntime.CalledFunctions = nil
+	_, _, err = puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.Equal(t, ErrImagePullBackOff, err)
+	fakeRuntime.AssertCalls([]string{"GetImageRef"})
+
+	// Once the Retry-After delay has passed the pull goes through again.
+	fakeRuntime.Err = nil
+	fakeClock.Step(time.Minute)
+	fakeRuntime.CalledFunctions = nil
+	_, _, err = puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.NoError(t, err)
+	fakeRuntime.AssertCalls([]string{"GetImageRef", "ImageStats", "PullImage", "GetImageSize"})
+}
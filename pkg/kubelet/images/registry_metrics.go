@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"strings"
+	"sync"
+
+	"k8s.io/kubernetes/pkg/util/parsers"
+)
+
+const (
+	// maxRegistryMetricLabels bounds the number of distinct registry hosts
+	// reported as metric label values on one node. A node pulls from a
+	// handful of registries; anything beyond the cap is folded into one
+	// overflow value so a flood of unique hosts cannot blow up metric
+	// cardinality.
+	maxRegistryMetricLabels = 10
+
+	// overflowRegistryLabel is the label value used once the cap on
+	// distinct registry hosts is reached.
+	overflowRegistryLabel = "other"
+
+	// unresolvedRegistryLabel is the label value for images without an
+	// explicit registry host, which are completed by the runtime.
+	unresolvedRegistryLabel = "unresolved"
+)
+
+// Failure classes for the per-registry pull failure counter.
+const (
+	pullFailureAuth        = "auth"
+	pullFailureNotFound    = "not_found"
+	pullFailureTimeout     = "timeout"
+	pullFailureRateLimited = "rate_limited"
+	pullFailureOther       = "other"
+)
+
+// registryLabelTracker caps the cardinality of the registry metric label by
+// remembering the first hosts it sees and folding the rest into the
+// overflow value.
+type registryLabelTracker struct {
+	lock  sync.Mutex
+	hosts map[string]bool
+}
+
+func newRegistryLabelTracker() *registryLabelTracker {
+	return &registryLabelTracker{hosts: map[string]bool{}}
+}
+
+// label returns the metric label value to use for the given registry host.
+func (t *registryLabelTracker) label(host string) string {
+	if host == unresolvedRegistryLabel {
+		return host
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.hosts[host] {
+		return host
+	}
+	if len(t.hosts) >= maxRegistryMetricLabels {
+		return overflowRegistryLabel
+	}
+	t.hosts[host] = true
+	return host
+}
+
+// registryHostForImage returns the registry host component of the image
+// reference, or unresolvedRegistryLabel when it cannot be determined. Short
+// names are normalized to docker.io by image name parsing, matching the
+// default registry runtimes resolve them against.
+func registryHostForImage(image string) string {
+	repo, _, _, err := parsers.ParseImageName(image)
+	if err != nil {
+		return unresolvedRegistryLabel
+	}
+	host, _, found := strings.Cut(repo, "/")
+	if !found || !isRegistryHost(host) {
+		return unresolvedRegistryLabel
+	}
+	return host
+}
+
+// classifyPullError maps a pull error onto the coarse failure classes of
+// the per-registry failure counter. Runtimes do not surface typed errors
+// over the CRI, so the classification works on well-known message
+// fragments and falls back to "other".
+func classifyPullError(err error) string {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case containsAny(msg, "unauthorized", "authentication required", "forbidden", "access denied"):
+		return pullFailureAuth
+	case containsAny(msg, "not found", "manifest unknown", "name unknown"):
+		return pullFailureNotFound
+	case containsAny(msg, "context deadline exceeded", "timed out", "timeout"):
+		return pullFailureTimeout
+	case containsAny(msg, "toomanyrequests", "too many requests", "rate limit"):
+		return pullFailureRateLimited
+	}
+	return pullFailureOther
+}
+
+func containsAny(s string, markers ...string) bool {
+	for _, marker := range markers {
+		if strings.Contains(s, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRegistryLabelTracker(t *testing.T) {
+	tracker := newRegistryLabelTracker()
+	for i := 0; i < maxRegistryMetricLabels; i++ {
+		host := fmt.Sprintf("registry-%d.test", i)
+		if got := tracker.label(host); got != host {
+			t.Errorf("expected host %q to get its own label, got %q", host, got)
+		}
+	}
+	// Hosts beyond the cap fold into the overflow label, known hosts keep
+	// theirs.
+	if got := tracker.label("registry-overflow.test"); got != overflowRegistryLabel {
+		t.Errorf("expected the overflow label beyond the cap, got %q", got)
+	}
+	if got := tracker.label("registry-0.test"); got != "registry-0.test" {
+		t.Errorf("expected a known host to keep its label, got %q", got)
+	}
+	if got := tracker.label(unresolvedRegistryLabel); got != unresolvedRegistryLabel {
+		t.Errorf("expected the unresolved label to pass through, got %q", got)
+	}
+}
+
+func TestRegistryHostForImage(t *testing.T) {
+	for image, expected := range map[string]string{
+		"registry.test/ns/app:v1":    "registry.test",
+		"registry.test:5000/app:v1":  "registry.test:5000",
+		"localhost:5000/app:v1":      "localhost:5000",
+		"busybox:latest":             "docker.io",
+		"library/busybox:latest":     "docker.io",
+		"invalid image reference !!": unresolvedRegistryLabel,
+	} {
+		if got := registryHostForImage(image); got != expected {
+			t.Errorf("registryHostForImage(%q): expected %q, got %q", image, expected, got)
+		}
+	}
+}
+
+func TestClassifyPullError(t *testing.T) {
+	for message, expected := range map[string]string{
+		"failed to authorize: 401 Unauthorized":              pullFailureAuth,
+		"pull access denied, repository does not exist":      pullFailureAuth,
+		"manifest unknown: manifest sha256:abc is not known": pullFailureNotFound,
+		"registry.test/app:v1: not found":                    pullFailureNotFound,
+		"context deadline exceeded":                          pullFailureTimeout,
+		"dial tcp 10.0.0.1:443: i/o timeout":                 pullFailureTimeout,
+		"toomanyrequests: you have reached your pull limit":  pullFailureRateLimited,
+		"received unexpected HTTP status: 500":               pullFailureOther,
+	} {
+		if got := classifyPullError(errors.New(message)); got != expected {
+			t.Errorf("classifyPullError(%q): expected %q, got %q", message, expected, got)
+		}
+	}
+}
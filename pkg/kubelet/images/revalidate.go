@@ -0,0 +1,245 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"k8s.io/kubernetes/pkg/credentialprovider"
+	kubecontainer "k8s.io/kubernetes/pkg/kubelet/container"
+	"k8s.io/kubernetes/pkg/util/parsers"
+)
+
+// revalidateTimeout bounds each registry round trip of a tag revalidation.
+// Like the credential preflight, revalidation runs in the pod sync path and
+// must not hold up the worker longer than a pull attempt would.
+const revalidateTimeout = preflightTimeout
+
+// tagIsStale reports whether a locally present tag should be pulled again
+// because the registry now serves a different digest for it. The registry is
+// consulted at most once per revalidation interval for each image, and a
+// failure to resolve the remote digest fails open: a registry hiccup must
+// not degrade PullIfNotPresent below its classic behavior.
+func (m *imageManager) tagIsStale(ctx context.Context, spec kubecontainer.ImageSpec, image string) bool {
+	if m.revalidateInterval <= 0 || m.manifests == nil {
+		return false
+	}
+	if strings.Contains(image, "@") {
+		// A digest-pinned reference cannot go stale.
+		return false
+	}
+	now := m.backOff.Clock.Now()
+	m.revalidateLock.Lock()
+	// An expired timestamp no longer suppresses a check; dropping it keeps
+	// the map from accumulating entries for images that left the node.
+	for checked, last := range m.revalidated {
+		if checked != image && now.Sub(last) >= m.revalidateInterval {
+			delete(m.revalidated, checked)
+		}
+	}
+	if last, checked := m.revalidated[image]; checked && now.Sub(last) < m.revalidateInterval {
+		m.revalidateLock.Unlock()
+		return false
+	}
+	m.revalidated[image] = now
+	m.revalidateLock.Unlock()
+	resolution, err := m.ResolveManifest(ctx, image, "")
+	if err != nil {
+		klog.V(4).InfoS("Failed to resolve the registry digest for tag revalidation, keeping the local image", "image", image, "err", err)
+		return false
+	}
+	// The local content is current exactly when the runtime already has the
+	// image the resolved digest names.
+	pinned := spec
+	pinned.Image = imageDigestRef(image, resolution.Digest)
+	imageRef, err := m.imageService.GetImageRef(ctx, pinned)
+	if err != nil {
+		klog.V(4).InfoS("Failed to inspect the local image during tag revalidation, keeping the local image", "image", pinned.Image, "err", err)
+		return false
+	}
+	if imageRef != "" {
+		return false
+	}
+	klog.V(2).InfoS("Local image tag no longer matches the registry digest, pulling again", "image", image, "digest", resolution.Digest)
+	return true
+}
+
+// imageDigestRef rewrites an image reference to pin it by digest, dropping
+// any tag.
+func imageDigestRef(image, digest string) string {
+	if at := strings.IndexByte(image, '@'); at >= 0 {
+		image = image[:at]
+	}
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		image = image[:colon]
+	}
+	return image + "@" + digest
+}
+
+// registryDigestResolver resolves the digest a registry currently serves for
+// an image reference with a HEAD request on its manifest, following one
+// Bearer token challenge when the registry issues one. It implements
+// resolveManifestFunc on top of the node-level keyring; pod pull secrets are
+// not consulted, so a registry that rejects the node credentials simply
+// fails the resolution.
+type registryDigestResolver struct {
+	client  *http.Client
+	keyring credentialprovider.DockerKeyring
+}
+
+func newRegistryDigestResolver(keyring credentialprovider.DockerKeyring) *registryDigestResolver {
+	return &registryDigestResolver{
+		client:  &http.Client{Timeout: revalidateTimeout},
+		keyring: keyring,
+	}
+}
+
+// resolve implements resolveManifestFunc. The credential hash is ignored
+// because the resolver works from the node-level keyring alone.
+func (r *registryDigestResolver) resolve(ctx context.Context, image, _ string) (ManifestResolution, error) {
+	repo, tag, digest, err := parsers.ParseImageName(image)
+	if err != nil {
+		return ManifestResolution{}, err
+	}
+	if digest != "" {
+		// A digest-pinned reference already names its content.
+		return ManifestResolution{Digest: digest}, nil
+	}
+	manifestURL, ok := manifestURL(repo, tag, digest)
+	if !ok {
+		return ManifestResolution{}, fmt.Errorf("no explicit registry host in %q to resolve the digest from", image)
+	}
+	authorizations := []string{""}
+	if creds, withCredentials := r.keyring.Lookup(repo); withCredentials {
+		authorizations = nil
+		for _, cred := range creds {
+			if authorization := authorizationHeader(cred); authorization != "" {
+				authorizations = append(authorizations, authorization)
+			}
+		}
+		// Fall back to an anonymous request when every credential fails,
+		// matching how runtimes attempt pulls.
+		authorizations = append(authorizations, "")
+	}
+	var lastErr error
+	for _, authorization := range authorizations {
+		resolution, err := r.head(ctx, repo, manifestURL, authorization)
+		if err == nil {
+			return resolution, nil
+		}
+		lastErr = err
+	}
+	return ManifestResolution{}, lastErr
+}
+
+// head performs the manifest HEAD request, obtaining a Bearer token first
+// when the registry answers 401 with a Bearer challenge.
+func (r *registryDigestResolver) head(ctx context.Context, repo, manifestURL, authorization string) (ManifestResolution, error) {
+	resp, err := r.doHead(ctx, manifestURL, authorization)
+	if err != nil {
+		return ManifestResolution{}, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		if realm, service, ok := parseBearerChallenge(resp.Header.Get("WWW-Authenticate")); ok {
+			token, err := r.bearerToken(ctx, repo, realm, service, authorization)
+			if err != nil {
+				return ManifestResolution{}, err
+			}
+			if resp, err = r.doHead(ctx, manifestURL, "Bearer "+token); err != nil {
+				return ManifestResolution{}, err
+			}
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ManifestResolution{}, fmt.Errorf("unexpected status %q resolving the manifest digest", resp.Status)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return ManifestResolution{}, fmt.Errorf("registry did not report a manifest digest")
+	}
+	return ManifestResolution{Digest: digest}, nil
+}
+
+func (r *registryDigestResolver) doHead(ctx context.Context, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestAcceptTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// bearerToken obtains a pull token from the token endpoint named in a Bearer
+// challenge, presenting the credential when one is available.
+func (r *registryDigestResolver) bearerToken(ctx context.Context, repo, realm, service, authorization string) (string, error) {
+	tokenURL, err := url.Parse(realm)
+	if err != nil {
+		return "", err
+	}
+	query := tokenURL.Query()
+	if service != "" {
+		query.Set("service", service)
+	}
+	if _, repoPath, found := strings.Cut(repo, "/"); found {
+		query.Set("scope", fmt.Sprintf("repository:%s:pull", repoPath))
+	}
+	tokenURL.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %q from the token endpoint", resp.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("token endpoint returned no token")
+}
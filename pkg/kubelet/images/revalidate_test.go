@@ -0,0 +1,189 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/credentialprovider"
+	kubecontainer "k8s.io/kubernetes/pkg/kubelet/container"
+)
+
+func TestImageDigestRef(t *testing.T) {
+	for _, tc := range []struct {
+		image    string
+		expected string
+	}{
+		{"app:v1", "app@sha256:new"},
+		{"registry.test:5000/ns/app:v1", "registry.test:5000/ns/app@sha256:new"},
+		{"registry.test:5000/app", "registry.test:5000/app@sha256:new"},
+		{"app@sha256:old", "app@sha256:new"},
+	} {
+		if got := imageDigestRef(tc.image, "sha256:new"); got != tc.expected {
+			t.Errorf("imageDigestRef(%q): expected %q, got %q", tc.image, tc.expected, got)
+		}
+	}
+}
+
+func TestRegistryDigestResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("expected a HEAD request, got %s", r.Method)
+		}
+		if r.URL.Path != "/v2/app/manifests/v1" {
+			t.Errorf("unexpected manifest path %q", r.URL.Path)
+		}
+		w.Header().Set("Docker-Content-Digest", "sha256:abc")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	host := server.Listener.Addr().String()
+
+	resolver := newRegistryDigestResolver(&credentialprovider.BasicDockerKeyring{})
+	resolution, err := resolver.resolve(context.Background(), host+"/app:v1", "")
+	if err != nil {
+		t.Fatalf("expected the digest to resolve, got %v", err)
+	}
+	if resolution.Digest != "sha256:abc" {
+		t.Errorf("expected digest sha256:abc, got %q", resolution.Digest)
+	}
+
+	// A digest-pinned reference resolves without a registry round trip.
+	pinnedDigest := "sha256:b891081dd31ceeea45cf0b0a1bc39b75a5b83b8b157f4167172d49a6a478822b"
+	resolution, err = resolver.resolve(context.Background(), "registry.invalid/app@"+pinnedDigest, "")
+	if err != nil {
+		t.Fatalf("expected a pinned reference to resolve locally, got %v", err)
+	}
+	if resolution.Digest != pinnedDigest {
+		t.Errorf("expected digest %s, got %q", pinnedDigest, resolution.Digest)
+	}
+}
+
+func TestRegistryDigestResolverBearerChallenge(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	host := server.Listener.Addr().String()
+
+	mux.HandleFunc("/v2/app/manifests/v1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="http://`+host+`/token",service="registry.test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", "sha256:abc")
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("scope"); got != "repository:app:pull" {
+			t.Errorf("unexpected token scope %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token":"pull-token"}`))
+	})
+
+	resolver := newRegistryDigestResolver(&credentialprovider.BasicDockerKeyring{})
+	resolution, err := resolver.resolve(context.Background(), host+"/app:v1", "")
+	if err != nil {
+		t.Fatalf("expected the digest to resolve after the token exchange, got %v", err)
+	}
+	if resolution.Digest != "sha256:abc" {
+		t.Errorf("expected digest sha256:abc, got %q", resolution.Digest)
+	}
+}
+
+func TestEnsureImageExistsRevalidatesStaleTag(t *testing.T) {
+	ctx := context.Background()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test_pod",
+			Namespace:       "test-ns",
+			UID:             "bar",
+			ResourceVersion: "42",
+		}}
+
+	c := pullerTestCase{
+		containerImage: "present_image",
+		policy:         v1.PullIfNotPresent,
+	}
+	puller, fakeClock, fakeRuntime, container, _ := pullerTestEnv(t, c, false, nil)
+	m := puller.(*imageManager)
+	m.revalidateInterval = 5 * time.Minute
+
+	resolves := 0
+	currentDigest := "sha256:aaaa"
+	m.setManifestResolver(func(ctx context.Context, imageRef, credentialHash string) (ManifestResolution, error) {
+		resolves++
+		return ManifestResolution{Digest: currentDigest}, nil
+	})
+
+	// The local tag does not match the resolved digest, so despite being
+	// present the image is pulled again.
+	_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resolves)
+	fakeRuntime.AssertCalls([]string{"GetImageRef", "GetImageRef", "ImageStats", "PullImage", "GetImageSize"})
+
+	// The pull stored the resolved digest locally.
+	fakeRuntime.ImageList = append(fakeRuntime.ImageList, kubecontainer.Image{ID: "present_image@sha256:aaaa"})
+
+	// Within the revalidation interval the registry is left alone.
+	fakeRuntime.CalledFunctions = nil
+	_, _, err = puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resolves)
+	fakeRuntime.AssertCalls([]string{"GetImageRef"})
+
+	// Once the interval has elapsed the digest is resolved again, and an
+	// unchanged digest does not trigger a pull.
+	fakeClock.Step(5*time.Minute + time.Second)
+	fakeRuntime.CalledFunctions = nil
+	_, _, err = puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resolves)
+	fakeRuntime.AssertCalls([]string{"GetImageRef", "GetImageRef"})
+
+	// When the registry starts serving a new digest for the tag, the next
+	// revalidation pulls the image again.
+	currentDigest = "sha256:bbbb"
+	fakeClock.Step(5*time.Minute + time.Second)
+	fakeRuntime.CalledFunctions = nil
+	_, _, err = puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, resolves)
+	fakeRuntime.AssertCalls([]string{"GetImageRef", "GetImageRef", "ImageStats", "PullImage", "GetImageSize"})
+
+	// A failing resolution falls back to the classic PullIfNotPresent
+	// behavior instead of failing the sync.
+	m.setManifestResolver(func(ctx context.Context, imageRef, credentialHash string) (ManifestResolution, error) {
+		return ManifestResolution{}, errors.New("registry unreachable")
+	})
+	fakeClock.Step(5*time.Minute + time.Second)
+	fakeRuntime.CalledFunctions = nil
+	_, _, err = puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.NoError(t, err)
+	fakeRuntime.AssertCalls([]string{"GetImageRef"})
+}
@@ -22,6 +22,7 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	kubecontainer "k8s.io/kubernetes/pkg/kubelet/container"
 )
 
 var (
@@ -39,6 +40,28 @@ var (
 
 	// ErrInvalidImageName - Unable to parse the image name.
 	ErrInvalidImageName = errors.New("InvalidImageName")
+
+	// ErrInvalidImageCredentials - The registry rejected every available
+	// image pull credential during the preflight check, so the pull cannot
+	// succeed until the imagePullSecrets or credential provider
+	// configuration are fixed.
+	ErrInvalidImageCredentials = errors.New("InvalidImageCredentials")
+
+	// ErrImageVerificationFailed - The configured image verifier plugin
+	// rejected the resolved image digest, or could not produce a verdict;
+	// verification fails closed, so the container is not created.
+	ErrImageVerificationFailed = errors.New("ImageVerificationFailed")
+
+	// ErrRegistryRateLimited - The registry answered the pull with HTTP 429,
+	// so retrying immediately cannot succeed; when the registry supplied a
+	// Retry-After delay the next pull attempt is held back at least that
+	// long.
+	ErrRegistryRateLimited = errors.New("RegistryRateLimited")
+
+	// ErrImagePullHTMLResponse - The registry replied with an HTML document,
+	// which usually means the request never reached a registry and was
+	// answered by a captive portal or SSO proxy login page instead.
+	ErrImagePullHTMLResponse = errors.New("ErrImagePullHTMLResponse")
 )
 
 // ImageManager provides an interface to manage the lifecycle of images.
@@ -50,5 +73,14 @@ type ImageManager interface {
 	// EnsureImageExists ensures that image specified in `container` exists.
 	EnsureImageExists(ctx context.Context, pod *v1.Pod, container *v1.Container, pullSecrets []v1.Secret, podSandboxConfig *runtimeapi.PodSandboxConfig, podRuntimeHandler string) (string, string, error)
 
+	// PullProgress returns the progress of an in-flight pull of the given
+	// image, if one is in progress. The image is the reference from the
+	// container spec, as passed to EnsureImageExists.
+	PullProgress(ctx context.Context, image string) (*kubecontainer.ImagePullProgress, bool)
+
+	// PrefetchImages pulls the given images ahead of pod scheduling and
+	// returns one result per spec, in order.
+	PrefetchImages(ctx context.Context, specs []kubecontainer.ImagePrefetchSpec) []kubecontainer.ImagePrefetchResult
+
 	// TODO(ronl): consolidating image managing and deleting operation in this interface
 }
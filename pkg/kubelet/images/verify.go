@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// imageVerifierAPIVersion identifies the wire format of the verifier plugin
+// request and response, so the protocol can evolve like the credential
+// provider plugin API does.
+const imageVerifierAPIVersion = "imageverifier.kubelet.k8s.io/v1alpha1"
+
+// imageVerifierTimeout bounds each plugin invocation. Verification runs in
+// the pod sync path after a pull, so a stuck verifier must not hold up the
+// worker indefinitely; a timeout counts as a rejection because verification
+// fails closed.
+const imageVerifierTimeout = 30 * time.Second
+
+// imageVerifyFunc approves or rejects a resolved image before a container is
+// created from it. A returned error wraps ErrImageVerificationFailed;
+// verification has no inconclusive outcome.
+type imageVerifyFunc func(ctx context.Context, pod *v1.Pod, image, imageRef string) error
+
+// ImageVerificationRequest is the JSON document the kubelet writes to the
+// verifier plugin's standard input. The image is the reference from the
+// container spec after tag defaulting; the imageRef identifies the exact
+// content the runtime resolved it to, so verifiers can check signatures and
+// attestations against the digest that would actually run.
+type ImageVerificationRequest struct {
+	APIVersion   string `json:"apiVersion"`
+	Kind         string `json:"kind"`
+	Image        string `json:"image"`
+	ImageRef     string `json:"imageRef"`
+	PodNamespace string `json:"podNamespace,omitempty"`
+	PodName      string `json:"podName,omitempty"`
+}
+
+// ImageVerificationResponse is the JSON document the verifier plugin writes
+// to its standard output. The reason is included in the event and waiting
+// message when the image is rejected.
+type ImageVerificationResponse struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Allowed    bool   `json:"allowed"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// execImageVerifier runs an external verifier binary per image, exchanging
+// JSON over stdin/stdout, following the exec model of the credential
+// provider plugins. Any failure to obtain a verdict — plugin crash, timeout,
+// malformed response — counts as a rejection.
+type execImageVerifier struct {
+	binPath string
+	timeout time.Duration
+}
+
+func newExecImageVerifier(binPath string) *execImageVerifier {
+	return &execImageVerifier{
+		binPath: binPath,
+		timeout: imageVerifierTimeout,
+	}
+}
+
+// verify implements imageVerifyFunc.
+func (e *execImageVerifier) verify(ctx context.Context, pod *v1.Pod, image, imageRef string) error {
+	request := ImageVerificationRequest{
+		APIVersion:   imageVerifierAPIVersion,
+		Kind:         "ImageVerificationRequest",
+		Image:        image,
+		ImageRef:     imageRef,
+		PodNamespace: pod.Namespace,
+		PodName:      pod.Name,
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("encoding verification request for image %q: %v: %w", image, err, ErrImageVerificationFailed)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, e.binPath)
+	cmd.Stdin = bytes.NewReader(payload)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		detail := strings.TrimSpace(stderr.String())
+		if detail == "" {
+			detail = err.Error()
+		}
+		return fmt.Errorf("image verifier plugin failed for image %q: %s: %w", image, detail, ErrImageVerificationFailed)
+	}
+
+	var response ImageVerificationResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return fmt.Errorf("decoding verifier plugin response for image %q: %v: %w", image, err, ErrImageVerificationFailed)
+	}
+	if !response.Allowed {
+		reason := response.Reason
+		if reason == "" {
+			reason = "the verifier rejected the image"
+		}
+		return fmt.Errorf("image %q (%s) rejected: %s: %w", image, imageRef, reason, ErrImageVerificationFailed)
+	}
+	return nil
+}
+
+// verifyImage runs the configured verifier against a resolved image. It is a
+// no-op when no verifier is configured.
+func (m *imageManager) verifyImage(ctx context.Context, pod *v1.Pod, image, imageRef string) error {
+	if m.verifier == nil {
+		return nil
+	}
+	return m.verifier(ctx, pod, image, imageRef)
+}
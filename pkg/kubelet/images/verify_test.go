@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// writeVerifierPlugin writes an executable shell script acting as a verifier
+// plugin and returns its path.
+func writeVerifierPlugin(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("verifier plugin test scripts require a POSIX shell")
+	}
+	path := filepath.Join(t.TempDir(), "verifier")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755))
+	return path
+}
+
+func TestExecImageVerifier(t *testing.T) {
+	ctx := context.Background()
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test_pod", Namespace: "test-ns"}}
+
+	for _, tc := range []struct {
+		name        string
+		script      string
+		expectError string
+	}{
+		{
+			name:   "allowed image",
+			script: `echo '{"apiVersion":"imageverifier.kubelet.k8s.io/v1alpha1","kind":"ImageVerificationResponse","allowed":true}'`,
+		},
+		{
+			name:        "rejected image with reason",
+			script:      `echo '{"allowed":false,"reason":"no attestation for digest"}'`,
+			expectError: "no attestation for digest",
+		},
+		{
+			name:        "rejected image without reason",
+			script:      `echo '{"allowed":false}'`,
+			expectError: "the verifier rejected the image",
+		},
+		{
+			name:        "plugin failure",
+			script:      `echo "signature database unavailable" >&2; exit 1`,
+			expectError: "signature database unavailable",
+		},
+		{
+			name:        "malformed response",
+			script:      `echo 'not json'`,
+			expectError: "decoding verifier plugin response",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			verifier := newExecImageVerifier(writeVerifierPlugin(t, tc.script))
+			err := verifier.verify(ctx, pod, "registry.test/app:v1", "sha256:abc")
+			if tc.expectError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrImageVerificationFailed), "expected error to wrap ErrImageVerificationFailed, got %v", err)
+			assert.Contains(t, err.Error(), tc.expectError)
+		})
+	}
+}
+
+func TestExecImageVerifierRequest(t *testing.T) {
+	ctx := context.Background()
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test_pod", Namespace: "test-ns"}}
+
+	// The plugin copies its stdin to a file, so the test can assert on the
+	// request it received.
+	requestFile := filepath.Join(t.TempDir(), "request.json")
+	verifier := newExecImageVerifier(writeVerifierPlugin(t, fmt.Sprintf(
+		"cat > %q\necho '{\"allowed\":true}'", requestFile)))
+	require.NoError(t, verifier.verify(ctx, pod, "registry.test/app:v1", "sha256:abc"))
+
+	payload, err := os.ReadFile(requestFile)
+	require.NoError(t, err)
+	var request ImageVerificationRequest
+	require.NoError(t, json.Unmarshal(payload, &request))
+	assert.Equal(t, ImageVerificationRequest{
+		APIVersion:   imageVerifierAPIVersion,
+		Kind:         "ImageVerificationRequest",
+		Image:        "registry.test/app:v1",
+		ImageRef:     "sha256:abc",
+		PodNamespace: "test-ns",
+		PodName:      "test_pod",
+	}, request)
+}
+
+func TestEnsureImageExistsVerification(t *testing.T) {
+	ctx := context.Background()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test_pod",
+			Namespace:       "test-ns",
+			UID:             "bar",
+			ResourceVersion: "42",
+		}}
+
+	// A rejected freshly pulled image surfaces ImageVerificationFailed and
+	// counts against the pull backoff.
+	c := pullerTestCase{containerImage: "missing_image", policy: v1.PullIfNotPresent}
+	puller, fakeClock, fakeRuntime, container, _ := pullerTestEnv(t, c, false, nil)
+	m := puller.(*imageManager)
+	m.verifier = func(ctx context.Context, pod *v1.Pod, image, imageRef string) error {
+		return fmt.Errorf("image %q rejected: %w", image, ErrImageVerificationFailed)
+	}
+	fakeRuntime.CalledFunctions = nil
+	_, _, err := puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.Equal(t, ErrImageVerificationFailed, err)
+	fakeRuntime.AssertCalls([]string{"GetImageRef", "ImageStats", "PullImage", "GetImageSize"})
+
+	// The image was pulled before the rejection, so the next sync finds it
+	// present and verification rejects it again without another pull.
+	fakeRuntime.CalledFunctions = nil
+	_, _, err = puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.Equal(t, ErrImageVerificationFailed, err)
+	fakeRuntime.AssertCalls([]string{"GetImageRef"})
+
+	// An approved image is usable again.
+	m.verifier = func(ctx context.Context, pod *v1.Pod, image, imageRef string) error {
+		return nil
+	}
+	fakeClock.Step(time.Hour)
+	fakeRuntime.CalledFunctions = nil
+	_, _, err = puller.EnsureImageExists(ctx, pod, container, nil, nil, "")
+	assert.NoError(t, err)
+}
@@ -435,6 +435,9 @@ func NewMainKubelet(kubeCfg *kubeletconfiginternal.KubeletConfiguration,
 		MinAge:               kubeCfg.ImageMinimumGCAge.Duration,
 		HighThresholdPercent: int(kubeCfg.ImageGCHighThresholdPercent),
 		LowThresholdPercent:  int(kubeCfg.ImageGCLowThresholdPercent),
+		Type:                 kubeCfg.ImageGCPolicy,
+		SizeBudgetBytes:      kubeCfg.ImageGCSizeBudgetBytes,
+		PinnedImages:         kubeCfg.PinnedImages,
 	}
 
 	if utilfeature.DefaultFeatureGate.Enabled(features.ImageMaximumGCAge) {
@@ -602,6 +605,10 @@ func NewMainKubelet(kubeCfg *kubeletconfiginternal.KubeletConfiguration,
 
 		klet.secretManager = secretManager
 		klet.configMapManager = configMapManager
+	} else if utilfeature.DefaultFeatureGate.Enabled(features.KubeletStaticPodImagePullSecrets) && kubeCfg.StaticPodImagePullSecretsDir != "" {
+		// Standalone kubelets resolve imagePullSecrets referenced by static
+		// pods from dockerconfigjson files in a node-local directory.
+		klet.secretManager = secret.NewStaticPodSecretManager(kubeCfg.StaticPodImagePullSecretsDir)
 	}
 
 	machineInfo, err := klet.cadvisor.MachineInfo()
@@ -673,10 +680,14 @@ func NewMainKubelet(kubeCfg *kubeletconfiginternal.KubeletConfiguration,
 		imageBackOff,
 		kubeCfg.SerializeImagePulls,
 		kubeCfg.MaxParallelImagePulls,
+		kubeCfg.MaxParallelImagePullsPerRuntimeClass,
 		float32(kubeCfg.RegistryPullQPS),
 		int(kubeCfg.RegistryBurst),
+		kubeCfg.ImagePullMaxBandwidth,
+		kubeCfg.ImagePullRevalidateInterval.Duration,
 		imageCredentialProviderConfigFile,
 		imageCredentialProviderBinDir,
+		crOptions.ImageVerifierBin,
 		kubeCfg.CPUCFSQuota,
 		kubeCfg.CPUCFSQuotaPeriod,
 		kubeDeps.RemoteRuntimeService,
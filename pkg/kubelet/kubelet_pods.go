@@ -32,6 +32,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	v1 "k8s.io/api/core/v1"
@@ -52,6 +53,8 @@ import (
 	podshelper "k8s.io/kubernetes/pkg/apis/core/pods"
 	v1helper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
 	v1qos "k8s.io/kubernetes/pkg/apis/core/v1/helper/qos"
+	"k8s.io/kubernetes/pkg/credentialprovider"
+	credentialprovidersecrets "k8s.io/kubernetes/pkg/credentialprovider/secrets"
 	"k8s.io/kubernetes/pkg/features"
 	"k8s.io/kubernetes/pkg/fieldpath"
 	"k8s.io/kubernetes/pkg/kubelet/cm"
@@ -62,6 +65,7 @@ import (
 	"k8s.io/kubernetes/pkg/kubelet/status"
 	kubetypes "k8s.io/kubernetes/pkg/kubelet/types"
 	"k8s.io/kubernetes/pkg/kubelet/util"
+	"k8s.io/kubernetes/pkg/kubelet/util/format"
 	utilfs "k8s.io/kubernetes/pkg/util/filesystem"
 	utilkernel "k8s.io/kubernetes/pkg/util/kernel"
 	utilpod "k8s.io/kubernetes/pkg/util/pod"
@@ -1012,6 +1016,40 @@ func (kl *Kubelet) getPullSecretsForPod(pod *v1.Pod) []v1.Secret {
 	return pullSecrets
 }
 
+// TraceImageCredentialResolution returns a structured trace of the
+// credential resolution the kubelet would perform for pulling image. When a
+// pod is named, its image pull secrets are included in the resolution, just
+// as they would be for a real pull. The trace identifies the consulted
+// sources but never contains secret values.
+func (kl *Kubelet) TraceImageCredentialResolution(image, podNamespace, podName string) (*credentialprovider.ResolutionTrace, error) {
+	keyring := credentialprovider.DockerKeyring(credentialprovider.NewDockerKeyring())
+
+	if podName != "" {
+		pod, ok := kl.podManager.GetPodByName(podNamespace, podName)
+		if !ok {
+			return nil, fmt.Errorf("pod %q not found", format.PodDesc(podName, podNamespace, ""))
+		}
+		var err error
+		keyring, err = credentialprovidersecrets.MakeDockerKeyring(kl.getPullSecretsForPod(pod), keyring)
+		if err != nil {
+			return nil, fmt.Errorf("building keyring for pod %q: %w", format.Pod(pod), err)
+		}
+	}
+
+	return credentialprovider.TraceResolution(keyring, image), nil
+}
+
+// PrefetchImages pulls the given images ahead of pod scheduling, so they
+// are already cached when pods referencing them are created. It returns an
+// error if the container runtime does not support prefetching.
+func (kl *Kubelet) PrefetchImages(ctx context.Context, specs []kubecontainer.ImagePrefetchSpec) ([]kubecontainer.ImagePrefetchResult, error) {
+	prefetcher, ok := kl.containerRuntime.(kubecontainer.ImagePrefetcher)
+	if !ok {
+		return nil, fmt.Errorf("container runtime does not support image prefetch")
+	}
+	return prefetcher.PrefetchImages(ctx, specs), nil
+}
+
 // PodCouldHaveRunningContainers returns true if the pod with the given UID could still have running
 // containers. This returns false if the pod has not yet been started or the pod is unknown.
 func (kl *Kubelet) PodCouldHaveRunningContainers(pod *v1.Pod) bool {
@@ -2199,6 +2237,21 @@ func (kl *Kubelet) convertToAPIContainerStatuses(pod *v1.Pod, podStatus *kubecon
 				status.LastTerminationState = oldStatus.LastTerminationState
 			}
 		}
+		// While the container's image is still being pulled, surface the
+		// estimated pull progress in the waiting state so users see more
+		// than a bare ContainerCreating/PodInitializing reason. The CRI
+		// offers no per-pull progress, so the estimate comes from image
+		// storage growth since the pull started.
+		if status.State.Waiting != nil {
+			if provider, ok := kl.containerRuntime.(kubecontainer.ImagePullProgressProvider); ok {
+				if progress, inFlight := provider.PullProgress(context.TODO(), container.Image); inFlight {
+					waiting := *status.State.Waiting
+					waiting.Message = fmt.Sprintf("Pulling image %q: approximately %d bytes pulled, %v elapsed",
+						container.Image, progress.BytesPulled, time.Since(progress.StartTime).Truncate(time.Second))
+					status.State = v1.ContainerState{Waiting: &waiting}
+				}
+			}
+		}
 		statuses[container.Name] = status
 	}
 
@@ -3209,8 +3209,12 @@ func TestSyncPodSpans(t *testing.T) {
 		kubelet.backOff,
 		kubeCfg.SerializeImagePulls,
 		kubeCfg.MaxParallelImagePulls,
+		kubeCfg.MaxParallelImagePullsPerRuntimeClass,
 		float32(kubeCfg.RegistryPullQPS),
 		int(kubeCfg.RegistryBurst),
+		kubeCfg.ImagePullMaxBandwidth,
+		kubeCfg.ImagePullRevalidateInterval.Duration,
+		"",
 		"",
 		"",
 		kubeCfg.CPUCFSQuota,
@@ -135,9 +135,14 @@ func newFakeKubeRuntimeManager(runtimeService internalapi.RuntimeService, imageS
 		flowcontrol.NewBackOff(time.Second, 300*time.Second),
 		false,
 		utilpointer.Int32Ptr(0), // No limit on max parallel image pulls,
+		nil,                     // No per runtime class pull limits,
 		0,                       // Disable image pull throttling by setting QPS to 0,
 		0,
+		0, // no pull bandwidth cap,
+		0, // no tag revalidation
 		&fakePodPullingTimeRecorder{},
+		"", // no image pull report
+		"", // no image verifier plugin
 	)
 	kubeRuntimeManager.runner = lifecycle.NewHandlerRunner(
 		&fakeHTTP{},
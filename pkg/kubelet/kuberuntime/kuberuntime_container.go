@@ -252,6 +252,25 @@ func (m *kubeGenericRuntimeManager) startContainer(ctx context.Context, podSandb
 	}
 
 	containerID, err := m.runtimeService.CreateContainer(ctx, podSandboxID, containerConfig, podSandboxConfig)
+	if err != nil && crierror.IsNotFound(err) {
+		// The image can be removed, e.g. by image garbage collection or an
+		// administrator, between the pull completing and the container being
+		// created. If the image is indeed gone, re-pull it and retry the
+		// creation once instead of failing the sync and leaving recovery to
+		// the next one.
+		if ref, getErr := m.GetImageRef(ctx, kubecontainer.ImageSpec{Image: imageRef}); getErr == nil && ref == "" {
+			klog.InfoS("Image was removed before the container could be created, re-pulling", "pod", klog.KObj(pod), "containerName", container.Name, "image", container.Image)
+			m.recordContainerEvent(pod, container, "", v1.EventTypeWarning, events.FailedToCreateContainer, "Image %q was removed before the container could be created, re-pulling", container.Image)
+			imageRef, msg, err = m.imagePuller.EnsureImageExists(ctx, pod, container, pullSecrets, podSandboxConfig, podRuntimeHandler)
+			if err != nil {
+				s, _ := grpcstatus.FromError(err)
+				m.recordContainerEvent(pod, container, "", v1.EventTypeWarning, events.FailedToCreateContainer, "Error: %v", s.Message())
+				return msg, err
+			}
+			containerConfig.Image.Image = imageRef
+			containerID, err = m.runtimeService.CreateContainer(ctx, podSandboxID, containerConfig, podSandboxConfig)
+		}
+	}
 	if err != nil {
 		s, _ := grpcstatus.FromError(err)
 		m.recordContainerEvent(pod, container, containerID, v1.EventTypeWarning, events.FailedToCreateContainer, "Error: %v", s.Message())
@@ -18,6 +18,7 @@ package kuberuntime
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -29,6 +30,8 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -38,6 +41,7 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	apitest "k8s.io/cri-api/pkg/apis/testing"
 
 	"k8s.io/kubernetes/pkg/features"
 	kubecontainer "k8s.io/kubernetes/pkg/kubelet/container"
@@ -101,6 +105,74 @@ func TestRemoveContainer(t *testing.T) {
 	assert.Empty(t, containers)
 }
 
+// imageRemovingRuntimeService fails the first CreateContainer call with a
+// gRPC NotFound error and removes the image from the image service,
+// simulating the image being garbage collected between the pull completing
+// and the container being created.
+type imageRemovingRuntimeService struct {
+	*apitest.FakeRuntimeService
+	imageService *apitest.FakeImageService
+	failedOnce   bool
+}
+
+func (r *imageRemovingRuntimeService) CreateContainer(ctx context.Context, podSandboxID string, config *runtimeapi.ContainerConfig, sandboxConfig *runtimeapi.PodSandboxConfig) (string, error) {
+	if !r.failedOnce {
+		r.failedOnce = true
+		if err := r.imageService.RemoveImage(ctx, &runtimeapi.ImageSpec{Image: config.Image.Image}); err != nil {
+			return "", err
+		}
+		return "", grpcstatus.Error(codes.NotFound, fmt.Sprintf("image %q not found", config.Image.Image))
+	}
+	return r.FakeRuntimeService.CreateContainer(ctx, podSandboxID, config, sandboxConfig)
+}
+
+// TestStartContainerImageRemovedBeforeCreate tests that startContainer
+// re-pulls the image and retries the creation when the image disappears
+// between the pull completing and CreateContainer running.
+func TestStartContainerImageRemovedBeforeCreate(t *testing.T) {
+	ctx := context.Background()
+	fakeRuntime, fakeImage, m, err := createTestRuntimeManager()
+	require.NoError(t, err)
+	runtimeService := &imageRemovingRuntimeService{
+		FakeRuntimeService: fakeRuntime,
+		imageService:       fakeImage,
+	}
+	m.runtimeService = runtimeService
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:       "12345678",
+			Name:      "bar",
+			Namespace: "new",
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:            "foo",
+					Image:           "busybox",
+					ImagePullPolicy: v1.PullIfNotPresent,
+				},
+			},
+		},
+	}
+	fakeSandBox, _ := makeAndSetFakePod(t, m, fakeRuntime, pod)
+	sandboxConfig, err := m.generatePodSandboxConfig(pod, 0)
+	require.NoError(t, err)
+
+	_, err = m.startContainer(ctx, fakeSandBox.Id, sandboxConfig, containerStartSpec(&pod.Spec.Containers[0]), pod, &kubecontainer.PodStatus{}, nil, "", []string{})
+	assert.NoError(t, err)
+	assert.True(t, runtimeService.failedOnce)
+	// The image is pulled once by the initial EnsureImageExists and a second
+	// time when the failed creation is retried.
+	pullCount := 0
+	for _, call := range fakeImage.Called {
+		if call == "PullImage" {
+			pullCount++
+		}
+	}
+	assert.Equal(t, 2, pullCount)
+}
+
 // TestKillContainer tests killing the container in a Pod.
 func TestKillContainer(t *testing.T) {
 	_, _, m, _ := createTestRuntimeManager()
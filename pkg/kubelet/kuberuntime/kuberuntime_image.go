@@ -171,6 +171,18 @@ func (m *kubeGenericRuntimeManager) ImageStats(ctx context.Context) (*kubecontai
 	return stats, nil
 }
 
+// PullProgress implements kubecontainer.ImagePullProgressProvider by
+// reporting the image puller's in-flight pulls.
+func (m *kubeGenericRuntimeManager) PullProgress(ctx context.Context, image string) (*kubecontainer.ImagePullProgress, bool) {
+	return m.imagePuller.PullProgress(ctx, image)
+}
+
+// PrefetchImages implements kubecontainer.ImagePrefetcher by pulling the
+// given images through the image puller.
+func (m *kubeGenericRuntimeManager) PrefetchImages(ctx context.Context, specs []kubecontainer.ImagePrefetchSpec) []kubecontainer.ImagePrefetchResult {
+	return m.imagePuller.PrefetchImages(ctx, specs)
+}
+
 func (m *kubeGenericRuntimeManager) ImageFsInfo(ctx context.Context) (*runtimeapi.ImageFsInfoResponse, error) {
 	allImages, err := m.imageService.ImageFsInfo(ctx)
 	if err != nil {
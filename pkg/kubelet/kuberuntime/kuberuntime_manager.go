@@ -195,10 +195,14 @@ func NewKubeGenericRuntimeManager(
 	imageBackOff *flowcontrol.Backoff,
 	serializeImagePulls bool,
 	maxParallelImagePulls *int32,
+	maxParallelImagePullsPerRuntimeClass map[string]int32,
 	imagePullQPS float32,
 	imagePullBurst int,
+	imagePullMaxBandwidth int64,
+	imagePullRevalidateInterval time.Duration,
 	imageCredentialProviderConfigFile string,
 	imageCredentialProviderBinDir string,
+	imageVerifierBin string,
 	cpuCFSQuota bool,
 	cpuCFSQuotaPeriod metav1.Duration,
 	runtimeService internalapi.RuntimeService,
@@ -264,7 +268,11 @@ func NewKubeGenericRuntimeManager(
 		"apiVersion", typedVersion.RuntimeApiVersion)
 
 	if imageCredentialProviderConfigFile != "" || imageCredentialProviderBinDir != "" {
-		if err := plugin.RegisterCredentialProviderPlugins(imageCredentialProviderConfigFile, imageCredentialProviderBinDir); err != nil {
+		registerPlugins := plugin.RegisterCredentialProviderPlugins
+		if utilfeature.DefaultFeatureGate.Enabled(features.KubeletCredentialProviderReload) {
+			registerPlugins = plugin.RegisterCredentialProviderPluginsWithReload
+		}
+		if err := registerPlugins(imageCredentialProviderConfigFile, imageCredentialProviderBinDir); err != nil {
 			klog.ErrorS(err, "Failed to register CRI auth plugins")
 			os.Exit(1)
 		}
@@ -277,9 +285,14 @@ func NewKubeGenericRuntimeManager(
 		imageBackOff,
 		serializeImagePulls,
 		maxParallelImagePulls,
+		maxParallelImagePullsPerRuntimeClass,
 		imagePullQPS,
 		imagePullBurst,
-		podPullingTimeRecorder)
+		imagePullMaxBandwidth,
+		imagePullRevalidateInterval,
+		podPullingTimeRecorder,
+		rootDirectory,
+		imageVerifierBin)
 	kubeRuntimeManager.runner = lifecycle.NewHandlerRunner(insecureContainerLifecycleHTTPClient, kubeRuntimeManager, kubeRuntimeManager, recorder)
 	kubeRuntimeManager.containerGC = newContainerGC(runtimeService, podStateProvider, kubeRuntimeManager, tracer)
 	kubeRuntimeManager.podStateProvider = podStateProvider
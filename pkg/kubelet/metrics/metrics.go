@@ -30,48 +30,55 @@ import (
 
 // This const block defines the metric names for the kubelet metrics.
 const (
-	FirstNetworkPodStartSLIDurationKey = "first_network_pod_start_sli_duration_seconds"
-	KubeletSubsystem                   = "kubelet"
-	NodeNameKey                        = "node_name"
-	NodeLabelKey                       = "node"
-	NodeStartupPreKubeletKey           = "node_startup_pre_kubelet_duration_seconds"
-	NodeStartupPreRegistrationKey      = "node_startup_pre_registration_duration_seconds"
-	NodeStartupRegistrationKey         = "node_startup_registration_duration_seconds"
-	NodeStartupPostRegistrationKey     = "node_startup_post_registration_duration_seconds"
-	NodeStartupKey                     = "node_startup_duration_seconds"
-	PodWorkerDurationKey               = "pod_worker_duration_seconds"
-	PodStartDurationKey                = "pod_start_duration_seconds"
-	PodStartSLIDurationKey             = "pod_start_sli_duration_seconds"
-	PodStartTotalDurationKey           = "pod_start_total_duration_seconds"
-	CgroupManagerOperationsKey         = "cgroup_manager_duration_seconds"
-	PodWorkerStartDurationKey          = "pod_worker_start_duration_seconds"
-	PodStatusSyncDurationKey           = "pod_status_sync_duration_seconds"
-	PLEGRelistDurationKey              = "pleg_relist_duration_seconds"
-	PLEGDiscardEventsKey               = "pleg_discard_events"
-	PLEGRelistIntervalKey              = "pleg_relist_interval_seconds"
-	PLEGLastSeenKey                    = "pleg_last_seen_seconds"
-	EventedPLEGConnErrKey              = "evented_pleg_connection_error_count"
-	EventedPLEGConnKey                 = "evented_pleg_connection_success_count"
-	EventedPLEGConnLatencyKey          = "evented_pleg_connection_latency_seconds"
-	EvictionsKey                       = "evictions"
-	EvictionStatsAgeKey                = "eviction_stats_age_seconds"
-	PreemptionsKey                     = "preemptions"
-	VolumeStatsCapacityBytesKey        = "volume_stats_capacity_bytes"
-	VolumeStatsAvailableBytesKey       = "volume_stats_available_bytes"
-	VolumeStatsUsedBytesKey            = "volume_stats_used_bytes"
-	VolumeStatsInodesKey               = "volume_stats_inodes"
-	VolumeStatsInodesFreeKey           = "volume_stats_inodes_free"
-	VolumeStatsInodesUsedKey           = "volume_stats_inodes_used"
-	VolumeStatsHealthStatusAbnormalKey = "volume_stats_health_status_abnormal"
-	RunningPodsKey                     = "running_pods"
-	RunningContainersKey               = "running_containers"
-	DesiredPodCountKey                 = "desired_pods"
-	ActivePodCountKey                  = "active_pods"
-	MirrorPodCountKey                  = "mirror_pods"
-	WorkingPodCountKey                 = "working_pods"
-	OrphanedRuntimePodTotalKey         = "orphaned_runtime_pods_total"
-	RestartedPodTotalKey               = "restarted_pods_total"
-	ImagePullDurationKey               = "image_pull_duration_seconds"
+	FirstNetworkPodStartSLIDurationKey  = "first_network_pod_start_sli_duration_seconds"
+	KubeletSubsystem                    = "kubelet"
+	NodeNameKey                         = "node_name"
+	NodeLabelKey                        = "node"
+	NodeStartupPreKubeletKey            = "node_startup_pre_kubelet_duration_seconds"
+	NodeStartupPreRegistrationKey       = "node_startup_pre_registration_duration_seconds"
+	NodeStartupRegistrationKey          = "node_startup_registration_duration_seconds"
+	NodeStartupPostRegistrationKey      = "node_startup_post_registration_duration_seconds"
+	NodeStartupKey                      = "node_startup_duration_seconds"
+	PodWorkerDurationKey                = "pod_worker_duration_seconds"
+	PodStartDurationKey                 = "pod_start_duration_seconds"
+	PodStartSLIDurationKey              = "pod_start_sli_duration_seconds"
+	PodStartTotalDurationKey            = "pod_start_total_duration_seconds"
+	CgroupManagerOperationsKey          = "cgroup_manager_duration_seconds"
+	PodWorkerStartDurationKey           = "pod_worker_start_duration_seconds"
+	PodStatusSyncDurationKey            = "pod_status_sync_duration_seconds"
+	PLEGRelistDurationKey               = "pleg_relist_duration_seconds"
+	PLEGDiscardEventsKey                = "pleg_discard_events"
+	PLEGRelistIntervalKey               = "pleg_relist_interval_seconds"
+	PLEGLastSeenKey                     = "pleg_last_seen_seconds"
+	EventedPLEGConnErrKey               = "evented_pleg_connection_error_count"
+	EventedPLEGConnKey                  = "evented_pleg_connection_success_count"
+	EventedPLEGConnLatencyKey           = "evented_pleg_connection_latency_seconds"
+	EvictionsKey                        = "evictions"
+	EvictionStatsAgeKey                 = "eviction_stats_age_seconds"
+	PreemptionsKey                      = "preemptions"
+	VolumeStatsCapacityBytesKey         = "volume_stats_capacity_bytes"
+	VolumeStatsAvailableBytesKey        = "volume_stats_available_bytes"
+	VolumeStatsUsedBytesKey             = "volume_stats_used_bytes"
+	VolumeStatsInodesKey                = "volume_stats_inodes"
+	VolumeStatsInodesFreeKey            = "volume_stats_inodes_free"
+	VolumeStatsInodesUsedKey            = "volume_stats_inodes_used"
+	VolumeStatsHealthStatusAbnormalKey  = "volume_stats_health_status_abnormal"
+	RunningPodsKey                      = "running_pods"
+	RunningContainersKey                = "running_containers"
+	DesiredPodCountKey                  = "desired_pods"
+	ActivePodCountKey                   = "active_pods"
+	MirrorPodCountKey                   = "mirror_pods"
+	WorkingPodCountKey                  = "working_pods"
+	OrphanedRuntimePodTotalKey          = "orphaned_runtime_pods_total"
+	RestartedPodTotalKey                = "restarted_pods_total"
+	ImagePullDurationKey                = "image_pull_duration_seconds"
+	ImageRegistryPullDurationKey        = "image_registry_pull_duration_seconds"
+	ImageRegistryPulledBytesKey         = "image_registry_pulled_bytes_total"
+	ImageRegistryPullFailuresKey        = "image_registry_pull_failures_total"
+	ImageManifestResolutionsKey         = "image_manifest_resolutions_total"
+	ImageManifestResolutionCacheHitsKey = "image_manifest_resolution_cache_hits_total"
+	ImagePullBandwidthThrottledKey      = "image_pull_bandwidth_throttled_total"
+	ImagePullBandwidthThrottleWaitKey   = "image_pull_bandwidth_throttle_wait_duration_seconds"
 
 	// Metrics keys of remote runtime operations
 	RuntimeOperationsKey         = "runtime_operations_total"
@@ -899,6 +906,92 @@ var (
 		[]string{"image_size_in_bytes"},
 	)
 
+	// ImageRegistryPullDuration is a Histogram of image pull durations broken
+	// down by registry host, so a single slow registry can be told apart from
+	// generally slow pulls. The images package caps the number of distinct
+	// registry label values.
+	ImageRegistryPullDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      KubeletSubsystem,
+			Name:           ImageRegistryPullDurationKey,
+			Help:           "Duration in seconds to pull an image, broken down by registry host.",
+			Buckets:        imagePullDurationBuckets,
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"registry"},
+	)
+
+	// ImageRegistryPulledBytes counts the image bytes pulled from each
+	// registry host.
+	ImageRegistryPulledBytes = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      KubeletSubsystem,
+			Name:           ImageRegistryPulledBytesKey,
+			Help:           "Total image bytes pulled, broken down by registry host.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"registry"},
+	)
+
+	// ImageRegistryPullFailures counts failed pulls by registry host and a
+	// coarse error class (auth, not_found, timeout, rate_limited, other).
+	ImageRegistryPullFailures = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      KubeletSubsystem,
+			Name:           ImageRegistryPullFailuresKey,
+			Help:           "Total failed image pulls, broken down by registry host and error class.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"registry", "reason"},
+	)
+
+	// ImageManifestResolutions tracks how many times the kubelet resolved an
+	// image manifest from the registry on behalf of any resolution consumer
+	// (pull, prefetch, size estimation).
+	ImageManifestResolutions = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      KubeletSubsystem,
+			Name:           ImageManifestResolutionsKey,
+			Help:           "Number of image manifest resolutions performed against the registry.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	// ImageManifestResolutionCacheHits tracks manifest resolution requests
+	// served from the short-lived resolution cache without contacting the
+	// registry.
+	ImageManifestResolutionCacheHits = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      KubeletSubsystem,
+			Name:           ImageManifestResolutionCacheHitsKey,
+			Help:           "Number of image manifest resolution requests served from cache.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	// ImagePullBandwidthThrottled counts the pulls delayed to keep the
+	// aggregate pull bandwidth under the configured cap.
+	ImagePullBandwidthThrottled = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      KubeletSubsystem,
+			Name:           ImagePullBandwidthThrottledKey,
+			Help:           "Number of image pulls delayed by the image pull bandwidth cap.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	// ImagePullBandwidthThrottleWait tracks how long throttled pulls waited
+	// for pull bandwidth to become available.
+	ImagePullBandwidthThrottleWait = metrics.NewHistogram(
+		&metrics.HistogramOpts{
+			Subsystem:      KubeletSubsystem,
+			Name:           ImagePullBandwidthThrottleWaitKey,
+			Help:           "Duration in seconds image pulls waited for pull bandwidth to become available.",
+			Buckets:        metrics.DefBuckets,
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
 	LifecycleHandlerSleepTerminated = metrics.NewCounter(
 		&metrics.CounterOpts{
 			Subsystem:      KubeletSubsystem,
@@ -922,6 +1015,13 @@ func Register(collectors ...metrics.StableCollector) {
 		legacyregistry.MustRegister(PodStartSLIDuration)
 		legacyregistry.MustRegister(PodStartTotalDuration)
 		legacyregistry.MustRegister(ImagePullDuration)
+		legacyregistry.MustRegister(ImageRegistryPullDuration)
+		legacyregistry.MustRegister(ImageRegistryPulledBytes)
+		legacyregistry.MustRegister(ImageRegistryPullFailures)
+		legacyregistry.MustRegister(ImageManifestResolutions)
+		legacyregistry.MustRegister(ImageManifestResolutionCacheHits)
+		legacyregistry.MustRegister(ImagePullBandwidthThrottled)
+		legacyregistry.MustRegister(ImagePullBandwidthThrottleWait)
 		legacyregistry.MustRegister(NodeStartupPreKubeletDuration)
 		legacyregistry.MustRegister(NodeStartupPreRegistrationDuration)
 		legacyregistry.MustRegister(NodeStartupRegistrationDuration)
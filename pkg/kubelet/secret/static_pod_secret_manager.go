@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// staticPodSecretManager resolves secrets from dockerconfigjson files in a
+// node-local directory instead of the API. It backs imagePullSecrets for
+// static pods on standalone kubelets, where no API server is available. A
+// secret named "foo" referenced from namespace "ns" resolves to the file
+// <dir>/foo.json, whose content is the .dockerconfigjson payload.
+type staticPodSecretManager struct {
+	dir string
+}
+
+// NewStaticPodSecretManager creates a Manager that resolves secrets from
+// dockerconfigjson files under dir. Files are re-read on every lookup, so
+// rotated credentials take effect without restarting the kubelet.
+func NewStaticPodSecretManager(dir string) Manager {
+	return &staticPodSecretManager{dir: dir}
+}
+
+func (s *staticPodSecretManager) GetSecret(namespace, name string) (*v1.Secret, error) {
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("secret name %q must not reference a path", name)
+	}
+
+	path := filepath.Join(s.dir, name+".json")
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("static pod secret %q not found: %w", name, err)
+	}
+	if mode := info.Mode().Perm(); mode&0o077 != 0 {
+		return nil, fmt.Errorf("static pod secret file %s has permissions %#o, must not be accessible by group or others", path, mode)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading static pod secret file %s: %w", path, err)
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("static pod secret file %s does not contain valid JSON", path)
+	}
+
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Type: v1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			v1.DockerConfigJsonKey: data,
+		},
+	}, nil
+}
+
+func (s *staticPodSecretManager) RegisterPod(pod *v1.Pod) {
+}
+
+func (s *staticPodSecretManager) UnregisterPod(pod *v1.Pod) {
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestStaticPodSecretManagerGetSecret(t *testing.T) {
+	dir := t.TempDir()
+	payload := `{"auths":{"registry.example.com":{"auth":"dXNlcjpwYXNz"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "regcred.json"), []byte(payload), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "world-readable.json"), []byte(payload), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "not-json.json"), []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewStaticPodSecretManager(dir)
+
+	testCases := []struct {
+		name        string
+		secretName  string
+		expectError string
+	}{
+		{
+			name:       "valid secret",
+			secretName: "regcred",
+		},
+		{
+			name:        "missing secret",
+			secretName:  "does-not-exist",
+			expectError: "not found",
+		},
+		{
+			name:        "world readable file rejected",
+			secretName:  "world-readable",
+			expectError: "must not be accessible by group or others",
+		},
+		{
+			name:        "invalid payload rejected",
+			secretName:  "not-json",
+			expectError: "does not contain valid JSON",
+		},
+		{
+			name:        "path traversal rejected",
+			secretName:  "../regcred",
+			expectError: "must not reference a path",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			secret, err := manager.GetSecret("kube-system", tc.secretName)
+			if tc.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if secret.Type != v1.SecretTypeDockerConfigJson {
+				t.Errorf("expected secret type %s, got %s", v1.SecretTypeDockerConfigJson, secret.Type)
+			}
+			if secret.Namespace != "kube-system" || secret.Name != tc.secretName {
+				t.Errorf("unexpected secret identity %s/%s", secret.Namespace, secret.Name)
+			}
+			if string(secret.Data[v1.DockerConfigJsonKey]) != payload {
+				t.Errorf("unexpected secret payload %q", secret.Data[v1.DockerConfigJsonKey])
+			}
+		})
+	}
+}
+
+func TestStaticPodSecretManagerReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "regcred.json")
+	if err := os.WriteFile(path, []byte(`{"auths":{}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewStaticPodSecretManager(dir)
+	if _, err := manager.GetSecret("default", "regcred"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rotated := `{"auths":{"registry.example.com":{"auth":"bmV3OmNyZWRz"}}}`
+	if err := os.WriteFile(path, []byte(rotated), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := manager.GetSecret("default", "regcred")
+	if err != nil {
+		t.Fatalf("unexpected error after rotation: %v", err)
+	}
+	if string(secret.Data[v1.DockerConfigJsonKey]) != rotated {
+		t.Errorf("expected rotated payload to be served, got %q", secret.Data[v1.DockerConfigJsonKey])
+	}
+}
@@ -113,10 +113,11 @@ func AuthzTestCases() []AuthzTestCase {
 		"/checkpoint/{podNamespace}/{podID}/{containerName}":   "checkpoint",
 		"/configz": "proxy",
 		"/containerLogs/{podNamespace}/{podID}/{containerName}": "proxy",
-		"/debug/flags/v":                                     "proxy",
-		"/debug/pprof/{subpath:*}":                           "proxy",
-		"/exec/{podNamespace}/{podID}/{containerName}":       "proxy",
-		"/exec/{podNamespace}/{podID}/{uid}/{containerName}": "proxy",
+		"/debug/credential-resolution/":                         "proxy",
+		"/debug/flags/v":                                        "proxy",
+		"/debug/pprof/{subpath:*}":                              "proxy",
+		"/exec/{podNamespace}/{podID}/{containerName}":          "proxy",
+		"/exec/{podNamespace}/{podID}/{uid}/{containerName}":    "proxy",
 		"/healthz":                            "proxy",
 		"/healthz/log":                        "proxy",
 		"/healthz/ping":                       "proxy",
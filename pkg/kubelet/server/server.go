@@ -19,6 +19,7 @@ package server
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
@@ -77,6 +78,7 @@ import (
 	"k8s.io/kubernetes/pkg/api/legacyscheme"
 	api "k8s.io/kubernetes/pkg/apis/core"
 	"k8s.io/kubernetes/pkg/apis/core/v1/validation"
+	"k8s.io/kubernetes/pkg/credentialprovider"
 	"k8s.io/kubernetes/pkg/features"
 	kubeletconfiginternal "k8s.io/kubernetes/pkg/kubelet/apis/config"
 	apisgrpc "k8s.io/kubernetes/pkg/kubelet/apis/grpc"
@@ -85,6 +87,7 @@ import (
 	"k8s.io/kubernetes/pkg/kubelet/prober"
 	servermetrics "k8s.io/kubernetes/pkg/kubelet/server/metrics"
 	"k8s.io/kubernetes/pkg/kubelet/server/stats"
+	"k8s.io/kubernetes/pkg/util/parsers"
 )
 
 func init() {
@@ -99,8 +102,10 @@ const (
 	statsPath           = "/stats/"
 	logsPath            = "/logs/"
 	checkpointPath      = "/checkpoint/"
+	prefetchPath        = "/prefetch"
 	pprofBasePath       = "/debug/pprof/"
 	debugFlagPath       = "/debug/flags/v"
+	credResolutionPath  = "/debug/credential-resolution"
 )
 
 // Server is a http.Handler which exposes kubelet functionality over HTTP.
@@ -266,6 +271,8 @@ type HostInterface interface {
 	GetPortForward(ctx context.Context, podName, podNamespace string, podUID types.UID, portForwardOpts portforward.V4Options) (*url.URL, error)
 	ListMetricDescriptors(ctx context.Context) ([]*runtimeapi.MetricDescriptor, error)
 	ListPodSandboxMetrics(ctx context.Context) ([]*runtimeapi.PodSandboxMetrics, error)
+	TraceImageCredentialResolution(image, podNamespace, podName string) (*credentialprovider.ResolutionTrace, error)
+	PrefetchImages(ctx context.Context, specs []kubecontainer.ImagePrefetchSpec) ([]kubecontainer.ImagePrefetchResult, error)
 }
 
 // NewServer initializes and configures a kubelet.Server object to handle HTTP requests.
@@ -453,6 +460,17 @@ func (s *Server) InstallDefaultHandlers() {
 			Operation("checkpoint"))
 		s.restfulCont.Add(ws)
 	}
+
+	// Only enable the image prefetch API if the feature is enabled
+	if utilfeature.DefaultFeatureGate.Enabled(features.KubeletImagePrefetch) {
+		s.addMetricsBucketMatcher("prefetch")
+		ws = &restful.WebService{}
+		ws.Path(prefetchPath).Produces(restful.MIME_JSON)
+		ws.Route(ws.POST("").
+			To(s.prefetchImages).
+			Operation("prefetchImages"))
+		s.restfulCont.Add(ws)
+	}
 }
 
 // InstallDebuggingHandlers registers the HTTP request patterns that serve logs or run commands/containers
@@ -547,6 +565,16 @@ func (s *Server) InstallDebuggingHandlers() {
 		To(s.getRunningPods).
 		Operation("getRunningPods"))
 	s.restfulCont.Add(ws)
+
+	s.addMetricsBucketMatcher("debug")
+	ws = new(restful.WebService)
+	ws.
+		Path(credResolutionPath).
+		Produces(restful.MIME_JSON)
+	ws.Route(ws.POST("").
+		To(s.getCredentialResolution).
+		Operation("getCredentialResolution"))
+	s.restfulCont.Add(ws)
 }
 
 // InstallDebuggingDisabledHandlers registers the HTTP request patterns that provide better error message
@@ -785,6 +813,146 @@ func (s *Server) getLogs(request *restful.Request, response *restful.Response) {
 	s.host.ServeLogs(response, request.Request)
 }
 
+// credentialResolutionRequest is the body accepted by the
+// /debug/credential-resolution endpoint.
+type credentialResolutionRequest struct {
+	// Image is the image reference to resolve credentials for.
+	Image string `json:"image"`
+	// PodNamespace and PodName optionally name a pod whose image pull
+	// secrets participate in the resolution.
+	PodNamespace string `json:"podNamespace,omitempty"`
+	PodName      string `json:"podName,omitempty"`
+}
+
+// getCredentialResolution handles the debug endpoint explaining which
+// credential sources would be used to pull a given image. The returned
+// trace identifies sources and match patterns but never secret values.
+func (s *Server) getCredentialResolution(request *restful.Request, response *restful.Response) {
+	var req credentialResolutionRequest
+	if err := json.NewDecoder(request.Request.Body).Decode(&req); err != nil {
+		response.WriteError(http.StatusBadRequest, fmt.Errorf("decoding request body: %w", err))
+		return
+	}
+	if req.Image == "" {
+		response.WriteError(http.StatusBadRequest, fmt.Errorf("image is required"))
+		return
+	}
+
+	trace, err := s.host.TraceImageCredentialResolution(req.Image, req.PodNamespace, req.PodName)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	data, err := json.Marshal(trace)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	writeJSONResponse(response, data)
+}
+
+// prefetchRequest is the body accepted by the prefetch endpoint.
+type prefetchRequest struct {
+	Images []prefetchImage `json:"images"`
+}
+
+// prefetchImage names one image to prefetch. Username and password are
+// optional; without them the pull uses the node-level keyring only.
+type prefetchImage struct {
+	Image    string `json:"image"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// prefetchResult reports the outcome of prefetching one image.
+type prefetchResult struct {
+	Image    string `json:"image"`
+	ImageRef string `json:"imageRef,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// prefetchImages handles a request to pull a list of images ahead of pod
+// scheduling, so the images are already cached when pods referencing them
+// are created.
+func (s *Server) prefetchImages(request *restful.Request, response *restful.Response) {
+	var req prefetchRequest
+	if err := json.NewDecoder(request.Request.Body).Decode(&req); err != nil {
+		response.WriteError(http.StatusBadRequest, fmt.Errorf("decoding request body: %w", err))
+		return
+	}
+	if len(req.Images) == 0 {
+		response.WriteError(http.StatusBadRequest, fmt.Errorf("images is required"))
+		return
+	}
+	specs := make([]kubecontainer.ImagePrefetchSpec, 0, len(req.Images))
+	for _, image := range req.Images {
+		if image.Image == "" {
+			response.WriteError(http.StatusBadRequest, fmt.Errorf("image is required"))
+			return
+		}
+		spec := kubecontainer.ImagePrefetchSpec{Image: image.Image}
+		if image.Username != "" || image.Password != "" {
+			secret, err := dockerConfigJSONSecret(image.Image, image.Username, image.Password)
+			if err != nil {
+				response.WriteError(http.StatusBadRequest, err)
+				return
+			}
+			spec.PullSecrets = []v1.Secret{secret}
+		}
+		specs = append(specs, spec)
+	}
+
+	results, err := s.host.PrefetchImages(request.Request.Context(), specs)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	out := make([]prefetchResult, 0, len(results))
+	for _, result := range results {
+		r := prefetchResult{Image: result.Image, ImageRef: result.ImageRef}
+		if result.Err != nil {
+			r.Error = result.Err.Error()
+		}
+		out = append(out, r)
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	writeJSONResponse(response, data)
+}
+
+// dockerConfigJSONSecret builds a dockerconfigjson pull secret holding the
+// given credentials for the image's registry, in the same format as a
+// pod's imagePullSecrets.
+func dockerConfigJSONSecret(image, username, password string) (v1.Secret, error) {
+	repo, _, _, err := parsers.ParseImageName(image)
+	if err != nil {
+		return v1.Secret{}, fmt.Errorf("parsing image %q: %w", image, err)
+	}
+	host := repo
+	if i := strings.Index(repo, "/"); i > 0 {
+		host = repo[:i]
+	}
+	cfg := credentialprovider.DockerConfigJSON{
+		Auths: credentialprovider.DockerConfig{
+			host: credentialprovider.DockerConfigEntry{
+				Username: username,
+				Password: password,
+			},
+		},
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return v1.Secret{}, fmt.Errorf("encoding pull secret for image %q: %w", image, err)
+	}
+	return v1.Secret{
+		Type: v1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{v1.DockerConfigJsonKey: data},
+	}, nil
+}
+
 type execRequestParams struct {
 	podNamespace  string
 	podName       string
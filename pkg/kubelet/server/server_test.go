@@ -19,6 +19,7 @@ package server
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -59,9 +60,11 @@ import (
 	"k8s.io/kubelet/pkg/cri/streaming/portforward"
 	remotecommandserver "k8s.io/kubelet/pkg/cri/streaming/remotecommand"
 	_ "k8s.io/kubernetes/pkg/apis/core/install"
+	"k8s.io/kubernetes/pkg/credentialprovider"
 	"k8s.io/kubernetes/pkg/features"
 	kubeletconfiginternal "k8s.io/kubernetes/pkg/kubelet/apis/config"
 	"k8s.io/kubernetes/pkg/kubelet/cm"
+	kubecontainer "k8s.io/kubernetes/pkg/kubelet/container"
 	"k8s.io/kubernetes/pkg/kubelet/server/stats"
 	"k8s.io/kubernetes/pkg/volume"
 )
@@ -83,12 +86,15 @@ type fakeKubelet struct {
 	getAttachCheck      func(string, types.UID, string, remotecommandserver.Options)
 	getPortForwardCheck func(string, string, types.UID, portforward.V4Options)
 
-	containerLogsFunc func(ctx context.Context, podFullName, containerName string, logOptions *v1.PodLogOptions, stdout, stderr io.Writer) error
-	hostnameFunc      func() string
-	resyncInterval    time.Duration
-	loopEntryTime     time.Time
-	plegHealth        bool
-	streamingRuntime  streaming.Server
+	containerLogsFunc        func(ctx context.Context, podFullName, containerName string, logOptions *v1.PodLogOptions, stdout, stderr io.Writer) error
+	credentialResolutionFunc func(image, podNamespace, podName string) (*credentialprovider.ResolutionTrace, error)
+	prefetchImagesFunc       func(ctx context.Context, specs []kubecontainer.ImagePrefetchSpec) ([]kubecontainer.ImagePrefetchResult, error)
+
+	hostnameFunc     func() string
+	resyncInterval   time.Duration
+	loopEntryTime    time.Time
+	plegHealth       bool
+	streamingRuntime streaming.Server
 }
 
 func (fk *fakeKubelet) ResyncInterval() time.Duration {
@@ -154,6 +160,24 @@ func (fk *fakeKubelet) ListPodSandboxMetrics(ctx context.Context) ([]*runtimeapi
 	return nil, nil
 }
 
+func (fk *fakeKubelet) TraceImageCredentialResolution(image, podNamespace, podName string) (*credentialprovider.ResolutionTrace, error) {
+	if fk.credentialResolutionFunc != nil {
+		return fk.credentialResolutionFunc(image, podNamespace, podName)
+	}
+	return &credentialprovider.ResolutionTrace{Image: image}, nil
+}
+
+func (fk *fakeKubelet) PrefetchImages(ctx context.Context, specs []kubecontainer.ImagePrefetchSpec) ([]kubecontainer.ImagePrefetchResult, error) {
+	if fk.prefetchImagesFunc != nil {
+		return fk.prefetchImagesFunc(ctx, specs)
+	}
+	results := make([]kubecontainer.ImagePrefetchResult, 0, len(specs))
+	for _, spec := range specs {
+		results = append(results, kubecontainer.ImagePrefetchResult{Image: spec.Image})
+	}
+	return results, nil
+}
+
 type fakeRuntime struct {
 	execFunc        func(string, []string, io.Reader, io.WriteCloser, io.WriteCloser, bool, <-chan remotecommand.TerminalSize) error
 	attachFunc      func(string, io.Reader, io.WriteCloser, io.WriteCloser, bool, <-chan remotecommand.TerminalSize) error
@@ -375,6 +399,117 @@ func getPodName(name, namespace string) string {
 	return name + "_" + namespace
 }
 
+func TestCredentialResolutionEndpoint(t *testing.T) {
+	fw := newServerTest()
+	defer fw.testHTTPServer.Close()
+
+	fw.fakeKubelet.credentialResolutionFunc = func(image, podNamespace, podName string) (*credentialprovider.ResolutionTrace, error) {
+		if podNamespace != "podNamespace" || podName != "podName" {
+			t.Errorf("unexpected pod reference %s/%s", podNamespace, podName)
+		}
+		return &credentialprovider.ResolutionTrace{
+			Image: image,
+			Steps: []credentialprovider.TraceStep{
+				{Source: "pull secrets", MatchPattern: "registry.test", Matched: true},
+			},
+			FinalSource:      "pull secrets",
+			CredentialsFound: true,
+		}, nil
+	}
+
+	body := strings.NewReader(`{"image":"registry.test/app:v1","podNamespace":"podNamespace","podName":"podName"}`)
+	resp, err := http.Post(fw.testHTTPServer.URL+"/debug/credential-resolution", "application/json", body)
+	if err != nil {
+		t.Fatalf("Got error POSTing: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	var trace credentialprovider.ResolutionTrace
+	if err := json.NewDecoder(resp.Body).Decode(&trace); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if trace.Image != "registry.test/app:v1" || trace.FinalSource != "pull secrets" || !trace.CredentialsFound {
+		t.Errorf("unexpected trace: %+v", trace)
+	}
+
+	// An empty image is rejected.
+	resp, err = http.Post(fw.testHTTPServer.URL+"/debug/credential-resolution", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("Got error POSTing: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for missing image, got %d", resp.StatusCode)
+	}
+}
+
+func TestPrefetchImagesEndpoint(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.KubeletImagePrefetch, true)
+	fw := newServerTest()
+	defer fw.testHTTPServer.Close()
+
+	var gotSpecs []kubecontainer.ImagePrefetchSpec
+	fw.fakeKubelet.prefetchImagesFunc = func(ctx context.Context, specs []kubecontainer.ImagePrefetchSpec) ([]kubecontainer.ImagePrefetchResult, error) {
+		gotSpecs = specs
+		return []kubecontainer.ImagePrefetchResult{
+			{Image: "registry.test/app:v1", ImageRef: "sha256:abc"},
+			{Image: "registry.test/other:v2", Err: fmt.Errorf("pull failed")},
+		}, nil
+	}
+
+	body := strings.NewReader(`{"images":[{"image":"registry.test/app:v1","username":"user","password":"pass"},{"image":"registry.test/other:v2"}]}`)
+	resp, err := http.Post(fw.testHTTPServer.URL+"/prefetch", "application/json", body)
+	if err != nil {
+		t.Fatalf("Got error POSTing: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	var results []prefetchResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(results) != 2 || results[0].ImageRef != "sha256:abc" || results[1].Error != "pull failed" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+
+	// The credentials are forwarded as a dockerconfigjson pull secret for
+	// the image's registry.
+	if len(gotSpecs) != 2 {
+		t.Fatalf("expected 2 specs, got %+v", gotSpecs)
+	}
+	if len(gotSpecs[0].PullSecrets) != 1 {
+		t.Fatalf("expected a pull secret for the first image, got %+v", gotSpecs[0].PullSecrets)
+	}
+	secret := gotSpecs[0].PullSecrets[0]
+	if secret.Type != v1.SecretTypeDockerConfigJson {
+		t.Errorf("unexpected secret type %q", secret.Type)
+	}
+	var cfg credentialprovider.DockerConfigJSON
+	if err := json.Unmarshal(secret.Data[v1.DockerConfigJsonKey], &cfg); err != nil {
+		t.Fatalf("decoding pull secret: %v", err)
+	}
+	if entry, ok := cfg.Auths["registry.test"]; !ok || entry.Username != "user" || entry.Password != "pass" {
+		t.Errorf("unexpected pull secret contents: %+v", cfg.Auths)
+	}
+	if len(gotSpecs[1].PullSecrets) != 0 {
+		t.Errorf("expected no pull secrets for the second image, got %+v", gotSpecs[1].PullSecrets)
+	}
+
+	// A request without images is rejected.
+	resp, err = http.Post(fw.testHTTPServer.URL+"/prefetch", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("Got error POSTing: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for missing images, got %d", resp.StatusCode)
+	}
+}
+
 func TestServeLogs(t *testing.T) {
 	fw := newServerTest()
 	defer fw.testHTTPServer.Close()
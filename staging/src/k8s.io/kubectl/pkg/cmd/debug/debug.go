@@ -102,6 +102,9 @@ var (
 		# Create an interactive debugging session on a node and immediately attach to it.
 		# The container will run in the host namespaces and the host's filesystem will be mounted at /host
 		kubectl debug node/mynode -it --image=busybox
+
+		# Check whether a node can pull an image and report which stage of the pull fails, if any
+		kubectl debug node/mynode --image-pull-check=myproj/app:v1
 `))
 )
 
@@ -120,6 +123,7 @@ type DebugOptions struct {
 	Replace            bool
 	Env                []corev1.EnvVar
 	Image              string
+	ImagePullCheck     string
 	Interactive        bool
 	KeepLabels         bool
 	KeepAnnotations    bool
@@ -196,6 +200,7 @@ func (o *DebugOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&o.Replace, "replace", o.Replace, i18n.T("When used with '--copy-to', delete the original Pod."))
 	cmd.Flags().StringToString("env", nil, i18n.T("Environment variables to set in the container."))
 	cmd.Flags().StringVar(&o.Image, "image", o.Image, i18n.T("Container image to use for debug container."))
+	cmd.Flags().StringVar(&o.ImagePullCheck, "image-pull-check", o.ImagePullCheck, i18n.T("When debugging a node, check that the node can resolve, authenticate to and pull this image, and report the result instead of starting a debugging session."))
 	cmd.Flags().BoolVar(&o.KeepLabels, "keep-labels", o.KeepLabels, i18n.T("If true, keep the original pod labels.(This flag only works when used with '--copy-to')"))
 	cmd.Flags().BoolVar(&o.KeepAnnotations, "keep-annotations", o.KeepAnnotations, i18n.T("If true, keep the original pod annotations.(This flag only works when used with '--copy-to')"))
 	cmd.Flags().BoolVar(&o.KeepLiveness, "keep-liveness", o.KeepLiveness, i18n.T("If true, keep the original pod liveness probes.(This flag only works when used with '--copy-to')"))
@@ -338,11 +343,26 @@ func (o *DebugOptions) Validate() error {
 			return fmt.Errorf("--same-node may only be used with --copy-to.")
 		case len(o.SetImages) > 0:
 			return fmt.Errorf("--set-image may only be used with --copy-to.")
-		case len(o.Image) == 0:
+		case len(o.Image) == 0 && len(o.ImagePullCheck) == 0:
 			return fmt.Errorf("you must specify --image when not using --copy-to.")
 		}
 	}
 
+	// ImagePullCheck
+	if len(o.ImagePullCheck) > 0 {
+		if !reference.ReferenceRegexp.MatchString(o.ImagePullCheck) {
+			return fmt.Errorf("invalid image name %q: %v", o.ImagePullCheck, reference.ErrReferenceInvalidFormat)
+		}
+		switch {
+		case len(o.CopyTo) > 0:
+			return fmt.Errorf("--image-pull-check may not be used with --copy-to.")
+		case o.Interactive || o.TTY || (o.Attach && o.attachChanged):
+			return fmt.Errorf("--image-pull-check does not create a session to attach to.")
+		case len(o.Args) > 0:
+			return fmt.Errorf("--image-pull-check does not run a command.")
+		}
+	}
+
 	// Image
 	if len(o.Image) > 0 && !reference.ReferenceRegexp.MatchString(o.Image) {
 		return fmt.Errorf("invalid image name %q: %v", o.Image, reference.ErrReferenceInvalidFormat)
@@ -449,6 +469,10 @@ func (o *DebugOptions) Run(restClientGetter genericclioptions.RESTClientGetter,
 // visitNode handles debugging for node targets by creating a privileged pod running in the host namespaces.
 // Returns an already created pod and container name for subsequent attach, if applicable.
 func (o *DebugOptions) visitNode(ctx context.Context, node *corev1.Node) (*corev1.Pod, string, error) {
+	if len(o.ImagePullCheck) > 0 {
+		return nil, "", o.runImagePullCheck(ctx, node)
+	}
+
 	pods := o.podClient.Pods(o.Namespace)
 	debugPod, err := o.generateNodeDebugPod(node)
 	if err != nil {
@@ -468,6 +492,9 @@ func (o *DebugOptions) visitNode(ctx context.Context, node *corev1.Node) (*corev
 //
 // visitPod returns a pod and debug container name for subsequent attach, if applicable.
 func (o *DebugOptions) visitPod(ctx context.Context, pod *corev1.Pod) (*corev1.Pod, string, error) {
+	if len(o.ImagePullCheck) > 0 {
+		return nil, "", fmt.Errorf("--image-pull-check may only be used when debugging a node")
+	}
 	if len(o.CopyTo) > 0 {
 		return o.debugByCopy(ctx, pod)
 	}
@@ -0,0 +1,244 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// imagePullCheckTimeout bounds how long the check waits for the kubelet
+	// to report a pull result. Pulls that take longer than this are treated
+	// as a transfer failure.
+	imagePullCheckTimeout      = 5 * time.Minute
+	imagePullCheckPollInterval = 2 * time.Second
+)
+
+// pullCheckStage is one phase of an image pull that the check reports on.
+// The stages are ordered: a failure in one stage means the later stages were
+// never reached.
+type pullCheckStage string
+
+const (
+	pullStageResolution pullCheckStage = "name resolution"
+	pullStageTLS        pullCheckStage = "TLS handshake"
+	pullStageAuth       pullCheckStage = "authentication"
+	pullStageManifest   pullCheckStage = "image resolution"
+	pullStageTransfer   pullCheckStage = "transfer"
+)
+
+var pullCheckStages = []pullCheckStage{pullStageResolution, pullStageTLS, pullStageAuth, pullStageManifest, pullStageTransfer}
+
+// pullCheckResult is the outcome of one image pull check. An empty
+// failedStage means the pull succeeded; detail then holds the kubelet's
+// Pulled event message, which includes the pull duration.
+type pullCheckResult struct {
+	failedStage pullCheckStage
+	detail      string
+}
+
+// runImagePullCheck verifies the node can pull o.ImagePullCheck by creating a
+// throwaway pod pinned to the node with an always-pull policy, waiting for
+// the kubelet to report the pull result, and printing a per-stage report.
+// The pod is deleted when the check finishes.
+func (o *DebugOptions) runImagePullCheck(ctx context.Context, node *corev1.Node) error {
+	pods := o.podClient.Pods(o.Namespace)
+	checkPod := o.generateImagePullCheckPod(node)
+	if !o.Quiet {
+		fmt.Fprintf(o.Out, "Checking whether node %s can pull image %s using pod %s.\n", node.Name, o.ImagePullCheck, checkPod.Name)
+	}
+	created, err := pods.Create(ctx, checkPod, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Best effort: the pod has served its purpose either way.
+		if err := pods.Delete(context.Background(), created.Name, *metav1.NewDeleteOptions(0)); err != nil {
+			fmt.Fprintf(o.ErrOut, "warning: couldn't clean up pod %s: %v\n", created.Name, err)
+		}
+	}()
+
+	result, err := o.waitForPullResult(ctx, created)
+	if err != nil {
+		return err
+	}
+
+	o.printImagePullCheckReport(node.Name, result)
+	if result.failedStage != "" {
+		return fmt.Errorf("node %s failed to pull image %s during %s", node.Name, o.ImagePullCheck, result.failedStage)
+	}
+	return nil
+}
+
+// generateImagePullCheckPod returns the throwaway pod used to exercise a pull
+// on the target node. Unlike the node debug pod it runs no command and gets
+// no host access; only the image pull matters.
+func (o *DebugOptions) generateImagePullCheckPod(node *corev1.Node) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("image-pull-check-%s-%s", node.Name, nameSuffixFunc(5)),
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:                     "image-pull-check",
+					Image:                    o.ImagePullCheck,
+					ImagePullPolicy:          corev1.PullAlways,
+					TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+				},
+			},
+			NodeName:      node.Name,
+			RestartPolicy: corev1.RestartPolicyNever,
+			Tolerations: []corev1.Toleration{
+				{
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+		},
+	}
+}
+
+// waitForPullResult polls the check pod until the kubelet reports that the
+// pull succeeded or failed, or the check times out.
+func (o *DebugOptions) waitForPullResult(ctx context.Context, pod *corev1.Pod) (pullCheckResult, error) {
+	var result pullCheckResult
+	err := wait.PollUntilContextTimeout(ctx, imagePullCheckPollInterval, imagePullCheckTimeout, true, func(ctx context.Context) (bool, error) {
+		p, err := o.podClient.Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		s := getContainerStatusByName(p, pod.Spec.Containers[0].Name)
+		if s == nil {
+			return false, nil
+		}
+		// Any state past waiting for the image means the pull finished. The
+		// container will usually exit immediately since it runs no useful
+		// command; that's fine, only the pull matters.
+		if s.State.Running != nil || s.State.Terminated != nil {
+			result.detail = o.latestPullEventMessage(ctx, p, "Pulled")
+			return true, nil
+		}
+		if s.State.Waiting == nil {
+			return false, nil
+		}
+		switch s.State.Waiting.Reason {
+		case "ErrImagePull", "InvalidImageName":
+			result.detail = s.State.Waiting.Message
+		case "ImagePullBackOff":
+			// The back-off status message doesn't say why the pull failed;
+			// the kubelet's Failed event does.
+			result.detail = o.latestPullEventMessage(ctx, p, "Failed")
+			if result.detail == "" {
+				result.detail = s.State.Waiting.Message
+			}
+		default:
+			return false, nil
+		}
+		result.failedStage = classifyPullFailure(result.detail)
+		return true, nil
+	})
+	if wait.Interrupted(err) {
+		// No result within the timeout: the pull is stuck or extremely slow,
+		// which the report attributes to the transfer stage.
+		return pullCheckResult{
+			failedStage: pullStageTransfer,
+			detail:      fmt.Sprintf("no pull result after %v", imagePullCheckTimeout),
+		}, nil
+	}
+	return result, err
+}
+
+// latestPullEventMessage returns the message of the most recent event with
+// the given reason for the pod, or "" if there is none.
+func (o *DebugOptions) latestPullEventMessage(ctx context.Context, pod *corev1.Pod, reason string) string {
+	selector := fields.Set{
+		"involvedObject.name":      pod.Name,
+		"involvedObject.namespace": pod.Namespace,
+		"reason":                   reason,
+	}.AsSelector().String()
+	events, err := o.podClient.Events(pod.Namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+	if err != nil || len(events.Items) == 0 {
+		return ""
+	}
+	latest := events.Items[0]
+	for _, e := range events.Items[1:] {
+		if e.LastTimestamp.After(latest.LastTimestamp.Time) {
+			latest = e
+		}
+	}
+	return latest.Message
+}
+
+// classifyPullFailure maps a kubelet pull error message to the stage of the
+// pull it failed in. The messages come from the container runtime, so the
+// matching is necessarily on well-known substrings; anything unrecognized is
+// attributed to the transfer stage.
+func classifyPullFailure(message string) pullCheckStage {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "no such host"),
+		strings.Contains(lower, "server misbehaving"),
+		strings.Contains(lower, "name resolution"):
+		return pullStageResolution
+	case strings.Contains(lower, "x509"),
+		strings.Contains(lower, "tls"),
+		strings.Contains(lower, "certificate"):
+		return pullStageTLS
+	case strings.Contains(lower, "unauthorized"),
+		strings.Contains(lower, "authentication required"),
+		strings.Contains(lower, "pull access denied"),
+		strings.Contains(lower, "forbidden"):
+		return pullStageAuth
+	case strings.Contains(lower, "not found"),
+		strings.Contains(lower, "manifest unknown"),
+		strings.Contains(lower, "invalid image name"),
+		strings.Contains(lower, "invalid reference format"):
+		return pullStageManifest
+	default:
+		return pullStageTransfer
+	}
+}
+
+// printImagePullCheckReport prints one line per pull stage. Stages before a
+// failure passed, the failing stage carries the kubelet's error message, and
+// later stages were never reached.
+func (o *DebugOptions) printImagePullCheckReport(nodeName string, result pullCheckResult) {
+	fmt.Fprintf(o.Out, "Image pull check for %s on node %s:\n", o.ImagePullCheck, nodeName)
+	failed := false
+	for _, stage := range pullCheckStages {
+		switch {
+		case stage == result.failedStage:
+			fmt.Fprintf(o.Out, "  %s: FAILED: %s\n", stage, result.detail)
+			failed = true
+		case failed:
+			fmt.Fprintf(o.Out, "  %s: not reached\n", stage)
+		default:
+			fmt.Fprintf(o.Out, "  %s: ok\n", stage)
+		}
+	}
+	if !failed && result.detail != "" {
+		fmt.Fprintf(o.Out, "  %s\n", result.detail)
+	}
+}
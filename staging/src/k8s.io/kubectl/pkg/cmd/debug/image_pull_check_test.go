@@ -0,0 +1,169 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+func TestClassifyPullFailure(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		message  string
+		expected pullCheckStage
+	}{
+		{
+			name:     "unknown registry host",
+			message:  `failed to pull image "registry.test/app": dial tcp: lookup registry.test: no such host`,
+			expected: pullStageResolution,
+		},
+		{
+			name:     "broken DNS server",
+			message:  `lookup registry.test on 10.0.0.10:53: server misbehaving`,
+			expected: pullStageResolution,
+		},
+		{
+			name:     "self-signed certificate",
+			message:  `x509: certificate signed by unknown authority`,
+			expected: pullStageTLS,
+		},
+		{
+			name:     "plain http registry",
+			message:  `http: server gave HTTP response to HTTPS client, tls handshake failed`,
+			expected: pullStageTLS,
+		},
+		{
+			name:     "missing credentials",
+			message:  `failed to authorize: 401 Unauthorized`,
+			expected: pullStageAuth,
+		},
+		{
+			name:     "token service challenge",
+			message:  `pull access denied, repository does not exist or may require authorization`,
+			expected: pullStageAuth,
+		},
+		{
+			name:     "unknown tag",
+			message:  `manifest unknown: manifest tagged by "v999" is not found`,
+			expected: pullStageManifest,
+		},
+		{
+			name:     "connection reset mid transfer",
+			message:  `read tcp 10.0.0.5:443: connection reset by peer`,
+			expected: pullStageTransfer,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := classifyPullFailure(tc.message); got != tc.expected {
+				t.Errorf("classifyPullFailure(%q) = %q, want %q", tc.message, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateImagePullCheckPod(t *testing.T) {
+	defer func(old func(int) string) { nameSuffixFunc = old }(nameSuffixFunc)
+	var suffixCounter int
+	nameSuffixFunc = func(int) string {
+		suffixCounter++
+		return fmt.Sprint(suffixCounter)
+	}
+
+	opts := &DebugOptions{
+		ImagePullCheck: "registry.test/app:v1",
+	}
+	pod := opts.generateImagePullCheckPod(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-XXX",
+		},
+	})
+	expected := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "image-pull-check-node-XXX-1",
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:                     "image-pull-check",
+					Image:                    "registry.test/app:v1",
+					ImagePullPolicy:          corev1.PullAlways,
+					TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+				},
+			},
+			NodeName:      "node-XXX",
+			RestartPolicy: corev1.RestartPolicyNever,
+			Tolerations: []corev1.Toleration{
+				{
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, pod); diff != "" {
+		t.Errorf("unexpected pod generated (-want +got):\n%s", diff)
+	}
+}
+
+func TestPrintImagePullCheckReport(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		result        pullCheckResult
+		expectedLines []string
+	}{
+		{
+			name:   "successful pull",
+			result: pullCheckResult{detail: `Successfully pulled image "registry.test/app:v1" in 1.2s`},
+			expectedLines: []string{
+				"name resolution: ok",
+				"transfer: ok",
+				`Successfully pulled image "registry.test/app:v1" in 1.2s`,
+			},
+		},
+		{
+			name:   "auth failure",
+			result: pullCheckResult{failedStage: pullStageAuth, detail: "401 Unauthorized"},
+			expectedLines: []string{
+				"TLS handshake: ok",
+				"authentication: FAILED: 401 Unauthorized",
+				"image resolution: not reached",
+				"transfer: not reached",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			streams, _, out, _ := genericiooptions.NewTestIOStreams()
+			opts := &DebugOptions{
+				ImagePullCheck: "registry.test/app:v1",
+				IOStreams:      streams,
+			}
+			opts.printImagePullCheckReport("node-XXX", tc.result)
+			for _, line := range tc.expectedLines {
+				if !strings.Contains(out.String(), line) {
+					t.Errorf("expected report to contain %q, got:\n%s", line, out.String())
+				}
+			}
+		})
+	}
+}
@@ -87,6 +87,26 @@ type CredentialProvider struct {
 	// to pass argument to the plugin.
 	// +optional
 	Env []ExecEnvVar `json:"env,omitempty"`
+
+	// maxConcurrentExecutions is the maximum number of plugin processes the kubelet
+	// will run concurrently for this provider. Additional invocations are queued in
+	// FIFO order until a slot frees up. If unset, a default limit of 10 is applied.
+	// +optional
+	MaxConcurrentExecutions *int32 `json:"maxConcurrentExecutions,omitempty"`
+
+	// cacheDurationOverride, when set, overrides both the cache duration returned by
+	// the plugin and defaultCacheDuration for credentials from this provider. This
+	// lets operators tune caching for plugins that return unsuitable durations
+	// without changing the plugin. A value of 0 disables caching entirely.
+	// +optional
+	CacheDurationOverride *metav1.Duration `json:"cacheDurationOverride,omitempty"`
+
+	// staleCredentialsWindow is how long expired cached credentials may still be
+	// served when the plugin binary is failing, so a temporary plugin outage does
+	// not break image pulls that authenticated successfully before it. Unset or
+	// zero disables serving stale credentials.
+	// +optional
+	StaleCredentialsWindow *metav1.Duration `json:"staleCredentialsWindow,omitempty"`
 }
 
 // ExecEnvVar is used for setting environment variables when executing an exec-based
@@ -49,6 +49,21 @@ func (in *CredentialProvider) DeepCopyInto(out *CredentialProvider) {
 		*out = make([]ExecEnvVar, len(*in))
 		copy(*out, *in)
 	}
+	if in.MaxConcurrentExecutions != nil {
+		in, out := &in.MaxConcurrentExecutions, &out.MaxConcurrentExecutions
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CacheDurationOverride != nil {
+		in, out := &in.CacheDurationOverride, &out.CacheDurationOverride
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.StaleCredentialsWindow != nil {
+		in, out := &in.StaleCredentialsWindow, &out.StaleCredentialsWindow
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	return
 }
 
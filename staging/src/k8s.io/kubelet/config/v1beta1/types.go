@@ -41,6 +41,17 @@ const (
 	HairpinNone = "none"
 )
 
+// Valid values for the ImageGCPolicy field.
+const (
+	// ThresholdImageGCPolicy frees images only when disk usage crosses
+	// imageGCHighThresholdPercent, down to imageGCLowThresholdPercent.
+	ThresholdImageGCPolicy = "Threshold"
+	// LeastRecentlyUsedImageGCPolicy keeps unused images within
+	// imageGCSizeBudgetBytes, removing the least recently used images
+	// beyond the budget.
+	LeastRecentlyUsedImageGCPolicy = "LeastRecentlyUsed"
+)
+
 // ResourceChangeDetectionStrategy denotes a mode in which internal
 // managers (secret, configmap) are discovering object changes.
 type ResourceChangeDetectionStrategy string
@@ -126,6 +137,14 @@ type KubeletConfiguration struct {
 	// Default: nil
 	// +optional
 	StaticPodURLHeader map[string][]string `json:"staticPodURLHeader,omitempty"`
+	// staticPodImagePullSecretsDir is a directory containing dockerconfigjson
+	// files named <secret-name>.json that standalone kubelets use to resolve
+	// imagePullSecrets referenced by static pods. It requires the
+	// KubeletStaticPodImagePullSecrets feature gate to be enabled and is only
+	// honored when the kubelet has no API server client.
+	// Default: ""
+	// +optional
+	StaticPodImagePullSecretsDir string `json:"staticPodImagePullSecretsDir,omitempty"`
 	// address is the IP address for the Kubelet to serve on (set to 0.0.0.0
 	// for all interfaces).
 	// Default: "0.0.0.0"
@@ -318,6 +337,26 @@ type KubeletConfiguration struct {
 	// Default: 80
 	// +optional
 	ImageGCLowThresholdPercent *int32 `json:"imageGCLowThresholdPercent,omitempty"`
+	// imageGCPolicy selects how image garbage collection chooses images to
+	// remove under disk pressure. "Threshold" removes least recently used
+	// images until disk usage drops below imageGCLowThresholdPercent.
+	// "LeastRecentlyUsed" keeps unused images within
+	// imageGCSizeBudgetBytes, removing the least recently used images
+	// beyond the budget.
+	// Default: "Threshold"
+	// +optional
+	ImageGCPolicy string `json:"imageGCPolicy,omitempty"`
+	// imageGCSizeBudgetBytes is the total size in bytes that unused images
+	// may occupy when imageGCPolicy is "LeastRecentlyUsed". It must be
+	// positive when that policy is selected and is ignored otherwise.
+	// Default: 0
+	// +optional
+	ImageGCSizeBudgetBytes int64 `json:"imageGCSizeBudgetBytes,omitempty"`
+	// pinnedImages lists image references that are never garbage collected,
+	// in addition to images pinned by the container runtime.
+	// Default: nil
+	// +optional
+	PinnedImages []string `json:"pinnedImages,omitempty"`
 	// volumeStatsAggPeriod is the frequency for calculating and caching volume
 	// disk usage for all pods.
 	// Default: "1m"
@@ -499,6 +538,30 @@ type KubeletConfiguration struct {
 	// Default: nil
 	// +optional
 	MaxParallelImagePulls *int32 `json:"maxParallelImagePulls,omitempty"`
+	// MaxParallelImagePullsPerRuntimeClass overrides the pull parallelism for
+	// pods of specific runtime classes, keyed by the runtime handler name.
+	// A value of 1 serializes pulls for that runtime class; higher values
+	// bound its parallelism. Runtime classes that are not listed use the
+	// node-wide SerializeImagePulls / MaxParallelImagePulls behavior.
+	// Default: nil
+	// +optional
+	MaxParallelImagePullsPerRuntimeClass map[string]int32 `json:"maxParallelImagePullsPerRuntimeClass,omitempty"`
+	// ImagePullMaxBandwidth caps the aggregate image pull bandwidth of the
+	// node in bytes per second. When pulls transfer faster than the cap,
+	// subsequent pulls are delayed until the average falls back under it.
+	// This field cannot be a negative number.
+	// Default: 0 (no cap)
+	// +optional
+	ImagePullMaxBandwidth int64 `json:"imagePullMaxBandwidth,omitempty"`
+	// ImagePullRevalidateInterval is how often the kubelet revalidates a
+	// locally present image tag against the digest the registry currently
+	// serves for it when the pull policy is PullIfNotPresent, pulling again
+	// only when the digest changed. This keeps mutable tags reasonably fresh
+	// without the cost of PullAlways. Digest-pinned images are never
+	// revalidated. This field cannot be a negative number.
+	// Default: "0s" (revalidation disabled)
+	// +optional
+	ImagePullRevalidateInterval metav1.Duration `json:"imagePullRevalidateInterval,omitempty"`
 	// evictionHard is a map of signal names to quantities that defines hard eviction
 	// thresholds. For example: `{"memory.available": "300Mi"}`.
 	// To explicitly disable, pass a 0% or 100% threshold on an arbitrary resource.
@@ -1028,6 +1091,26 @@ type CredentialProvider struct {
 	// to pass argument to the plugin.
 	// +optional
 	Env []ExecEnvVar `json:"env,omitempty"`
+
+	// maxConcurrentExecutions is the maximum number of plugin processes the kubelet
+	// will run concurrently for this provider. Additional invocations are queued in
+	// FIFO order until a slot frees up. If unset, a default limit of 10 is applied.
+	// +optional
+	MaxConcurrentExecutions *int32 `json:"maxConcurrentExecutions,omitempty"`
+
+	// cacheDurationOverride, when set, overrides both the cache duration returned by
+	// the plugin and defaultCacheDuration for credentials from this provider. This
+	// lets operators tune caching for plugins that return unsuitable durations
+	// without changing the plugin. A value of 0 disables caching entirely.
+	// +optional
+	CacheDurationOverride *metav1.Duration `json:"cacheDurationOverride,omitempty"`
+
+	// staleCredentialsWindow is how long expired cached credentials may still be
+	// served when the plugin binary is failing, so a temporary plugin outage does
+	// not break image pulls that authenticated successfully before it. Unset or
+	// zero disables serving stale credentials.
+	// +optional
+	StaleCredentialsWindow *metav1.Duration `json:"staleCredentialsWindow,omitempty"`
 }
 
 // ExecEnvVar is used for setting environment variables when executing an exec-based
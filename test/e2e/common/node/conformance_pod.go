@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+)
+
+// ConformancePod is the multi-container counterpart of ConformanceContainer:
+// it wraps a whole pod spec with init containers, sidecar (restartable init)
+// containers, and any number of app containers, with per-container status
+// accessors. Conformance tests use it to validate ordering, restart policy
+// interactions, and image pull behavior across the pod instead of
+// single-container cases only.
+type ConformancePod struct {
+	// BaseName prefixes the generated pod name. Defaults to
+	// "conformance-pod".
+	BaseName string
+	// InitContainers run to completion, in order, before the app
+	// containers start.
+	InitContainers []v1.Container
+	// SidecarContainers are restartable init containers: they are appended
+	// to the init containers with their restart policy defaulted to
+	// Always, so they keep running alongside the app containers.
+	SidecarContainers []v1.Container
+	// Containers are the app containers.
+	Containers       []v1.Container
+	RestartPolicy    v1.RestartPolicy
+	Volumes          []v1.Volume
+	ImagePullSecrets []string
+
+	PodClient          *e2epod.PodClient
+	podName            string
+	PodSecurityContext *v1.PodSecurityContext
+}
+
+// Create creates the defined conformance pod.
+func (cp *ConformancePod) Create(ctx context.Context) {
+	baseName := cp.BaseName
+	if baseName == "" {
+		baseName = "conformance-pod"
+	}
+	cp.podName = baseName + string(uuid.NewUUID())
+	imagePullSecrets := []v1.LocalObjectReference{}
+	for _, s := range cp.ImagePullSecrets {
+		imagePullSecrets = append(imagePullSecrets, v1.LocalObjectReference{Name: s})
+	}
+	initContainers := append([]v1.Container{}, cp.InitContainers...)
+	restartAlways := v1.ContainerRestartPolicyAlways
+	for _, sidecar := range cp.SidecarContainers {
+		if sidecar.RestartPolicy == nil {
+			sidecar.RestartPolicy = &restartAlways
+		}
+		initContainers = append(initContainers, sidecar)
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: cp.podName,
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy:    cp.RestartPolicy,
+			InitContainers:   initContainers,
+			Containers:       cp.Containers,
+			SecurityContext:  cp.PodSecurityContext,
+			Volumes:          cp.Volumes,
+			ImagePullSecrets: imagePullSecrets,
+		},
+	}
+	cp.PodClient.Create(ctx, pod)
+}
+
+// Delete deletes the defined conformance pod.
+func (cp *ConformancePod) Delete(ctx context.Context) error {
+	return cp.PodClient.Delete(ctx, cp.podName, *metav1.NewDeleteOptions(0))
+}
+
+// GetPod returns the current state of the pod, for assertions the dedicated
+// accessors do not cover, such as ordering between container start times.
+func (cp *ConformancePod) GetPod(ctx context.Context) (*v1.Pod, error) {
+	return cp.PodClient.Get(ctx, cp.podName, metav1.GetOptions{})
+}
+
+// IsReady returns whether this pod is ready and error if any.
+func (cp *ConformancePod) IsReady(ctx context.Context) (bool, error) {
+	pod, err := cp.GetPod(ctx)
+	if err != nil {
+		return false, err
+	}
+	return podutil.IsPodReady(pod), nil
+}
+
+// GetPhase returns the phase of the pod lifecycle and error if any.
+func (cp *ConformancePod) GetPhase(ctx context.Context) (v1.PodPhase, error) {
+	pod, err := cp.GetPod(ctx)
+	if err != nil {
+		// it doesn't matter what phase to return as error would not be nil
+		return v1.PodSucceeded, err
+	}
+	return pod.Status.Phase, nil
+}
+
+// GetStatusFor returns the current status of the named container, whether it
+// is an init container, a sidecar, or an app container.
+func (cp *ConformancePod) GetStatusFor(ctx context.Context, name string) (v1.ContainerStatus, error) {
+	pod, err := cp.GetPod(ctx)
+	if err != nil {
+		return v1.ContainerStatus{}, err
+	}
+	for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, status := range statuses {
+			if status.Name == name {
+				return status, nil
+			}
+		}
+	}
+	return v1.ContainerStatus{}, fmt.Errorf("no status for container %q in pod %q", name, cp.podName)
+}
+
+// Present returns whether this pod is present and error if any.
+func (cp *ConformancePod) Present(ctx context.Context) (bool, error) {
+	_, err := cp.GetPod(ctx)
+	if err == nil {
+		return true, nil
+	}
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
@@ -19,6 +19,7 @@ package node
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -26,6 +27,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+	"k8s.io/kubernetes/pkg/kubelet/events"
 	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
 )
 
@@ -44,6 +46,16 @@ type ConformanceContainer struct {
 	Volumes          []v1.Volume
 	ImagePullSecrets []string
 
+	// Lifecycle, when set, attaches postStart/preStop hooks to the
+	// container, so table-driven cases can add hooks without rebuilding the
+	// whole v1.Container.
+	Lifecycle *v1.Lifecycle
+	// StartupProbe, LivenessProbe and ReadinessProbe, when set, attach the
+	// corresponding probe to the container.
+	StartupProbe   *v1.Probe
+	LivenessProbe  *v1.Probe
+	ReadinessProbe *v1.Probe
+
 	PodClient          *e2epod.PodClient
 	podName            string
 	PodSecurityContext *v1.PodSecurityContext
@@ -56,6 +68,18 @@ func (cc *ConformanceContainer) Create(ctx context.Context) {
 	for _, s := range cc.ImagePullSecrets {
 		imagePullSecrets = append(imagePullSecrets, v1.LocalObjectReference{Name: s})
 	}
+	if cc.Lifecycle != nil {
+		cc.Container.Lifecycle = cc.Lifecycle
+	}
+	if cc.StartupProbe != nil {
+		cc.Container.StartupProbe = cc.StartupProbe
+	}
+	if cc.LivenessProbe != nil {
+		cc.Container.LivenessProbe = cc.LivenessProbe
+	}
+	if cc.ReadinessProbe != nil {
+		cc.Container.ReadinessProbe = cc.ReadinessProbe
+	}
 	pod := &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: cc.podName,
@@ -110,6 +134,73 @@ func (cc *ConformanceContainer) GetStatus(ctx context.Context) (v1.ContainerStat
 	return statuses[0], nil
 }
 
+// GetEvents returns the events emitted for this container's pod so far and
+// error if any, so tests can assert on the kubelet's Pulling/Pulled/Failed
+// events instead of inferring behavior from pod phase alone.
+func (cc *ConformanceContainer) GetEvents(ctx context.Context) ([]v1.Event, error) {
+	pod, err := cc.PodClient.Get(ctx, cc.podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return cc.PodClient.Events(ctx, pod)
+}
+
+// GetLastTerminationState returns the state of this container's last
+// termination and error if any, so restart tests can assert on exit codes
+// and termination reasons across back-off restarts.
+func (cc *ConformanceContainer) GetLastTerminationState(ctx context.Context) (v1.ContainerState, error) {
+	status, err := cc.GetStatus(ctx)
+	if err != nil {
+		return v1.ContainerState{}, err
+	}
+	return status.LastTerminationState, nil
+}
+
+// GetRestartCount returns the number of times this container was restarted
+// and error if any.
+func (cc *ConformanceContainer) GetRestartCount(ctx context.Context) (int32, error) {
+	status, err := cc.GetStatus(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return status.RestartCount, nil
+}
+
+// VerifyLifecycleEventOrder checks that the given event reasons were emitted
+// for this container's pod in the given order, so hook tests can assert for
+// example that FailedPostStartHook was followed by Killing.
+func (cc *ConformanceContainer) VerifyLifecycleEventOrder(ctx context.Context, reasons ...string) error {
+	events, err := cc.GetEvents(ctx)
+	if err != nil {
+		return err
+	}
+	return e2epod.VerifyEventOrder(events, reasons...)
+}
+
+// RestartedByProbe returns whether this container was restarted because the
+// probe of the given type ("Startup" or "Liveness") failed, based on the
+// restart count and the kubelet's Unhealthy events, and error if any.
+func (cc *ConformanceContainer) RestartedByProbe(ctx context.Context, probeType string) (bool, error) {
+	count, err := cc.GetRestartCount(ctx)
+	if err != nil {
+		return false, err
+	}
+	if count == 0 {
+		return false, nil
+	}
+	podEvents, err := cc.GetEvents(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, event := range podEvents {
+		if event.Reason == events.ContainerUnhealthy &&
+			strings.HasPrefix(event.Message, probeType+" probe failed") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // Present returns whether this pod is present and error if any
 func (cc *ConformanceContainer) Present(ctx context.Context) (bool, error) {
 	_, err := cc.PodClient.Get(ctx, cc.podName, metav1.GetOptions{})
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2enode "k8s.io/kubernetes/test/e2e/framework/node"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	e2eregistry "k8s.io/kubernetes/test/e2e/framework/registry"
+	e2eskipper "k8s.io/kubernetes/test/e2e/framework/skipper"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = SIGDescribe("Multi-arch image pull", func() {
+	f := framework.NewDefaultFramework("image-platform")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	ginkgo.It("should resolve the node's platform from a manifest list", f.WithNodeConformance(), func(ctx context.Context) {
+		node, err := e2enode.GetRandomReadySchedulableNode(ctx, f.ClientSet)
+		framework.ExpectNoError(err, "getting a schedulable node")
+		arch := node.Labels[v1.LabelArchStable]
+		if arch != "amd64" && arch != "arm64" {
+			e2eskipper.Skipf("Node architecture %q is not covered by the published manifest list", arch)
+		}
+
+		ginkgo.By("deploying a registry serving pause as an amd64/arm64 manifest list")
+		registry, err := e2eregistry.SetupRegistry(ctx, f.ClientSet, f.Namespace.Name, e2eregistry.Options{
+			PreloadSpecs: []e2eregistry.PreloadSpec{
+				{
+					SourceImage: imageutils.GetPauseImageName(),
+					Platforms:   []string{"linux/amd64", "linux/arm64"},
+					TargetRef:   "pause:multiarch",
+				},
+			},
+		})
+		framework.ExpectNoError(err, "setting up registry")
+		ginkgo.DeferCleanup(registry.Teardown)
+
+		ginkgo.By("pulling the manifest list on the node under test")
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "image-platform-puller",
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{
+					{
+						Name:            "pause",
+						Image:           registry.ImageRef("pause:multiarch"),
+						ImagePullPolicy: v1.PullAlways,
+					},
+				},
+			},
+		}
+		nodeSelection := e2epod.NodeSelection{Name: node.Name}
+		// A running container proves the kubelet and runtime picked the
+		// platform matching the node out of the list instead of failing on
+		// or running a foreign-architecture child.
+		e2epod.NewPodClient(f).CreateSyncWithNodeSelection(ctx, pod, nodeSelection)
+
+		ginkgo.By("verifying the runtime descended from the list to a child manifest")
+		entries, err := registry.AccessLog(ctx)
+		framework.ExpectNoError(err, "reading registry access log")
+		sawChildManifest := false
+		for _, entry := range e2eregistry.EntriesForPath(entries, "/pause/manifests/") {
+			if strings.Contains(entry.Path, "/manifests/sha256:") {
+				sawChildManifest = true
+				break
+			}
+		}
+		gomega.Expect(sawChildManifest).To(gomega.BeTrueBecause(
+			"expected the runtime to fetch a platform child manifest by digest after the manifest list"))
+	})
+})
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	e2eregistry "k8s.io/kubernetes/test/e2e/framework/registry"
+	e2eskipper "k8s.io/kubernetes/test/e2e/framework/skipper"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+)
+
+var _ = SIGDescribe("Image pull over IPv6", func() {
+	f := framework.NewDefaultFramework("image-pull-ipv6")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	ginkgo.Context("when a registry is reachable under both address families", func() {
+		ginkgo.It("should pull an image addressed by the registry's IPv6 endpoint", func(ctx context.Context) {
+			ginkgo.By("deploying a dual-stack registry")
+			registry, err := e2eregistry.SetupRegistry(ctx, f.ClientSet, f.Namespace.Name, e2eregistry.Options{
+				DualStack: true,
+			})
+			framework.ExpectNoError(err, "setting up registry")
+			ginkgo.DeferCleanup(registry.Teardown)
+
+			// Addressing the registry by its IPv6 ClusterIP forces the pull
+			// over the v6 path, which DNS-based references don't guarantee.
+			imageRef, err := registry.ImageRefForFamily(v1.IPv6Protocol, "pause:testing")
+			if err != nil {
+				e2eskipper.Skipf("cluster did not assign an IPv6 endpoint to the registry: %v", err)
+			}
+
+			ginkgo.By("starting a pod whose image is pulled over the IPv6 endpoint")
+			e2epod.NewPodClient(f).CreateSync(ctx, &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "image-pull-ipv6",
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name:            "pause",
+							Image:           imageRef,
+							ImagePullPolicy: v1.PullAlways,
+						},
+					},
+				},
+			})
+		})
+	})
+})
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/kubernetes/pkg/kubelet/events"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2emetrics "k8s.io/kubernetes/test/e2e/framework/metrics"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = SIGDescribe("Image pull events", func() {
+	f := framework.NewDefaultFramework("image-pull-events")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	ginkgo.Context("when a container with an unchanged IfNotPresent image restarts", func() {
+		ginkgo.It("should not re-emit Pulling or Pulled events on restart", func(ctx context.Context) {
+			podClient := e2epod.NewPodClient(f)
+			pod := podClient.Create(ctx, &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "image-pull-events-crasher",
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyAlways,
+					Containers: []v1.Container{
+						{
+							Name:            "crasher",
+							Image:           imageutils.GetE2EImage(imageutils.BusyBox),
+							ImagePullPolicy: v1.PullIfNotPresent,
+							Command:         []string{"sh", "-c", "exit 1"},
+						},
+					},
+				},
+			})
+
+			restartCount := func() (int32, error) {
+				pod, err := podClient.Get(ctx, pod.Name, metav1.GetOptions{})
+				if err != nil {
+					return 0, err
+				}
+				status := e2epod.FindContainerStatusInPod(pod, "crasher")
+				if status == nil {
+					return 0, nil
+				}
+				return status.RestartCount, nil
+			}
+
+			ginkgo.By("waiting for the first container start")
+			gomega.Eventually(ctx, restartCount, framework.PodStartTimeout, framework.Poll).Should(gomega.BeNumerically(">=", 1))
+			pod, err := podClient.Get(ctx, pod.Name, metav1.GetOptions{})
+			framework.ExpectNoError(err, "getting the scheduled pod")
+
+			// The pull duration histogram counts completed pulls on the
+			// node, so an unchanged count across the restarts proves no
+			// redundant pull happened beyond what the events show.
+			completedPulls := func() float64 {
+				ms, err := e2emetrics.GetKubeletMetrics(ctx, f.ClientSet, pod.Spec.NodeName)
+				framework.ExpectNoError(err, "grabbing kubelet metrics from node %s", pod.Spec.NodeName)
+				var total float64
+				for _, sample := range ms["image_pull_duration_seconds_count"] {
+					total += float64(sample.Value)
+				}
+				return total
+			}
+			pullsAfterFirstStart := completedPulls()
+
+			ginkgo.By("waiting for the container to restart at least 5 times")
+			gomega.Eventually(ctx, restartCount, framework.PodStartTimeout, framework.Poll).Should(gomega.BeNumerically(">=", 5))
+
+			podSelector := fields.Set{
+				"involvedObject.kind":      "Pod",
+				"involvedObject.name":      pod.Name,
+				"involvedObject.namespace": f.Namespace.Name,
+			}
+
+			// The kubelet aggregates repeated identical events by bumping their
+			// count, so summing counts catches re-emission even when the event
+			// objects are deduplicated.
+			countEvents := func(reason string) int32 {
+				selector := podSelector
+				selector["reason"] = reason
+				eventList, err := f.ClientSet.CoreV1().Events(f.Namespace.Name).List(ctx, metav1.ListOptions{
+					FieldSelector: selector.AsSelector().String(),
+				})
+				framework.ExpectNoError(err, "listing %s events for pod %s", reason, pod.Name)
+				var total int32
+				for _, event := range eventList.Items {
+					count := event.Count
+					if count == 0 {
+						count = 1
+					}
+					total += count
+				}
+				return total
+			}
+
+			ginkgo.By("verifying the image was reported pulled or already present exactly once")
+			gomega.Expect(countEvents(events.PulledImage)).To(gomega.Equal(int32(1)),
+				"expected exactly one %s event despite restarts", events.PulledImage)
+
+			ginkgo.By("verifying no pull was started after the first container start")
+			gomega.Expect(countEvents(events.PullingImage)).To(gomega.BeNumerically("<=", 1),
+				"expected no %s events beyond the initial pull", events.PullingImage)
+
+			ginkgo.By("verifying the image pull metrics did not increment across the restarts")
+			gomega.Expect(completedPulls()).To(gomega.Equal(pullsAfterFirstStart),
+				"expected no completed image pulls on node %s during the restarts", pod.Spec.NodeName)
+		})
+	})
+})
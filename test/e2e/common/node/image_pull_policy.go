@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	e2eregistry "k8s.io/kubernetes/test/e2e/framework/registry"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = SIGDescribe("Image pull policy", func() {
+	f := framework.NewDefaultFramework("image-pull-policy")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	ginkgo.Context("when containers in a pod share an image with conflicting pull policies", func() {
+		ginkgo.It("should re-pull a retagged image for the container with PullAlways", func(ctx context.Context) {
+			ginkgo.By("deploying a registry with the shared tag and a replacement image")
+			registry, err := e2eregistry.SetupRegistry(ctx, f.ClientSet, f.Namespace.Name, e2eregistry.Options{
+				PreloadSpecs: append(e2eregistry.DefaultPreloadSpecs(), e2eregistry.PreloadSpec{
+					Generate:  &e2eregistry.GenerateOptions{Layers: 1, LayerSizeBytes: 1024},
+					TargetRef: "replacement:v2",
+				}),
+			})
+			framework.ExpectNoError(err, "setting up registry")
+			ginkgo.DeferCleanup(registry.Teardown)
+			sharedImage := registry.ImageRef("pause:testing")
+
+			ginkgo.By("caching the original image on the node")
+			podClient := e2epod.NewPodClient(f)
+			firstPod := podClient.CreateSync(ctx, &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "image-pull-policy-original",
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name:            "pause",
+							Image:           sharedImage,
+							ImagePullPolicy: v1.PullIfNotPresent,
+						},
+					},
+				},
+			})
+			originalStatus := e2epod.FindContainerStatusInPod(firstPod, "pause")
+			gomega.Expect(originalStatus).NotTo(gomega.BeNil())
+			originalImageID := originalStatus.ImageID
+
+			ginkgo.By("retagging the shared tag to the replacement image in the registry")
+			retagPod := podClient.Create(ctx, &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "image-pull-policy-retag",
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:  "retag",
+							Image: imageutils.GetE2EImage(imageutils.RegistryPreload),
+							// The registry serves plain HTTP on its pod IP;
+							// only the node's container runtime is configured
+							// to trust it, so crane needs --insecure.
+							Command: []string{"/bin/sh", "-c", fmt.Sprintf(
+								"crane copy --insecure %s %s", registry.ImageRef("replacement:v2"), sharedImage)},
+						},
+					},
+				},
+			})
+			framework.ExpectNoError(e2epod.WaitForPodSuccessInNamespace(
+				ctx, f.ClientSet, retagPod.Name, f.Namespace.Name), "retagging image")
+
+			ginkgo.By("starting a pod with IfNotPresent and Always containers sharing the image")
+			secondPod := podClient.CreateSync(ctx, &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "image-pull-policy-mixed",
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name:            "if-not-present",
+							Image:           sharedImage,
+							ImagePullPolicy: v1.PullIfNotPresent,
+						},
+						{
+							Name:            "always",
+							Image:           sharedImage,
+							ImagePullPolicy: v1.PullAlways,
+						},
+					},
+				},
+			})
+
+			ginkgo.By("verifying the Always container runs the new digest regardless of sync order")
+			alwaysStatus := e2epod.FindContainerStatusInPod(secondPod, "always")
+			gomega.Expect(alwaysStatus).NotTo(gomega.BeNil())
+			gomega.Expect(alwaysStatus.ImageID).NotTo(gomega.Equal(originalImageID),
+				"expected the Always container to pull the retagged image instead of reusing the cached one")
+		})
+	})
+})
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/kubernetes/pkg/kubelet/events"
+	"k8s.io/kubernetes/pkg/kubelet/images"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	e2eregistry "k8s.io/kubernetes/test/e2e/framework/registry"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = SIGDescribe("Image pull rate limiting", func() {
+	f := framework.NewDefaultFramework("image-pull-rate-limit")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	ginkgo.Context("when the registry answers pulls with 429", func() {
+		ginkgo.It("should surface RegistryRateLimited and back off", func(ctx context.Context) {
+			ginkgo.By("deploying a registry that rate limits after the first request")
+			reg, err := e2eregistry.SetupRegistry(ctx, f.ClientSet, f.Namespace.Name, e2eregistry.Options{
+				Faults: &e2eregistry.FaultOptions{
+					RateLimitAfter:    1,
+					RetryAfterSeconds: 60,
+				},
+			})
+			framework.ExpectNoError(err, "setting up rate limited registry")
+			ginkgo.DeferCleanup(reg.Teardown)
+
+			ginkgo.By("creating a pod that pulls through the rate limited registry")
+			podClient := e2epod.NewPodClient(f)
+			pod := podClient.Create(ctx, &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "image-pull-rate-limited",
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:            "rate-limited",
+							Image:           reg.ImageRef("pause:testing"),
+							ImagePullPolicy: v1.PullAlways,
+						},
+					},
+				},
+			})
+
+			ginkgo.By("waiting for the kubelet to report the registry rate limit")
+			rateLimitedEvents := fields.Set{
+				"involvedObject.kind":      "Pod",
+				"involvedObject.name":      pod.Name,
+				"involvedObject.namespace": f.Namespace.Name,
+				"reason":                   events.RegistryRateLimited,
+			}.AsSelector().String()
+			gomega.Eventually(ctx, func() (int, error) {
+				eventList, err := f.ClientSet.CoreV1().Events(f.Namespace.Name).List(ctx, metav1.ListOptions{
+					FieldSelector: rateLimitedEvents,
+				})
+				if err != nil {
+					return 0, err
+				}
+				return len(eventList.Items), nil
+			}, framework.PodStartTimeout, framework.Poll).Should(gomega.BeNumerically(">=", 1),
+				"expected at least one %s event", events.RegistryRateLimited)
+
+			ginkgo.By("verifying the container is waiting on the rate limit")
+			gomega.Eventually(ctx, func() error {
+				pod, err := podClient.Get(ctx, pod.Name, metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				status := e2epod.FindContainerStatusInPod(pod, "rate-limited")
+				if status == nil || status.State.Waiting == nil {
+					return fmt.Errorf("container is not waiting")
+				}
+				switch reason := status.State.Waiting.Reason; reason {
+				case images.ErrRegistryRateLimited.Error(), images.ErrImagePullBackOff.Error():
+					return nil
+				default:
+					return fmt.Errorf("unexpected waiting reason: %q", reason)
+				}
+			}, framework.PodStartTimeout, framework.Poll).Should(gomega.Succeed())
+		})
+	})
+})
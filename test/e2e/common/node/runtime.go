@@ -343,9 +343,9 @@ while true; do sleep 1; done
 					return nil
 				}
 
-				// The image registry is not stable, which sometimes causes the test to fail. Add retry mechanism to make this less flaky.
-				const flakeRetry = 3
-				for i := 1; i <= flakeRetry; i++ {
+				// The image registry is not stable, which sometimes causes the test to fail.
+				// Retry any failure through the framework's flake policy to make this less flaky.
+				err := framework.RetryOnFlake(ctx, 3, nil, func(ctx context.Context) error {
 					var err error
 					ginkgo.By("create the container")
 					container.Create(ctx)
@@ -357,15 +357,9 @@ while true; do sleep 1; done
 					}
 					ginkgo.By("delete the container")
 					_ = container.Delete(ctx)
-					if err == nil {
-						break
-					}
-					if i < flakeRetry {
-						framework.Logf("No.%d attempt failed: %v, retrying...", i, err)
-					} else {
-						framework.Failf("All %d attempts failed: %v", flakeRetry, err)
-					}
-				}
+					return err
+				})
+				framework.ExpectNoError(err, "checking container status")
 			}
 
 			f.It("should not be able to pull image from invalid registry", f.WithNodeConformance(), func(ctx context.Context) {
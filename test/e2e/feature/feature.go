@@ -85,6 +85,10 @@ var (
 	// TODO: document the feature (owning SIG, when to use this feature for a test)
 	ComprehensiveNamespaceDraining = framework.WithFeature(framework.ValidFeatures.Add("ComprehensiveNamespaceDraining"))
 
+	// CriProxy marks tests which depend on the node e2e CRI proxy being
+	// enabled (--cri-proxy-enabled) to inject failures into CRI calls.
+	CriProxy = framework.WithFeature(framework.ValidFeatures.Add("CriProxy"))
+
 	// TODO: document the feature (owning SIG, when to use this feature for a test)
 	CPUManager = framework.WithFeature(framework.ValidFeatures.Add("CPUManager"))
 
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package artifacts gathers per-test debugging bundles for failed specs.
+//
+// Suites register named collectors (kubelet logs, CRI state, fixture pod
+// logs, ...) and hook CollectIfFailed into a ginkgo.ReportAfterEach node.
+// When a spec fails and a report directory is configured, every registered
+// collector writes into a directory named after the spec, replacing ad-hoc
+// Logf dumps scattered over the tests.
+package artifacts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+
+	"k8s.io/kubernetes/test/e2e/framework"
+)
+
+// Collector writes debugging artifacts for a failed spec into dir. since is
+// the start time of the spec, so log collection can be scoped to the test
+// window.
+type Collector func(ctx context.Context, dir string, since time.Time) error
+
+var (
+	mu         sync.Mutex
+	collectors = map[string]Collector{}
+)
+
+// Register installs a named collector for every subsequently failing spec and
+// returns a function that removes it again. Suite-wide collectors register
+// from an init function and never unregister; fixtures with a bounded
+// lifetime (such as a registry pod) register on setup and unregister on
+// teardown.
+func Register(name string, collector Collector) func() {
+	mu.Lock()
+	defer mu.Unlock()
+	collectors[name] = collector
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(collectors, name)
+	}
+}
+
+// unsafeNameChars matches the characters of a spec or file name that are
+// replaced before use as a path element.
+var unsafeNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// CollectIfFailed runs every registered collector for a failed spec, writing
+// into a per-test directory under the report directory. It is meant to be
+// called from a ginkgo.ReportAfterEach node; collector failures are logged
+// and never fail the test.
+func CollectIfFailed(ctx context.Context, report ginkgo.SpecReport) {
+	if !report.Failed() || framework.TestContext.ReportDir == "" {
+		return
+	}
+	mu.Lock()
+	registered := make(map[string]Collector, len(collectors))
+	for name, collector := range collectors {
+		registered[name] = collector
+	}
+	mu.Unlock()
+	if len(registered) == 0 {
+		return
+	}
+
+	specName := unsafeNameChars.ReplaceAllString(report.FullText(), "_")
+	if len(specName) > 200 {
+		specName = specName[:200]
+	}
+	dir := filepath.Join(framework.TestContext.ReportDir, "artifacts", specName)
+	for name, collector := range registered {
+		subdir := filepath.Join(dir, name)
+		if err := os.MkdirAll(subdir, 0755); err != nil {
+			framework.Logf("Failed to create artifact directory %s: %v", subdir, err)
+			continue
+		}
+		if err := collector(ctx, subdir, report.StartTime); err != nil {
+			framework.Logf("Failed to collect %q artifacts: %v", name, err)
+		}
+	}
+	framework.Logf("Collected failure artifacts in %s", dir)
+}
+
+// WriteFile writes a single artifact file into the collector's directory,
+// sanitizing the file name.
+func WriteFile(dir, name string, data []byte) error {
+	return os.WriteFile(filepath.Join(dir, unsafeNameChars.ReplaceAllString(name, "_")), data, 0644)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifacts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/kubernetes/test/e2e/framework"
+)
+
+func failedReport() types.SpecReport {
+	return types.SpecReport{
+		ContainerHierarchyTexts: []string{"[sig-node] Image pulls"},
+		LeafNodeText:            "should pull: the image",
+		State:                   types.SpecStateFailed,
+	}
+}
+
+func TestCollectIfFailed(t *testing.T) {
+	oldReportDir := framework.TestContext.ReportDir
+	framework.TestContext.ReportDir = t.TempDir()
+	defer func() { framework.TestContext.ReportDir = oldReportDir }()
+
+	var collectedDir string
+	unregister := Register("test-collector", func(ctx context.Context, dir string, since time.Time) error {
+		collectedDir = dir
+		return WriteFile(dir, "state.txt", []byte("collected"))
+	})
+	defer unregister()
+
+	CollectIfFailed(context.Background(), failedReport())
+
+	require.NotEmpty(t, collectedDir, "collector should have run for a failed spec")
+	assert.Contains(t, collectedDir, filepath.Join("artifacts", "_sig-node_Image_pulls_should_pull_the_image"))
+	data, err := os.ReadFile(filepath.Join(collectedDir, "state.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "collected", string(data))
+}
+
+func TestCollectIfFailedSkipsPassedSpecs(t *testing.T) {
+	oldReportDir := framework.TestContext.ReportDir
+	framework.TestContext.ReportDir = t.TempDir()
+	defer func() { framework.TestContext.ReportDir = oldReportDir }()
+
+	unregister := Register("test-collector", func(ctx context.Context, dir string, since time.Time) error {
+		t.Error("collector must not run for a passed spec")
+		return nil
+	})
+	defer unregister()
+
+	report := failedReport()
+	report.State = types.SpecStatePassed
+	CollectIfFailed(context.Background(), report)
+}
+
+func TestUnregister(t *testing.T) {
+	oldReportDir := framework.TestContext.ReportDir
+	framework.TestContext.ReportDir = t.TempDir()
+	defer func() { framework.TestContext.ReportDir = oldReportDir }()
+
+	unregister := Register("test-collector", func(ctx context.Context, dir string, since time.Time) error {
+		t.Error("collector must not run after unregistering")
+		return nil
+	})
+	unregister()
+
+	CollectIfFailed(context.Background(), failedReport())
+}
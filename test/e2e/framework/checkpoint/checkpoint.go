@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package checkpoint contains helpers for exercising the kubelet's
+// container checkpoint API end to end: invoking the /checkpoint endpoint,
+// converting the resulting archive into an OCI image, and pushing that
+// image into a registry so the container can be restored from a pod spec.
+// It lives alongside the registry helpers because restore scenarios
+// combine the two.
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clientset "k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/kubernetes/test/e2e/framework"
+)
+
+// proxyTimeout bounds the node proxy call: the proxy tends to hang in some
+// cases when the node is not ready (#22165).
+const proxyTimeout = 2 * time.Minute
+
+// Checkpoint checkpoints the named container through the kubelet's
+// /checkpoint endpoint, reached via the API server's node proxy. It returns
+// the paths of the checkpoint archives the kubelet wrote on the node.
+// Errors returned by the kubelet are surfaced unmodified so callers can
+// classify them with SkipReason.
+func Checkpoint(ctx context.Context, c clientset.Interface, namespace, pod, container, node string) ([]string, error) {
+	var result restclient.Result
+	finished := make(chan struct{}, 1)
+	go func() {
+		result = c.CoreV1().RESTClient().Post().
+			Resource("nodes").
+			SubResource("proxy").
+			Name(fmt.Sprintf("%v:%v", node, framework.KubeletPort)).
+			Suffix(fmt.Sprintf("checkpoint/%s/%s/%s", namespace, pod, container)).
+			Do(ctx)
+		finished <- struct{}{}
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(proxyTimeout):
+		return nil, fmt.Errorf("timed out checkpointing container %q in pod %s/%s", container, namespace, pod)
+	}
+	if err := result.Error(); err != nil {
+		return nil, err
+	}
+	raw, err := result.Raw()
+	if err != nil {
+		return nil, err
+	}
+	response := struct {
+		Items []string `json:"items"`
+	}{}
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, fmt.Errorf("parsing checkpoint response %q: %w", string(raw), err)
+	}
+	return response.Items, nil
+}
+
+// unsupportedMarkers maps message fragments runtimes return for an
+// unsupported CheckpointContainer CRI call to the skip reason tests should
+// report.
+var unsupportedMarkers = map[string]string{
+	"(rpc error: code = Unimplemented desc = unknown method CheckpointContainer":                                  "Container engine does not implement 'CheckpointContainer'",
+	"(rpc error: code = Unimplemented desc = method CheckpointContainer not implemented)":                         "Container engine does not implement 'CheckpointContainer'",
+	"(rpc error: code = Unknown desc = checkpoint/restore support not available)":                                 "Container engine does not implement 'CheckpointContainer'",
+	"(rpc error: code = Unknown desc = CRIU binary not found or too old (<31600). Failed to checkpoint container": "Container engine reports missing or too old CRIU binary",
+}
+
+// SkipReason classifies a Checkpoint error as checkpointing being
+// unavailable in the environment rather than broken: the ContainerCheckpoint
+// feature gate being disabled answers 404, and runtimes without
+// checkpoint/restore support answer 500 with well-known messages. It
+// returns the reason tests should skip with.
+func SkipReason(err error) (string, bool) {
+	statusError, ok := err.(*apierrors.StatusError)
+	if !ok {
+		return "", false
+	}
+	switch int(statusError.ErrStatus.Code) {
+	case http.StatusNotFound:
+		return "Feature 'ContainerCheckpoint' is not enabled and not available", true
+	case http.StatusInternalServerError:
+		for marker, reason := range unsupportedMarkers {
+			if strings.Contains(statusError.ErrStatus.Message, marker) {
+				return reason, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpoint
+
+import (
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSkipReason(t *testing.T) {
+	testCases := []struct {
+		name        string
+		err         error
+		unsupported bool
+	}{
+		{
+			name:        "feature gate disabled",
+			err:         &apierrors.StatusError{ErrStatus: metav1.Status{Code: 404}},
+			unsupported: true,
+		},
+		{
+			name: "runtime without the CRI method",
+			err: &apierrors.StatusError{ErrStatus: metav1.Status{
+				Code:    500,
+				Message: `checkpointing of "default/pod/container" failed (rpc error: code = Unimplemented desc = unknown method CheckpointContainer)`,
+			}},
+			unsupported: true,
+		},
+		{
+			name: "runtime with checkpointing disabled",
+			err: &apierrors.StatusError{ErrStatus: metav1.Status{
+				Code:    500,
+				Message: `checkpointing of "default/pod/container" failed (rpc error: code = Unknown desc = checkpoint/restore support not available)`,
+			}},
+			unsupported: true,
+		},
+		{
+			name: "missing CRIU binary",
+			err: &apierrors.StatusError{ErrStatus: metav1.Status{
+				Code:    500,
+				Message: `checkpointing of "default/pod/container" failed (rpc error: code = Unknown desc = CRIU binary not found or too old (<31600). Failed to checkpoint container)`,
+			}},
+			unsupported: true,
+		},
+		{
+			name: "genuine server error",
+			err: &apierrors.StatusError{ErrStatus: metav1.Status{
+				Code:    500,
+				Message: "disk full",
+			}},
+		},
+		{
+			name: "not a status error",
+			err:  errors.New("connection refused"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reason, unsupported := SkipReason(tc.err)
+			if unsupported != tc.unsupported {
+				t.Fatalf("expected unsupported=%t, got %t (reason %q)", tc.unsupported, unsupported, reason)
+			}
+			if unsupported && reason == "" {
+				t.Error("expected a non-empty skip reason")
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"time"
+)
+
+const (
+	manifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	configMediaType   = "application/vnd.oci.image.config.v1+json"
+	layerMediaType    = "application/vnd.oci.image.layer.v1.tar"
+
+	// checkpointNameAnnotation names the checkpointed container in the
+	// image manifest, following the convention runtimes with restore
+	// support use to recognize checkpoint images.
+	checkpointNameAnnotation = "io.kubernetes.cri-o.annotations.checkpoint.name"
+
+	// pushTimeout bounds each registry round trip of a push.
+	pushTimeout = 2 * time.Minute
+)
+
+// Image is a single-layer OCI image wrapping a checkpoint archive, ready to
+// be pushed to a registry so a pod spec can restore the container from it.
+type Image struct {
+	layer    []byte
+	config   []byte
+	manifest []byte
+}
+
+// ociDescriptor is the subset of the OCI content descriptor the helpers
+// need.
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// ImageFromArchive wraps a checkpoint archive in an OCI image whose
+// manifest declares the name of the checkpointed container, which is how
+// runtimes with restore support recognize a pulled image as a checkpoint.
+func ImageFromArchive(archive []byte, containerName string) (*Image, error) {
+	if len(archive) == 0 {
+		return nil, fmt.Errorf("checkpoint archive is empty")
+	}
+	if containerName == "" {
+		return nil, fmt.Errorf("container name must not be empty")
+	}
+	config, err := json.Marshal(map[string]interface{}{
+		"architecture": runtime.GOARCH,
+		"os":           "linux",
+		"config":       map[string]interface{}{},
+		"rootfs": map[string]interface{}{
+			"type": "layers",
+			// The layer is stored uncompressed, so its diff ID equals its
+			// blob digest.
+			"diff_ids": []string{blobDigest(archive)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     manifestMediaType,
+		"config": ociDescriptor{
+			MediaType: configMediaType,
+			Digest:    blobDigest(config),
+			Size:      int64(len(config)),
+		},
+		"layers": []ociDescriptor{
+			{
+				MediaType: layerMediaType,
+				Digest:    blobDigest(archive),
+				Size:      int64(len(archive)),
+			},
+		},
+		"annotations": map[string]string{
+			checkpointNameAnnotation: containerName,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Image{
+		layer:    archive,
+		config:   config,
+		manifest: manifest,
+	}, nil
+}
+
+// Push uploads the image to the given registry host under repo:tag over the
+// registry V2 API and returns the resulting image reference. The registry
+// is addressed over plain HTTP, matching how the e2e registry fixture
+// serves pushes.
+func (i *Image) Push(ctx context.Context, host, repo, tag string) (string, error) {
+	client := &http.Client{Timeout: pushTimeout}
+	for _, blob := range [][]byte{i.layer, i.config} {
+		if err := pushBlob(ctx, client, host, repo, blob); err != nil {
+			return "", err
+		}
+	}
+	manifestURL := fmt.Sprintf("http://%s/v2/%s/manifests/%s", host, repo, tag)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, manifestURL, bytes.NewReader(i.manifest))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", manifestMediaType)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("unexpected status %q pushing the manifest: %s", resp.Status, string(body))
+	}
+	return fmt.Sprintf("%s/%s:%s", host, repo, tag), nil
+}
+
+// pushBlob uploads one blob using the two-step (initiate, then upload)
+// registry V2 flow, skipping blobs the registry already has.
+func pushBlob(ctx context.Context, client *http.Client, host, repo string, blob []byte) error {
+	digest := blobDigest(blob)
+	headURL := fmt.Sprintf("http://%s/v2/%s/blobs/%s", host, repo, digest)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, headURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	initiateURL := fmt.Sprintf("http://%s/v2/%s/blobs/uploads/", host, repo)
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, initiateURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err = client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("unexpected status %q initiating the upload of %s", resp.Status, digest)
+	}
+	uploadURL, err := resolveUploadLocation(initiateURL, resp.Header.Get("Location"), digest)
+	if err != nil {
+		return err
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err = client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected status %q uploading blob %s", resp.Status, digest)
+	}
+	return nil
+}
+
+// resolveUploadLocation turns the (possibly relative) Location header of an
+// initiated upload into the absolute URL completing it for the given
+// digest.
+func resolveUploadLocation(initiateURL, location, digest string) (string, error) {
+	if location == "" {
+		return "", fmt.Errorf("registry did not return an upload location")
+	}
+	base, err := url.Parse(initiateURL)
+	if err != nil {
+		return "", err
+	}
+	upload, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	upload = base.ResolveReference(upload)
+	query := upload.Query()
+	query.Set("digest", digest)
+	upload.RawQuery = query.Encode()
+	return upload.String(), nil
+}
+
+// blobDigest returns the OCI digest string of a blob.
+func blobDigest(blob []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(blob))
+}
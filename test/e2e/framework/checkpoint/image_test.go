@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRegistry implements just enough of the registry V2 push protocol for
+// the helpers under test.
+type fakeRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	uploads   int
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		blobs:     map[string][]byte{},
+		manifests: map[string][]byte{},
+	}
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case req.Method == http.MethodHead && strings.Contains(req.URL.Path, "/blobs/"):
+		digest := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+		if _, found := r.blobs[digest]; found {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/blobs/uploads/"):
+		r.uploads++
+		w.Header().Set("Location", fmt.Sprintf("/v2/uploads/%d", r.uploads))
+		w.WriteHeader(http.StatusAccepted)
+	case req.Method == http.MethodPut && strings.Contains(req.URL.Path, "/uploads/"):
+		digest := req.URL.Query().Get("digest")
+		if digest == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(req.Body)
+		r.blobs[digest] = body
+		w.WriteHeader(http.StatusCreated)
+	case req.Method == http.MethodPut && strings.Contains(req.URL.Path, "/manifests/"):
+		body, _ := io.ReadAll(req.Body)
+		r.manifests[req.URL.Path] = body
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestImageFromArchivePush(t *testing.T) {
+	registry := newFakeRegistry()
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	host := server.Listener.Addr().String()
+
+	archive := []byte("fake checkpoint archive")
+	image, err := ImageFromArchive(archive, "counter")
+	if err != nil {
+		t.Fatalf("converting the archive: %v", err)
+	}
+
+	ref, err := image.Push(context.Background(), host, "checkpoints/counter", "v1")
+	if err != nil {
+		t.Fatalf("pushing the image: %v", err)
+	}
+	if expected := host + "/checkpoints/counter:v1"; ref != expected {
+		t.Errorf("expected reference %q, got %q", expected, ref)
+	}
+
+	manifestBody, found := registry.manifests["/v2/checkpoints/counter/manifests/v1"]
+	if !found {
+		t.Fatalf("manifest was not pushed, got %v", registry.manifests)
+	}
+	manifest := struct {
+		MediaType  string            `json:"mediaType"`
+		Config     ociDescriptor     `json:"config"`
+		Layers     []ociDescriptor   `json:"layers"`
+		Annotation map[string]string `json:"annotations"`
+	}{}
+	if err := json.Unmarshal(manifestBody, &manifest); err != nil {
+		t.Fatalf("parsing the pushed manifest: %v", err)
+	}
+	if manifest.MediaType != manifestMediaType {
+		t.Errorf("unexpected manifest media type %q", manifest.MediaType)
+	}
+	if manifest.Annotation[checkpointNameAnnotation] != "counter" {
+		t.Errorf("expected the checkpoint name annotation, got %v", manifest.Annotation)
+	}
+	if len(manifest.Layers) != 1 {
+		t.Fatalf("expected a single layer, got %d", len(manifest.Layers))
+	}
+	layer, found := registry.blobs[manifest.Layers[0].Digest]
+	if !found {
+		t.Fatalf("layer blob %s was not pushed", manifest.Layers[0].Digest)
+	}
+	if string(layer) != string(archive) {
+		t.Error("pushed layer does not match the checkpoint archive")
+	}
+	if manifest.Layers[0].Digest != blobDigest(archive) {
+		t.Errorf("layer digest %s does not match the archive digest %s", manifest.Layers[0].Digest, blobDigest(archive))
+	}
+	if _, found := registry.blobs[manifest.Config.Digest]; !found {
+		t.Fatalf("config blob %s was not pushed", manifest.Config.Digest)
+	}
+
+	// A second push of the same content finds the blobs present and only
+	// re-uploads the manifest.
+	uploadsBefore := registry.uploads
+	if _, err := image.Push(context.Background(), host, "checkpoints/counter", "v2"); err != nil {
+		t.Fatalf("pushing the image again: %v", err)
+	}
+	if registry.uploads != uploadsBefore {
+		t.Errorf("expected no new blob uploads, got %d more", registry.uploads-uploadsBefore)
+	}
+}
+
+func TestImageFromArchiveValidation(t *testing.T) {
+	if _, err := ImageFromArchive(nil, "counter"); err == nil {
+		t.Error("expected an empty archive to be rejected")
+	}
+	if _, err := ImageFromArchive([]byte("archive"), ""); err == nil {
+		t.Error("expected an empty container name to be rejected")
+	}
+}
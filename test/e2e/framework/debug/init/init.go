@@ -35,6 +35,20 @@ func init() {
 				e2edebug.DumpAllNamespaceInfo(ctx, f.ClientSet, ns)
 			}
 
+			// Default artifact capture for framework.RetryOnFlake: dump the
+			// test namespace (events, pods, node debug info) for every
+			// failed attempt, so the evidence survives a successful retry.
+			ginkgo.BeforeEach(func() {
+				unregister := framework.RegisterFlakeArtifactCapture(func(ctx context.Context, attempt int, err error) {
+					if f.ClientSet == nil || f.Namespace == nil {
+						return
+					}
+					framework.Logf("Capturing artifacts for failed attempt %d: %v", attempt, err)
+					e2edebug.DumpAllNamespaceInfo(ctx, f.ClientSet, f.Namespace.Name)
+				})
+				ginkgo.DeferCleanup(unregister)
+			})
+
 			if framework.TestContext.GatherLogsSizes {
 				ginkgo.BeforeEach(func() {
 					var wg sync.WaitGroup
@@ -92,6 +106,26 @@ func init() {
 					})
 				})
 			}
+
+			if framework.TestContext.GatherNodeUsageDeltas {
+				ginkgo.BeforeEach(func(ctx context.Context) {
+					before, err := e2edebug.GrabNodeUsageSnapshots(ctx, f.ClientSet)
+					if err != nil {
+						framework.Logf("Error while grabbing node usage snapshots before test: %v", err)
+						return
+					}
+					ginkgo.DeferCleanup(func(ctx context.Context) {
+						ginkgo.By("Collecting node usage deltas", func() {
+							after, err := e2edebug.GrabNodeUsageSnapshots(ctx, f.ClientSet)
+							if err != nil {
+								framework.Logf("Error while grabbing node usage snapshots after test: %v", err)
+								return
+							}
+							f.TestSummaries = append(f.TestSummaries, e2edebug.ComputeNodeUsageDeltas(before, after))
+						})
+					})
+				})
+			}
 		},
 	)
 }
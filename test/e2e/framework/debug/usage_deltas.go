@@ -0,0 +1,192 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2emetrics "k8s.io/kubernetes/test/e2e/framework/metrics"
+)
+
+// usageDeltaKubeletMetrics are the kubelet /metrics values compared before
+// and after each test. Names are listed without the kubelet_ prefix, matching
+// what e2emetrics.GetKubeletMetrics returns. All of them are gauges or
+// monotonic counters whose growth across a test points at leaked state.
+var usageDeltaKubeletMetrics = []string{
+	"running_pods",
+	"running_containers",
+	"pleg_discard_events",
+}
+
+// NodeUsageSnapshot records the per-node quantities that NodeUsageDelta
+// compares before and after a test: kubelet /metrics values, pod and
+// filesystem usage from the CRI stats summary, and node cgroup memory usage.
+type NodeUsageSnapshot struct {
+	Time time.Time
+	// RunningPods is the number of pods in the kubelet stats summary.
+	RunningPods int
+	// NodeFsUsedBytes is the node filesystem usage reported by the kubelet.
+	NodeFsUsedBytes uint64
+	// ImageFsUsedBytes is the container runtime image filesystem usage.
+	ImageFsUsedBytes uint64
+	// SystemContainerMemoryBytes is the memory working set of the node's
+	// system cgroups (kubelet, runtime, pods), keyed by cgroup name.
+	SystemContainerMemoryBytes map[string]uint64
+	// KubeletMetrics holds the summed samples of every metric listed in
+	// usageDeltaKubeletMetrics.
+	KubeletMetrics map[string]float64
+}
+
+// NodeUsageDelta is the difference between two NodeUsageSnapshots of the same
+// node. Filesystem and memory deltas are signed: usage may shrink during a
+// test, e.g. when image garbage collection runs.
+type NodeUsageDelta struct {
+	Node                       string
+	RunningPods                int
+	NodeFsUsedBytes            int64
+	ImageFsUsedBytes           int64
+	SystemContainerMemoryBytes map[string]int64
+	KubeletMetrics             map[string]float64
+}
+
+// NodeUsageDeltaSummary holds the per-node usage deltas for one test and is
+// attached to the test artifacts through the framework's TestDataSummary
+// mechanism.
+type NodeUsageDeltaSummary struct {
+	Deltas []NodeUsageDelta
+}
+
+// PrintHumanReadable prints the usage deltas as a table, one row per node.
+func (s *NodeUsageDeltaSummary) PrintHumanReadable() string {
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 1, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "node\tpods\tnodefs(MB)\timagefs(MB)\n")
+	for _, delta := range s.Deltas {
+		fmt.Fprintf(w, "%q\t%+d\t%+.2f\t%+.2f\n", delta.Node, delta.RunningPods,
+			float64(delta.NodeFsUsedBytes)/(1024*1024), float64(delta.ImageFsUsedBytes)/(1024*1024))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// PrintJSON prints the usage deltas in JSON.
+func (s *NodeUsageDeltaSummary) PrintJSON() string {
+	return framework.PrettyPrintJSON(*s)
+}
+
+// SummaryKind returns the string of NodeUsageDeltaSummary
+func (s *NodeUsageDeltaSummary) SummaryKind() string {
+	return "NodeUsageDeltaSummary"
+}
+
+// GrabNodeUsageSnapshots takes a NodeUsageSnapshot of every node in the
+// cluster, keyed by node name. Nodes whose kubelet cannot be reached are
+// logged and left out rather than failing the test.
+func GrabNodeUsageSnapshots(ctx context.Context, c clientset.Interface) (map[string]NodeUsageSnapshot, error) {
+	nodeList, err := c.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error while listing nodes: %w", err)
+	}
+	snapshots := make(map[string]NodeUsageSnapshot, len(nodeList.Items))
+	for _, node := range nodeList.Items {
+		snapshot, err := grabNodeUsageSnapshot(ctx, c, node.Name)
+		if err != nil {
+			framework.Logf("Error while grabbing usage snapshot from node %s: %v", node.Name, err)
+			continue
+		}
+		snapshots[node.Name] = snapshot
+	}
+	return snapshots, nil
+}
+
+func grabNodeUsageSnapshot(ctx context.Context, c clientset.Interface, nodeName string) (NodeUsageSnapshot, error) {
+	summary, err := getStatsSummary(c, nodeName)
+	if err != nil {
+		return NodeUsageSnapshot{}, err
+	}
+	snapshot := NodeUsageSnapshot{
+		Time:                       time.Now(),
+		RunningPods:                len(summary.Pods),
+		SystemContainerMemoryBytes: map[string]uint64{},
+		KubeletMetrics:             map[string]float64{},
+	}
+	if summary.Node.Fs != nil {
+		snapshot.NodeFsUsedBytes = removeUint64Ptr(summary.Node.Fs.UsedBytes)
+	}
+	if summary.Node.Runtime != nil && summary.Node.Runtime.ImageFs != nil {
+		snapshot.ImageFsUsedBytes = removeUint64Ptr(summary.Node.Runtime.ImageFs.UsedBytes)
+	}
+	for _, container := range summary.Node.SystemContainers {
+		if container.Memory != nil {
+			snapshot.SystemContainerMemoryBytes[container.Name] = removeUint64Ptr(container.Memory.WorkingSetBytes)
+		}
+	}
+	metrics, err := e2emetrics.GetKubeletMetrics(ctx, c, nodeName)
+	if err != nil {
+		return NodeUsageSnapshot{}, err
+	}
+	for _, name := range usageDeltaKubeletMetrics {
+		total := 0.0
+		for _, sample := range metrics[name] {
+			total += float64(sample.Value)
+		}
+		snapshot.KubeletMetrics[name] = total
+	}
+	return snapshot, nil
+}
+
+// ComputeNodeUsageDeltas compares two snapshot sets and returns the per-node
+// deltas, sorted by node name. Nodes present in only one set are skipped;
+// there is no meaningful delta for them.
+func ComputeNodeUsageDeltas(before, after map[string]NodeUsageSnapshot) *NodeUsageDeltaSummary {
+	summary := &NodeUsageDeltaSummary{}
+	for node, beforeSnapshot := range before {
+		afterSnapshot, ok := after[node]
+		if !ok {
+			continue
+		}
+		delta := NodeUsageDelta{
+			Node:                       node,
+			RunningPods:                afterSnapshot.RunningPods - beforeSnapshot.RunningPods,
+			NodeFsUsedBytes:            int64(afterSnapshot.NodeFsUsedBytes) - int64(beforeSnapshot.NodeFsUsedBytes),
+			ImageFsUsedBytes:           int64(afterSnapshot.ImageFsUsedBytes) - int64(beforeSnapshot.ImageFsUsedBytes),
+			SystemContainerMemoryBytes: map[string]int64{},
+			KubeletMetrics:             map[string]float64{},
+		}
+		for name, afterBytes := range afterSnapshot.SystemContainerMemoryBytes {
+			if beforeBytes, ok := beforeSnapshot.SystemContainerMemoryBytes[name]; ok {
+				delta.SystemContainerMemoryBytes[name] = int64(afterBytes) - int64(beforeBytes)
+			}
+		}
+		for name, afterValue := range afterSnapshot.KubeletMetrics {
+			if beforeValue, ok := beforeSnapshot.KubeletMetrics[name]; ok {
+				delta.KubeletMetrics[name] = afterValue - beforeValue
+			}
+		}
+		summary.Deltas = append(summary.Deltas, delta)
+	}
+	sort.Slice(summary.Deltas, func(i, j int) bool { return summary.Deltas[i].Node < summary.Deltas[j].Node })
+	return summary
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// FlakeClassifier decides whether a failure was caused by the test
+// environment (and is therefore worth retrying) rather than by the code
+// under test. Classifiers work on the error message because most flakes
+// surface as untyped errors from the apiserver, the runtime or a registry.
+type FlakeClassifier func(err error) bool
+
+// RegistryFlake matches image pull failures caused by registry-side
+// errors that a retry can get past.
+func RegistryFlake(err error) bool {
+	return errContainsAny(err,
+		"500 internal server error",
+		"502 bad gateway",
+		"503 service unavailable",
+		"received unexpected http status: 5",
+		"registry is unavailable",
+	)
+}
+
+// NodeNotReadyFlake matches failures caused by the node dropping out of
+// Ready while the test ran.
+func NodeNotReadyFlake(err error) bool {
+	return errContainsAny(err,
+		"node is not ready",
+		"node not ready",
+		"nodenotready",
+	)
+}
+
+// APIThrottlingFlake matches failures caused by apiserver or client-side
+// request throttling.
+func APIThrottlingFlake(err error) bool {
+	return errContainsAny(err,
+		"too many requests",
+		"request rate exceeded",
+		"throttl",
+	)
+}
+
+// CombineFlakeClassifiers returns a classifier that matches when any of
+// the given classifiers does.
+func CombineFlakeClassifiers(classifiers ...FlakeClassifier) FlakeClassifier {
+	return func(err error) bool {
+		for _, classifier := range classifiers {
+			if classifier(err) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func errContainsAny(err error, markers ...string) bool {
+	msg := strings.ToLower(err.Error())
+	for _, marker := range markers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// FlakeArtifactCapture captures diagnostics after a failed attempt inside
+// RetryOnFlake, before the attempt is retried. Implementations dump
+// evidence such as pod logs, namespace events or kubelet log excerpts.
+type FlakeArtifactCapture func(ctx context.Context, attempt int, err error)
+
+type flakeArtifactEntry struct {
+	id      int
+	capture FlakeArtifactCapture
+}
+
+var (
+	flakeArtifactsLock    sync.Mutex
+	flakeArtifactNextID   int
+	flakeArtifactCaptures []flakeArtifactEntry
+)
+
+// RegisterFlakeArtifactCapture registers a capture to run after every
+// failed RetryOnFlake attempt and returns a function that removes it
+// again, suitable for ginkgo.DeferCleanup. The framework's init packages
+// register a default capture that dumps the test namespace.
+func RegisterFlakeArtifactCapture(capture FlakeArtifactCapture) func() {
+	flakeArtifactsLock.Lock()
+	defer flakeArtifactsLock.Unlock()
+	flakeArtifactNextID++
+	id := flakeArtifactNextID
+	flakeArtifactCaptures = append(flakeArtifactCaptures, flakeArtifactEntry{id: id, capture: capture})
+	return func() {
+		flakeArtifactsLock.Lock()
+		defer flakeArtifactsLock.Unlock()
+		for i, entry := range flakeArtifactCaptures {
+			if entry.id == id {
+				flakeArtifactCaptures = append(flakeArtifactCaptures[:i], flakeArtifactCaptures[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func captureFlakeArtifacts(ctx context.Context, attempt int, err error) {
+	flakeArtifactsLock.Lock()
+	captures := make([]flakeArtifactEntry, len(flakeArtifactCaptures))
+	copy(captures, flakeArtifactCaptures)
+	flakeArtifactsLock.Unlock()
+	for _, entry := range captures {
+		entry.capture(ctx, attempt, err)
+	}
+}
+
+// RetryOnFlake runs body up to attempts times, retrying only failures the
+// classifier recognizes as environmental flakes. A nil classifier treats
+// every failure as a flake. The registered artifact captures run after
+// each failed attempt, so the evidence for a flake is preserved even when
+// the retry then succeeds. The error of the last executed attempt is
+// returned; earlier flakes are logged.
+func RetryOnFlake(ctx context.Context, attempts int, classifier FlakeClassifier, body func(ctx context.Context) error) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = body(ctx); err == nil {
+			return nil
+		}
+		captureFlakeArtifacts(ctx, attempt, err)
+		if classifier != nil && !classifier(err) {
+			// Not a flake: retrying would only hide a real failure.
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		if attempt < attempts {
+			Logf("Attempt %d of %d failed with a flake: %v, retrying", attempt, attempts, err)
+		}
+	}
+	return err
+}
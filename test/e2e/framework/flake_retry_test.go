@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRetryOnFlake(t *testing.T) {
+	flake := errors.New("503 Service Unavailable")
+	failure := errors.New("assertion failed")
+
+	t.Run("retries flakes until success", func(t *testing.T) {
+		attempts := 0
+		err := RetryOnFlake(context.Background(), 3, RegistryFlake, func(ctx context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return flake
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if attempts != 3 {
+			t.Errorf("expected 3 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("stops on non-flake failures", func(t *testing.T) {
+		attempts := 0
+		err := RetryOnFlake(context.Background(), 3, RegistryFlake, func(ctx context.Context) error {
+			attempts++
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Errorf("expected the failure to be returned, got %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf("expected a non-flake to stop after 1 attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("nil classifier treats every failure as a flake", func(t *testing.T) {
+		attempts := 0
+		err := RetryOnFlake(context.Background(), 3, nil, func(ctx context.Context) error {
+			attempts++
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Errorf("expected the failure of the last attempt, got %v", err)
+		}
+		if attempts != 3 {
+			t.Errorf("expected all 3 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("captures run for every failed attempt", func(t *testing.T) {
+		captured := 0
+		unregister := RegisterFlakeArtifactCapture(func(ctx context.Context, attempt int, err error) {
+			captured++
+		})
+		defer unregister()
+		_ = RetryOnFlake(context.Background(), 2, nil, func(ctx context.Context) error {
+			return flake
+		})
+		if captured != 2 {
+			t.Errorf("expected 2 artifact captures, got %d", captured)
+		}
+		unregister()
+		_ = RetryOnFlake(context.Background(), 1, nil, func(ctx context.Context) error {
+			return flake
+		})
+		if captured != 2 {
+			t.Errorf("expected no captures after unregistering, got %d", captured)
+		}
+	})
+}
+
+func TestFlakeClassifiers(t *testing.T) {
+	combined := CombineFlakeClassifiers(RegistryFlake, NodeNotReadyFlake, APIThrottlingFlake)
+	for message, expected := range map[string]bool{
+		"received unexpected HTTP status: 502 Bad Gateway": true,
+		"RegistryUnavailable: registry is unavailable":     true,
+		"node is not ready yet":                            true,
+		"the server has received too many requests":        true,
+		"assertion failed: wrong exit code":                false,
+	} {
+		if got := combined(errors.New(message)); got != expected {
+			t.Errorf("classifier(%q): expected %v, got %v", message, expected, got)
+		}
+	}
+}
@@ -42,6 +42,12 @@ type ExecOptions struct {
 	Stdin         io.Reader
 	CaptureStdout bool
 	CaptureStderr bool
+	// TTY allocates a terminal for the command. The runtime merges stderr
+	// into stdout when set, so CaptureStderr should be false.
+	TTY bool
+	// TerminalSizeQueue feeds terminal resize events to the remote side.
+	// Only meaningful together with TTY.
+	TerminalSizeQueue remotecommand.TerminalSizeQueue
 	// If false, whitespace in std{err,out} will be removed.
 	PreserveWhitespace bool
 	Quiet              bool
@@ -61,9 +67,44 @@ func ExecWithOptionsContext(ctx context.Context, f *framework.Framework, options
 	config, err := framework.LoadConfig()
 	framework.ExpectNoError(err, "failed to load restclient config")
 
-	const tty = false
-
 	framework.Logf("ExecWithOptions: Clientset creation")
+	req := execRequest(f, options)
+
+	var stdout, stderr bytes.Buffer
+	framework.Logf("ExecWithOptions: execute(POST %s)", req.URL())
+	err = execute(ctx, "POST", req.URL(), config, streamOptions(options, &stdout, &stderr))
+
+	if options.PreserveWhitespace {
+		return stdout.String(), stderr.String(), err
+	}
+	return strings.TrimSpace(stdout.String()), strings.TrimSpace(stderr.String()), err
+}
+
+// ExecWithWebsocket executes a command in the specified container like
+// ExecWithOptionsContext, but over the WebSocket transport kubectl defaults
+// to instead of SPDY, so tests can cover both streaming paths of the CRI
+// streaming server.
+func ExecWithWebsocket(ctx context.Context, f *framework.Framework, options ExecOptions) (string, string, error) {
+	if !options.Quiet {
+		framework.Logf("ExecWithWebsocket %+v", options)
+	}
+	config, err := framework.LoadConfig()
+	framework.ExpectNoError(err, "failed to load restclient config")
+
+	req := execRequest(f, options)
+
+	var stdout, stderr bytes.Buffer
+	framework.Logf("ExecWithWebsocket: execute(GET %s)", req.URL())
+	err = executeWebSocket(ctx, req.URL(), config, streamOptions(options, &stdout, &stderr))
+
+	if options.PreserveWhitespace {
+		return stdout.String(), stderr.String(), err
+	}
+	return strings.TrimSpace(stdout.String()), strings.TrimSpace(stderr.String()), err
+}
+
+// execRequest builds the exec subresource request for the given options.
+func execRequest(f *framework.Framework, options ExecOptions) *restclient.Request {
 	req := f.ClientSet.CoreV1().RESTClient().Post().
 		Resource("pods").
 		Name(options.PodName).
@@ -76,17 +117,67 @@ func ExecWithOptionsContext(ctx context.Context, f *framework.Framework, options
 		Stdin:     options.Stdin != nil,
 		Stdout:    options.CaptureStdout,
 		Stderr:    options.CaptureStderr,
-		TTY:       tty,
+		TTY:       options.TTY,
 	}, scheme.ParameterCodec)
+	return req
+}
 
-	var stdout, stderr bytes.Buffer
-	framework.Logf("ExecWithOptions: execute(POST %s)", req.URL())
-	err = execute(ctx, "POST", req.URL(), config, options.Stdin, &stdout, &stderr, tty)
-
-	if options.PreserveWhitespace {
-		return stdout.String(), stderr.String(), err
+// streamOptions maps ExecOptions onto the remotecommand stream options
+// shared by the SPDY and WebSocket executors.
+func streamOptions(options ExecOptions, stdout, stderr io.Writer) remotecommand.StreamOptions {
+	return remotecommand.StreamOptions{
+		Stdin:             options.Stdin,
+		Stdout:            stdout,
+		Stderr:            stderr,
+		Tty:               options.TTY,
+		TerminalSizeQueue: options.TerminalSizeQueue,
 	}
-	return strings.TrimSpace(stdout.String()), strings.TrimSpace(stderr.String()), err
+}
+
+// AttachOptions passed to AttachWithWebsocket.
+type AttachOptions struct {
+	Namespace     string
+	PodName       string
+	ContainerName string
+	Stdin         io.Reader
+	Stdout        io.Writer
+	Stderr        io.Writer
+	// TTY attaches to the container's terminal. The runtime merges stderr
+	// into stdout when set.
+	TTY bool
+	// TerminalSizeQueue feeds terminal resize events to the remote side.
+	// Only meaningful together with TTY.
+	TerminalSizeQueue remotecommand.TerminalSizeQueue
+}
+
+// AttachWithWebsocket attaches to the specified container over the
+// WebSocket transport and streams until the context ends or the remote
+// side closes the connection.
+func AttachWithWebsocket(ctx context.Context, f *framework.Framework, options AttachOptions) error {
+	framework.Logf("AttachWithWebsocket %s/%s container %s", options.Namespace, options.PodName, options.ContainerName)
+	config, err := framework.LoadConfig()
+	framework.ExpectNoError(err, "failed to load restclient config")
+
+	req := f.ClientSet.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(options.PodName).
+		Namespace(options.Namespace).
+		SubResource("attach")
+	req.VersionedParams(&v1.PodAttachOptions{
+		Container: options.ContainerName,
+		Stdin:     options.Stdin != nil,
+		Stdout:    options.Stdout != nil,
+		Stderr:    options.Stderr != nil,
+		TTY:       options.TTY,
+	}, scheme.ParameterCodec)
+
+	return executeWebSocket(ctx, req.URL(), config, remotecommand.StreamOptions{
+		Stdin:             options.Stdin,
+		Stdout:            options.Stdout,
+		Stderr:            options.Stderr,
+		Tty:               options.TTY,
+		TerminalSizeQueue: options.TerminalSizeQueue,
+	})
 }
 
 // ExecCommandInContainerWithFullOutput executes a command in the
@@ -144,15 +235,19 @@ func ExecShellInPodWithFullOutput(ctx context.Context, f *framework.Framework, p
 	return execCommandInPodWithFullOutput(ctx, f, podName, "/bin/sh", "-c", cmd)
 }
 
-func execute(ctx context.Context, method string, url *url.URL, config *restclient.Config, stdin io.Reader, stdout, stderr io.Writer, tty bool) error {
+func execute(ctx context.Context, method string, url *url.URL, config *restclient.Config, streamOptions remotecommand.StreamOptions) error {
 	exec, err := remotecommand.NewSPDYExecutor(config, method, url)
 	if err != nil {
 		return err
 	}
-	return exec.StreamWithContext(ctx, remotecommand.StreamOptions{
-		Stdin:  stdin,
-		Stdout: stdout,
-		Stderr: stderr,
-		Tty:    tty,
-	})
+	return exec.StreamWithContext(ctx, streamOptions)
+}
+
+func executeWebSocket(ctx context.Context, url *url.URL, config *restclient.Config, streamOptions remotecommand.StreamOptions) error {
+	// The WebSocket executor upgrades a GET request, unlike SPDY's POST.
+	exec, err := remotecommand.NewWebSocketExecutor(config, "GET", url.String())
+	if err != nil {
+		return err
+	}
+	return exec.StreamWithContext(ctx, streamOptions)
 }
@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/test/utils/format"
+
+	"github.com/onsi/gomega"
+)
+
+// WaitForKubeletRejection waits until the given pod has been rejected by
+// kubelet admission with a reason containing expectedReasonSubstr. The
+// rejection is detected either through the Failed pod phase with
+// status.reason set by the kubelet, or through a kubelet warning event for
+// pods that never reach the node's status loop. Pods that are merely
+// unschedulable at the scheduler level are reported as a mismatch rather
+// than being confused with a kubelet rejection.
+func WaitForKubeletRejection(ctx context.Context, c clientset.Interface, pod *v1.Pod, expectedReasonSubstr string, timeout time.Duration) error {
+	conditionDesc := fmt.Sprintf("rejected by kubelet admission with reason containing %q", expectedReasonSubstr)
+	return WaitForPodCondition(ctx, c, pod.Namespace, pod.Name, conditionDesc, timeout, func(pod *v1.Pod) (bool, error) {
+		if pod.Status.Phase == v1.PodFailed {
+			if strings.Contains(pod.Status.Reason, expectedReasonSubstr) {
+				return true, nil
+			}
+			return true, gomega.StopTrying(fmt.Sprintf(
+				"pod failed with reason %q (message %q), expected a kubelet rejection reason containing %q:\n%s",
+				pod.Status.Reason, pod.Status.Message, expectedReasonSubstr, format.Object(pod.Status, 1)))
+		}
+
+		// A pod held back by the scheduler is not a kubelet rejection; fail
+		// fast so the caller does not mistake unschedulability for the
+		// feature under test.
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse && condition.Reason == v1.PodReasonUnschedulable {
+				return true, gomega.StopTrying(fmt.Sprintf(
+					"pod was not rejected by the kubelet, it is unschedulable at the scheduler level: %s", condition.Message))
+			}
+		}
+
+		// Pods rejected before binding completes never reach the Failed
+		// phase; the kubelet only records a warning event.
+		found, err := hasKubeletRejectionEvent(ctx, c, pod, expectedReasonSubstr)
+		if err != nil {
+			return false, err
+		}
+		return found, nil
+	})
+}
+
+// hasKubeletRejectionEvent returns true if a kubelet warning event for the
+// pod carries the expected rejection reason.
+func hasKubeletRejectionEvent(ctx context.Context, c clientset.Interface, pod *v1.Pod, expectedReasonSubstr string) (bool, error) {
+	selector := fields.Set{
+		"involvedObject.kind":      "Pod",
+		"involvedObject.name":      pod.Name,
+		"involvedObject.namespace": pod.Namespace,
+		"type":                     v1.EventTypeWarning,
+	}.AsSelector().String()
+	eventList, err := c.CoreV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return false, fmt.Errorf("listing warning events for pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	for _, event := range eventList.Items {
+		if event.Source.Component != "kubelet" {
+			continue
+		}
+		if strings.Contains(event.Reason, expectedReasonSubstr) || strings.Contains(event.Message, expectedReasonSubstr) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
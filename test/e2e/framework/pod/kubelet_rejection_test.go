@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForKubeletRejection(t *testing.T) {
+	const namespace = "test-ns"
+
+	basePod := func(mutate func(*v1.Pod)) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rejected-pod",
+				Namespace: namespace,
+			},
+		}
+		if mutate != nil {
+			mutate(pod)
+		}
+		return pod
+	}
+
+	testCases := []struct {
+		name           string
+		pod            *v1.Pod
+		extraObjects   []runtime.Object
+		expectedReason string
+		expectError    string
+	}{
+		{
+			name: "failed pod with matching admission reason",
+			pod: basePod(func(pod *v1.Pod) {
+				pod.Status.Phase = v1.PodFailed
+				pod.Status.Reason = "RegistryNotAllowed"
+				pod.Status.Message = "image registry is not in the node allowlist"
+			}),
+			expectedReason: "RegistryNotAllowed",
+		},
+		{
+			name: "failed pod with different reason reports rejection context",
+			pod: basePod(func(pod *v1.Pod) {
+				pod.Status.Phase = v1.PodFailed
+				pod.Status.Reason = "Evicted"
+				pod.Status.Message = "node was under memory pressure"
+			}),
+			expectedReason: "RegistryNotAllowed",
+			expectError:    `pod failed with reason "Evicted"`,
+		},
+		{
+			name: "unschedulable pod is distinguished from kubelet rejection",
+			pod: basePod(func(pod *v1.Pod) {
+				pod.Status.Phase = v1.PodPending
+				pod.Status.Conditions = []v1.PodCondition{
+					{
+						Type:    v1.PodScheduled,
+						Status:  v1.ConditionFalse,
+						Reason:  v1.PodReasonUnschedulable,
+						Message: "0/3 nodes are available",
+					},
+				}
+			}),
+			expectedReason: "RegistryNotAllowed",
+			expectError:    "unschedulable at the scheduler level",
+		},
+		{
+			name: "pending pod with kubelet rejection event",
+			pod: basePod(func(pod *v1.Pod) {
+				pod.Status.Phase = v1.PodPending
+			}),
+			extraObjects: []runtime.Object{
+				&v1.Event{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "rejected-pod.rejection",
+						Namespace: namespace,
+					},
+					InvolvedObject: v1.ObjectReference{
+						Kind:      "Pod",
+						Name:      "rejected-pod",
+						Namespace: namespace,
+					},
+					Type:    v1.EventTypeWarning,
+					Reason:  "RegistryNotAllowed",
+					Message: "image registry is not in the node allowlist",
+					Source:  v1.EventSource{Component: "kubelet"},
+				},
+			},
+			expectedReason: "RegistryNotAllowed",
+		},
+		{
+			name: "pending pod without rejection times out",
+			pod: basePod(func(pod *v1.Pod) {
+				pod.Status.Phase = v1.PodPending
+			}),
+			expectedReason: "RegistryNotAllowed",
+			expectError:    "rejected by kubelet admission",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			objects := append([]runtime.Object{tc.pod}, tc.extraObjects...)
+			client := fake.NewSimpleClientset(objects...)
+
+			err := WaitForKubeletRejection(context.Background(), client, tc.pod, tc.expectedReason, time.Second)
+			if tc.expectError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error containing %q, got none", tc.expectError)
+			}
+			if !strings.Contains(err.Error(), tc.expectError) {
+				t.Fatalf("expected error containing %q, got: %v", tc.expectError, err)
+			}
+		})
+	}
+}
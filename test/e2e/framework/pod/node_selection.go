@@ -18,14 +18,21 @@ package pod
 
 import (
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // NodeSelection specifies where to run a pod, using a combination of fixed node name,
-// node selector and/or affinity.
+// node selector, affinity, tolerations and/or topology spread constraints.
 type NodeSelection struct {
 	Name     string
 	Selector map[string]string
 	Affinity *v1.Affinity
+	// Tolerations are appended to the pod's tolerations, e.g. to let test
+	// pods land on tainted nodes.
+	Tolerations []v1.Toleration
+	// TopologySpreadConstraints are appended to the pod's topology spread
+	// constraints, e.g. to spread replicated test pods across nodes.
+	TopologySpreadConstraints []v1.TopologySpreadConstraint
 }
 
 // setNodeAffinityRequirement sets affinity with specified operator to nodeName to nodeSelection
@@ -80,6 +87,61 @@ func SetAntiAffinity(nodeSelection *NodeSelection, nodeName string) {
 	setNodeAffinityRequirement(nodeSelection, v1.NodeSelectorOpNotIn, nodeName)
 }
 
+// SetTolerations appends the given tolerations to nodeSelection.
+func SetTolerations(nodeSelection *NodeSelection, tolerations ...v1.Toleration) {
+	nodeSelection.Tolerations = append(nodeSelection.Tolerations, tolerations...)
+}
+
+// SetTopologySpreadRequirement appends a hard topology spread constraint to
+// nodeSelection, spreading pods matching podLabels across the given topology
+// key (e.g. "kubernetes.io/hostname") with the given maximum skew.
+func SetTopologySpreadRequirement(nodeSelection *NodeSelection, topologyKey string, maxSkew int32, podLabels map[string]string) {
+	nodeSelection.TopologySpreadConstraints = append(nodeSelection.TopologySpreadConstraints, v1.TopologySpreadConstraint{
+		TopologyKey:       topologyKey,
+		MaxSkew:           maxSkew,
+		WhenUnsatisfiable: v1.DoNotSchedule,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: podLabels},
+	})
+}
+
+// podAffinityTerm builds the affinity term shared by SetPodAffinity and
+// SetPodAntiAffinity.
+func podAffinityTerm(topologyKey string, podLabels map[string]string) v1.PodAffinityTerm {
+	return v1.PodAffinityTerm{
+		TopologyKey:   topologyKey,
+		LabelSelector: &metav1.LabelSelector{MatchLabels: podLabels},
+	}
+}
+
+// SetPodAffinity requires pods to be co-located with pods matching podLabels
+// within the given topology key, e.g. on the same node for
+// "kubernetes.io/hostname".
+func SetPodAffinity(nodeSelection *NodeSelection, topologyKey string, podLabels map[string]string) {
+	if nodeSelection.Affinity == nil {
+		nodeSelection.Affinity = &v1.Affinity{}
+	}
+	if nodeSelection.Affinity.PodAffinity == nil {
+		nodeSelection.Affinity.PodAffinity = &v1.PodAffinity{}
+	}
+	nodeSelection.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+		nodeSelection.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+		podAffinityTerm(topologyKey, podLabels))
+}
+
+// SetPodAntiAffinity requires pods to not be co-located with pods matching
+// podLabels within the given topology key.
+func SetPodAntiAffinity(nodeSelection *NodeSelection, topologyKey string, podLabels map[string]string) {
+	if nodeSelection.Affinity == nil {
+		nodeSelection.Affinity = &v1.Affinity{}
+	}
+	if nodeSelection.Affinity.PodAntiAffinity == nil {
+		nodeSelection.Affinity.PodAntiAffinity = &v1.PodAntiAffinity{}
+	}
+	nodeSelection.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+		nodeSelection.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+		podAffinityTerm(topologyKey, podLabels))
+}
+
 // SetNodeAffinity modifies the given pod object with
 // NodeAffinity to the given node name.
 func SetNodeAffinity(podSpec *v1.PodSpec, nodeName string) {
@@ -93,6 +155,8 @@ func SetNodeAffinity(podSpec *v1.PodSpec, nodeName string) {
 func SetNodeSelection(podSpec *v1.PodSpec, nodeSelection NodeSelection) {
 	podSpec.NodeSelector = nodeSelection.Selector
 	podSpec.Affinity = nodeSelection.Affinity
+	podSpec.Tolerations = append(podSpec.Tolerations, nodeSelection.Tolerations...)
+	podSpec.TopologySpreadConstraints = append(podSpec.TopologySpreadConstraints, nodeSelection.TopologySpreadConstraints...)
 	// pod.Spec.NodeName should not be set directly because
 	// it will bypass the scheduler, potentially causing
 	// kubelet to Fail the pod immediately if it's out of
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestSetNodeSelection(t *testing.T) {
+	nodeSelection := NodeSelection{
+		Name:     "node-1",
+		Selector: map[string]string{"topology.kubernetes.io/zone": "zone-a"},
+	}
+	SetTolerations(&nodeSelection, v1.Toleration{Key: "dedicated", Operator: v1.TolerationOpExists})
+	SetTopologySpreadRequirement(&nodeSelection, "kubernetes.io/hostname", 1, map[string]string{"app": "test"})
+
+	podSpec := &v1.PodSpec{}
+	SetNodeSelection(podSpec, nodeSelection)
+
+	assert.Equal(t, nodeSelection.Selector, podSpec.NodeSelector)
+	assert.Len(t, podSpec.Tolerations, 1)
+	assert.Len(t, podSpec.TopologySpreadConstraints, 1)
+	assert.Equal(t, v1.DoNotSchedule, podSpec.TopologySpreadConstraints[0].WhenUnsatisfiable)
+	// The fixed node name must be expressed as node affinity, never as
+	// spec.nodeName, so the pod still goes through the scheduler.
+	assert.Empty(t, podSpec.NodeName)
+	if assert.NotNil(t, podSpec.Affinity) && assert.NotNil(t, podSpec.Affinity.NodeAffinity) {
+		terms := podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		if assert.Len(t, terms, 1) {
+			assert.Equal(t, []string{"node-1"}, terms[0].MatchFields[0].Values)
+		}
+	}
+}
+
+func TestSetPodAffinity(t *testing.T) {
+	var colocate, separate NodeSelection
+	SetPodAffinity(&colocate, "kubernetes.io/hostname", map[string]string{"app": "registry"})
+	SetPodAntiAffinity(&separate, "kubernetes.io/hostname", map[string]string{"app": "registry"})
+
+	if assert.NotNil(t, colocate.Affinity.PodAffinity) {
+		terms := colocate.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		if assert.Len(t, terms, 1) {
+			assert.Equal(t, "kubernetes.io/hostname", terms[0].TopologyKey)
+			assert.Equal(t, map[string]string{"app": "registry"}, terms[0].LabelSelector.MatchLabels)
+		}
+	}
+	if assert.NotNil(t, separate.Affinity.PodAntiAffinity) {
+		assert.Len(t, separate.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 1)
+	}
+}
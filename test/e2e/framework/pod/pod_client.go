@@ -141,6 +141,24 @@ func (c *PodClient) CreateSync(ctx context.Context, pod *v1.Pod) *v1.Pod {
 	return p
 }
 
+// CreateWithNodeSelection applies the given NodeSelection to the pod's spec
+// and creates it (don't wait for it to start). It lets tests co-locate or
+// anti-co-locate pods without setting NodeName directly, which would bypass
+// the scheduler.
+func (c *PodClient) CreateWithNodeSelection(ctx context.Context, pod *v1.Pod, nodeSelection NodeSelection) *v1.Pod {
+	ginkgo.GinkgoHelper()
+	SetNodeSelection(&pod.Spec, nodeSelection)
+	return c.Create(ctx, pod)
+}
+
+// CreateSyncWithNodeSelection applies the given NodeSelection to the pod's
+// spec, creates it, and waits for it to start and be running and ready.
+func (c *PodClient) CreateSyncWithNodeSelection(ctx context.Context, pod *v1.Pod, nodeSelection NodeSelection) *v1.Pod {
+	ginkgo.GinkgoHelper()
+	SetNodeSelection(&pod.Spec, nodeSelection)
+	return c.CreateSync(ctx, pod)
+}
+
 // CreateBatch create a batch of pods. All pods are created before waiting.
 func (c *PodClient) CreateBatch(ctx context.Context, pods []*v1.Pod) []*v1.Pod {
 	ginkgo.GinkgoHelper()
@@ -249,6 +267,14 @@ func (c *PodClient) mungeSpec(pod *v1.Pod) {
 	// the policy to DNSDefault, which is configured per node.
 	pod.Spec.DNSPolicy = v1.DNSDefault
 
+	// When a runtime handler is selected for the suite, run pods under it
+	// unless the test picked a runtime class explicitly. The suite setup
+	// creates a RuntimeClass named after the handler.
+	if framework.TestContext.RuntimeHandler != "" && pod.Spec.RuntimeClassName == nil {
+		handler := framework.TestContext.RuntimeHandler
+		pod.Spec.RuntimeClassName = &handler
+	}
+
 	// PrepullImages only works for node e2e now. For cluster e2e, image prepull is not enforced,
 	// we should not munge ImagePullPolicy for cluster e2e pods.
 	if !framework.TestContext.PrepullImages {
@@ -330,6 +356,16 @@ func (c *PodClient) WaitForErrorEventOrSuccess(ctx context.Context, pod *v1.Pod)
 	return ev, err
 }
 
+// Events returns the events that reference the given pod, in the order the
+// API server returns them.
+func (c *PodClient) Events(ctx context.Context, pod *v1.Pod) ([]v1.Event, error) {
+	evnts, err := c.f.ClientSet.CoreV1().Events(pod.Namespace).Search(scheme.Scheme, pod)
+	if err != nil {
+		return nil, fmt.Errorf("error in listing events: %w", err)
+	}
+	return evnts.Items, nil
+}
+
 // MatchContainerOutput gets output of a container and match expected regexp in the output.
 func (c *PodClient) MatchContainerOutput(ctx context.Context, name string, containerName string, expectedRegexp string) error {
 	f := c.f
@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	"github.com/onsi/ginkgo/v2"
+
+	"k8s.io/kubernetes/test/e2e/framework"
+)
+
+// userNamespaceUnsupportedMarkers are message fragments container runtimes
+// emit when they cannot run user-namespaced pods, e.g. because the runtime
+// predates user namespace support or the filesystem cannot do idmapped
+// mounts.
+var userNamespaceUnsupportedMarkers = []string{
+	"user namespaces",
+	"userns",
+	"idmap",
+	"usernsmode",
+}
+
+// SkipIfUserNamespacesUnsupported probes the node by running a short pod with
+// hostUsers=false and skips the test when the container runtime reports that
+// it cannot run user-namespaced pods. A probe failure that does not look like
+// missing runtime support fails the test, so genuine user namespace breakage
+// is not silently skipped over.
+func SkipIfUserNamespacesUnsupported(ctx context.Context, f *framework.Framework) {
+	ginkgo.GinkgoHelper()
+	ginkgo.By("probing whether the runtime supports user-namespaced pods")
+	hostUsers := false
+	podClient := NewPodClient(f)
+	pod := podClient.Create(ctx, &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "userns-probe-" + string(uuid.NewUUID()),
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyNever,
+			HostUsers:     &hostUsers,
+			Containers: []v1.Container{
+				{
+					Name:    "probe",
+					Image:   GetDefaultTestImage(),
+					Command: []string{"cat", "/proc/self/uid_map"},
+				},
+			},
+		},
+	})
+	ginkgo.DeferCleanup(func(ctx context.Context) {
+		podClient.DeleteSync(ctx, pod.Name, metav1.DeleteOptions{}, DefaultPodDeletionTimeout)
+	})
+
+	err := WaitForPodSuccessInNamespaceTimeout(ctx, f.ClientSet, pod.Name, f.Namespace.Name, f.Timeouts.PodStart)
+	if err == nil {
+		return
+	}
+	if reason, unsupported := userNamespacesUnsupported(ctx, f, podClient, pod.Name); unsupported {
+		ginkgo.Skip(reason)
+	}
+	framework.Failf("user namespace probe pod did not succeed: %v", err)
+}
+
+// userNamespacesUnsupported reports whether the probe pod failed because the
+// runtime cannot run user-namespaced pods, based on its container status
+// messages and events.
+func userNamespacesUnsupported(ctx context.Context, f *framework.Framework, podClient *PodClient, podName string) (string, bool) {
+	var messages []string
+	if pod, err := podClient.Get(ctx, podName, metav1.GetOptions{}); err == nil {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting != nil {
+				messages = append(messages, status.State.Waiting.Message)
+			}
+			if status.State.Terminated != nil {
+				messages = append(messages, status.State.Terminated.Message)
+			}
+		}
+		messages = append(messages, pod.Status.Message)
+		if events, err := podClient.Events(ctx, pod); err == nil {
+			for _, event := range events {
+				if event.Type == v1.EventTypeWarning {
+					messages = append(messages, event.Message)
+				}
+			}
+		}
+	}
+	for _, message := range messages {
+		lowered := strings.ToLower(message)
+		for _, marker := range userNamespaceUnsupportedMarkers {
+			if strings.Contains(lowered, marker) {
+				return "Runtime does not support user-namespaced pods: " + message, true
+			}
+		}
+	}
+	return "", false
+}
@@ -19,6 +19,7 @@ package pod
 import (
 	"flag"
 	"fmt"
+	"sort"
 
 	"github.com/onsi/ginkgo/v2"
 	"github.com/onsi/gomega"
@@ -275,3 +276,28 @@ func FindContainerStatusInPod(pod *v1.Pod, containerName string) *v1.ContainerSt
 	}
 	return nil
 }
+
+// VerifyEventOrder checks that the given event reasons occurred in the given
+// order: the events are sorted by first timestamp and the reasons must appear
+// as an ordered subsequence, with unrelated events in between allowed.
+func VerifyEventOrder(events []v1.Event, reasons ...string) error {
+	sorted := make([]v1.Event, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].FirstTimestamp.Before(&sorted[j].FirstTimestamp)
+	})
+	next := 0
+	for _, event := range sorted {
+		if next < len(reasons) && event.Reason == reasons[next] {
+			next++
+		}
+	}
+	if next < len(reasons) {
+		observed := make([]string, 0, len(sorted))
+		for _, event := range sorted {
+			observed = append(observed, event.Reason)
+		}
+		return fmt.Errorf("event %q did not occur after %v, observed order: %v", reasons[next], reasons[:next], observed)
+	}
+	return nil
+}
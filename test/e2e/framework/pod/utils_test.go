@@ -18,6 +18,7 @@ package pod
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -93,3 +94,24 @@ func TestMixinRestrictedPodSecurity(t *testing.T) {
 	})
 
 }
+
+func TestVerifyEventOrder(t *testing.T) {
+	event := func(reason string, offset int) v1.Event {
+		return v1.Event{
+			Reason:         reason,
+			FirstTimestamp: metav1.NewTime(metav1.Now().Add(time.Duration(offset) * time.Second)),
+		}
+	}
+	// Listed out of order to exercise the sort by first timestamp.
+	events := []v1.Event{
+		event("Killing", 30),
+		event("Pulled", 10),
+		event("Started", 20),
+		event("Scheduled", 0),
+	}
+
+	assert.NoError(t, VerifyEventOrder(events, "Pulled", "Started", "Killing"))
+	assert.NoError(t, VerifyEventOrder(events, "Scheduled", "Killing"))
+	assert.Error(t, VerifyEventOrder(events, "Started", "Pulled"), "reversed order should not verify")
+	assert.Error(t, VerifyEventOrder(events, "Pulled", "FailedPostStartHook"), "missing reason should not verify")
+}
@@ -856,3 +856,60 @@ func WaitForContainerTerminated(ctx context.Context, c clientset.Interface, name
 		return false, nil
 	})
 }
+
+// Canonical reasons the kubelet sets on a waiting container. Tests should
+// assert against these constants instead of duplicating the strings.
+const (
+	// WaitingReasonErrImagePull is set while an image pull is failing.
+	WaitingReasonErrImagePull = "ErrImagePull"
+	// WaitingReasonImagePullBackOff is set while the kubelet is backing off
+	// from failed image pulls.
+	WaitingReasonImagePullBackOff = "ImagePullBackOff"
+	// WaitingReasonCrashLoopBackOff is set while the kubelet is backing off
+	// from restarting a crashing container.
+	WaitingReasonCrashLoopBackOff = "CrashLoopBackOff"
+	// WaitingReasonCreateContainerConfigError is set when generating the
+	// container configuration failed, e.g. because a referenced ConfigMap
+	// or Secret does not exist.
+	WaitingReasonCreateContainerConfigError = "CreateContainerConfigError"
+)
+
+// HaveContainerWaitingWithReason matches a pod whose named container is in
+// the Waiting state for one of the given reasons, typically one of the
+// WaitingReason constants above.
+func HaveContainerWaitingWithReason(containerName string, reasons ...string) types.GomegaMatcher {
+	return gcustom.MakeMatcher(func(pod *v1.Pod) (bool, error) {
+		for _, statuses := range [][]v1.ContainerStatus{pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses, pod.Status.EphemeralContainerStatuses} {
+			for _, cs := range statuses {
+				if cs.Name != containerName {
+					continue
+				}
+				if cs.State.Waiting == nil {
+					return false, nil
+				}
+				for _, reason := range reasons {
+					if cs.State.Waiting.Reason == reason {
+						return true, nil
+					}
+				}
+				return false, nil
+			}
+		}
+		return false, nil
+	}).WithTemplate("Expected container {{.Data.container}} {{.To}} be waiting with one of the reasons {{.Data.reasons}}\nGot instead:\n{{.FormattedActual}}").WithTemplateData(map[string]interface{}{
+		"container": containerName,
+		"reasons":   reasons,
+	})
+}
+
+// WaitForContainerWaitingReason waits for the given Pod container to be in
+// the Waiting state for one of the given reasons. It replaces hand-rolled
+// polling loops comparing status.State.Waiting.Reason against the canonical
+// kubelet reason strings.
+func WaitForContainerWaitingReason(ctx context.Context, c clientset.Interface, pod *v1.Pod, containerName string, timeout time.Duration, reasons ...string) error {
+	conditionDesc := fmt.Sprintf("container %s waiting with one of the reasons %v", containerName, reasons)
+	matcher := HaveContainerWaitingWithReason(containerName, reasons...)
+	return WaitForPodCondition(ctx, c, pod.Namespace, pod.Name, conditionDesc, timeout, func(pod *v1.Pod) (bool, error) {
+		return matcher.Match(pod)
+	})
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"k8s.io/kubectl/pkg/util/podutils"
+
+	"github.com/onsi/ginkgo/v2"
+
+	"k8s.io/kubernetes/test/e2e/framework"
+)
+
+// statusTransition is one observed change of a pod's status. Failed
+// watch-based waits report these so the exact time of every transition is
+// available for debugging, which polling loops cannot provide.
+type statusTransition struct {
+	when    time.Time
+	event   watch.EventType
+	summary string
+}
+
+func (t statusTransition) String() string {
+	return fmt.Sprintf("%s %s %s", t.when.Format(time.RFC3339Nano), t.event, t.summary)
+}
+
+// WaitForPodConditionWatch waits up to timeout for the named pod to satisfy
+// condition, observing the pod through a watch instead of polling its status
+// on an interval: the condition is re-evaluated the moment the pod changes,
+// so the wait returns as soon as the condition holds. conditionDesc describes
+// the condition for error messages. On failure the observed status
+// transitions are logged with their timestamps and the last one is included
+// in the returned error. It returns the pod as last observed.
+func WaitForPodConditionWatch(ctx context.Context, c clientset.Interface, ns, podName, conditionDesc string, timeout time.Duration, condition func(pod *v1.Pod) (bool, error)) (*v1.Pod, error) {
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", podName).String()
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(ctx, timeout)
+	defer cancel()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return c.CoreV1().Pods(ns).List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return c.CoreV1().Pods(ns).Watch(ctx, options)
+		},
+	}
+	var lastPod *v1.Pod
+	var transitions []statusTransition
+	_, err := watchtools.UntilWithSync(ctx, lw, &v1.Pod{}, nil, func(event watch.Event) (bool, error) {
+		if event.Type == watch.Deleted {
+			return false, apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, podName)
+		}
+		pod, ok := event.Object.(*v1.Pod)
+		if !ok {
+			return false, fmt.Errorf("unexpected object type %T while watching pod %q", event.Object, podName)
+		}
+		lastPod = pod
+		if summary := podStatusSummary(pod); len(transitions) == 0 || transitions[len(transitions)-1].summary != summary {
+			transitions = append(transitions, statusTransition{when: time.Now(), event: event.Type, summary: summary})
+		}
+		return condition(pod)
+	})
+	if err != nil {
+		framework.Logf("Pod %q did not become %s, observed status transitions:", podName, conditionDesc)
+		for _, transition := range transitions {
+			framework.Logf("  %s", transition)
+		}
+		if len(transitions) == 0 {
+			return lastPod, fmt.Errorf("waiting for pod %q in namespace %q to be %s (pod never observed): %w", podName, ns, conditionDesc, err)
+		}
+		last := transitions[len(transitions)-1]
+		return lastPod, fmt.Errorf("waiting for pod %q in namespace %q to be %s (last status since %s: %s): %w",
+			podName, ns, conditionDesc, last.when.Format(time.RFC3339Nano), last.summary, err)
+	}
+	return lastPod, nil
+}
+
+// podStatusSummary condenses a pod's status into one line: phase, readiness
+// and the state of each container. Transitions are recorded whenever the
+// summary changes.
+func podStatusSummary(pod *v1.Pod) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "phase=%s", pod.Status.Phase)
+	if pod.Status.Reason != "" {
+		fmt.Fprintf(&b, " reason=%s", pod.Status.Reason)
+	}
+	fmt.Fprintf(&b, " ready=%t", podutils.IsPodReady(pod))
+	for _, status := range pod.Status.ContainerStatuses {
+		fmt.Fprintf(&b, " %s=%s", status.Name, containerStateSummary(status.State))
+	}
+	return b.String()
+}
+
+func containerStateSummary(state v1.ContainerState) string {
+	switch {
+	case state.Waiting != nil:
+		return fmt.Sprintf("Waiting(%s)", state.Waiting.Reason)
+	case state.Running != nil:
+		return "Running"
+	case state.Terminated != nil:
+		return fmt.Sprintf("Terminated(%s,exit=%d)", state.Terminated.Reason, state.Terminated.ExitCode)
+	}
+	return "Unknown"
+}
+
+// WaitForConditionWatch is the PodClient form of WaitForPodConditionWatch,
+// waiting for the named pod in the client's namespace.
+func (c *PodClient) WaitForConditionWatch(ctx context.Context, name, conditionDesc string, timeout time.Duration, condition func(pod *v1.Pod) (bool, error)) (*v1.Pod, error) {
+	return WaitForPodConditionWatch(ctx, c.f.ClientSet, c.namespace, name, conditionDesc, timeout, condition)
+}
+
+// WaitForRunningAndReadyWatch waits for the pod to be running and ready,
+// failing the test if it reaches a terminal phase or the timeout instead.
+func (c *PodClient) WaitForRunningAndReadyWatch(ctx context.Context, name string, timeout time.Duration) *v1.Pod {
+	ginkgo.GinkgoHelper()
+	pod, err := c.WaitForConditionWatch(ctx, name, "running and ready", timeout, func(pod *v1.Pod) (bool, error) {
+		switch pod.Status.Phase {
+		case v1.PodFailed, v1.PodSucceeded:
+			return false, fmt.Errorf("pod %q reached terminal phase %s while waiting for it to be running and ready", name, pod.Status.Phase)
+		}
+		return podutils.IsPodReady(pod), nil
+	})
+	framework.ExpectNoError(err, "wait for pod %q to be running and ready", name)
+	return pod
+}
+
+// WaitForSuccessWatch is the watch-based equivalent of WaitForSuccess: it
+// waits for the pod to succeed and fails the test if the pod fails instead.
+func (c *PodClient) WaitForSuccessWatch(ctx context.Context, name string, timeout time.Duration) {
+	ginkgo.GinkgoHelper()
+	_, err := c.WaitForConditionWatch(ctx, name, string(v1.PodSucceeded), timeout, func(pod *v1.Pod) (bool, error) {
+		switch pod.Status.Phase {
+		case v1.PodFailed:
+			return false, fmt.Errorf("pod %q failed with reason: %q, message: %q", name, pod.Status.Reason, pod.Status.Message)
+		case v1.PodSucceeded:
+			return true, nil
+		default:
+			return false, nil
+		}
+	})
+	framework.ExpectNoError(err, "wait for pod %q to succeed", name)
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForPodConditionWatch(t *testing.T) {
+	const namespace = "test-ns"
+
+	newPod := func(phase v1.PodPhase) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "watched-pod",
+				Namespace: namespace,
+			},
+			Status: v1.PodStatus{Phase: phase},
+		}
+	}
+	isRunning := func(pod *v1.Pod) (bool, error) {
+		return pod.Status.Phase == v1.PodRunning, nil
+	}
+
+	t.Run("condition already met", func(t *testing.T) {
+		client := fake.NewSimpleClientset(newPod(v1.PodRunning))
+		pod, err := WaitForPodConditionWatch(context.Background(), client, namespace, "watched-pod", "running", 5*time.Second, isRunning)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if pod.Status.Phase != v1.PodRunning {
+			t.Fatalf("expected the running pod to be returned, got phase %s", pod.Status.Phase)
+		}
+	})
+
+	t.Run("condition met after an update", func(t *testing.T) {
+		client := fake.NewSimpleClientset(newPod(v1.PodPending))
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			if _, err := client.CoreV1().Pods(namespace).UpdateStatus(context.Background(), newPod(v1.PodRunning), metav1.UpdateOptions{}); err != nil {
+				t.Errorf("updating the pod status: %v", err)
+			}
+		}()
+		if _, err := WaitForPodConditionWatch(context.Background(), client, namespace, "watched-pod", "running", 10*time.Second, isRunning); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("pod deletion surfaces as not found", func(t *testing.T) {
+		client := fake.NewSimpleClientset(newPod(v1.PodPending))
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			if err := client.CoreV1().Pods(namespace).Delete(context.Background(), "watched-pod", metav1.DeleteOptions{}); err != nil {
+				t.Errorf("deleting the pod: %v", err)
+			}
+		}()
+		_, err := WaitForPodConditionWatch(context.Background(), client, namespace, "watched-pod", "running", 10*time.Second, isRunning)
+		if !apierrors.IsNotFound(err) {
+			t.Fatalf("expected a not-found error, got: %v", err)
+		}
+	})
+
+	t.Run("timeout reports the last observed status", func(t *testing.T) {
+		client := fake.NewSimpleClientset(newPod(v1.PodPending))
+		_, err := WaitForPodConditionWatch(context.Background(), client, namespace, "watched-pod", "running", time.Second, isRunning)
+		if err == nil {
+			t.Fatal("expected a timeout error, got none")
+		}
+		for _, expected := range []string{"running", "phase=Pending"} {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected error to contain %q, got: %v", expected, err)
+			}
+		}
+	})
+
+	t.Run("condition error aborts the wait", func(t *testing.T) {
+		client := fake.NewSimpleClientset(newPod(v1.PodFailed))
+		_, err := WaitForPodConditionWatch(context.Background(), client, namespace, "watched-pod", "running", 5*time.Second, func(pod *v1.Pod) (bool, error) {
+			if pod.Status.Phase == v1.PodFailed {
+				return false, apierrors.NewBadRequest("pod failed")
+			}
+			return isRunning(pod)
+		})
+		if err == nil || !strings.Contains(err.Error(), "pod failed") {
+			t.Fatalf("expected the condition's error, got: %v", err)
+		}
+	})
+}
+
+func TestPodStatusSummary(t *testing.T) {
+	pod := &v1.Pod{
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionTrue},
+			},
+			ContainerStatuses: []v1.ContainerStatus{
+				{Name: "main", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+				{Name: "sidecar", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}},
+				{Name: "init", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Completed", ExitCode: 0}}},
+			},
+		},
+	}
+	expected := "phase=Running ready=true main=Running sidecar=Waiting(ImagePullBackOff) init=Terminated(Completed,exit=0)"
+	if summary := podStatusSummary(pod); summary != expected {
+		t.Errorf("expected summary %q, got %q", expected, summary)
+	}
+}
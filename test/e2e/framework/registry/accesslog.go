@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// logOptions selects the registry container when fetching the access log.
+var logOptions = v1.PodLogOptions{Container: "registry"}
+
+// AccessLogEntry describes one request the registry served. The proxy
+// sidecars (forward, fault and auth proxy) emit one JSON line per request;
+// credential material is reduced to header presence and the basic-auth
+// username so tests can assert credential scoping without ever logging
+// secrets. Entries parsed from the registry container's own distribution
+// log carry only the method and path; distribution never logs
+// authorization details.
+type AccessLogEntry struct {
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+	// Path is the request path, e.g. "/v2/pause/manifests/testing".
+	Path string `json:"path"`
+	// HasAuthorization records whether the request carried an
+	// Authorization header of any scheme.
+	HasAuthorization bool `json:"hasAuthorization"`
+	// BasicAuthUser is the username from a basic Authorization header, or
+	// empty when the request was anonymous or used another scheme.
+	BasicAuthUser string `json:"basicAuthUser"`
+}
+
+// distributionLogEntry is the shape of the logrus JSON records the stock
+// registry (distribution) container emits, e.g. its "response completed"
+// lines carrying http.request.* fields.
+type distributionLogEntry struct {
+	Method string `json:"http.request.method"`
+	URI    string `json:"http.request.uri"`
+}
+
+// ParseAccessLog parses a JSON-lines access log. It understands both the
+// schema the agnhost registry proxies emit and the logrus records of the
+// stock registry container; lines that are neither (startup output, text
+// logging) are skipped.
+func ParseAccessLog(data []byte) ([]AccessLogEntry, error) {
+	var entries []AccessLogEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := []byte(strings.TrimSpace(scanner.Text()))
+		if !bytes.HasPrefix(line, []byte("{")) {
+			continue
+		}
+		var entry AccessLogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		if entry.Method == "" || entry.Path == "" {
+			var record distributionLogEntry
+			if err := json.Unmarshal(line, &record); err != nil || record.Method == "" || record.URI == "" {
+				continue
+			}
+			entry = AccessLogEntry{
+				Method: record.Method,
+				// The URI may carry a query string, e.g. on tag listings.
+				Path: strings.SplitN(record.URI, "?", 2)[0],
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanning access log: %w", err)
+	}
+	return entries, nil
+}
+
+// AccessLog returns the requests the registry has served so far.
+func (r *Registry) AccessLog(ctx context.Context) ([]AccessLogEntry, error) {
+	data, err := r.client.CoreV1().Pods(r.Namespace).GetLogs(podName, &logOptions).Do(ctx).Raw()
+	if err != nil {
+		return nil, fmt.Errorf("reading registry access log: %w", err)
+	}
+	return ParseAccessLog(data)
+}
+
+// EntriesForPath filters access log entries down to those whose path
+// contains the given substring, typically an image repository name.
+func EntriesForPath(entries []AccessLogEntry, pathSubstring string) []AccessLogEntry {
+	var matched []AccessLogEntry
+	for _, entry := range entries {
+		if strings.Contains(entry.Path, pathSubstring) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
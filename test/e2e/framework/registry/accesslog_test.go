@@ -0,0 +1,63 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAccessLog(t *testing.T) {
+	log := []byte(`time="2024-01-01T00:00:00Z" level=info msg="listening on [::]:5000"
+{"method":"GET","path":"/v2/","hasAuthorization":false,"basicAuthUser":""}
+not a json line at all
+{"level":"debug","msg":"no method or path here"}
+{"method":"GET","path":"/v2/pause/manifests/testing","hasAuthorization":true,"basicAuthUser":"e2e-user"}
+{this is broken json
+{"go.version":"go1.20.8","http.request.host":"10.0.0.7:5000","http.request.id":"8b2fa2a5","http.request.method":"GET","http.request.remoteaddr":"10.0.0.1:53422","http.request.uri":"/v2/pause/tags/list?n=50","http.response.status":200,"level":"info","msg":"response completed","time":"2024-01-01T00:00:01Z"}
+{"method":"HEAD","path":"/v2/pause/blobs/sha256:abc","hasAuthorization":false,"basicAuthUser":""}
+`)
+
+	entries, err := ParseAccessLog(log)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []AccessLogEntry{
+		{Method: "GET", Path: "/v2/"},
+		{Method: "GET", Path: "/v2/pause/manifests/testing", HasAuthorization: true, BasicAuthUser: "e2e-user"},
+		{Method: "GET", Path: "/v2/pause/tags/list"},
+		{Method: "HEAD", Path: "/v2/pause/blobs/sha256:abc"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("unexpected entries:\ngot:  %+v\nwant: %+v", entries, want)
+	}
+}
+
+func TestEntriesForPath(t *testing.T) {
+	entries := []AccessLogEntry{
+		{Method: "GET", Path: "/v2/"},
+		{Method: "GET", Path: "/v2/pause/manifests/testing", HasAuthorization: true, BasicAuthUser: "e2e-user"},
+		{Method: "HEAD", Path: "/v2/other/blobs/sha256:abc"},
+	}
+	matched := EntriesForPath(entries, "/pause/")
+	if len(matched) != 1 || matched[0].Path != "/v2/pause/manifests/testing" {
+		t.Errorf("unexpected matches: %+v", matched)
+	}
+	if matched := EntriesForPath(entries, "/missing/"); len(matched) != 0 {
+		t.Errorf("expected no matches, got %+v", matched)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// dockerConfigJSON mirrors the subset of the dockerconfigjson format the
+// kubelet reads for registry credentials.
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigAuth `json:"auths"`
+}
+
+type dockerConfigAuth struct {
+	Auth string `json:"auth"`
+}
+
+// DockerConfigJSON returns a dockerconfigjson payload granting access to
+// the given registry host with the given basic-auth credentials.
+func DockerConfigJSON(host, username, password string) ([]byte, error) {
+	cfg := dockerConfigJSON{
+		Auths: map[string]dockerConfigAuth{
+			host: {
+				Auth: base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+			},
+		},
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling docker config: %w", err)
+	}
+	return data, nil
+}
+
+// WriteNodeDockerConfig writes a dockerconfigjson for the given registry
+// host to path on the node reached through access, so node components like
+// the kubelet and the container runtime can authenticate to the registry.
+func WriteNodeDockerConfig(ctx context.Context, access NodeAccess, path, host, username, password string) error {
+	data, err := DockerConfigJSON(host, username, password)
+	if err != nil {
+		return err
+	}
+	return access.WriteFile(ctx, path, data, 0o600)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// hostsByFamily maps each address family the pod network assigned to the
+// registry pod to a host:port endpoint built from the corresponding pod IP,
+// so the endpoints are reachable from nodes without kube-proxy. IPv6
+// addresses come out bracketed, so the endpoints can prefix image
+// references directly.
+func hostsByFamily(pod *v1.Pod) map[v1.IPFamily]string {
+	hosts := map[v1.IPFamily]string{}
+	for _, podIP := range pod.Status.PodIPs {
+		ip := net.ParseIP(podIP.IP)
+		if ip == nil {
+			continue
+		}
+		family := v1.IPv6Protocol
+		if ip.To4() != nil {
+			family = v1.IPv4Protocol
+		}
+		if _, ok := hosts[family]; !ok {
+			hosts[family] = net.JoinHostPort(podIP.IP, strconv.Itoa(registryPort))
+		}
+	}
+	return hosts
+}
+
+// HostForFamily returns the host:port under which the registry is reachable
+// over the given address family, built from the registry pod's IP of that
+// family. It fails when the pod network did not assign the family; callers
+// typically skip in that case.
+func (r *Registry) HostForFamily(family v1.IPFamily) (string, error) {
+	host, ok := r.hostsByFamily[family]
+	if !ok {
+		return "", fmt.Errorf("registry has no %s endpoint: the pod network did not assign a pod IP of that family", family)
+	}
+	return host, nil
+}
+
+// ImageRefForFamily returns the full reference for a preloaded image with the
+// registry addressed by its endpoint of the given address family, forcing
+// pulls over that family regardless of how the registry's DNS name resolves.
+func (r *Registry) ImageRefForFamily(family v1.IPFamily, targetRef string) (string, error) {
+	host, err := r.HostForFamily(family)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s", host, targetRef), nil
+}
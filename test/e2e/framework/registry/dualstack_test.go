@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestHostsByFamily(t *testing.T) {
+	pod := &v1.Pod{
+		Status: v1.PodStatus{
+			PodIPs: []v1.PodIP{{IP: "10.0.0.7"}, {IP: "fd00::7"}},
+		},
+	}
+	hosts := hostsByFamily(pod)
+	if hosts[v1.IPv4Protocol] != "10.0.0.7:5000" {
+		t.Errorf("unexpected IPv4 endpoint %q", hosts[v1.IPv4Protocol])
+	}
+	if hosts[v1.IPv6Protocol] != "[fd00::7]:5000" {
+		t.Errorf("unexpected IPv6 endpoint %q", hosts[v1.IPv6Protocol])
+	}
+}
+
+func TestImageRefForFamily(t *testing.T) {
+	r := &Registry{hostsByFamily: map[v1.IPFamily]string{
+		v1.IPv6Protocol: "[fd00::7]:5000",
+	}}
+	ref, err := r.ImageRefForFamily(v1.IPv6Protocol, "pause:testing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ref != "[fd00::7]:5000/pause:testing" {
+		t.Errorf("unexpected image reference %q", ref)
+	}
+	if _, err := r.ImageRefForFamily(v1.IPv4Protocol, "pause:testing"); err == nil || !strings.Contains(err.Error(), "no IPv4 endpoint") {
+		t.Errorf("expected a missing-family error, got %v", err)
+	}
+}
+
+func TestRegistryServiceDualStack(t *testing.T) {
+	svc := registryService("test-ns", Options{DualStack: true})
+	if svc.Spec.IPFamilyPolicy == nil || *svc.Spec.IPFamilyPolicy != v1.IPFamilyPolicyPreferDualStack {
+		t.Errorf("expected a prefer-dual-stack service, got %+v", svc.Spec.IPFamilyPolicy)
+	}
+	svc = registryService("test-ns", Options{})
+	if svc.Spec.IPFamilyPolicy != nil {
+		t.Errorf("expected no family policy by default, got %v", *svc.Spec.IPFamilyPolicy)
+	}
+}
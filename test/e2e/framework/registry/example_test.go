@@ -0,0 +1,47 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry_test
+
+import (
+	"context"
+
+	clientset "k8s.io/client-go/kubernetes"
+	e2eregistry "k8s.io/kubernetes/test/e2e/framework/registry"
+)
+
+// Example demonstrates how an out-of-tree suite deploys a registry with its
+// own preloaded content and hands the resulting image references to tests.
+func Example() {
+	var client clientset.Interface // provided by the suite's framework
+	ctx := context.Background()
+
+	registry, err := e2eregistry.SetupRegistry(ctx, client, "test-namespace", e2eregistry.Options{
+		PreloadSpecs: []e2eregistry.PreloadSpec{
+			{SourceImage: "registry.k8s.io/pause:3.9", TargetRef: "pause:testing"},
+			{Generate: &e2eregistry.GenerateOptions{Layers: 4, LayerSizeBytes: 64 << 20}, TargetRef: "large/image:v1"},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = registry.Teardown(ctx)
+	}()
+
+	// Tests reference preloaded content through the handle.
+	_ = registry.ImageRef("large/image:v1")
+}
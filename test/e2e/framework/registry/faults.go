@@ -0,0 +1,198 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+)
+
+const (
+	// faultProxyPort is the port the fault proxy listens on inside the pod.
+	faultProxyPort = 5001
+
+	faultProxyPodName     = "e2e-registry-faults"
+	faultProxyServiceName = "e2e-registry-faults"
+)
+
+// FaultOptions configures the fault-injecting reverse proxy that SetupRegistry
+// stands up in front of the registry when Options.Faults is set. It lets tests
+// drive ErrImagePull and ImagePullBackOff paths deterministically instead of
+// waiting for a real registry to misbehave.
+//
+// Faults are applied in order: the first DropRequests matching requests are
+// dropped, the next FailCount are answered with FailStatus, and everything
+// after that is proxied through, subject to bandwidth throttling and blob
+// corruption.
+type FaultOptions struct {
+	// DropRequests drops the first N matching requests by closing the
+	// connection without a response.
+	DropRequests int
+
+	// FailStatus, when non-zero, is the HTTP status code (e.g. 401, 429 or
+	// 500) returned for FailCount matching requests after the dropped ones.
+	FailStatus int
+	// FailCount is how many requests receive FailStatus. Required when
+	// FailStatus is set.
+	FailCount int
+
+	// BandwidthBytesPerSec throttles response bodies to the given rate.
+	// Zero means unthrottled.
+	BandwidthBytesPerSec int64
+
+	// CorruptBlobs flips a byte in every blob response body, so the pull
+	// fails digest verification after the transfer completes.
+	CorruptBlobs bool
+
+	// RateLimitAfter, when positive, lets the first N matching requests
+	// through and answers every later one with HTTP 429, simulating a
+	// registry pull rate limit.
+	RateLimitAfter int
+	// RetryAfterSeconds is the Retry-After header value sent with the 429
+	// responses. Zero omits the header. Requires RateLimitAfter.
+	RetryAfterSeconds int
+
+	// PathPrefix restricts all faults to requests whose path starts with
+	// the given prefix, e.g. "/v2/pause/" to fault one repository. Empty
+	// matches every request. Requests outside the prefix are proxied
+	// through untouched.
+	PathPrefix string
+}
+
+// validateFaultOptions rejects configurations the fault proxy cannot serve.
+func validateFaultOptions(opts *FaultOptions) error {
+	if opts.DropRequests < 0 {
+		return fmt.Errorf("DropRequests must not be negative, got %d", opts.DropRequests)
+	}
+	if opts.FailStatus != 0 && (opts.FailStatus < 400 || opts.FailStatus > 599) {
+		return fmt.Errorf("FailStatus must be a 4xx or 5xx status code, got %d", opts.FailStatus)
+	}
+	if opts.FailStatus != 0 && opts.FailCount <= 0 {
+		return fmt.Errorf("FailCount must be positive when FailStatus is set")
+	}
+	if opts.FailStatus == 0 && opts.FailCount != 0 {
+		return fmt.Errorf("FailCount requires FailStatus")
+	}
+	if opts.BandwidthBytesPerSec < 0 {
+		return fmt.Errorf("BandwidthBytesPerSec must not be negative, got %d", opts.BandwidthBytesPerSec)
+	}
+	if opts.RateLimitAfter < 0 {
+		return fmt.Errorf("RateLimitAfter must not be negative, got %d", opts.RateLimitAfter)
+	}
+	if opts.RetryAfterSeconds < 0 {
+		return fmt.Errorf("RetryAfterSeconds must not be negative, got %d", opts.RetryAfterSeconds)
+	}
+	if opts.RetryAfterSeconds > 0 && opts.RateLimitAfter == 0 {
+		return fmt.Errorf("RetryAfterSeconds requires RateLimitAfter")
+	}
+	if opts.DropRequests == 0 && opts.FailStatus == 0 && opts.BandwidthBytesPerSec == 0 && !opts.CorruptBlobs && opts.RateLimitAfter == 0 {
+		return fmt.Errorf("at least one fault must be configured")
+	}
+	return nil
+}
+
+// faultProxyEnv renders the proxy container's environment from the options.
+// The upstream is the registry's in-pod listener, so the proxy and registry
+// must run in the same pod or the upstream must be a reachable URL.
+func faultProxyEnv(opts *FaultOptions, upstreamURL string) []v1.EnvVar {
+	env := []v1.EnvVar{
+		{Name: "FAULT_UPSTREAM_URL", Value: upstreamURL},
+	}
+	if opts.DropRequests > 0 {
+		env = append(env, v1.EnvVar{Name: "FAULT_DROP_REQUESTS", Value: strconv.Itoa(opts.DropRequests)})
+	}
+	if opts.FailStatus != 0 {
+		env = append(env,
+			v1.EnvVar{Name: "FAULT_FAIL_STATUS", Value: strconv.Itoa(opts.FailStatus)},
+			v1.EnvVar{Name: "FAULT_FAIL_COUNT", Value: strconv.Itoa(opts.FailCount)},
+		)
+	}
+	if opts.BandwidthBytesPerSec > 0 {
+		env = append(env, v1.EnvVar{Name: "FAULT_BANDWIDTH_BYTES_PER_SEC", Value: strconv.FormatInt(opts.BandwidthBytesPerSec, 10)})
+	}
+	if opts.CorruptBlobs {
+		env = append(env, v1.EnvVar{Name: "FAULT_CORRUPT_BLOBS", Value: "true"})
+	}
+	if opts.RateLimitAfter > 0 {
+		env = append(env, v1.EnvVar{Name: "FAULT_RATE_LIMIT_AFTER", Value: strconv.Itoa(opts.RateLimitAfter)})
+		if opts.RetryAfterSeconds > 0 {
+			env = append(env, v1.EnvVar{Name: "FAULT_RETRY_AFTER_SECONDS", Value: strconv.Itoa(opts.RetryAfterSeconds)})
+		}
+	}
+	if opts.PathPrefix != "" {
+		env = append(env, v1.EnvVar{Name: "FAULT_PATH_PREFIX", Value: opts.PathPrefix})
+	}
+	return env
+}
+
+// faultProxyContainer builds the proxy sidecar that runs next to the registry
+// container and fronts its in-pod listener. The proxy is the agnhost
+// registry-fault-proxy subcommand, which applies the faults configured
+// through its environment and emits the same JSON-lines access log format
+// as the other registry proxies.
+func faultProxyContainer(opts *FaultOptions) v1.Container {
+	return v1.Container{
+		Name:  "fault-proxy",
+		Image: imageutils.GetE2EImage(imageutils.Agnhost),
+		Args:  []string{"registry-fault-proxy", "--port", strconv.Itoa(faultProxyPort)},
+		Ports: []v1.ContainerPort{
+			{ContainerPort: faultProxyPort},
+		},
+		Env: faultProxyEnv(opts, fmt.Sprintf("http://127.0.0.1:%d", registryPort)),
+	}
+}
+
+// FaultLog returns the requests the fault proxy has handled so far,
+// including dropped and rejected ones that never reached the registry's own
+// access log. It returns an error when no fault proxy was configured.
+func (r *Registry) FaultLog(ctx context.Context) ([]AccessLogEntry, error) {
+	if !r.faultProxy {
+		return nil, fmt.Errorf("no fault proxy configured for this registry")
+	}
+	opts := v1.PodLogOptions{Container: "fault-proxy"}
+	data, err := r.client.CoreV1().Pods(r.Namespace).GetLogs(podName, &opts).Do(ctx).Raw()
+	if err != nil {
+		return nil, fmt.Errorf("reading fault proxy access log: %w", err)
+	}
+	return ParseAccessLog(data)
+}
+
+// faultProxyService exposes the fault proxy under its own name, so tests can
+// also reach the registry directly while pulls go through the proxy.
+func faultProxyService(namespace string) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      faultProxyServiceName,
+			Namespace: namespace,
+		},
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{"app": podName},
+			Ports: []v1.ServicePort{
+				{
+					Port:       faultProxyPort,
+					TargetPort: intstr.FromInt32(faultProxyPort),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateFaultOptions(t *testing.T) {
+	testCases := []struct {
+		name        string
+		opts        FaultOptions
+		expectError string
+	}{
+		{
+			name: "drop requests",
+			opts: FaultOptions{DropRequests: 3},
+		},
+		{
+			name: "fail with status",
+			opts: FaultOptions{FailStatus: 429, FailCount: 5},
+		},
+		{
+			name: "throttle and corrupt",
+			opts: FaultOptions{BandwidthBytesPerSec: 1024, CorruptBlobs: true},
+		},
+		{
+			name:        "no fault configured",
+			opts:        FaultOptions{PathPrefix: "/v2/pause/"},
+			expectError: "at least one fault",
+		},
+		{
+			name:        "negative drop count",
+			opts:        FaultOptions{DropRequests: -1},
+			expectError: "DropRequests must not be negative",
+		},
+		{
+			name:        "success status code",
+			opts:        FaultOptions{FailStatus: 200, FailCount: 1},
+			expectError: "4xx or 5xx",
+		},
+		{
+			name:        "fail status without count",
+			opts:        FaultOptions{FailStatus: 500},
+			expectError: "FailCount must be positive",
+		},
+		{
+			name:        "fail count without status",
+			opts:        FaultOptions{FailCount: 2},
+			expectError: "FailCount requires FailStatus",
+		},
+		{
+			name:        "negative bandwidth",
+			opts:        FaultOptions{BandwidthBytesPerSec: -1},
+			expectError: "BandwidthBytesPerSec must not be negative",
+		},
+		{
+			name: "rate limit with retry-after",
+			opts: FaultOptions{RateLimitAfter: 10, RetryAfterSeconds: 60},
+		},
+		{
+			name:        "negative rate limit threshold",
+			opts:        FaultOptions{RateLimitAfter: -1},
+			expectError: "RateLimitAfter must not be negative",
+		},
+		{
+			name:        "retry-after without rate limit",
+			opts:        FaultOptions{RetryAfterSeconds: 30},
+			expectError: "RetryAfterSeconds requires RateLimitAfter",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateFaultOptions(&tc.opts)
+			if tc.expectError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+func TestFaultProxyEnv(t *testing.T) {
+	opts := FaultOptions{
+		DropRequests:         2,
+		FailStatus:           500,
+		FailCount:            3,
+		BandwidthBytesPerSec: 4096,
+		CorruptBlobs:         true,
+		RateLimitAfter:       10,
+		RetryAfterSeconds:    60,
+		PathPrefix:           "/v2/pause/",
+	}
+	env := map[string]string{}
+	for _, e := range faultProxyEnv(&opts, "http://127.0.0.1:5000") {
+		env[e.Name] = e.Value
+	}
+	expected := map[string]string{
+		"FAULT_UPSTREAM_URL":            "http://127.0.0.1:5000",
+		"FAULT_DROP_REQUESTS":           "2",
+		"FAULT_FAIL_STATUS":             "500",
+		"FAULT_FAIL_COUNT":              "3",
+		"FAULT_BANDWIDTH_BYTES_PER_SEC": "4096",
+		"FAULT_CORRUPT_BLOBS":           "true",
+		"FAULT_RATE_LIMIT_AFTER":        "10",
+		"FAULT_RETRY_AFTER_SECONDS":     "60",
+		"FAULT_PATH_PREFIX":             "/v2/pause/",
+	}
+	for name, value := range expected {
+		if env[name] != value {
+			t.Errorf("expected %s=%q, got %q", name, value, env[name])
+		}
+	}
+	if len(env) != len(expected) {
+		t.Errorf("unexpected extra environment variables: %v", env)
+	}
+
+	// Unset faults must not leak environment variables: the proxy applies
+	// every fault it finds configured.
+	minimal := faultProxyEnv(&FaultOptions{DropRequests: 1}, "http://127.0.0.1:5000")
+	if len(minimal) != 2 {
+		t.Errorf("expected only the upstream and drop variables, got %v", minimal)
+	}
+}
+
+func TestRegistryPodWithFaults(t *testing.T) {
+	opts := Options{Faults: &FaultOptions{DropRequests: 1}}
+	pod := registryPod("test-ns", DefaultPreloadSpecs(), opts)
+	var found bool
+	for _, c := range pod.Spec.Containers {
+		if c.Name == "fault-proxy" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a fault-proxy container alongside the registry, got %v", pod.Spec.Containers)
+	}
+}
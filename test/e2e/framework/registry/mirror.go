@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// defaultMirrorUpstream is the registry mirrored when MirrorOptions
+	// does not name one. Almost all test images live there, so mirroring it
+	// is what air-gapped labs need most.
+	defaultMirrorUpstream = "https://registry.k8s.io"
+
+	// containerdCertsDir is the directory containerd reads per-host
+	// registry configuration from (the config_path of its CRI registry
+	// config).
+	containerdCertsDir = "/etc/containerd/certs.d"
+
+	// nodeCAFileName and nodeHostsFileName are the file names the node
+	// helpers place under containerdCertsDir/<host>/.
+	nodeCAFileName    = "ca.crt"
+	nodeHostsFileName = "hosts.toml"
+)
+
+// MirrorOptions runs the registry as a pull-through cache instead of a
+// standalone registry: requests for content that is not already cached are
+// forwarded to the upstream registry and the response is cached locally.
+// Combined with PreloadSpecs for the images the lab cannot reach upstream,
+// this lets node suites run fully air-gapped. Note that the registry
+// rejects pushes while mirroring; preloaded content is unaffected because
+// it is pushed before the registry starts serving.
+type MirrorOptions struct {
+	// UpstreamURL is the URL of the registry to mirror. Defaults to
+	// https://registry.k8s.io.
+	UpstreamURL string
+}
+
+// validateMirrorOptions checks that the upstream URL, when set, is a plain
+// http(s) base URL.
+func validateMirrorOptions(opts *MirrorOptions) error {
+	if opts.UpstreamURL == "" {
+		return nil
+	}
+	u, err := url.Parse(opts.UpstreamURL)
+	if err != nil {
+		return fmt.Errorf("invalid upstream URL %q: %w", opts.UpstreamURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("upstream URL %q must use http or https", opts.UpstreamURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("upstream URL %q must include a host", opts.UpstreamURL)
+	}
+	return nil
+}
+
+// upstreamURL returns the configured upstream, defaulted.
+func (m *MirrorOptions) upstreamURL() string {
+	if m.UpstreamURL == "" {
+		return defaultMirrorUpstream
+	}
+	return m.UpstreamURL
+}
+
+// UpstreamHost returns the host of the mirrored registry, i.e. the host
+// image references use on nodes that pull through the mirror.
+func (m *MirrorOptions) UpstreamHost() string {
+	u, err := url.Parse(m.upstreamURL())
+	if err != nil {
+		// validateMirrorOptions rejected this during setup.
+		return ""
+	}
+	return u.Host
+}
+
+// TLSOptions serves the registry over HTTPS with a freshly generated CA and
+// serving certificate. The CA is available from Registry.CACertificate so
+// it can be installed into the node's container runtime, e.g. with
+// InstallNodeMirror.
+type TLSOptions struct {
+	// ExtraDNSNames are additional subject alternative names for the
+	// serving certificate, beyond the registry service's cluster DNS
+	// names. Needed when nodes reach the registry under a name of their
+	// own, e.g. through a host alias.
+	ExtraDNSNames []string
+}
+
+// CACertificate returns the PEM-encoded CA certificate the registry's
+// serving certificate chains to, or nil when the registry serves plain
+// HTTP.
+func (r *Registry) CACertificate() []byte {
+	return r.caCert
+}
+
+// InstallNodeMirror configures the node's containerd to pull images for the
+// given upstream host through the mirror at mirrorURL, by writing a
+// hosts.toml under containerd's certs.d directory. A non-nil caPEM is
+// installed next to it and referenced, so HTTPS mirrors with a generated CA
+// are trusted. Containerd picks the configuration up without a restart.
+// RemoveNodeMirror undoes the installation.
+func InstallNodeMirror(ctx context.Context, access NodeAccess, upstreamHost, mirrorURL string, caPEM []byte) error {
+	dir := containerdCertsDir + "/" + upstreamHost
+	caPath := ""
+	if caPEM != nil {
+		caPath = dir + "/" + nodeCAFileName
+		if err := access.WriteFile(ctx, caPath, caPEM, 0o644); err != nil {
+			return fmt.Errorf("installing mirror CA for %s: %w", upstreamHost, err)
+		}
+	}
+	hosts := hostsTOML(upstreamHost, mirrorURL, caPath)
+	if err := access.WriteFile(ctx, dir+"/"+nodeHostsFileName, []byte(hosts), 0o644); err != nil {
+		return fmt.Errorf("installing mirror configuration for %s: %w", upstreamHost, err)
+	}
+	return nil
+}
+
+// RemoveNodeMirror removes the containerd mirror configuration installed by
+// InstallNodeMirror. It is safe to call even if installation only partially
+// succeeded.
+func RemoveNodeMirror(ctx context.Context, access NodeAccess, upstreamHost string) error {
+	dir := containerdCertsDir + "/" + upstreamHost
+	hostsErr := access.RemoveFile(ctx, dir+"/"+nodeHostsFileName)
+	caErr := access.RemoveFile(ctx, dir+"/"+nodeCAFileName)
+	if hostsErr != nil {
+		return hostsErr
+	}
+	return caErr
+}
+
+// InstallNodeRegistryConfig configures the node's containerd to pull
+// directly from the registry endpoint host (a host:port), by writing a
+// hosts.toml under containerd's certs.d directory. With a nil caPEM the
+// endpoint is declared as plain HTTP, which containerd otherwise refuses
+// for unknown registries; a non-nil caPEM declares an HTTPS endpoint and is
+// installed next to the hosts.toml and referenced. Containerd picks the
+// configuration up without a restart. RemoveNodeRegistryConfig undoes the
+// installation. SetupRegistry installs this configuration itself for the
+// endpoints it hands out; the helper is exported for suites that expose a
+// registry under an endpoint of their own.
+func InstallNodeRegistryConfig(ctx context.Context, access NodeAccess, host string, caPEM []byte) error {
+	dir := containerdCertsDir + "/" + host
+	scheme := "http"
+	caPath := ""
+	if caPEM != nil {
+		scheme = "https"
+		caPath = dir + "/" + nodeCAFileName
+		if err := access.WriteFile(ctx, caPath, caPEM, 0o644); err != nil {
+			return fmt.Errorf("installing registry CA for %s: %w", host, err)
+		}
+	}
+	hosts := registryHostsTOML(scheme, host, caPath)
+	if err := access.WriteFile(ctx, dir+"/"+nodeHostsFileName, []byte(hosts), 0o644); err != nil {
+		return fmt.Errorf("installing registry configuration for %s: %w", host, err)
+	}
+	return nil
+}
+
+// RemoveNodeRegistryConfig removes the containerd configuration installed
+// by InstallNodeRegistryConfig. It is safe to call even if installation
+// only partially succeeded.
+func RemoveNodeRegistryConfig(ctx context.Context, access NodeAccess, host string) error {
+	dir := containerdCertsDir + "/" + host
+	hostsErr := access.RemoveFile(ctx, dir+"/"+nodeHostsFileName)
+	caErr := access.RemoveFile(ctx, dir+"/"+nodeCAFileName)
+	if hostsErr != nil {
+		return hostsErr
+	}
+	return caErr
+}
+
+// registryHostsTOML renders the containerd hosts.toml that lets pulls reach
+// the registry endpoint itself over the given scheme. caPath, when
+// non-empty, is the node path of the CA the endpoint's certificate chains
+// to.
+func registryHostsTOML(scheme, host, caPath string) string {
+	endpoint := scheme + "://" + host
+	var b strings.Builder
+	fmt.Fprintf(&b, "server = %q\n\n", endpoint)
+	fmt.Fprintf(&b, "[host.%q]\n", endpoint)
+	fmt.Fprintf(&b, "  capabilities = [\"pull\", \"resolve\"]\n")
+	if caPath != "" {
+		fmt.Fprintf(&b, "  ca = %q\n", caPath)
+	}
+	return b.String()
+}
+
+// hostsTOML renders the containerd hosts.toml that routes pulls for
+// upstreamHost through the mirror. caPath, when non-empty, is the node path
+// of the CA the mirror's certificate chains to.
+func hostsTOML(upstreamHost, mirrorURL, caPath string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "server = %q\n\n", "https://"+upstreamHost)
+	fmt.Fprintf(&b, "[host.%q]\n", mirrorURL)
+	fmt.Fprintf(&b, "  capabilities = [\"pull\", \"resolve\"]\n")
+	if caPath != "" {
+		fmt.Fprintf(&b, "  ca = %q\n", caPath)
+	}
+	return b.String()
+}
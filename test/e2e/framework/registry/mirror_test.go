@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestValidateMirrorOptions(t *testing.T) {
+	testCases := []struct {
+		name        string
+		opts        MirrorOptions
+		expectError string
+	}{
+		{
+			name: "default upstream",
+			opts: MirrorOptions{},
+		},
+		{
+			name: "explicit upstream",
+			opts: MirrorOptions{UpstreamURL: "https://mirror.example:5000"},
+		},
+		{
+			name: "plain http upstream",
+			opts: MirrorOptions{UpstreamURL: "http://mirror.example"},
+		},
+		{
+			name:        "missing scheme",
+			opts:        MirrorOptions{UpstreamURL: "mirror.example"},
+			expectError: "must use http or https",
+		},
+		{
+			name:        "missing host",
+			opts:        MirrorOptions{UpstreamURL: "https://"},
+			expectError: "must include a host",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateMirrorOptions(&tc.opts)
+			if tc.expectError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+func TestMirrorUpstreamHost(t *testing.T) {
+	for upstream, expected := range map[string]string{
+		"":                             "registry.k8s.io",
+		"https://mirror.example:5000":  "mirror.example:5000",
+		"http://registry.example/path": "registry.example",
+	} {
+		opts := MirrorOptions{UpstreamURL: upstream}
+		if got := opts.UpstreamHost(); got != expected {
+			t.Errorf("UpstreamHost with upstream %q: expected %q, got %q", upstream, expected, got)
+		}
+	}
+}
+
+func TestHostsTOML(t *testing.T) {
+	got := hostsTOML("registry.k8s.io", "https://e2e-registry.ns.svc:5000", "/etc/containerd/certs.d/registry.k8s.io/ca.crt")
+	expected := `server = "https://registry.k8s.io"
+
+[host."https://e2e-registry.ns.svc:5000"]
+  capabilities = ["pull", "resolve"]
+  ca = "/etc/containerd/certs.d/registry.k8s.io/ca.crt"
+`
+	if got != expected {
+		t.Errorf("unexpected hosts.toml:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	got = hostsTOML("registry.k8s.io", "http://e2e-registry.ns.svc:5000", "")
+	if strings.Contains(got, "ca =") {
+		t.Errorf("expected no ca entry without a CA path, got:\n%s", got)
+	}
+}
+
+func TestRegistryHostsTOML(t *testing.T) {
+	got := registryHostsTOML("https", "10.0.0.7:5000", "/etc/containerd/certs.d/10.0.0.7:5000/ca.crt")
+	expected := `server = "https://10.0.0.7:5000"
+
+[host."https://10.0.0.7:5000"]
+  capabilities = ["pull", "resolve"]
+  ca = "/etc/containerd/certs.d/10.0.0.7:5000/ca.crt"
+`
+	if got != expected {
+		t.Errorf("unexpected hosts.toml:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	got = registryHostsTOML("http", "10.0.0.7:5000", "")
+	if !strings.Contains(got, `[host."http://10.0.0.7:5000"]`) {
+		t.Errorf("expected a plain-HTTP host entry, got:\n%s", got)
+	}
+	if strings.Contains(got, "ca =") {
+		t.Errorf("expected no ca entry without a CA path, got:\n%s", got)
+	}
+}
+
+func TestTLSSecretFor(t *testing.T) {
+	secret, caPEM, err := tlsSecretFor("test-ns", []net.IP{net.ParseIP("10.96.0.7")}, &TLSOptions{ExtraDNSNames: []string{"node-local.example"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, key := range []string{tlsCertFileName, tlsKeyFileName} {
+		if len(secret.Data[key]) == 0 {
+			t.Errorf("expected secret to carry %s", key)
+		}
+	}
+
+	caBlock, _ := pem.Decode(caPEM)
+	if caBlock == nil {
+		t.Fatalf("CA is not valid PEM")
+	}
+	caCert, err := x509.ParseCertificate(caBlock.Bytes)
+	if err != nil {
+		t.Fatalf("parsing CA certificate: %v", err)
+	}
+	if !caCert.IsCA {
+		t.Errorf("expected the returned certificate to be a CA")
+	}
+
+	certBlock, _ := pem.Decode(secret.Data[tlsCertFileName])
+	if certBlock == nil {
+		t.Fatalf("serving certificate is not valid PEM")
+	}
+	servingCert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		t.Fatalf("parsing serving certificate: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	for _, name := range []string{"e2e-registry.test-ns.svc", "e2e-registry.test-ns.svc.cluster.local", "e2e-registry", "node-local.example"} {
+		if _, err := servingCert.Verify(x509.VerifyOptions{Roots: roots, DNSName: name}); err != nil {
+			t.Errorf("serving certificate does not verify for %q: %v", name, err)
+		}
+	}
+	if err := servingCert.VerifyHostname("10.96.0.7"); err != nil {
+		t.Errorf("serving certificate does not verify for the ClusterIP: %v", err)
+	}
+}
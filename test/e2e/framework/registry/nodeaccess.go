@@ -0,0 +1,136 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+)
+
+// NodeAccess abstracts file access on a node under test, so registry
+// helpers that need to place node-local configuration (credential files,
+// certificates) work both from node-local suites and from cluster-level
+// suites that can only reach nodes through pods. Out-of-tree providers can
+// supply their own implementation (e.g. SSH-based).
+type NodeAccess interface {
+	// WriteFile writes data to the given absolute path on the node,
+	// creating parent directories as needed.
+	WriteFile(ctx context.Context, path string, data []byte, mode os.FileMode) error
+	// RemoveFile removes the given path on the node. Removing a path that
+	// does not exist is not an error.
+	RemoveFile(ctx context.Context, path string) error
+}
+
+// LocalNodeAccess is a NodeAccess for suites that run directly on the node
+// under test, such as e2e_node.
+type LocalNodeAccess struct{}
+
+func (LocalNodeAccess) WriteFile(_ context.Context, path string, data []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, mode)
+}
+
+func (LocalNodeAccess) RemoveFile(_ context.Context, path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// podNodeAccess is a NodeAccess for cluster-level suites. Each operation
+// runs a short-lived privileged pod on the target node with the host
+// filesystem mounted.
+type podNodeAccess struct {
+	client    clientset.Interface
+	namespace string
+	nodeName  string
+}
+
+// NewPodNodeAccess returns a NodeAccess that reaches the named node's
+// filesystem through privileged pods created in the given namespace.
+func NewPodNodeAccess(client clientset.Interface, namespace, nodeName string) NodeAccess {
+	return &podNodeAccess{client: client, namespace: namespace, nodeName: nodeName}
+}
+
+func (p *podNodeAccess) WriteFile(ctx context.Context, path string, data []byte, mode os.FileMode) error {
+	script := fmt.Sprintf("mkdir -p $(dirname /host%[1]s) && printf '%%s' \"$FILE_CONTENT\" > /host%[1]s && chmod %#o /host%[1]s", path, mode.Perm())
+	return p.runOnNode(ctx, script, []v1.EnvVar{{Name: "FILE_CONTENT", Value: string(data)}})
+}
+
+func (p *podNodeAccess) RemoveFile(ctx context.Context, path string) error {
+	return p.runOnNode(ctx, fmt.Sprintf("rm -f /host%s", path), nil)
+}
+
+func (p *podNodeAccess) runOnNode(ctx context.Context, script string, env []v1.EnvVar) error {
+	privileged := true
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "registry-node-access-",
+			Namespace:    p.namespace,
+		},
+		Spec: v1.PodSpec{
+			NodeName:      p.nodeName,
+			RestartPolicy: v1.RestartPolicyNever,
+			Containers: []v1.Container{
+				{
+					Name:    "node-access",
+					Image:   imageutils.GetE2EImage(imageutils.BusyBox),
+					Command: []string{"sh", "-c", script},
+					Env:     env,
+					SecurityContext: &v1.SecurityContext{
+						Privileged: &privileged,
+					},
+					VolumeMounts: []v1.VolumeMount{
+						{Name: "host", MountPath: "/host"},
+					},
+				},
+			},
+			Volumes: []v1.Volume{
+				{
+					Name: "host",
+					VolumeSource: v1.VolumeSource{
+						HostPath: &v1.HostPathVolumeSource{Path: "/"},
+					},
+				},
+			},
+		},
+	}
+
+	pod, err := p.client.CoreV1().Pods(p.namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("creating node access pod: %w", err)
+	}
+	defer func() {
+		_ = p.client.CoreV1().Pods(p.namespace).Delete(context.Background(), pod.Name, metav1.DeleteOptions{})
+	}()
+
+	if err := e2epod.WaitForPodSuccessInNamespaceTimeout(ctx, p.client, pod.Name, p.namespace, 2*time.Minute); err != nil {
+		return fmt.Errorf("waiting for node access pod: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeNodeAccess records file operations in memory, standing in for a node
+// filesystem in interface-level tests.
+type fakeNodeAccess struct {
+	files map[string][]byte
+	modes map[string]os.FileMode
+}
+
+func newFakeNodeAccess() *fakeNodeAccess {
+	return &fakeNodeAccess{
+		files: map[string][]byte{},
+		modes: map[string]os.FileMode{},
+	}
+}
+
+func (f *fakeNodeAccess) WriteFile(_ context.Context, path string, data []byte, mode os.FileMode) error {
+	f.files[path] = data
+	f.modes[path] = mode
+	return nil
+}
+
+func (f *fakeNodeAccess) RemoveFile(_ context.Context, path string) error {
+	delete(f.files, path)
+	delete(f.modes, path)
+	return nil
+}
+
+func TestWriteNodeDockerConfig(t *testing.T) {
+	access := newFakeNodeAccess()
+	const path = "/var/lib/kubelet/config.json"
+
+	if err := WriteNodeDockerConfig(context.Background(), access, path, "registry.test:5000", "user", "pass"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, ok := access.files[path]
+	if !ok {
+		t.Fatalf("expected a file at %s, got %v", path, access.files)
+	}
+	if mode := access.modes[path]; mode != 0o600 {
+		t.Errorf("expected credential file mode 0600, got %#o", mode)
+	}
+
+	var cfg dockerConfigJSON
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("written credential file is not valid JSON: %v", err)
+	}
+	auth, ok := cfg.Auths["registry.test:5000"]
+	if !ok {
+		t.Fatalf("expected an auth entry for the registry host, got %v", cfg.Auths)
+	}
+	if want := base64.StdEncoding.EncodeToString([]byte("user:pass")); auth.Auth != want {
+		t.Errorf("expected auth %q, got %q", want, auth.Auth)
+	}
+
+	if err := access.RemoveFile(context.Background(), path); err != nil {
+		t.Fatalf("unexpected error removing file: %v", err)
+	}
+	if _, ok := access.files[path]; ok {
+		t.Errorf("expected file to be removed")
+	}
+}
+
+func TestLocalNodeAccess(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "config.json")
+	access := LocalNodeAccess{}
+
+	if err := access.WriteFile(context.Background(), path, []byte("content"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error reading back file: %v", err)
+	}
+	if string(data) != "content" {
+		t.Errorf("unexpected file content %q", data)
+	}
+
+	if err := access.RemoveFile(context.Background(), path); err != nil {
+		t.Fatalf("unexpected error removing file: %v", err)
+	}
+	if err := access.RemoveFile(context.Background(), path); err != nil {
+		t.Errorf("removing a missing file should not error, got %v", err)
+	}
+}
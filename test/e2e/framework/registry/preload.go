@@ -0,0 +1,284 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+)
+
+// PreloadSpec declares a single image or OCI artifact that must exist in
+// the registry before it starts serving. Exactly one of SourceImage,
+// Generate and Artifact must be set.
+type PreloadSpec struct {
+	// SourceImage is an existing image reference that is copied into the
+	// registry unchanged.
+	SourceImage string
+	// Generate, when set, synthesizes an image from random layer content
+	// instead of copying an existing one. Used by tests that need images
+	// of a specific shape (large layers, many layers) without hosting
+	// them anywhere.
+	Generate *GenerateOptions
+	// Artifact, when set, synthesizes an OCI artifact instead of a
+	// container image, so tests can exercise registry content that is not
+	// runnable.
+	Artifact *ArtifactOptions
+	// Platforms, when set, publishes the target as a manifest list holding
+	// exactly the listed platforms (e.g. "linux/amd64", "linux/arm64") of
+	// the source image, so tests can exercise the kubelet's platform
+	// resolution against a list of known shape. The source must itself be a
+	// multi-arch image covering every listed platform, and its manifest
+	// list media type (Docker schema 2 list or OCI index) is preserved.
+	// Only valid with SourceImage; when empty the source manifest is copied
+	// unchanged.
+	Platforms []string
+	// TargetRef is the repository and tag the image is published under,
+	// relative to the registry root, e.g. "pause:testing" or
+	// "nested/repo/app:v1". It must not include a registry host.
+	TargetRef string
+}
+
+// GenerateOptions describes a synthetic image for PreloadSpec.Generate.
+type GenerateOptions struct {
+	// LayerSizeBytes is the size of each generated layer. Defaults to 1 MiB.
+	LayerSizeBytes int64
+	// Layers is the number of layers to generate. Defaults to 1.
+	Layers int
+}
+
+// ArtifactOptions describes a synthetic OCI artifact for
+// PreloadSpec.Artifact. The artifact is assembled from generated payload
+// files and pushed under the declared artifact type. Artifacts share the
+// registry's authentication with images, so pulls of either exercise the
+// same credential paths. Note that mounting artifacts into pods requires an
+// image volume source in the core API; consumers in this tree pull artifacts
+// at the registry level instead.
+type ArtifactOptions struct {
+	// ArtifactType is the OCI artifactType recorded in the manifest, e.g.
+	// "application/vnd.example.model". Defaults to
+	// "application/vnd.test.artifact".
+	ArtifactType string
+	// Files maps payload file names to their size in bytes; each becomes
+	// one layer of random content. Defaults to a single 1 KiB file named
+	// "artifact.bin".
+	Files map[string]int64
+}
+
+// DefaultPreloadSpecs returns the preload set used when a suite does not
+// declare its own: the pause image published as pause:testing, matching
+// what the previously hardcoded registry content provided.
+func DefaultPreloadSpecs() []PreloadSpec {
+	return []PreloadSpec{
+		{
+			SourceImage: imageutils.GetPauseImageName(),
+			TargetRef:   "pause:testing",
+		},
+	}
+}
+
+// ValidatePreloadSpecs checks that every spec is well formed and that no
+// two specs publish the same target reference.
+func ValidatePreloadSpecs(specs []PreloadSpec) error {
+	seen := map[string]bool{}
+	for i, spec := range specs {
+		if err := validatePreloadSpec(spec); err != nil {
+			return fmt.Errorf("spec %d: %w", i, err)
+		}
+		if seen[spec.TargetRef] {
+			return fmt.Errorf("spec %d: duplicate target %q", i, spec.TargetRef)
+		}
+		seen[spec.TargetRef] = true
+	}
+	return nil
+}
+
+func validatePreloadSpec(spec PreloadSpec) error {
+	if spec.TargetRef == "" {
+		return fmt.Errorf("target reference must be set")
+	}
+	if strings.Contains(spec.TargetRef, "://") {
+		return fmt.Errorf("target %q must not include a scheme", spec.TargetRef)
+	}
+	if !strings.Contains(spec.TargetRef, ":") {
+		return fmt.Errorf("target %q must include a tag", spec.TargetRef)
+	}
+	sources := 0
+	if spec.SourceImage != "" {
+		sources++
+	}
+	if spec.Generate != nil {
+		sources++
+	}
+	if spec.Artifact != nil {
+		sources++
+	}
+	if sources != 1 {
+		return fmt.Errorf("exactly one of SourceImage, Generate and Artifact must be set")
+	}
+	if len(spec.Platforms) > 0 {
+		if spec.SourceImage == "" {
+			return fmt.Errorf("platforms can only be set together with SourceImage")
+		}
+		seen := map[string]bool{}
+		for _, platform := range spec.Platforms {
+			if !strings.Contains(platform, "/") {
+				return fmt.Errorf("platform %q must be of the form os/arch", platform)
+			}
+			if seen[platform] {
+				return fmt.Errorf("duplicate platform %q", platform)
+			}
+			seen[platform] = true
+		}
+	}
+	if spec.Generate != nil {
+		if spec.Generate.Layers < 0 {
+			return fmt.Errorf("generate layer count must not be negative")
+		}
+		if spec.Generate.LayerSizeBytes < 0 {
+			return fmt.Errorf("generate layer size must not be negative")
+		}
+	}
+	if spec.Artifact != nil {
+		if spec.Artifact.ArtifactType != "" && !strings.Contains(spec.Artifact.ArtifactType, "/") {
+			return fmt.Errorf("artifact type %q must be a media type", spec.Artifact.ArtifactType)
+		}
+		for name, size := range spec.Artifact.Files {
+			if name == "" {
+				return fmt.Errorf("artifact file name must not be empty")
+			}
+			if strings.Contains(name, "/") {
+				return fmt.Errorf("artifact file name %q must not contain a path separator", name)
+			}
+			if size < 0 {
+				return fmt.Errorf("artifact file %q size must not be negative", name)
+			}
+		}
+	}
+	return nil
+}
+
+// preloadInitContainer builds the init container that runs before the
+// registry container, serving the shared storage volume on localhost and
+// pushing every declared image into it.
+func preloadInitContainer(specs []PreloadSpec) v1.Container {
+	steps := []string{
+		// Any failed push must fail the init container, or the registry
+		// would start serving with images missing. The background serve
+		// line is unaffected by -e.
+		"set -e",
+		// Serve the shared storage volume so crane can push into it,
+		// then wait for the local registry to answer.
+		"registry serve /etc/docker/registry/config.yml &",
+		fmt.Sprintf("while ! wget -q -O /dev/null http://localhost:%d/v2/; do sleep 0.1; done", registryPort),
+	}
+	for _, spec := range specs {
+		steps = append(steps, preloadStep(spec))
+	}
+	return v1.Container{
+		Name:    "preload",
+		Image:   preloadImage,
+		Command: []string{"/bin/sh", "-c", strings.Join(steps, "\n")},
+		VolumeMounts: []v1.VolumeMount{
+			{Name: storageVolumeName, MountPath: storageMountPath},
+		},
+	}
+}
+
+// preloadStep returns the shell command that publishes one PreloadSpec to
+// the registry serving on localhost.
+func preloadStep(spec PreloadSpec) string {
+	target := fmt.Sprintf("localhost:%d/%s", registryPort, spec.TargetRef)
+	if spec.Generate != nil {
+		return generateImageCommand(spec.Generate, target)
+	}
+	if spec.Artifact != nil {
+		return generateArtifactCommand(spec.Artifact, target)
+	}
+	if len(spec.Platforms) > 0 {
+		return multiArchCopyCommand(spec.SourceImage, spec.Platforms, target)
+	}
+	return pushImageCommand(spec.SourceImage, target)
+}
+
+// pushImageCommand returns the shell command that copies an existing image
+// into the registry.
+func pushImageCommand(source, target string) string {
+	return fmt.Sprintf("crane copy %s %s", source, target)
+}
+
+// multiArchCopyCommand returns the shell command that publishes target as a
+// manifest list holding exactly the listed platforms of the source image.
+// The full source manifest is copied to a scratch tag first, then filtered
+// down to the requested platforms, which keeps the source's manifest list
+// media type intact.
+func multiArchCopyCommand(source string, platforms []string, target string) string {
+	scratch := target + "-all"
+	return strings.Join([]string{
+		fmt.Sprintf("crane copy %s %s", source, scratch),
+		fmt.Sprintf("crane index filter %s --platform %s -t %s", scratch, strings.Join(platforms, ","), target),
+	}, " && ")
+}
+
+// generateImageCommand returns the shell command that synthesizes an image
+// from random layer content and pushes it to the registry.
+func generateImageCommand(opts *GenerateOptions, target string) string {
+	layers := opts.Layers
+	if layers == 0 {
+		layers = 1
+	}
+	size := opts.LayerSizeBytes
+	if size == 0 {
+		size = 1 << 20
+	}
+	return strings.Join([]string{
+		fmt.Sprintf("for i in $(seq 1 %d); do head -c %d /dev/urandom > /tmp/layer-$i; done", layers, size),
+		fmt.Sprintf("crane append -t %s $(for i in $(seq 1 %d); do echo -f /tmp/layer-$i; done)", target, layers),
+	}, " && ")
+}
+
+// generateArtifactCommand returns the shell command that assembles an OCI
+// artifact from random payload files and pushes it with oras. It runs in a
+// subshell so the working directory change does not leak into later preload
+// steps.
+func generateArtifactCommand(opts *ArtifactOptions, target string) string {
+	artifactType := opts.ArtifactType
+	if artifactType == "" {
+		artifactType = "application/vnd.test.artifact"
+	}
+	files := opts.Files
+	if len(files) == 0 {
+		files = map[string]int64{"artifact.bin": 1 << 10}
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	steps := []string{
+		"mkdir -p /tmp/artifact",
+		"cd /tmp/artifact",
+	}
+	for _, name := range names {
+		steps = append(steps, fmt.Sprintf("head -c %d /dev/urandom > %s", files[name], name))
+	}
+	steps = append(steps, fmt.Sprintf("oras push --artifact-type %s %s %s", artifactType, target, strings.Join(names, " ")))
+	return "(" + strings.Join(steps, " && ") + ")"
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePreloadSpecs(t *testing.T) {
+	testCases := []struct {
+		name        string
+		specs       []PreloadSpec
+		expectError string
+	}{
+		{
+			name:  "default specs are valid",
+			specs: DefaultPreloadSpecs(),
+		},
+		{
+			name: "source image",
+			specs: []PreloadSpec{
+				{SourceImage: "registry.k8s.io/pause:3.9", TargetRef: "pause:testing"},
+			},
+		},
+		{
+			name: "generated image with nested repo",
+			specs: []PreloadSpec{
+				{Generate: &GenerateOptions{Layers: 3, LayerSizeBytes: 1 << 20}, TargetRef: "nested/repo/app:v1"},
+			},
+		},
+		{
+			name: "missing target",
+			specs: []PreloadSpec{
+				{SourceImage: "registry.k8s.io/pause:3.9"},
+			},
+			expectError: "target reference must be set",
+		},
+		{
+			name: "target with scheme",
+			specs: []PreloadSpec{
+				{SourceImage: "registry.k8s.io/pause:3.9", TargetRef: "https://example/pause:testing"},
+			},
+			expectError: "must not include a scheme",
+		},
+		{
+			name: "target without tag",
+			specs: []PreloadSpec{
+				{SourceImage: "registry.k8s.io/pause:3.9", TargetRef: "pause"},
+			},
+			expectError: "must include a tag",
+		},
+		{
+			name: "neither source nor generate",
+			specs: []PreloadSpec{
+				{TargetRef: "pause:testing"},
+			},
+			expectError: "exactly one of SourceImage, Generate and Artifact",
+		},
+		{
+			name: "both source and generate",
+			specs: []PreloadSpec{
+				{SourceImage: "registry.k8s.io/pause:3.9", Generate: &GenerateOptions{}, TargetRef: "pause:testing"},
+			},
+			expectError: "exactly one of SourceImage, Generate and Artifact",
+		},
+		{
+			name: "negative layer count",
+			specs: []PreloadSpec{
+				{Generate: &GenerateOptions{Layers: -1}, TargetRef: "big:v1"},
+			},
+			expectError: "layer count must not be negative",
+		},
+		{
+			name: "artifact",
+			specs: []PreloadSpec{
+				{Artifact: &ArtifactOptions{ArtifactType: "application/vnd.example.model", Files: map[string]int64{"weights.bin": 4096}}, TargetRef: "models/example:v1"},
+			},
+		},
+		{
+			name: "artifact type without slash",
+			specs: []PreloadSpec{
+				{Artifact: &ArtifactOptions{ArtifactType: "model"}, TargetRef: "models/example:v1"},
+			},
+			expectError: "must be a media type",
+		},
+		{
+			name: "artifact file with path separator",
+			specs: []PreloadSpec{
+				{Artifact: &ArtifactOptions{Files: map[string]int64{"dir/weights.bin": 1}}, TargetRef: "models/example:v1"},
+			},
+			expectError: "must not contain a path separator",
+		},
+		{
+			name: "negative artifact file size",
+			specs: []PreloadSpec{
+				{Artifact: &ArtifactOptions{Files: map[string]int64{"weights.bin": -1}}, TargetRef: "models/example:v1"},
+			},
+			expectError: "size must not be negative",
+		},
+		{
+			name: "multi-arch source",
+			specs: []PreloadSpec{
+				{SourceImage: "registry.k8s.io/pause:3.9", Platforms: []string{"linux/amd64", "linux/arm64"}, TargetRef: "pause:testing"},
+			},
+		},
+		{
+			name: "platforms without source image",
+			specs: []PreloadSpec{
+				{Generate: &GenerateOptions{}, Platforms: []string{"linux/amd64"}, TargetRef: "big:v1"},
+			},
+			expectError: "platforms can only be set together with SourceImage",
+		},
+		{
+			name: "platform without os",
+			specs: []PreloadSpec{
+				{SourceImage: "registry.k8s.io/pause:3.9", Platforms: []string{"amd64"}, TargetRef: "pause:testing"},
+			},
+			expectError: "must be of the form os/arch",
+		},
+		{
+			name: "duplicate platforms",
+			specs: []PreloadSpec{
+				{SourceImage: "registry.k8s.io/pause:3.9", Platforms: []string{"linux/amd64", "linux/amd64"}, TargetRef: "pause:testing"},
+			},
+			expectError: "duplicate platform",
+		},
+		{
+			name: "duplicate targets",
+			specs: []PreloadSpec{
+				{SourceImage: "registry.k8s.io/pause:3.9", TargetRef: "pause:testing"},
+				{Generate: &GenerateOptions{}, TargetRef: "pause:testing"},
+			},
+			expectError: "duplicate target",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidatePreloadSpecs(tc.specs)
+			if tc.expectError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error containing %q, got none", tc.expectError)
+			}
+			if !strings.Contains(err.Error(), tc.expectError) {
+				t.Fatalf("expected error containing %q, got %q", tc.expectError, err.Error())
+			}
+		})
+	}
+}
+
+func TestPreloadInitContainer(t *testing.T) {
+	specs := []PreloadSpec{
+		{SourceImage: "registry.k8s.io/pause:3.9", TargetRef: "pause:testing"},
+		{Generate: &GenerateOptions{Layers: 2, LayerSizeBytes: 4096}, TargetRef: "nested/repo/big:v1"},
+	}
+	container := preloadInitContainer(specs)
+
+	if len(container.Command) != 3 || container.Command[0] != "/bin/sh" {
+		t.Fatalf("expected a /bin/sh -c command, got %v", container.Command)
+	}
+	script := container.Command[2]
+
+	for _, want := range []string{
+		"set -e",
+		"registry serve",
+		"crane copy registry.k8s.io/pause:3.9 localhost:5000/pause:testing",
+		"head -c 4096 /dev/urandom",
+		"crane append -t localhost:5000/nested/repo/big:v1",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected init container script to contain %q, script:\n%s", want, script)
+		}
+	}
+
+	if len(container.VolumeMounts) != 1 || container.VolumeMounts[0].Name != storageVolumeName {
+		t.Errorf("expected init container to mount %s, got %v", storageVolumeName, container.VolumeMounts)
+	}
+}
+
+func TestMultiArchCopyCommand(t *testing.T) {
+	command := multiArchCopyCommand("registry.k8s.io/pause:3.9", []string{"linux/amd64", "linux/arm64"}, "localhost:5000/pause:testing")
+	expected := "crane copy registry.k8s.io/pause:3.9 localhost:5000/pause:testing-all && " +
+		"crane index filter localhost:5000/pause:testing-all --platform linux/amd64,linux/arm64 -t localhost:5000/pause:testing"
+	if command != expected {
+		t.Errorf("expected command %q, got %q", expected, command)
+	}
+}
+
+func TestGenerateArtifactCommand(t *testing.T) {
+	opts := &ArtifactOptions{
+		ArtifactType: "application/vnd.example.model",
+		Files:        map[string]int64{"weights.bin": 4096, "config.json": 128},
+	}
+	command := generateArtifactCommand(opts, "localhost:5000/models/example:v1")
+	// File names are sorted so the command is deterministic.
+	expected := "(mkdir -p /tmp/artifact && cd /tmp/artifact && head -c 128 /dev/urandom > config.json && head -c 4096 /dev/urandom > weights.bin && oras push --artifact-type application/vnd.example.model localhost:5000/models/example:v1 config.json weights.bin)"
+	if command != expected {
+		t.Errorf("expected command %q, got %q", expected, command)
+	}
+
+	defaulted := generateArtifactCommand(&ArtifactOptions{}, "localhost:5000/models/example:v1")
+	for _, want := range []string{
+		"--artifact-type application/vnd.test.artifact",
+		"head -c 1024 /dev/urandom > artifact.bin",
+	} {
+		if !strings.Contains(defaulted, want) {
+			t.Errorf("expected defaulted command to contain %q, got %q", want, defaulted)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientset "k8s.io/client-go/kubernetes"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+)
+
+const (
+	// proxyPort is the port the proxy listens on inside the pod.
+	proxyPort = 3128
+
+	proxyPodName     = "e2e-forward-proxy"
+	proxyServiceName = "e2e-forward-proxy"
+)
+
+// ProxyOptions configures the forward proxy deployed by SetupProxy.
+type ProxyOptions struct {
+	// Auth, when set, requires proxy authentication with the given
+	// basic-auth credentials.
+	Auth *BasicAuthOptions
+}
+
+// Proxy is a forward HTTP proxy running inside the cluster under test.
+// Tests use it to verify that node components honor HTTP(S)_PROXY and
+// NO_PROXY when pulling images.
+type Proxy struct {
+	host string
+	// Namespace is the namespace the proxy pod and service run in.
+	Namespace string
+	// Pod is the running proxy pod.
+	Pod *v1.Pod
+
+	auth   *BasicAuthOptions
+	client clientset.Interface
+}
+
+// SetupProxy deploys a forward proxy pod and service into the given
+// namespace and blocks until the proxy is running.
+func SetupProxy(ctx context.Context, c clientset.Interface, namespace string, opts ProxyOptions) (*Proxy, error) {
+	pod := proxyPod(namespace, opts.Auth)
+	pod, err := c.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("creating proxy pod: %w", err)
+	}
+	svc := proxyService(namespace)
+	if _, err := c.CoreV1().Services(namespace).Create(ctx, svc, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("creating proxy service: %w", err)
+	}
+
+	if err := e2epod.WaitTimeoutForPodRunningInNamespace(ctx, c, pod.Name, namespace, 5*time.Minute); err != nil {
+		return nil, fmt.Errorf("waiting for proxy pod to run: %w", err)
+	}
+	pod, err = c.CoreV1().Pods(namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Proxy{
+		// The proxy is addressed by pod IP, so node components reach it
+		// without cluster DNS or kube-proxy, matching how registry
+		// endpoints are handed out.
+		host:      net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(proxyPort)),
+		Namespace: namespace,
+		Pod:       pod,
+		auth:      opts.Auth,
+		client:    c,
+	}, nil
+}
+
+// Host returns the host:port under which the proxy is reachable.
+func (p *Proxy) Host() string {
+	return p.host
+}
+
+// URL returns the proxy URL suitable for HTTP_PROXY/HTTPS_PROXY environment
+// variables, including credentials when the proxy requires authentication.
+func (p *Proxy) URL() string {
+	if p.auth != nil {
+		return fmt.Sprintf("http://%s:%s@%s", p.auth.Username, p.auth.Password, p.host)
+	}
+	return fmt.Sprintf("http://%s", p.host)
+}
+
+// AccessLog returns the requests the proxy has served so far. The path of
+// each entry is the request target, so EntriesForPath can filter by the
+// destination host.
+func (p *Proxy) AccessLog(ctx context.Context) ([]AccessLogEntry, error) {
+	opts := v1.PodLogOptions{Container: "proxy"}
+	data, err := p.client.CoreV1().Pods(p.Namespace).GetLogs(proxyPodName, &opts).Do(ctx).Raw()
+	if err != nil {
+		return nil, fmt.Errorf("reading proxy access log: %w", err)
+	}
+	return ParseAccessLog(data)
+}
+
+// Teardown removes the proxy pod and service. It is safe to call even if
+// setup only partially succeeded.
+func (p *Proxy) Teardown(ctx context.Context) error {
+	podErr := p.client.CoreV1().Pods(p.Namespace).Delete(ctx, proxyPodName, metav1.DeleteOptions{})
+	svcErr := p.client.CoreV1().Services(p.Namespace).Delete(ctx, proxyServiceName, metav1.DeleteOptions{})
+	if podErr != nil {
+		return podErr
+	}
+	return svcErr
+}
+
+// proxyPod builds the proxy pod around the agnhost forward-proxy
+// subcommand, which emits the same JSON-lines access log format as the
+// registry proxies, with the path field carrying the full request target
+// (the CONNECT host or absolute URI).
+func proxyPod(namespace string, auth *BasicAuthOptions) *v1.Pod {
+	container := v1.Container{
+		Name:  "proxy",
+		Image: imageutils.GetE2EImage(imageutils.Agnhost),
+		Args:  []string{"forward-proxy", "--port", strconv.Itoa(proxyPort)},
+		Ports: []v1.ContainerPort{
+			{ContainerPort: proxyPort},
+		},
+	}
+	if auth != nil {
+		container.Env = append(container.Env,
+			v1.EnvVar{Name: "PROXY_AUTH_USER", Value: auth.Username},
+			v1.EnvVar{Name: "PROXY_AUTH_PASSWORD", Value: auth.Password},
+		)
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      proxyPodName,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": proxyPodName},
+		},
+		Spec: v1.PodSpec{
+			Containers:    []v1.Container{container},
+			RestartPolicy: v1.RestartPolicyNever,
+		},
+	}
+}
+
+func proxyService(namespace string) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      proxyServiceName,
+			Namespace: namespace,
+		},
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{"app": proxyPodName},
+			Ports: []v1.ServicePort{
+				{
+					Port:       proxyPort,
+					TargetPort: intstr.FromInt32(proxyPort),
+				},
+			},
+		},
+	}
+}
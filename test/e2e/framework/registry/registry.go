@@ -0,0 +1,668 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registry contains helpers for running a container image registry
+// inside the cluster under test and preloading it with content that image
+// pull tests can reference. Tests use it to exercise pull paths (private
+// registries, credential providers, pull policies) without depending on
+// registries outside the cluster.
+package registry
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientset "k8s.io/client-go/kubernetes"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/kubernetes/test/e2e/framework/artifacts"
+	e2enode "k8s.io/kubernetes/test/e2e/framework/node"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+)
+
+var (
+	// registryImage is the image used for the registry container itself.
+	registryImage = imageutils.GetE2EImage(imageutils.Registry)
+	// preloadImage is the image used by the preload and htpasswd init
+	// containers. It bundles the registry binary, crane, oras and htpasswd,
+	// so init containers can serve the shared storage volume locally and
+	// push images and OCI artifacts into it before the registry container
+	// starts. Its source lives in test/images/registry-preload.
+	preloadImage = imageutils.GetE2EImage(imageutils.RegistryPreload)
+)
+
+const (
+	// registryPort is the port the registry listens on inside the pod.
+	registryPort = 5000
+
+	// storageVolumeName is the emptyDir shared between the preload init
+	// container and the registry container.
+	storageVolumeName = "registry-storage"
+	storageMountPath  = "/var/lib/registry"
+
+	// authVolumeName holds the generated htpasswd file when basic auth is
+	// enabled.
+	authVolumeName = "registry-auth"
+	authMountPath  = "/auth"
+
+	// tlsVolumeName holds the generated serving certificate when TLS is
+	// enabled, mounted from the tlsSecretName secret.
+	tlsVolumeName   = "registry-tls"
+	tlsMountPath    = "/certs"
+	tlsSecretName   = "e2e-registry-tls"
+	tlsCertFileName = "tls.crt"
+	tlsKeyFileName  = "tls.key"
+
+	podName     = "e2e-registry"
+	serviceName = "e2e-registry"
+)
+
+// Options configures the registry deployed by SetupRegistry. It is the
+// stable configuration surface of this package: out-of-tree suites should
+// only need to fill in Options rather than fork the deployment logic.
+type Options struct {
+	// PreloadSpecs describes the content pushed into the registry before
+	// it starts serving. When empty, DefaultPreloadSpecs is used so that
+	// existing consumers keep finding pause:testing.
+	PreloadSpecs []PreloadSpec
+
+	// NodeAccess is used to place node-local configuration for the
+	// registry: the containerd configuration that makes the registry's
+	// endpoints trusted, and credential files written by helpers. When
+	// unset, every ready schedulable node is configured through privileged
+	// pods; node-local suites should set it to LocalNodeAccess to configure
+	// the node under test directly.
+	NodeAccess NodeAccess
+
+	// Auth, when set, requires basic authentication for all registry
+	// requests. Preloading is unaffected: it goes through the localhost
+	// listener before the registry starts serving.
+	Auth *BasicAuthOptions
+
+	// ScopedAuth, when set, fronts the registry with an authorizing proxy
+	// that serves multiple users with per-repository scopes and a
+	// bearer-token endpoint. Cannot be combined with Auth, TLS or Faults:
+	// the proxy fronts the registry's plain HTTP listener and is the only
+	// front a registry can have.
+	ScopedAuth *ScopedAuthOptions
+
+	// Mirror, when set, runs the registry as a pull-through cache of an
+	// upstream registry instead of a standalone one, so air-gapped nodes
+	// can keep pulling upstream image references. PreloadSpecs still
+	// apply; declared images are served from the cache without ever
+	// contacting the upstream.
+	Mirror *MirrorOptions
+
+	// DualStack, when set, requests a dual-stack Service for the registry
+	// and exposes a host:port endpoint per address family the pod network
+	// assigned to the registry pod, via Registry.HostForFamily. Setup
+	// still succeeds on single-stack clusters; callers should skip when
+	// the family they need is missing. Cannot be combined with TLS, whose
+	// serving certificate covers only the primary endpoint, or with proxy
+	// fronts (Faults, ScopedAuth), which serve on a single port.
+	DualStack bool
+
+	// TLS, when set, serves the registry over HTTPS with a generated CA
+	// and serving certificate. Pulls go through the Service's ClusterIP,
+	// which the certificate covers, and the CA is installed into each
+	// node's container runtime during setup. It is also exposed via
+	// Registry.CACertificate for scenarios that configure nodes
+	// themselves, e.g. with InstallNodeMirror. Preloading is unaffected:
+	// it goes through the localhost listener before the registry starts
+	// serving.
+	TLS *TLSOptions
+
+	// Faults, when set, fronts the registry with a fault-injecting reverse
+	// proxy and routes Host and ImageRef through it, so pulls hit the
+	// configured faults deterministically. Cannot be combined with TLS:
+	// the proxy fronts the registry's plain HTTP listener.
+	Faults *FaultOptions
+}
+
+// BasicAuthOptions protects the registry with a single htpasswd user.
+type BasicAuthOptions struct {
+	Username string
+	Password string
+}
+
+// Handle is the interface suites use to interact with a deployed registry.
+// Cluster-level and node-level suites receive the same interface, and
+// out-of-tree providers can wrap it without depending on the concrete type.
+type Handle interface {
+	// Host returns the host:port under which cluster workloads reach the
+	// registry, suitable for prefixing image references.
+	Host() string
+	// ImageRef returns the full reference for an image in this registry
+	// given the repo:tag it was preloaded under.
+	ImageRef(targetRef string) string
+	// Teardown removes the registry deployment. It is safe to call even
+	// if setup only partially succeeded.
+	Teardown(ctx context.Context) error
+}
+
+// Registry is the in-cluster Handle implementation returned by
+// SetupRegistry.
+type Registry struct {
+	host string
+	// Namespace is the namespace the registry pod and service run in.
+	Namespace string
+	// Pod is the running registry pod.
+	Pod *v1.Pod
+
+	caCert        []byte
+	hostsByFamily map[v1.IPFamily]string
+	tlsSecret     bool
+	faultProxy    bool
+	authProxy     bool
+	client        clientset.Interface
+
+	// nodeConfigs records the per-node containerd configuration installed
+	// for this registry's endpoints, so Teardown can remove it again.
+	nodeConfigs []nodeRegistryConfig
+
+	// unregisterArtifacts removes the registry pod log collector installed
+	// for failed-spec artifact bundles.
+	unregisterArtifacts func()
+}
+
+var _ Handle = &Registry{}
+
+// SetupRegistry deploys a registry pod and service into the given namespace
+// and blocks until the registry is running with all preloaded content in
+// place. Content is pushed by an init container generated from
+// opts.PreloadSpecs, so suites can declare the images they need instead of
+// baking them into a custom registry image.
+func SetupRegistry(ctx context.Context, c clientset.Interface, namespace string, opts Options) (*Registry, error) {
+	specs := opts.PreloadSpecs
+	if len(specs) == 0 {
+		specs = DefaultPreloadSpecs()
+	}
+	if err := ValidatePreloadSpecs(specs); err != nil {
+		return nil, fmt.Errorf("invalid preload specs: %w", err)
+	}
+	if opts.Mirror != nil {
+		if err := validateMirrorOptions(opts.Mirror); err != nil {
+			return nil, fmt.Errorf("invalid mirror options: %w", err)
+		}
+	}
+	if opts.Faults != nil {
+		if err := validateFaultOptions(opts.Faults); err != nil {
+			return nil, fmt.Errorf("invalid fault options: %w", err)
+		}
+		if opts.TLS != nil {
+			return nil, fmt.Errorf("fault injection cannot be combined with TLS")
+		}
+	}
+	if opts.ScopedAuth != nil {
+		if err := validateScopedAuthOptions(opts.ScopedAuth); err != nil {
+			return nil, fmt.Errorf("invalid scoped auth options: %w", err)
+		}
+		if opts.Auth != nil || opts.TLS != nil || opts.Faults != nil {
+			return nil, fmt.Errorf("scoped auth cannot be combined with basic auth, TLS or fault injection")
+		}
+	}
+	if opts.DualStack && (opts.TLS != nil || opts.Faults != nil || opts.ScopedAuth != nil) {
+		return nil, fmt.Errorf("dual-stack cannot be combined with TLS or proxy fronts")
+	}
+
+	// The Service is created first so the TLS serving certificate can cover
+	// the ClusterIP the apiserver assigns, which is what nodes pull through
+	// when TLS is enabled.
+	svc := registryService(namespace, opts)
+	svc, err := c.CoreV1().Services(namespace).Create(ctx, svc, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("creating registry service: %w", err)
+	}
+
+	var caCert []byte
+	tlsSecret := false
+	if opts.TLS != nil {
+		secret, ca, err := tlsSecretFor(namespace, serviceIPs(svc), opts.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("generating registry serving certificate: %w", err)
+		}
+		if _, err := c.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			return nil, fmt.Errorf("creating registry TLS secret: %w", err)
+		}
+		caCert = ca
+		tlsSecret = true
+	}
+
+	pod := registryPod(namespace, specs, opts)
+	pod, err = c.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("creating registry pod: %w", err)
+	}
+	if opts.Faults != nil {
+		if _, err := c.CoreV1().Services(namespace).Create(ctx, faultProxyService(namespace), metav1.CreateOptions{}); err != nil {
+			return nil, fmt.Errorf("creating fault proxy service: %w", err)
+		}
+	}
+	if opts.ScopedAuth != nil {
+		if _, err := c.CoreV1().Services(namespace).Create(ctx, authProxyService(namespace), metav1.CreateOptions{}); err != nil {
+			return nil, fmt.Errorf("creating auth proxy service: %w", err)
+		}
+	}
+
+	if err := e2epod.WaitTimeoutForPodRunningInNamespace(ctx, c, pod.Name, namespace, 5*time.Minute); err != nil {
+		return nil, fmt.Errorf("waiting for registry pod to run: %w", err)
+	}
+	pod, err = c.CoreV1().Pods(namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Endpoints are handed out by IP, so image references resolve without
+	// cluster DNS on the pull path: container runtimes resolve registry
+	// hosts through the host's resolver, which cannot see .svc names. The
+	// pod IP works without kube-proxy, which node-local suites lack; only
+	// TLS pulls go through the ClusterIP the serving certificate covers.
+	host := net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(registryPort))
+	if opts.TLS != nil {
+		host = net.JoinHostPort(svc.Spec.ClusterIP, strconv.Itoa(registryPort))
+	}
+	if opts.Faults != nil {
+		// Pulls go through the fault proxy; the registry itself stays
+		// reachable on its own port for direct inspection.
+		host = net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(faultProxyPort))
+	}
+	if opts.ScopedAuth != nil {
+		// Pulls go through the auth proxy; the registry itself stays
+		// reachable on its own port for direct inspection.
+		host = net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(authProxyPort))
+	}
+	r := &Registry{
+		host:          host,
+		hostsByFamily: hostsByFamily(pod),
+		Namespace:     namespace,
+		Pod:           pod,
+		caCert:        caCert,
+		tlsSecret:     tlsSecret,
+		faultProxy:    opts.Faults != nil,
+		authProxy:     opts.ScopedAuth != nil,
+		client:        c,
+	}
+	// Runtimes refuse plain-HTTP registries and unknown CAs unless the node
+	// is configured for them, so every endpoint handed out above is
+	// declared to containerd on every node that may pull from it.
+	if err := r.installNodeConfigs(ctx, opts); err != nil {
+		return nil, fmt.Errorf("configuring nodes for the registry: %w", err)
+	}
+	r.unregisterArtifacts = artifacts.Register("registry-"+namespace, r.collectPodLogs)
+	return r, nil
+}
+
+// nodeRegistryConfig is one containerd registry configuration installed on
+// one node.
+type nodeRegistryConfig struct {
+	access NodeAccess
+	host   string
+}
+
+// installNodeConfigs installs containerd configuration for every endpoint
+// this registry hands out, on every node that may pull from it.
+func (r *Registry) installNodeConfigs(ctx context.Context, opts Options) error {
+	accesses, err := r.nodeAccesses(ctx, opts)
+	if err != nil {
+		return err
+	}
+	hosts := []string{r.host}
+	for _, host := range r.hostsByFamily {
+		if host != r.host {
+			hosts = append(hosts, host)
+		}
+	}
+	for _, access := range accesses {
+		for _, host := range hosts {
+			if err := InstallNodeRegistryConfig(ctx, access, host, r.caCert); err != nil {
+				return err
+			}
+			r.nodeConfigs = append(r.nodeConfigs, nodeRegistryConfig{access: access, host: host})
+		}
+	}
+	return nil
+}
+
+// nodeAccesses returns the NodeAccess for every node that needs registry
+// configuration: the one the caller supplied, or pod-based access to each
+// ready schedulable node when the caller supplied none.
+func (r *Registry) nodeAccesses(ctx context.Context, opts Options) ([]NodeAccess, error) {
+	if opts.NodeAccess != nil {
+		return []NodeAccess{opts.NodeAccess}, nil
+	}
+	nodes, err := e2enode.GetReadySchedulableNodes(ctx, r.client)
+	if err != nil {
+		return nil, fmt.Errorf("listing nodes to configure: %w", err)
+	}
+	accesses := make([]NodeAccess, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		accesses = append(accesses, NewPodNodeAccess(r.client, r.Namespace, node.Name))
+	}
+	return accesses, nil
+}
+
+// collectPodLogs writes the registry pod's container logs into a failed
+// spec's artifact bundle, scoped to the test window.
+func (r *Registry) collectPodLogs(ctx context.Context, dir string, since time.Time) error {
+	sinceTime := metav1.NewTime(since)
+	var lastErr error
+	for _, container := range append(r.Pod.Spec.InitContainers, r.Pod.Spec.Containers...) {
+		logs, err := r.client.CoreV1().Pods(r.Namespace).GetLogs(r.Pod.Name, &v1.PodLogOptions{
+			Container: container.Name,
+			SinceTime: &sinceTime,
+		}).DoRaw(ctx)
+		if err != nil {
+			lastErr = fmt.Errorf("getting logs of container %q: %w", container.Name, err)
+			continue
+		}
+		if err := artifacts.WriteFile(dir, container.Name+".log", logs); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// Host returns the host:port under which cluster workloads reach the
+// registry.
+func (r *Registry) Host() string {
+	return r.host
+}
+
+// PodHost returns the host:port under which the registry pod is reached
+// directly by its pod IP, bypassing any proxy fronts and the TLS listener's
+// ClusterIP. Suites use it to push content from the test process itself.
+func (r *Registry) PodHost() string {
+	return net.JoinHostPort(r.Pod.Status.PodIP, strconv.Itoa(registryPort))
+}
+
+// Teardown removes the registry pod and service. It is safe to call even if
+// setup only partially succeeded.
+func (r *Registry) Teardown(ctx context.Context) error {
+	if r.unregisterArtifacts != nil {
+		r.unregisterArtifacts()
+	}
+	var nodeErr error
+	for _, cfg := range r.nodeConfigs {
+		if err := RemoveNodeRegistryConfig(ctx, cfg.access, cfg.host); err != nil && nodeErr == nil {
+			nodeErr = err
+		}
+	}
+	podErr := r.client.CoreV1().Pods(r.Namespace).Delete(ctx, podName, metav1.DeleteOptions{})
+	svcErr := r.client.CoreV1().Services(r.Namespace).Delete(ctx, serviceName, metav1.DeleteOptions{})
+	var secretErr error
+	if r.tlsSecret {
+		secretErr = r.client.CoreV1().Secrets(r.Namespace).Delete(ctx, tlsSecretName, metav1.DeleteOptions{})
+	}
+	var faultSvcErr error
+	if r.faultProxy {
+		faultSvcErr = r.client.CoreV1().Services(r.Namespace).Delete(ctx, faultProxyServiceName, metav1.DeleteOptions{})
+	}
+	var authSvcErr error
+	if r.authProxy {
+		authSvcErr = r.client.CoreV1().Services(r.Namespace).Delete(ctx, authProxyServiceName, metav1.DeleteOptions{})
+	}
+	if podErr != nil {
+		return podErr
+	}
+	if svcErr != nil {
+		return svcErr
+	}
+	if secretErr != nil {
+		return secretErr
+	}
+	if faultSvcErr != nil {
+		return faultSvcErr
+	}
+	if authSvcErr != nil {
+		return authSvcErr
+	}
+	return nodeErr
+}
+
+// ImageRef returns the full reference for an image in this registry given
+// the repo:tag it was preloaded under.
+func (r *Registry) ImageRef(targetRef string) string {
+	return fmt.Sprintf("%s/%s", r.host, targetRef)
+}
+
+// ServiceName returns the name of the Service fronting the registry, for
+// tests that need to inspect it, e.g. to resolve its cluster IP.
+func (r *Registry) ServiceName() string {
+	return serviceName
+}
+
+func registryPod(namespace string, specs []PreloadSpec, opts Options) *v1.Pod {
+	registryContainer := v1.Container{
+		Name:  "registry",
+		Image: registryImage,
+		// JSON logging makes the container's "response completed" records
+		// parseable by ParseAccessLog.
+		Env: []v1.EnvVar{
+			{Name: "REGISTRY_LOG_FORMATTER", Value: "json"},
+		},
+		Ports: []v1.ContainerPort{
+			{ContainerPort: registryPort},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{Name: storageVolumeName, MountPath: storageMountPath},
+		},
+		ReadinessProbe: &v1.Probe{
+			ProbeHandler: v1.ProbeHandler{
+				HTTPGet: &v1.HTTPGetAction{
+					Path: "/v2/",
+					Port: intstr.FromInt32(registryPort),
+				},
+			},
+		},
+	}
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": podName},
+		},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{preloadInitContainer(specs)},
+			Volumes: []v1.Volume{
+				{
+					Name: storageVolumeName,
+					VolumeSource: v1.VolumeSource{
+						EmptyDir: &v1.EmptyDirVolumeSource{},
+					},
+				},
+			},
+			RestartPolicy: v1.RestartPolicyNever,
+		},
+	}
+
+	if auth := opts.Auth; auth != nil {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+			Name: authVolumeName,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		})
+		pod.Spec.InitContainers = append(pod.Spec.InitContainers, htpasswdInitContainer(auth))
+		registryContainer.VolumeMounts = append(registryContainer.VolumeMounts, v1.VolumeMount{
+			Name: authVolumeName, MountPath: authMountPath,
+		})
+		registryContainer.Env = append(registryContainer.Env,
+			v1.EnvVar{Name: "REGISTRY_AUTH", Value: "htpasswd"},
+			v1.EnvVar{Name: "REGISTRY_AUTH_HTPASSWD_REALM", Value: "e2e-registry"},
+			v1.EnvVar{Name: "REGISTRY_AUTH_HTPASSWD_PATH", Value: authMountPath + "/htpasswd"},
+		)
+		// The readiness endpoint requires credentials once auth is on; an
+		// unauthenticated 401 still proves the registry is serving.
+		registryContainer.ReadinessProbe = nil
+	}
+
+	if opts.Mirror != nil {
+		// Only the registry container mirrors the upstream; the preload
+		// init container keeps pushing into an unconfigured local
+		// registry, so declared images are cached before serving starts.
+		registryContainer.Env = append(registryContainer.Env,
+			v1.EnvVar{Name: "REGISTRY_PROXY_REMOTEURL", Value: opts.Mirror.upstreamURL()},
+		)
+	}
+
+	if opts.TLS != nil {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+			Name: tlsVolumeName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{SecretName: tlsSecretName},
+			},
+		})
+		registryContainer.VolumeMounts = append(registryContainer.VolumeMounts, v1.VolumeMount{
+			Name: tlsVolumeName, MountPath: tlsMountPath, ReadOnly: true,
+		})
+		registryContainer.Env = append(registryContainer.Env,
+			v1.EnvVar{Name: "REGISTRY_HTTP_TLS_CERTIFICATE", Value: tlsMountPath + "/" + tlsCertFileName},
+			v1.EnvVar{Name: "REGISTRY_HTTP_TLS_KEY", Value: tlsMountPath + "/" + tlsKeyFileName},
+		)
+		if registryContainer.ReadinessProbe != nil {
+			// The kubelet does not verify the serving certificate when
+			// probing over HTTPS, so the probe works with the generated CA.
+			registryContainer.ReadinessProbe.HTTPGet.Scheme = v1.URISchemeHTTPS
+		}
+	}
+
+	pod.Spec.Containers = []v1.Container{registryContainer}
+	if opts.Faults != nil {
+		// The proxy runs next to the registry and fronts its in-pod
+		// listener, so faulted requests never reach the registry's log.
+		pod.Spec.Containers = append(pod.Spec.Containers, faultProxyContainer(opts.Faults))
+	}
+	if opts.ScopedAuth != nil {
+		// The proxy runs next to the registry and fronts its in-pod
+		// listener; the registry itself stays unauthenticated, so only
+		// requests through the proxy are authorized.
+		pod.Spec.Containers = append(pod.Spec.Containers, authProxyContainer(opts.ScopedAuth))
+	}
+	return pod
+}
+
+// tlsSecretFor generates a serving certificate for the registry service's
+// cluster DNS names and ClusterIPs plus any extra names, returning the
+// kubernetes.io/tls secret to mount into the registry pod and the
+// PEM-encoded CA the certificate chains to.
+func tlsSecretFor(namespace string, ips []net.IP, opts *TLSOptions) (*v1.Secret, []byte, error) {
+	host := fmt.Sprintf("%s.%s.svc", serviceName, namespace)
+	alternateDNS := append([]string{
+		serviceName,
+		fmt.Sprintf("%s.%s", serviceName, namespace),
+		host + ".cluster.local",
+	}, opts.ExtraDNSNames...)
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey(host, ips, alternateDNS)
+	if err != nil {
+		return nil, nil, err
+	}
+	// GenerateSelfSignedCertKey returns the serving certificate followed
+	// by the CA it chains to; the CA is what nodes need to trust.
+	caPEM, err := lastCertificateBlock(certPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tlsSecretName,
+			Namespace: namespace,
+		},
+		Type: v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			tlsCertFileName: certPEM,
+			tlsKeyFileName:  keyPEM,
+		},
+	}
+	return secret, caPEM, nil
+}
+
+// serviceIPs parses the ClusterIPs the apiserver assigned to the Service.
+func serviceIPs(svc *v1.Service) []net.IP {
+	var ips []net.IP
+	for _, clusterIP := range svc.Spec.ClusterIPs {
+		if ip := net.ParseIP(clusterIP); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// lastCertificateBlock returns the last PEM certificate block in bundle,
+// re-encoded on its own.
+func lastCertificateBlock(bundle []byte) ([]byte, error) {
+	var last *pem.Block
+	rest := bundle
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == certutil.CertificateBlockType {
+			last = block
+		}
+	}
+	if last == nil {
+		return nil, fmt.Errorf("no certificate found in generated bundle")
+	}
+	return pem.EncodeToMemory(last), nil
+}
+
+// htpasswdInitContainer generates the htpasswd file for basic auth before
+// the registry starts.
+func htpasswdInitContainer(auth *BasicAuthOptions) v1.Container {
+	return v1.Container{
+		Name:    "htpasswd",
+		Image:   preloadImage,
+		Command: []string{"/bin/sh", "-c", fmt.Sprintf("htpasswd -Bbc %s/htpasswd %s %s", authMountPath, auth.Username, auth.Password)},
+		VolumeMounts: []v1.VolumeMount{
+			{Name: authVolumeName, MountPath: authMountPath},
+		},
+	}
+}
+
+func registryService(namespace string, opts Options) *v1.Service {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName,
+			Namespace: namespace,
+		},
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{"app": podName},
+			Ports: []v1.ServicePort{
+				{
+					Port:       registryPort,
+					TargetPort: intstr.FromInt32(registryPort),
+				},
+			},
+		},
+	}
+	if opts.DualStack {
+		// Prefer rather than require, so the registry still comes up on
+		// single-stack clusters and callers can skip the missing family.
+		policy := v1.IPFamilyPolicyPreferDualStack
+		svc.Spec.IPFamilyPolicy = &policy
+	}
+	return svc
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+)
+
+const (
+	// authProxyPort is the port the auth proxy listens on inside the pod.
+	authProxyPort = 5002
+
+	authProxyServiceName = "e2e-registry-users"
+)
+
+// AccessLevel is the access a user has to a repository.
+type AccessLevel string
+
+const (
+	// AccessNone denies the user all access to the repository. It is the
+	// implicit level for repositories without a scope, and can be set
+	// explicitly to document a negative fixture.
+	AccessNone AccessLevel = "none"
+	// AccessPull grants pull-only access.
+	AccessPull AccessLevel = "pull"
+	// AccessPushPull grants push and pull access.
+	AccessPushPull AccessLevel = "push,pull"
+)
+
+// RepositoryScope grants one access level on one repository, e.g. pull-only
+// on "team-a/app". The repository is the name without the registry host or
+// tag. "*" matches every repository.
+type RepositoryScope struct {
+	Repository string      `json:"repository"`
+	Access     AccessLevel `json:"access"`
+}
+
+// User is one registry account with its repository scopes. Repositories
+// without a scope are denied, so a user with no scopes is a valid
+// no-access fixture.
+type User struct {
+	Name     string            `json:"name"`
+	Password string            `json:"password"`
+	Scopes   []RepositoryScope `json:"scopes,omitempty"`
+}
+
+// ScopedAuthOptions protects the registry with multiple users whose access
+// is authorized per repository, instead of the single all-or-nothing
+// htpasswd user of BasicAuthOptions. Requests authenticate with basic auth
+// or with bearer tokens obtained from the proxy's token endpoint; both are
+// checked against the same scopes, so tests can verify the kubelet
+// respects per-repository authorization under either scheme.
+type ScopedAuthOptions struct {
+	Users []User
+}
+
+// validateScopedAuthOptions rejects user sets the auth proxy cannot serve.
+func validateScopedAuthOptions(opts *ScopedAuthOptions) error {
+	if len(opts.Users) == 0 {
+		return fmt.Errorf("at least one user must be configured")
+	}
+	seen := map[string]bool{}
+	for _, user := range opts.Users {
+		if user.Name == "" {
+			return fmt.Errorf("user name must not be empty")
+		}
+		if user.Password == "" {
+			return fmt.Errorf("user %q must have a password", user.Name)
+		}
+		if seen[user.Name] {
+			return fmt.Errorf("duplicate user %q", user.Name)
+		}
+		seen[user.Name] = true
+		for _, scope := range user.Scopes {
+			if scope.Repository == "" {
+				return fmt.Errorf("user %q has a scope without a repository", user.Name)
+			}
+			switch scope.Access {
+			case AccessNone, AccessPull, AccessPushPull:
+			default:
+				return fmt.Errorf("user %q has unknown access level %q for repository %q", user.Name, scope.Access, scope.Repository)
+			}
+		}
+	}
+	return nil
+}
+
+// authProxyEnv renders the proxy container's environment from the options.
+// The users are passed as JSON; the proxy derives the token-realm URL it
+// advertises from the incoming request's Host header, so it works under
+// whatever endpoint the registry is handed out on.
+func authProxyEnv(opts *ScopedAuthOptions, upstreamURL string) []v1.EnvVar {
+	// The user set is validated before the pod is built, and these types
+	// always marshal.
+	users, _ := json.Marshal(opts.Users)
+	return []v1.EnvVar{
+		{Name: "AUTH_UPSTREAM_URL", Value: upstreamURL},
+		{Name: "AUTH_USERS", Value: string(users)},
+	}
+}
+
+// authProxyContainer builds the proxy sidecar that runs next to the
+// registry container and fronts its in-pod listener. It runs the agnhost
+// registry-auth-proxy subcommand, which enforces the users' repository
+// scopes under basic auth and the Docker registry bearer-token flow, and
+// emits the JSON access-log lines ParseAccessLog understands.
+func authProxyContainer(opts *ScopedAuthOptions) v1.Container {
+	return v1.Container{
+		Name:  "auth-proxy",
+		Image: imageutils.GetE2EImage(imageutils.Agnhost),
+		Args:  []string{"registry-auth-proxy", "--port", strconv.Itoa(authProxyPort)},
+		Ports: []v1.ContainerPort{
+			{ContainerPort: authProxyPort},
+		},
+		Env: authProxyEnv(opts, fmt.Sprintf("http://127.0.0.1:%d", registryPort)),
+	}
+}
+
+// authProxyService exposes the auth proxy under its own name, so tests can
+// also reach the registry directly while pulls go through the proxy.
+func authProxyService(namespace string) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      authProxyServiceName,
+			Namespace: namespace,
+		},
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{"app": podName},
+			Ports: []v1.ServicePort{
+				{
+					Port:       authProxyPort,
+					TargetPort: intstr.FromInt32(authProxyPort),
+				},
+			},
+		},
+	}
+}
+
+// DockerConfigSecret returns a dockerconfigjson pull secret for the given
+// user against this registry, for use as a pod imagePullSecret. The secret
+// is not created; tests create it in their own namespace.
+func (r *Registry) DockerConfigSecret(name string, user User) (*v1.Secret, error) {
+	data, err := DockerConfigJSON(r.host, user.Name, user.Password)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Type: v1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			v1.DockerConfigJsonKey: data,
+		},
+	}, nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestValidateScopedAuthOptions(t *testing.T) {
+	testCases := []struct {
+		name        string
+		opts        ScopedAuthOptions
+		expectError string
+	}{
+		{
+			name: "multiple users with different scopes",
+			opts: ScopedAuthOptions{Users: []User{
+				{Name: "puller", Password: "secret", Scopes: []RepositoryScope{
+					{Repository: "team-a/app", Access: AccessPull},
+				}},
+				{Name: "pusher", Password: "secret", Scopes: []RepositoryScope{
+					{Repository: "team-a/app", Access: AccessPushPull},
+				}},
+				{Name: "outsider", Password: "secret"},
+			}},
+		},
+		{
+			name: "explicit no access scope",
+			opts: ScopedAuthOptions{Users: []User{
+				{Name: "outsider", Password: "secret", Scopes: []RepositoryScope{
+					{Repository: "team-a/app", Access: AccessNone},
+				}},
+			}},
+		},
+		{
+			name:        "no users",
+			opts:        ScopedAuthOptions{},
+			expectError: "at least one user",
+		},
+		{
+			name: "empty user name",
+			opts: ScopedAuthOptions{Users: []User{
+				{Password: "secret"},
+			}},
+			expectError: "user name must not be empty",
+		},
+		{
+			name: "empty password",
+			opts: ScopedAuthOptions{Users: []User{
+				{Name: "puller"},
+			}},
+			expectError: "must have a password",
+		},
+		{
+			name: "duplicate user",
+			opts: ScopedAuthOptions{Users: []User{
+				{Name: "puller", Password: "secret"},
+				{Name: "puller", Password: "other"},
+			}},
+			expectError: "duplicate user",
+		},
+		{
+			name: "scope without repository",
+			opts: ScopedAuthOptions{Users: []User{
+				{Name: "puller", Password: "secret", Scopes: []RepositoryScope{
+					{Access: AccessPull},
+				}},
+			}},
+			expectError: "scope without a repository",
+		},
+		{
+			name: "unknown access level",
+			opts: ScopedAuthOptions{Users: []User{
+				{Name: "puller", Password: "secret", Scopes: []RepositoryScope{
+					{Repository: "team-a/app", Access: "admin"},
+				}},
+			}},
+			expectError: "unknown access level",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateScopedAuthOptions(&tc.opts)
+			if tc.expectError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+func TestAuthProxyEnv(t *testing.T) {
+	opts := ScopedAuthOptions{Users: []User{
+		{Name: "puller", Password: "secret", Scopes: []RepositoryScope{
+			{Repository: "team-a/app", Access: AccessPull},
+		}},
+	}}
+	env := map[string]string{}
+	for _, e := range authProxyEnv(&opts, "http://127.0.0.1:5000") {
+		env[e.Name] = e.Value
+	}
+	if env["AUTH_UPSTREAM_URL"] != "http://127.0.0.1:5000" {
+		t.Errorf("unexpected upstream URL %q", env["AUTH_UPSTREAM_URL"])
+	}
+	if _, ok := env["AUTH_TOKEN_REALM"]; ok {
+		t.Errorf("unexpected AUTH_TOKEN_REALM; the proxy derives the realm from the request host")
+	}
+	var users []User
+	if err := json.Unmarshal([]byte(env["AUTH_USERS"]), &users); err != nil {
+		t.Fatalf("decoding users: %v", err)
+	}
+	if len(users) != 1 || users[0].Name != "puller" || users[0].Scopes[0].Access != AccessPull {
+		t.Errorf("unexpected users: %+v", users)
+	}
+}
+
+func TestRegistryPodWithScopedAuth(t *testing.T) {
+	opts := Options{ScopedAuth: &ScopedAuthOptions{Users: []User{
+		{Name: "puller", Password: "secret"},
+	}}}
+	pod := registryPod("test-ns", DefaultPreloadSpecs(), opts)
+	var found bool
+	for _, c := range pod.Spec.Containers {
+		if c.Name == "auth-proxy" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected an auth-proxy container alongside the registry, got %v", pod.Spec.Containers)
+	}
+}
+
+func TestDockerConfigSecret(t *testing.T) {
+	r := &Registry{host: "e2e-registry-users.test-ns.svc:5002"}
+	secret, err := r.DockerConfigSecret("puller-secret", User{Name: "puller", Password: "secret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if secret.Name != "puller-secret" || secret.Type != v1.SecretTypeDockerConfigJson {
+		t.Errorf("unexpected secret metadata: %+v", secret)
+	}
+	var cfg dockerConfigJSON
+	if err := json.Unmarshal(secret.Data[v1.DockerConfigJsonKey], &cfg); err != nil {
+		t.Fatalf("decoding secret: %v", err)
+	}
+	if _, ok := cfg.Auths[r.host]; !ok {
+		t.Errorf("expected an auth entry for %q, got %+v", r.host, cfg.Auths)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	"k8s.io/kubernetes/test/e2e/framework/artifacts"
+)
+
+// The node-level collectors are registered for the whole suite: when a spec
+// fails they capture the kubelet journal scoped to the test window, the CRI
+// view of pods, containers and images, and the container runtime journal
+// into the per-test artifact directory.
+func init() {
+	artifacts.Register("kubelet", collectKubeletJournal)
+	artifacts.Register("cri", collectCRIState)
+	artifacts.Register("runtime", collectRuntimeJournal)
+}
+
+var _ = ginkgo.ReportAfterEach(func(report ginkgo.SpecReport) {
+	artifacts.CollectIfFailed(context.Background(), report)
+})
+
+// journalSinceFormat is the timestamp format journalctl accepts for --since.
+const journalSinceFormat = "2006-01-02 15:04:05"
+
+func collectKubeletJournal(ctx context.Context, dir string, since time.Time) error {
+	unit := findKubeletServiceName(false)
+	out, err := exec.CommandContext(ctx, "journalctl", "-u", unit, "--since", since.Format(journalSinceFormat)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("journalctl for unit %q: %v, output: %s", unit, err, out)
+	}
+	return artifacts.WriteFile(dir, "kubelet.log", out)
+}
+
+func collectRuntimeJournal(ctx context.Context, dir string, since time.Time) error {
+	unit, err := findContainerRuntimeServiceName()
+	if err != nil {
+		return fmt.Errorf("finding container runtime unit: %w", err)
+	}
+	out, err := exec.CommandContext(ctx, "journalctl", "-u", unit, "--since", since.Format(journalSinceFormat)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("journalctl for unit %q: %v, output: %s", unit, err, out)
+	}
+	return artifacts.WriteFile(dir, "runtime.log", out)
+}
+
+// collectCRIState dumps the runtime's view of sandboxes, containers and
+// images, the equivalent of crictl pods/ps/images plus a verbose inspect of
+// every container.
+func collectCRIState(ctx context.Context, dir string, _ time.Time) error {
+	rs, is, err := getCRIClient()
+	if err != nil {
+		return fmt.Errorf("getting CRI client: %w", err)
+	}
+
+	sandboxes, err := rs.ListPodSandbox(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("listing pod sandboxes: %w", err)
+	}
+	if err := writeJSONArtifact(dir, "pods.json", sandboxes); err != nil {
+		return err
+	}
+
+	containers, err := rs.ListContainers(ctx, &runtimeapi.ContainerFilter{})
+	if err != nil {
+		return fmt.Errorf("listing containers: %w", err)
+	}
+	if err := writeJSONArtifact(dir, "containers.json", containers); err != nil {
+		return err
+	}
+
+	images, err := is.ListImages(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("listing images: %w", err)
+	}
+	if err := writeJSONArtifact(dir, "images.json", images); err != nil {
+		return err
+	}
+
+	statuses := map[string]*runtimeapi.ContainerStatusResponse{}
+	for _, container := range containers {
+		status, err := rs.ContainerStatus(ctx, container.Id, true)
+		if err != nil {
+			// The container may be gone between list and inspect.
+			continue
+		}
+		statuses[container.Id] = status
+	}
+	return writeJSONArtifact(dir, "container-statuses.json", statuses)
+}
+
+func writeJSONArtifact(dir, name string, obj interface{}) error {
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", name, err)
+	}
+	return artifacts.WriteFile(dir, name, data)
+}
@@ -19,21 +19,14 @@ package e2enode
 import (
 	"archive/tar"
 	"context"
-	"encoding/json"
-	"fmt"
 	"io"
-	"net/http"
 	"os"
-	"strings"
-	"time"
 
 	"github.com/onsi/ginkgo/v2"
 	v1 "k8s.io/api/core/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	clientset "k8s.io/client-go/kubernetes"
-	restclient "k8s.io/client-go/rest"
 	"k8s.io/kubernetes/test/e2e/framework"
+	e2echeckpoint "k8s.io/kubernetes/test/e2e/framework/checkpoint"
 	e2emetrics "k8s.io/kubernetes/test/e2e/framework/metrics"
 	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
 	"k8s.io/kubernetes/test/e2e/nodefeature"
@@ -44,40 +37,6 @@ import (
 	"github.com/onsi/gomega"
 )
 
-const (
-	// timeout for proxy requests.
-	proxyTimeout = 2 * time.Minute
-)
-
-type checkpointResult struct {
-	Items []string `json:"items"`
-}
-
-// proxyPostRequest performs a post on a node proxy endpoint given the nodename and rest client.
-func proxyPostRequest(ctx context.Context, c clientset.Interface, node, endpoint string, port int) (restclient.Result, error) {
-	// proxy tends to hang in some cases when Node is not ready. Add an artificial timeout for this call. #22165
-	var result restclient.Result
-	finished := make(chan struct{}, 1)
-	go func() {
-		result = c.CoreV1().RESTClient().Post().
-			Resource("nodes").
-			SubResource("proxy").
-			Name(fmt.Sprintf("%v:%v", node, port)).
-			Suffix(endpoint).
-			Do(ctx)
-
-		finished <- struct{}{}
-	}()
-	select {
-	case <-finished:
-		return result, nil
-	case <-ctx.Done():
-		return restclient.Result{}, nil
-	case <-time.After(proxyTimeout):
-		return restclient.Result{}, nil
-	}
-}
-
 func getCheckpointContainerMetric(ctx context.Context, f *framework.Framework, pod *v1.Pod) (int, error) {
 	framework.Logf("Getting 'checkpoint_container' metrics from %q", pod.Spec.NodeName)
 	ms, err := e2emetrics.GetKubeletMetrics(
@@ -180,95 +139,28 @@ var _ = SIGDescribe("Checkpoint Container", nodefeature.CheckpointContainer, fun
 			pod.Spec.Containers[0].Name,
 			pod.Spec.NodeName,
 		)
-		result, err := proxyPostRequest(
+		items, err := e2echeckpoint.Checkpoint(
 			ctx,
 			f.ClientSet,
+			f.Namespace.Name,
+			pod.Name,
+			pod.Spec.Containers[0].Name,
 			pod.Spec.NodeName,
-			fmt.Sprintf(
-				"checkpoint/%s/%s/%s",
-				f.Namespace.Name,
-				pod.Name,
-				pod.Spec.Containers[0].Name,
-			),
-			framework.KubeletPort,
 		)
-
-		framework.ExpectNoError(err)
-
-		err = result.Error()
 		if err != nil {
-			statusError, ok := err.(*apierrors.StatusError)
-			if !ok {
-				framework.Failf("got error %#v, expected StatusError", err)
+			// Depending on the environment the checkpoint API may be
+			// unavailable rather than broken: the feature gate may be off or
+			// the container engine may lack checkpoint/restore support.
+			if reason, unsupported := e2echeckpoint.SkipReason(err); unsupported {
+				ginkgo.Skip(reason)
 			}
-			// If we are testing against a kubelet with ContainerCheckpoint == false
-			// we should get a 404. So a 404 is (also) a good sign.
-			if (int(statusError.ErrStatus.Code)) == http.StatusNotFound {
-				ginkgo.Skip("Feature 'ContainerCheckpoint' is not enabled and not available")
-				return
-			}
-
-			// If the container engine has not implemented the Checkpoint CRI API
-			// we will get 500 and a message with
-			// '(rpc error: code = Unimplemented desc = unknown method CheckpointContainer'
-			// or
-			// '(rpc error: code = Unimplemented desc = method CheckpointContainer not implemented)'
-			// if the container engine returns that it explicitly has disabled support for it.
-			// or
-			// '(rpc error: code = Unknown desc = checkpoint/restore support not available)'
-			// if the container engine explicitly disabled the checkpoint/restore support
-			// or
-			// '(rpc error: code = Unknown desc = CRIU binary not found or too old (<31600). Failed to checkpoint container'
-			// if the CRIU binary was not found if it is too old
-			if (int(statusError.ErrStatus.Code)) == http.StatusInternalServerError {
-				if strings.Contains(
-					statusError.ErrStatus.Message,
-					"(rpc error: code = Unimplemented desc = unknown method CheckpointContainer",
-				) {
-					ginkgo.Skip("Container engine does not implement 'CheckpointContainer'")
-					return
-				}
-				if strings.Contains(
-					statusError.ErrStatus.Message,
-					"(rpc error: code = Unimplemented desc = method CheckpointContainer not implemented)",
-				) {
-					ginkgo.Skip("Container engine does not implement 'CheckpointContainer'")
-					return
-				}
-				if strings.Contains(
-					statusError.ErrStatus.Message,
-					"(rpc error: code = Unknown desc = checkpoint/restore support not available)",
-				) {
-					ginkgo.Skip("Container engine does not implement 'CheckpointContainer'")
-					return
-				}
-				if strings.Contains(
-					statusError.ErrStatus.Message,
-					"(rpc error: code = Unknown desc = CRIU binary not found or too old (<31600). Failed to checkpoint container",
-				) {
-					ginkgo.Skip("Container engine reports missing or too old CRIU binary")
-					return
-				}
-			}
-			framework.Failf(
-				"Unexpected status code (%d) during 'CheckpointContainer': %q",
-				statusError.ErrStatus.Code,
-				statusError.ErrStatus.Message,
-			)
+			framework.ExpectNoError(err, "checkpointing the container")
 		}
 
-		framework.ExpectNoError(err)
-
 		// Checkpointing actually worked. Verify that the checkpoint exists and that
 		// it is a checkpoint.
 
-		raw, err := result.Raw()
-		framework.ExpectNoError(err)
-		answer := checkpointResult{}
-		err = json.Unmarshal(raw, &answer)
-		framework.ExpectNoError(err)
-
-		for _, item := range answer.Items {
+		for _, item := range items {
 			// Check that the file exists
 			_, err := os.Stat(item)
 			framework.ExpectNoError(err)
@@ -0,0 +1,197 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2echeckpoint "k8s.io/kubernetes/test/e2e/framework/checkpoint"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	e2eregistry "k8s.io/kubernetes/test/e2e/framework/registry"
+	"k8s.io/kubernetes/test/e2e/nodefeature"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/gomega"
+)
+
+const (
+	// checkpointContainerName is the name of the checkpointed container; the
+	// restored container must use the same name for the runtime to match the
+	// checkpoint image to it.
+	checkpointContainerName = "counter"
+	// checkpointCounterFile is the in-container file the workload keeps its
+	// state in; surviving the checkpoint/restore round trip proves the
+	// container resumed rather than restarted.
+	checkpointCounterFile = "/tmp/counter"
+)
+
+// restoreUnsupportedMarkers are message fragments container runtimes emit
+// when a pulled checkpoint image cannot be restored, e.g. because the
+// runtime lacks restore support or CRIU is unavailable.
+var restoreUnsupportedMarkers = []string{
+	"criu",
+	"restor",
+	"unimplemented",
+	"not implemented",
+}
+
+var _ = SIGDescribe("Checkpoint restore", nodefeature.CheckpointContainer, framework.WithSerial(), func() {
+	f := framework.NewDefaultFramework("checkpoint-restore")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	ginkgo.It("should restore a checkpointed container from an OCI image via a pod spec", func(ctx context.Context) {
+		ginkgo.By("deploying a registry to hold the checkpoint image")
+		reg, err := e2eregistry.SetupRegistry(ctx, f.ClientSet, f.Namespace.Name, e2eregistry.Options{
+			NodeAccess: e2eregistry.LocalNodeAccess{},
+		})
+		framework.ExpectNoError(err, "setting up the registry")
+		ginkgo.DeferCleanup(reg.Teardown)
+
+		ginkgo.By("creating the container to checkpoint")
+		podClient := e2epod.NewPodClient(f)
+		pod := podClient.CreateSync(ctx, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "checkpoint-restore-source",
+				Namespace: f.Namespace.Name,
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{
+					{
+						Name:    checkpointContainerName,
+						Image:   imageutils.GetE2EImage(imageutils.BusyBox),
+						Command: []string{"/bin/sh", "-c"},
+						Args: []string{fmt.Sprintf(
+							"i=0; while true; do i=$((i+1)); echo $i > %s; sleep 1; done",
+							checkpointCounterFile,
+						)},
+					},
+				},
+			},
+		})
+
+		ginkgo.By("waiting for the container to accumulate state")
+		var countAtCheckpoint int
+		gomega.Eventually(ctx, func() (int, error) {
+			return readCheckpointCounter(f, pod.Name)
+		}, 30*time.Second, time.Second).Should(gomega.BeNumerically(">=", 1))
+		countAtCheckpoint, err = readCheckpointCounter(f, pod.Name)
+		framework.ExpectNoError(err, "reading the counter before the checkpoint")
+
+		ginkgo.By("checkpointing the container")
+		items, err := e2echeckpoint.Checkpoint(
+			ctx,
+			f.ClientSet,
+			f.Namespace.Name,
+			pod.Name,
+			checkpointContainerName,
+			pod.Spec.NodeName,
+		)
+		if err != nil {
+			if reason, unsupported := e2echeckpoint.SkipReason(err); unsupported {
+				ginkgo.Skip(reason)
+			}
+			framework.ExpectNoError(err, "checkpointing the container")
+		}
+		gomega.Expect(items).ToNot(gomega.BeEmpty(), "expected the kubelet to report the checkpoint archive")
+		for _, item := range items {
+			ginkgo.DeferCleanup(func() { framework.ExpectNoError(os.RemoveAll(item)) })
+		}
+
+		ginkgo.By("converting the checkpoint archive into an OCI image and pushing it")
+		archive, err := os.ReadFile(items[0])
+		framework.ExpectNoError(err, "reading checkpoint archive %q", items[0])
+		image, err := e2echeckpoint.ImageFromArchive(archive, checkpointContainerName)
+		framework.ExpectNoError(err, "converting the checkpoint archive")
+		_, err = image.Push(ctx, reg.PodHost(), "checkpoints/counter", "v1")
+		framework.ExpectNoError(err, "pushing the checkpoint image")
+
+		ginkgo.By("restoring the container from the pushed image via a pod spec")
+		restorePod := podClient.Create(ctx, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "checkpoint-restore-target",
+				Namespace: f.Namespace.Name,
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{
+					{
+						Name:            checkpointContainerName,
+						Image:           reg.ImageRef("checkpoints/counter:v1"),
+						ImagePullPolicy: v1.PullAlways,
+					},
+				},
+			},
+		})
+		if err := e2epod.WaitTimeoutForPodRunningInNamespace(ctx, f.ClientSet, restorePod.Name, f.Namespace.Name, framework.PodStartTimeout); err != nil {
+			if reason, unsupported := restoreUnsupported(ctx, f, restorePod.Name); unsupported {
+				ginkgo.Skip(reason)
+			}
+			framework.Failf("restored pod did not start: %v", err)
+		}
+
+		ginkgo.By("verifying the restored container resumed from the checkpointed state")
+		restoredCount, err := readCheckpointCounter(f, restorePod.Name)
+		framework.ExpectNoError(err, "reading the counter after the restore")
+		gomega.Expect(restoredCount).To(gomega.BeNumerically(">=", countAtCheckpoint),
+			"expected the counter to resume from at least %d, got %d", countAtCheckpoint, restoredCount)
+	})
+})
+
+// readCheckpointCounter returns the workload's current counter value.
+func readCheckpointCounter(f *framework.Framework, podName string) (int, error) {
+	stdout, stderr, err := e2epod.ExecCommandInContainerWithFullOutput(f, podName, checkpointContainerName,
+		"/bin/sh", "-c", fmt.Sprintf("cat %s", checkpointCounterFile))
+	if err != nil {
+		return 0, fmt.Errorf("reading %s: %w (stderr: %s)", checkpointCounterFile, err, stderr)
+	}
+	return strconv.Atoi(strings.TrimSpace(stdout))
+}
+
+// restoreUnsupported reports whether the restore pod failed because the
+// container runtime cannot restore checkpoint images, based on the messages
+// it attached to the container status.
+func restoreUnsupported(ctx context.Context, f *framework.Framework, podName string) (string, bool) {
+	pod, err := f.ClientSet.CoreV1().Pods(f.Namespace.Name).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return "", false
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		var message string
+		switch {
+		case status.State.Waiting != nil:
+			message = status.State.Waiting.Message
+		case status.State.Terminated != nil:
+			message = status.State.Terminated.Message
+		}
+		lowered := strings.ToLower(message)
+		for _, marker := range restoreUnsupportedMarkers {
+			if strings.Contains(lowered, marker) {
+				return fmt.Sprintf("Container engine cannot restore checkpoint images: %s", message), true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	e2eregistry "k8s.io/kubernetes/test/e2e/framework/registry"
+	"k8s.io/kubernetes/test/e2e_node/services"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+const (
+	// matrixExecPluginName is the name of the exec credential provider
+	// plugin installed by the exec mechanism.
+	matrixExecPluginName = "e2e-registry-provider"
+
+	matrixPassword = "e2e-matrix-password"
+)
+
+// pullCredentialMechanism is one way of supplying registry credentials for
+// an image pull. Each mechanism grants access to the protected registry
+// through a different code path in pkg/credentialprovider and the kubelet's
+// secret handling, so the same pull matrix runs against each of them.
+type pullCredentialMechanism struct {
+	name string
+	// user is the registry user this mechanism authenticates as, so the
+	// registry access log attributes pulls to the mechanism under test.
+	user string
+	// configure grants pods in the test namespace access to the protected
+	// registry and returns the mutation to apply to the pull pod. Cleanup
+	// is registered via DeferCleanup.
+	configure func(ctx context.Context, f *framework.Framework, reg *e2eregistry.Registry, user string) func(pod *v1.Pod)
+}
+
+// matrixPullSecret creates a dockerconfigjson secret for the registry in the
+// test namespace.
+func matrixPullSecret(ctx context.Context, f *framework.Framework, reg *e2eregistry.Registry, user string) *v1.Secret {
+	data, err := e2eregistry.DockerConfigJSON(reg.Host(), user, matrixPassword)
+	framework.ExpectNoError(err, "building docker config")
+	secret, err := f.ClientSet.CoreV1().Secrets(f.Namespace.Name).Create(ctx, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry-pull-secret"},
+		Type:       v1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{v1.DockerConfigJsonKey: data},
+	}, metav1.CreateOptions{})
+	framework.ExpectNoError(err, "creating pull secret")
+	return secret
+}
+
+func pullCredentialMechanisms() []pullCredentialMechanism {
+	return []pullCredentialMechanism{
+		{
+			name: "an exec credential provider plugin",
+			user: "e2e-exec-plugin-user",
+			configure: func(ctx context.Context, f *framework.Framework, reg *e2eregistry.Registry, user string) func(pod *v1.Pod) {
+				ginkgo.By("installing an exec credential provider plugin for the registry")
+				script := fmt.Sprintf(`#!/bin/sh
+cat > /dev/null
+cat <<'EOF'
+{"kind":"CredentialProviderResponse","apiVersion":"credentialprovider.kubelet.k8s.io/v1","cacheKeyType":"Registry","auth":{%q:{"username":%q,"password":%q}}}
+EOF
+`, reg.Host(), user, matrixPassword)
+				framework.ExpectNoError(services.InstallCredentialProviderPlugin(matrixExecPluginName, []byte(script)))
+				config := fmt.Sprintf(`apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+  - name: %s
+    matchImages:
+      - %q
+    defaultCacheDuration: "1m"
+    apiVersion: credentialprovider.kubelet.k8s.io/v1
+`, matrixExecPluginName, reg.Host())
+				framework.ExpectNoError(services.WriteCredentialProviderConfig([]byte(config)))
+				ginkgo.DeferCleanup(func(ctx context.Context) {
+					framework.ExpectNoError(services.ResetCredentialProviderConfig())
+					framework.ExpectNoError(services.RemoveCredentialProviderPlugin(matrixExecPluginName))
+					restartKubelet(true)
+					waitForKubeletToStart(ctx, f)
+				})
+
+				ginkgo.By("restarting the kubelet to register the plugin")
+				restartKubelet(true)
+				waitForKubeletToStart(ctx, f)
+				return func(pod *v1.Pod) {}
+			},
+		},
+		{
+			// Credentials flow through the pod's service account here: the
+			// kubelet resolves the secrets referenced by the service
+			// account's imagePullSecrets, not anything on the pod itself.
+			name: "a service account with image pull secrets",
+			user: "e2e-service-account-user",
+			configure: func(ctx context.Context, f *framework.Framework, reg *e2eregistry.Registry, user string) func(pod *v1.Pod) {
+				ginkgo.By("creating a service account referencing a pull secret")
+				secret := matrixPullSecret(ctx, f, reg, user)
+				sa, err := f.ClientSet.CoreV1().ServiceAccounts(f.Namespace.Name).Create(ctx, &v1.ServiceAccount{
+					ObjectMeta:       metav1.ObjectMeta{Name: "registry-puller"},
+					ImagePullSecrets: []v1.LocalObjectReference{{Name: secret.Name}},
+				}, metav1.CreateOptions{})
+				framework.ExpectNoError(err, "creating service account")
+				return func(pod *v1.Pod) {
+					pod.Spec.ServiceAccountName = sa.Name
+				}
+			},
+		},
+		{
+			name: "pod-level image pull secrets",
+			user: "e2e-pod-secret-user",
+			configure: func(ctx context.Context, f *framework.Framework, reg *e2eregistry.Registry, user string) func(pod *v1.Pod) {
+				ginkgo.By("creating a pull secret for the pod to reference")
+				secret := matrixPullSecret(ctx, f, reg, user)
+				return func(pod *v1.Pod) {
+					pod.Spec.ImagePullSecrets = []v1.LocalObjectReference{{Name: secret.Name}}
+				}
+			},
+		},
+	}
+}
+
+var _ = SIGDescribe("Image pull credential mechanisms", framework.WithSerial(), framework.WithDisruptive(), func() {
+	f := framework.NewDefaultFramework("credential-provider-matrix")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	for _, mechanism := range pullCredentialMechanisms() {
+		mechanism := mechanism
+		ginkgo.It(fmt.Sprintf("should pull from a private registry with %s", mechanism.name), func(ctx context.Context) {
+			ginkgo.By("deploying a registry protected by basic auth")
+			reg, err := e2eregistry.SetupRegistry(ctx, f.ClientSet, f.Namespace.Name, e2eregistry.Options{
+				NodeAccess: e2eregistry.LocalNodeAccess{},
+				Auth: &e2eregistry.BasicAuthOptions{
+					Username: mechanism.user,
+					Password: matrixPassword,
+				},
+			})
+			framework.ExpectNoError(err, "setting up protected registry")
+			ginkgo.DeferCleanup(reg.Teardown)
+
+			mutate := mechanism.configure(ctx, f, reg, mechanism.user)
+
+			ginkgo.By("pulling the preloaded image through the mechanism under test")
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "credential-matrix-puller"},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:            "pause",
+							Image:           reg.ImageRef("pause:testing"),
+							ImagePullPolicy: v1.PullAlways,
+						},
+					},
+				},
+			}
+			mutate(pod)
+			pod = e2epod.NewPodClient(f).Create(ctx, pod)
+			framework.ExpectNoError(e2epod.WaitTimeoutForPodRunningInNamespace(
+				ctx, f.ClientSet, pod.Name, f.Namespace.Name, framework.PodStartTimeout),
+				"pulling %s with %s", reg.ImageRef("pause:testing"), mechanism.name)
+
+			ginkgo.By("verifying the registry saw this mechanism's credentials")
+			entries, err := reg.AccessLog(ctx)
+			framework.ExpectNoError(err, "reading registry access log")
+			sawUser := false
+			for _, entry := range e2eregistry.EntriesForPath(entries, "/pause/") {
+				if entry.BasicAuthUser != "" {
+					gomega.Expect(entry.BasicAuthUser).To(gomega.Equal(mechanism.user),
+						"request %s %s carried an unexpected user", entry.Method, entry.Path)
+					sawUser = true
+				}
+			}
+			gomega.Expect(sawUser).To(gomega.BeTrueBecause(
+				"expected at least one request authenticated as %s", mechanism.user))
+		})
+	}
+})
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeletconfig "k8s.io/kubernetes/pkg/kubelet/apis/config"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	e2eregistry "k8s.io/kubernetes/test/e2e/framework/registry"
+	"k8s.io/kubernetes/test/e2e_node/services"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+)
+
+const (
+	// reloadPluginName is the exec plugin installed while the kubelet is
+	// running; the reloaded config is what makes the kubelet use it.
+	reloadPluginName = "e2e-reload-provider"
+
+	reloadUser     = "e2e-reload-user"
+	reloadPassword = "e2e-reload-password"
+)
+
+var _ = SIGDescribe("Credential provider config reload", framework.WithSerial(), framework.WithDisruptive(), func() {
+	f := framework.NewDefaultFramework("credential-provider-reload")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	tempSetCurrentKubeletConfig(f, func(ctx context.Context, initialConfig *kubeletconfig.KubeletConfiguration) {
+		if initialConfig.FeatureGates == nil {
+			initialConfig.FeatureGates = map[string]bool{}
+		}
+		initialConfig.FeatureGates["KubeletCredentialProviderReload"] = true
+	})
+
+	ginkgo.It("should apply a changed credential provider config without a kubelet restart", func(ctx context.Context) {
+		ginkgo.By("deploying a registry protected by basic auth")
+		reg, err := e2eregistry.SetupRegistry(ctx, f.ClientSet, f.Namespace.Name, e2eregistry.Options{
+			NodeAccess: e2eregistry.LocalNodeAccess{},
+			Auth: &e2eregistry.BasicAuthOptions{
+				Username: reloadUser,
+				Password: reloadPassword,
+			},
+		})
+		framework.ExpectNoError(err, "setting up protected registry")
+		ginkgo.DeferCleanup(reg.Teardown)
+
+		ginkgo.By("installing an exec plugin and rewriting the credential provider config while the kubelet is running")
+		script := fmt.Sprintf(`#!/bin/sh
+cat > /dev/null
+cat <<'EOF'
+{"kind":"CredentialProviderResponse","apiVersion":"credentialprovider.kubelet.k8s.io/v1","cacheKeyType":"Registry","auth":{%q:{"username":%q,"password":%q}}}
+EOF
+`, reg.Host(), reloadUser, reloadPassword)
+		framework.ExpectNoError(services.InstallCredentialProviderPlugin(reloadPluginName, []byte(script)))
+		config := fmt.Sprintf(`apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+  - name: %s
+    matchImages:
+      - %q
+    defaultCacheDuration: "1m"
+    apiVersion: credentialprovider.kubelet.k8s.io/v1
+`, reloadPluginName, reg.Host())
+		framework.ExpectNoError(services.WriteCredentialProviderConfig([]byte(config)))
+		ginkgo.DeferCleanup(func(ctx context.Context) {
+			// With reload enabled the restored placeholder config is also
+			// picked up live; no kubelet restart is needed.
+			framework.ExpectNoError(services.ResetCredentialProviderConfig())
+			framework.ExpectNoError(services.RemoveCredentialProviderPlugin(reloadPluginName))
+		})
+
+		ginkgo.By("pulling the preloaded image with credentials from the reloaded config")
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "credential-reload-puller"},
+			Spec: v1.PodSpec{
+				RestartPolicy: v1.RestartPolicyNever,
+				Containers: []v1.Container{
+					{
+						Name:            "pause",
+						Image:           reg.ImageRef("pause:testing"),
+						ImagePullPolicy: v1.PullAlways,
+					},
+				},
+			},
+		}
+		pod = e2epod.NewPodClient(f).Create(ctx, pod)
+		framework.ExpectNoError(e2epod.WaitTimeoutForPodRunningInNamespace(
+			ctx, f.ClientSet, pod.Name, f.Namespace.Name, framework.PodStartTimeout),
+			"pulling %s after a live config reload", reg.ImageRef("pause:testing"))
+	})
+})
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/kubernetes/pkg/credentialprovider"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2eregistry "k8s.io/kubernetes/test/e2e/framework/registry"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+// postCredentialResolution queries the kubelet debug endpoint for the
+// credential resolution trace of the given image.
+func postCredentialResolution(ctx context.Context, f *framework.Framework, nodeName, image string) (*credentialprovider.ResolutionTrace, error) {
+	body := fmt.Sprintf(`{"image":%q}`, image)
+	data, err := f.ClientSet.CoreV1().RESTClient().Post().
+		Resource("nodes").
+		SubResource("proxy").
+		Name(fmt.Sprintf("%v:%v", nodeName, framework.KubeletPort)).
+		Suffix("debug/credential-resolution").
+		Body([]byte(body)).
+		Do(ctx).Raw()
+	if err != nil {
+		return nil, fmt.Errorf("querying credential resolution for %q: %w", image, err)
+	}
+	var trace credentialprovider.ResolutionTrace
+	if err := json.Unmarshal(data, &trace); err != nil {
+		return nil, fmt.Errorf("decoding credential resolution trace: %w", err)
+	}
+	return &trace, nil
+}
+
+var _ = SIGDescribe("Credential resolution debug endpoint", framework.WithSerial(), func() {
+	f := framework.NewDefaultFramework("credential-resolution")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	ginkgo.It("should report which config.json entry matches an image", func(ctx context.Context) {
+		ginkgo.By("writing a node config.json with credentials for two registries")
+		auth := func(user string) map[string]string {
+			return map[string]string{"auth": base64.StdEncoding.EncodeToString([]byte(user + ":password"))}
+		}
+		config, err := json.Marshal(map[string]any{
+			"auths": map[string]any{
+				"reg-a.example.com": auth("user-a"),
+				"reg-b.example.com": auth("user-b"),
+			},
+		})
+		framework.ExpectNoError(err)
+		access := e2eregistry.LocalNodeAccess{}
+		framework.ExpectNoError(access.WriteFile(ctx, nodeDockerConfigPath, config, 0o600))
+		ginkgo.DeferCleanup(func(ctx context.Context) {
+			framework.ExpectNoError(access.RemoveFile(ctx, nodeDockerConfigPath))
+		})
+
+		node := getLocalNode(ctx, f)
+
+		ginkgo.By("verifying each image resolves to its own registry entry only")
+		// The node keyring caches the config file, so allow time for the
+		// freshly written file to be picked up.
+		gomega.Eventually(ctx, func() error {
+			for registry, otherRegistry := range map[string]string{
+				"reg-a.example.com": "reg-b.example.com",
+				"reg-b.example.com": "reg-a.example.com",
+			} {
+				trace, err := postCredentialResolution(ctx, f, node.Name, registry+"/app:v1")
+				if err != nil {
+					return err
+				}
+				if !trace.CredentialsFound {
+					return fmt.Errorf("no credentials found for %s yet: %+v", registry, trace)
+				}
+				matched := false
+				for _, step := range trace.Steps {
+					if step.Matched && step.MatchPattern == registry {
+						matched = true
+					}
+					if step.Matched && step.MatchPattern == otherRegistry {
+						return fmt.Errorf("image from %s wrongly matched the %s entry: %+v", registry, otherRegistry, trace)
+					}
+				}
+				if !matched {
+					return fmt.Errorf("expected a step matching %s, got %+v", registry, trace.Steps)
+				}
+			}
+			return nil
+		}, 6*time.Minute, 15*time.Second).Should(gomega.Succeed())
+
+		ginkgo.By("verifying an image from an unconfigured registry finds no credentials")
+		trace, err := postCredentialResolution(ctx, f, node.Name, "unconfigured.example.com/app:v1")
+		framework.ExpectNoError(err)
+		gomega.Expect(trace.CredentialsFound).To(gomega.BeFalseBecause(
+			"no configured source should match an unconfigured registry, got %+v", trace))
+	})
+})
@@ -0,0 +1,232 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+// execTransport names one of the two streaming transports the apiserver and
+// CRI streaming server support, with the framework helper that uses it.
+type execTransport struct {
+	name string
+	exec func(ctx context.Context, f *framework.Framework, options e2epod.ExecOptions) (string, string, error)
+}
+
+var execTransports = []execTransport{
+	{name: "SPDY", exec: e2epod.ExecWithOptionsContext},
+	{name: "WebSocket", exec: e2epod.ExecWithWebsocket},
+}
+
+// Exec, attach and port-forward all terminate in the runtime's CRI streaming
+// server. With WebSockets now the default kubectl transport, the server's
+// behavior has to hold on both paths, so every exec case below runs once per
+// transport.
+var _ = SIGDescribe("CRI streaming", func() {
+	f := framework.NewDefaultFramework("cri-streaming")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	for _, transport := range execTransports {
+		transport := transport
+		ginkgo.Context(fmt.Sprintf("over %s", transport.name), func() {
+			const podName = "cri-streaming-target"
+			const containerName = "target"
+
+			ginkgo.BeforeEach(func(ctx context.Context) {
+				pod := &v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: podName},
+					Spec: v1.PodSpec{
+						RestartPolicy: v1.RestartPolicyNever,
+						Containers: []v1.Container{
+							{
+								Name:    containerName,
+								Image:   imageutils.GetE2EImage(imageutils.BusyBox),
+								Command: []string{"sh", "-c", "sleep 10000"},
+							},
+						},
+					},
+				}
+				e2epod.NewPodClient(f).CreateSync(ctx, pod)
+			})
+
+			f.It("should stream large command output intact", f.WithNodeConformance(), func(ctx context.Context) {
+				const size = 1 << 20
+				stdout, _, err := transport.exec(ctx, f, e2epod.ExecOptions{
+					Command:            []string{"sh", "-c", fmt.Sprintf("head -c %d /dev/zero | tr '\\0' 'a'", size)},
+					Namespace:          f.Namespace.Name,
+					PodName:            podName,
+					ContainerName:      containerName,
+					CaptureStdout:      true,
+					CaptureStderr:      true,
+					PreserveWhitespace: true,
+					Quiet:              true,
+				})
+				framework.ExpectNoError(err, "executing large-output command over %s", transport.name)
+				gomega.Expect(stdout).To(gomega.HaveLen(size), "expected the full output to arrive")
+				gomega.Expect(strings.Count(stdout, "a")).To(gomega.Equal(size), "expected the output to arrive uncorrupted")
+			})
+
+			f.It("should propagate stdin half-close to the command", f.WithNodeConformance(), func(ctx context.Context) {
+				// cat only exits once it sees EOF on stdin, so getting the
+				// echoed payload back proves the client's half-close made it
+				// through apiserver and streaming server to the process.
+				payload := strings.Repeat("0123456789abcdef", 4096)
+				stdout, _, err := transport.exec(ctx, f, e2epod.ExecOptions{
+					Command:            []string{"cat"},
+					Namespace:          f.Namespace.Name,
+					PodName:            podName,
+					ContainerName:      containerName,
+					Stdin:              strings.NewReader(payload),
+					CaptureStdout:      true,
+					CaptureStderr:      true,
+					PreserveWhitespace: true,
+					Quiet:              true,
+				})
+				framework.ExpectNoError(err, "executing cat with closed stdin over %s", transport.name)
+				gomega.Expect(stdout).To(gomega.Equal(payload), "expected the payload to be echoed back completely")
+			})
+
+			f.It("should apply the initial terminal size to a TTY", f.WithNodeConformance(), func(ctx context.Context) {
+				stdout, _, err := transport.exec(ctx, f, e2epod.ExecOptions{
+					Command:           []string{"sh", "-c", "sleep 2 && stty size"},
+					Namespace:         f.Namespace.Name,
+					PodName:           podName,
+					ContainerName:     containerName,
+					CaptureStdout:     true,
+					TTY:               true,
+					TerminalSizeQueue: newTerminalSizeQueue(remotecommand.TerminalSize{Width: 123, Height: 34}),
+				})
+				framework.ExpectNoError(err, "executing stty in a TTY over %s", transport.name)
+				gomega.Expect(stdout).To(gomega.ContainSubstring("34 123"), "expected the terminal to have the size sent over the resize stream")
+			})
+
+			f.It("should unblock a hung stream when the context ends", func(ctx context.Context) {
+				execCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+				defer cancel()
+				start := time.Now()
+				_, _, err := transport.exec(execCtx, f, e2epod.ExecOptions{
+					Command:       []string{"sleep", "600"},
+					Namespace:     f.Namespace.Name,
+					PodName:       podName,
+					ContainerName: containerName,
+					CaptureStdout: true,
+					Quiet:         true,
+				})
+				gomega.Expect(err).To(gomega.HaveOccurred(), "expected the stream to fail once the context ended")
+				gomega.Expect(time.Since(start)).To(gomega.BeNumerically("<", 2*time.Minute), "expected the stream to unblock promptly")
+			})
+		})
+	}
+
+	f.It("should stream container output over a WebSocket attach", func(ctx context.Context) {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "cri-streaming-attach"},
+			Spec: v1.PodSpec{
+				RestartPolicy: v1.RestartPolicyNever,
+				Containers: []v1.Container{
+					{
+						Name:    "target",
+						Image:   imageutils.GetE2EImage(imageutils.BusyBox),
+						Command: []string{"sh", "-c", "while true; do echo ping; sleep 1; done"},
+					},
+				},
+			},
+		}
+		e2epod.NewPodClient(f).CreateSync(ctx, pod)
+
+		attachCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stdout := &syncBuffer{}
+		attachErr := make(chan error, 1)
+		go func() {
+			attachErr <- e2epod.AttachWithWebsocket(attachCtx, f, e2epod.AttachOptions{
+				Namespace:     f.Namespace.Name,
+				PodName:       pod.Name,
+				ContainerName: "target",
+				Stdout:        stdout,
+			})
+		}()
+
+		gomega.Eventually(ctx, stdout.String).WithTimeout(time.Minute).Should(
+			gomega.ContainSubstring("ping"), "expected attached stdout to deliver the container's output")
+
+		cancel()
+		err := <-attachErr
+		if err != nil && !errors.Is(err, context.Canceled) {
+			framework.Failf("unexpected attach error: %v", err)
+		}
+	})
+})
+
+// terminalSizeQueue feeds a fixed sequence of terminal sizes to a streaming
+// executor and then reports no further resizes.
+type terminalSizeQueue struct {
+	sizes chan remotecommand.TerminalSize
+}
+
+func newTerminalSizeQueue(sizes ...remotecommand.TerminalSize) *terminalSizeQueue {
+	q := &terminalSizeQueue{sizes: make(chan remotecommand.TerminalSize, len(sizes))}
+	for _, size := range sizes {
+		q.sizes <- size
+	}
+	close(q.sizes)
+	return q
+}
+
+func (q *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	size, ok := <-q.sizes
+	if !ok {
+		return nil
+	}
+	return &size
+}
+
+// syncBuffer is a bytes.Buffer that is safe to read while a streaming
+// goroutine writes to it.
+type syncBuffer struct {
+	lock   sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buffer.String()
+}
@@ -0,0 +1,535 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package criproxy implements a gRPC proxy which sits between the kubelet and
+// its container runtime. Every CRI call is forwarded to the real runtime
+// unmodified, but tests may register injectors which run before a call is
+// forwarded to delay it or fail it, making runtime-level fault scenarios
+// reproducible deterministically.
+package criproxy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	"k8s.io/cri-client/pkg/util"
+	utilexec "k8s.io/utils/exec"
+)
+
+// Names of the CRI APIs which injectors are keyed by.
+const (
+	Version                  = "Version"
+	RunPodSandbox            = "RunPodSandbox"
+	StopPodSandbox           = "StopPodSandbox"
+	RemovePodSandbox         = "RemovePodSandbox"
+	PodSandboxStatus         = "PodSandboxStatus"
+	ListPodSandbox           = "ListPodSandbox"
+	CreateContainer          = "CreateContainer"
+	StartContainer           = "StartContainer"
+	StopContainer            = "StopContainer"
+	RemoveContainer          = "RemoveContainer"
+	ListContainers           = "ListContainers"
+	ContainerStatus          = "ContainerStatus"
+	UpdateContainerResources = "UpdateContainerResources"
+	ReopenContainerLog       = "ReopenContainerLog"
+	ExecSync                 = "ExecSync"
+	Exec                     = "Exec"
+	Attach                   = "Attach"
+	PortForward              = "PortForward"
+	ContainerStats           = "ContainerStats"
+	ListContainerStats       = "ListContainerStats"
+	PodSandboxStats          = "PodSandboxStats"
+	ListPodSandboxStats      = "ListPodSandboxStats"
+	UpdateRuntimeConfig      = "UpdateRuntimeConfig"
+	Status                   = "Status"
+	CheckpointContainer      = "CheckpointContainer"
+	GetContainerEvents       = "GetContainerEvents"
+	ListMetricDescriptors    = "ListMetricDescriptors"
+	ListPodSandboxMetrics    = "ListPodSandboxMetrics"
+	RuntimeConfig            = "RuntimeConfig"
+	ListImages               = "ListImages"
+	ImageStatus              = "ImageStatus"
+	PullImage                = "PullImage"
+	RemoveImage              = "RemoveImage"
+	ImageFsInfo              = "ImageFsInfo"
+)
+
+// RemoteRuntime is the CRI proxy server. It implements both the runtime and
+// the image CRI services by delegating to the real runtime behind it.
+type RemoteRuntime struct {
+	server         *grpc.Server
+	runtimeService internalapi.RuntimeService
+	imageService   internalapi.ImageManagerService
+
+	lock      sync.Mutex
+	injectors []func(apiName string) error
+}
+
+// NewRemoteRuntimeProxy creates a new RemoteRuntime proxying to the given
+// runtime and image services.
+func NewRemoteRuntimeProxy(runtimeService internalapi.RuntimeService, imageService internalapi.ImageManagerService) *RemoteRuntime {
+	p := &RemoteRuntime{
+		server:         grpc.NewServer(),
+		runtimeService: runtimeService,
+		imageService:   imageService,
+	}
+	runtimeapi.RegisterRuntimeServiceServer(p.server, p)
+	runtimeapi.RegisterImageServiceServer(p.server, p)
+	return p
+}
+
+// GenerateEndpoint returns a fresh unix socket endpoint for the proxy to
+// listen on.
+func GenerateEndpoint() (string, error) {
+	dir, err := os.MkdirTemp("", "criproxy")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("unix://%s", filepath.Join(dir, "proxy.sock")), nil
+}
+
+// Start starts serving the CRI services on the given endpoint.
+func (f *RemoteRuntime) Start(endpoint string) error {
+	l, err := util.CreateListener(endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %q: %w", endpoint, err)
+	}
+	go f.server.Serve(l)
+	return nil
+}
+
+// Stop stops the underlying gRPC server.
+func (f *RemoteRuntime) Stop() {
+	f.server.Stop()
+}
+
+// AddInjector appends an injector which runs before every proxied CRI call.
+// The injector receives the name of the API being called; returning an error
+// fails the call without forwarding it, and blocking delays it.
+func (f *RemoteRuntime) AddInjector(injector func(apiName string) error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.injectors = append(f.injectors, injector)
+}
+
+// ResetInjectors removes all registered injectors.
+func (f *RemoteRuntime) ResetInjectors() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.injectors = nil
+}
+
+func (f *RemoteRuntime) runInjectors(apiName string) error {
+	f.lock.Lock()
+	injectors := make([]func(apiName string) error, len(f.injectors))
+	copy(injectors, f.injectors)
+	f.lock.Unlock()
+
+	for _, injector := range injectors {
+		if err := injector(apiName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Version returns the runtime name, runtime version, and runtime API version.
+func (f *RemoteRuntime) Version(ctx context.Context, req *runtimeapi.VersionRequest) (*runtimeapi.VersionResponse, error) {
+	if err := f.runInjectors(Version); err != nil {
+		return nil, err
+	}
+	return f.runtimeService.Version(ctx, req.Version)
+}
+
+// RunPodSandbox creates and starts a pod-level sandbox.
+func (f *RemoteRuntime) RunPodSandbox(ctx context.Context, req *runtimeapi.RunPodSandboxRequest) (*runtimeapi.RunPodSandboxResponse, error) {
+	if err := f.runInjectors(RunPodSandbox); err != nil {
+		return nil, err
+	}
+	sandboxID, err := f.runtimeService.RunPodSandbox(ctx, req.Config, req.RuntimeHandler)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.RunPodSandboxResponse{PodSandboxId: sandboxID}, nil
+}
+
+// StopPodSandbox stops any running process that is part of the sandbox.
+func (f *RemoteRuntime) StopPodSandbox(ctx context.Context, req *runtimeapi.StopPodSandboxRequest) (*runtimeapi.StopPodSandboxResponse, error) {
+	if err := f.runInjectors(StopPodSandbox); err != nil {
+		return nil, err
+	}
+	if err := f.runtimeService.StopPodSandbox(ctx, req.PodSandboxId); err != nil {
+		return nil, err
+	}
+	return &runtimeapi.StopPodSandboxResponse{}, nil
+}
+
+// RemovePodSandbox removes the sandbox.
+func (f *RemoteRuntime) RemovePodSandbox(ctx context.Context, req *runtimeapi.RemovePodSandboxRequest) (*runtimeapi.RemovePodSandboxResponse, error) {
+	if err := f.runInjectors(RemovePodSandbox); err != nil {
+		return nil, err
+	}
+	if err := f.runtimeService.RemovePodSandbox(ctx, req.PodSandboxId); err != nil {
+		return nil, err
+	}
+	return &runtimeapi.RemovePodSandboxResponse{}, nil
+}
+
+// PodSandboxStatus returns the status of the PodSandbox.
+func (f *RemoteRuntime) PodSandboxStatus(ctx context.Context, req *runtimeapi.PodSandboxStatusRequest) (*runtimeapi.PodSandboxStatusResponse, error) {
+	if err := f.runInjectors(PodSandboxStatus); err != nil {
+		return nil, err
+	}
+	return f.runtimeService.PodSandboxStatus(ctx, req.PodSandboxId, req.Verbose)
+}
+
+// ListPodSandbox returns a list of PodSandboxes.
+func (f *RemoteRuntime) ListPodSandbox(ctx context.Context, req *runtimeapi.ListPodSandboxRequest) (*runtimeapi.ListPodSandboxResponse, error) {
+	if err := f.runInjectors(ListPodSandbox); err != nil {
+		return nil, err
+	}
+	items, err := f.runtimeService.ListPodSandbox(ctx, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.ListPodSandboxResponse{Items: items}, nil
+}
+
+// CreateContainer creates a new container in the specified PodSandbox.
+func (f *RemoteRuntime) CreateContainer(ctx context.Context, req *runtimeapi.CreateContainerRequest) (*runtimeapi.CreateContainerResponse, error) {
+	if err := f.runInjectors(CreateContainer); err != nil {
+		return nil, err
+	}
+	containerID, err := f.runtimeService.CreateContainer(ctx, req.PodSandboxId, req.Config, req.SandboxConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.CreateContainerResponse{ContainerId: containerID}, nil
+}
+
+// StartContainer starts the container.
+func (f *RemoteRuntime) StartContainer(ctx context.Context, req *runtimeapi.StartContainerRequest) (*runtimeapi.StartContainerResponse, error) {
+	if err := f.runInjectors(StartContainer); err != nil {
+		return nil, err
+	}
+	if err := f.runtimeService.StartContainer(ctx, req.ContainerId); err != nil {
+		return nil, err
+	}
+	return &runtimeapi.StartContainerResponse{}, nil
+}
+
+// StopContainer stops a running container with a grace period.
+func (f *RemoteRuntime) StopContainer(ctx context.Context, req *runtimeapi.StopContainerRequest) (*runtimeapi.StopContainerResponse, error) {
+	if err := f.runInjectors(StopContainer); err != nil {
+		return nil, err
+	}
+	if err := f.runtimeService.StopContainer(ctx, req.ContainerId, req.Timeout); err != nil {
+		return nil, err
+	}
+	return &runtimeapi.StopContainerResponse{}, nil
+}
+
+// RemoveContainer removes the container.
+func (f *RemoteRuntime) RemoveContainer(ctx context.Context, req *runtimeapi.RemoveContainerRequest) (*runtimeapi.RemoveContainerResponse, error) {
+	if err := f.runInjectors(RemoveContainer); err != nil {
+		return nil, err
+	}
+	if err := f.runtimeService.RemoveContainer(ctx, req.ContainerId); err != nil {
+		return nil, err
+	}
+	return &runtimeapi.RemoveContainerResponse{}, nil
+}
+
+// ListContainers lists all containers by filters.
+func (f *RemoteRuntime) ListContainers(ctx context.Context, req *runtimeapi.ListContainersRequest) (*runtimeapi.ListContainersResponse, error) {
+	if err := f.runInjectors(ListContainers); err != nil {
+		return nil, err
+	}
+	containers, err := f.runtimeService.ListContainers(ctx, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.ListContainersResponse{Containers: containers}, nil
+}
+
+// ContainerStatus returns the status of the container.
+func (f *RemoteRuntime) ContainerStatus(ctx context.Context, req *runtimeapi.ContainerStatusRequest) (*runtimeapi.ContainerStatusResponse, error) {
+	if err := f.runInjectors(ContainerStatus); err != nil {
+		return nil, err
+	}
+	return f.runtimeService.ContainerStatus(ctx, req.ContainerId, req.Verbose)
+}
+
+// UpdateContainerResources updates ContainerConfig of the container.
+func (f *RemoteRuntime) UpdateContainerResources(ctx context.Context, req *runtimeapi.UpdateContainerResourcesRequest) (*runtimeapi.UpdateContainerResourcesResponse, error) {
+	if err := f.runInjectors(UpdateContainerResources); err != nil {
+		return nil, err
+	}
+	err := f.runtimeService.UpdateContainerResources(ctx, req.ContainerId, &runtimeapi.ContainerResources{Linux: req.Linux, Windows: req.Windows})
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.UpdateContainerResourcesResponse{}, nil
+}
+
+// ReopenContainerLog asks the runtime to reopen the stdout/stderr log file
+// for the container.
+func (f *RemoteRuntime) ReopenContainerLog(ctx context.Context, req *runtimeapi.ReopenContainerLogRequest) (*runtimeapi.ReopenContainerLogResponse, error) {
+	if err := f.runInjectors(ReopenContainerLog); err != nil {
+		return nil, err
+	}
+	if err := f.runtimeService.ReopenContainerLog(ctx, req.ContainerId); err != nil {
+		return nil, err
+	}
+	return &runtimeapi.ReopenContainerLogResponse{}, nil
+}
+
+// ExecSync runs a command in a container synchronously.
+func (f *RemoteRuntime) ExecSync(ctx context.Context, req *runtimeapi.ExecSyncRequest) (*runtimeapi.ExecSyncResponse, error) {
+	if err := f.runInjectors(ExecSync); err != nil {
+		return nil, err
+	}
+	var exitCode int32
+	stdout, stderr, err := f.runtimeService.ExecSync(ctx, req.ContainerId, req.Cmd, time.Duration(req.Timeout)*time.Second)
+	if err != nil {
+		exitError, ok := err.(utilexec.ExitError)
+		if !ok {
+			return nil, err
+		}
+		exitCode = int32(exitError.ExitStatus())
+	}
+	return &runtimeapi.ExecSyncResponse{
+		Stdout:   stdout,
+		Stderr:   stderr,
+		ExitCode: exitCode,
+	}, nil
+}
+
+// Exec prepares a streaming endpoint to execute a command in the container.
+func (f *RemoteRuntime) Exec(ctx context.Context, req *runtimeapi.ExecRequest) (*runtimeapi.ExecResponse, error) {
+	if err := f.runInjectors(Exec); err != nil {
+		return nil, err
+	}
+	return f.runtimeService.Exec(ctx, req)
+}
+
+// Attach prepares a streaming endpoint to attach to a running container.
+func (f *RemoteRuntime) Attach(ctx context.Context, req *runtimeapi.AttachRequest) (*runtimeapi.AttachResponse, error) {
+	if err := f.runInjectors(Attach); err != nil {
+		return nil, err
+	}
+	return f.runtimeService.Attach(ctx, req)
+}
+
+// PortForward prepares a streaming endpoint to forward ports from a PodSandbox.
+func (f *RemoteRuntime) PortForward(ctx context.Context, req *runtimeapi.PortForwardRequest) (*runtimeapi.PortForwardResponse, error) {
+	if err := f.runInjectors(PortForward); err != nil {
+		return nil, err
+	}
+	return f.runtimeService.PortForward(ctx, req)
+}
+
+// ContainerStats returns stats of the container.
+func (f *RemoteRuntime) ContainerStats(ctx context.Context, req *runtimeapi.ContainerStatsRequest) (*runtimeapi.ContainerStatsResponse, error) {
+	if err := f.runInjectors(ContainerStats); err != nil {
+		return nil, err
+	}
+	stats, err := f.runtimeService.ContainerStats(ctx, req.ContainerId)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.ContainerStatsResponse{Stats: stats}, nil
+}
+
+// ListContainerStats returns stats of all running containers.
+func (f *RemoteRuntime) ListContainerStats(ctx context.Context, req *runtimeapi.ListContainerStatsRequest) (*runtimeapi.ListContainerStatsResponse, error) {
+	if err := f.runInjectors(ListContainerStats); err != nil {
+		return nil, err
+	}
+	stats, err := f.runtimeService.ListContainerStats(ctx, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.ListContainerStatsResponse{Stats: stats}, nil
+}
+
+// PodSandboxStats returns stats of the pod sandbox.
+func (f *RemoteRuntime) PodSandboxStats(ctx context.Context, req *runtimeapi.PodSandboxStatsRequest) (*runtimeapi.PodSandboxStatsResponse, error) {
+	if err := f.runInjectors(PodSandboxStats); err != nil {
+		return nil, err
+	}
+	stats, err := f.runtimeService.PodSandboxStats(ctx, req.PodSandboxId)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.PodSandboxStatsResponse{Stats: stats}, nil
+}
+
+// ListPodSandboxStats returns stats of all running pod sandboxes.
+func (f *RemoteRuntime) ListPodSandboxStats(ctx context.Context, req *runtimeapi.ListPodSandboxStatsRequest) (*runtimeapi.ListPodSandboxStatsResponse, error) {
+	if err := f.runInjectors(ListPodSandboxStats); err != nil {
+		return nil, err
+	}
+	stats, err := f.runtimeService.ListPodSandboxStats(ctx, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.ListPodSandboxStatsResponse{Stats: stats}, nil
+}
+
+// UpdateRuntimeConfig updates the runtime configuration.
+func (f *RemoteRuntime) UpdateRuntimeConfig(ctx context.Context, req *runtimeapi.UpdateRuntimeConfigRequest) (*runtimeapi.UpdateRuntimeConfigResponse, error) {
+	if err := f.runInjectors(UpdateRuntimeConfig); err != nil {
+		return nil, err
+	}
+	if err := f.runtimeService.UpdateRuntimeConfig(ctx, req.RuntimeConfig); err != nil {
+		return nil, err
+	}
+	return &runtimeapi.UpdateRuntimeConfigResponse{}, nil
+}
+
+// Status returns the status of the runtime.
+func (f *RemoteRuntime) Status(ctx context.Context, req *runtimeapi.StatusRequest) (*runtimeapi.StatusResponse, error) {
+	if err := f.runInjectors(Status); err != nil {
+		return nil, err
+	}
+	return f.runtimeService.Status(ctx, req.Verbose)
+}
+
+// CheckpointContainer checkpoints the given container.
+func (f *RemoteRuntime) CheckpointContainer(ctx context.Context, req *runtimeapi.CheckpointContainerRequest) (*runtimeapi.CheckpointContainerResponse, error) {
+	if err := f.runInjectors(CheckpointContainer); err != nil {
+		return nil, err
+	}
+	if err := f.runtimeService.CheckpointContainer(ctx, req); err != nil {
+		return nil, err
+	}
+	return &runtimeapi.CheckpointContainerResponse{}, nil
+}
+
+// GetContainerEvents streams container events from the runtime.
+func (f *RemoteRuntime) GetContainerEvents(req *runtimeapi.GetEventsRequest, ces runtimeapi.RuntimeService_GetContainerEventsServer) error {
+	if err := f.runInjectors(GetContainerEvents); err != nil {
+		return err
+	}
+	// The container events are forwarded through an intermediate channel;
+	// the call to the backing runtime only returns when the stream closes.
+	containerEventsCh := make(chan *runtimeapi.ContainerEventResponse)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		errCh <- f.runtimeService.GetContainerEvents(containerEventsCh, nil)
+	}()
+	for event := range containerEventsCh {
+		if err := ces.Send(event); err != nil {
+			return err
+		}
+	}
+	return <-errCh
+}
+
+// ListMetricDescriptors gets the descriptors for the metrics that will be
+// returned in ListPodSandboxMetrics.
+func (f *RemoteRuntime) ListMetricDescriptors(ctx context.Context, req *runtimeapi.ListMetricDescriptorsRequest) (*runtimeapi.ListMetricDescriptorsResponse, error) {
+	if err := f.runInjectors(ListMetricDescriptors); err != nil {
+		return nil, err
+	}
+	descriptors, err := f.runtimeService.ListMetricDescriptors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.ListMetricDescriptorsResponse{Descriptors: descriptors}, nil
+}
+
+// ListPodSandboxMetrics returns metrics of all running pod sandboxes.
+func (f *RemoteRuntime) ListPodSandboxMetrics(ctx context.Context, req *runtimeapi.ListPodSandboxMetricsRequest) (*runtimeapi.ListPodSandboxMetricsResponse, error) {
+	if err := f.runInjectors(ListPodSandboxMetrics); err != nil {
+		return nil, err
+	}
+	metrics, err := f.runtimeService.ListPodSandboxMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.ListPodSandboxMetricsResponse{PodMetrics: metrics}, nil
+}
+
+// RuntimeConfig returns the configuration information of the runtime.
+func (f *RemoteRuntime) RuntimeConfig(ctx context.Context, req *runtimeapi.RuntimeConfigRequest) (*runtimeapi.RuntimeConfigResponse, error) {
+	if err := f.runInjectors(RuntimeConfig); err != nil {
+		return nil, err
+	}
+	return f.runtimeService.RuntimeConfig(ctx)
+}
+
+// ListImages lists existing images.
+func (f *RemoteRuntime) ListImages(ctx context.Context, req *runtimeapi.ListImagesRequest) (*runtimeapi.ListImagesResponse, error) {
+	if err := f.runInjectors(ListImages); err != nil {
+		return nil, err
+	}
+	images, err := f.imageService.ListImages(ctx, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.ListImagesResponse{Images: images}, nil
+}
+
+// ImageStatus returns the status of the image.
+func (f *RemoteRuntime) ImageStatus(ctx context.Context, req *runtimeapi.ImageStatusRequest) (*runtimeapi.ImageStatusResponse, error) {
+	if err := f.runInjectors(ImageStatus); err != nil {
+		return nil, err
+	}
+	return f.imageService.ImageStatus(ctx, req.Image, req.Verbose)
+}
+
+// PullImage pulls an image with authentication config.
+func (f *RemoteRuntime) PullImage(ctx context.Context, req *runtimeapi.PullImageRequest) (*runtimeapi.PullImageResponse, error) {
+	if err := f.runInjectors(PullImage); err != nil {
+		return nil, err
+	}
+	imageRef, err := f.imageService.PullImage(ctx, req.Image, req.Auth, req.SandboxConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeapi.PullImageResponse{ImageRef: imageRef}, nil
+}
+
+// RemoveImage removes the image.
+func (f *RemoteRuntime) RemoveImage(ctx context.Context, req *runtimeapi.RemoveImageRequest) (*runtimeapi.RemoveImageResponse, error) {
+	if err := f.runInjectors(RemoveImage); err != nil {
+		return nil, err
+	}
+	if err := f.imageService.RemoveImage(ctx, req.Image); err != nil {
+		return nil, err
+	}
+	return &runtimeapi.RemoveImageResponse{}, nil
+}
+
+// ImageFsInfo returns information of the filesystem that is used to store
+// images.
+func (f *RemoteRuntime) ImageFsInfo(ctx context.Context, req *runtimeapi.ImageFsInfoRequest) (*runtimeapi.ImageFsInfoResponse, error) {
+	if err := f.runInjectors(ImageFsInfo); err != nil {
+		return nil, err
+	}
+	return f.imageService.ImageFsInfo(ctx)
+}
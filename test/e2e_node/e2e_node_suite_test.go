@@ -35,6 +35,8 @@ import (
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
@@ -48,6 +50,7 @@ import (
 	e2eskipper "k8s.io/kubernetes/test/e2e/framework/skipper"
 	e2etestfiles "k8s.io/kubernetes/test/e2e/framework/testfiles"
 	e2etestingmanifests "k8s.io/kubernetes/test/e2e/testing-manifests"
+	"k8s.io/kubernetes/test/e2e_node/criproxy"
 	"k8s.io/kubernetes/test/e2e_node/services"
 	e2enodetestingmanifests "k8s.io/kubernetes/test/e2e_node/testing-manifests"
 	system "k8s.io/system-validators/validators"
@@ -70,6 +73,9 @@ import (
 
 var (
 	e2es *services.E2EServices
+	// e2eCriProxy is the CRI proxy the kubelet is connected to when the test
+	// suite runs with --cri-proxy-enabled; nil otherwise.
+	e2eCriProxy *criproxy.RemoteRuntime
 	// featureGates is a map of feature names to bools that enable or disable alpha/experimental features.
 	featureGates map[string]bool
 	// serviceFeatureGates is a map of feature names to bools that enable or
@@ -108,7 +114,9 @@ func registerNodeFlags(flags *flag.FlagSet) {
 	flags.BoolVar(&framework.TestContext.RequireDevices, "require-devices", false, "If true, require device plugins to be installed in the running environment.")
 	flags.Var(cliflag.NewMapStringBool(&featureGates), "feature-gates", "A set of key=value pairs that describe feature gates for alpha/experimental features.")
 	flags.Var(cliflag.NewMapStringBool(&serviceFeatureGates), "service-feature-gates", "A set of key=value pairs that describe feature gates for alpha/experimental features for API service.")
+	flags.StringVar(&framework.TestContext.RuntimeHandler, "runtime-handler", "", "The runtime handler (RuntimeClass handler name, e.g. runc, crun or kata) test pods should run under. If empty, pods run under the container runtime's default handler.")
 	flags.BoolVar(&framework.TestContext.StandaloneMode, "standalone-mode", false, "If true, starts kubelet in standalone mode.")
+	flags.BoolVar(&framework.TestContext.CriProxyEnabled, "cri-proxy-enabled", false, "If true, enable a CRI API proxy which allows the tests to inject failures into CRI calls.")
 }
 
 func init() {
@@ -242,6 +250,23 @@ var _ = ginkgo.SynchronizedBeforeSuite(func(ctx context.Context) []byte {
 	// We should mask locksmithd when provisioning the machine.
 	maskLocksmithdOnCoreos()
 
+	if framework.TestContext.CriProxyEnabled {
+		klog.Infof("Start cri proxy")
+		rService, iService, err := getCRIClient()
+		framework.ExpectNoError(err)
+
+		e2eCriProxy = criproxy.NewRemoteRuntimeProxy(rService, iService)
+		endpoint, err := criproxy.GenerateEndpoint()
+		framework.ExpectNoError(err)
+
+		err = e2eCriProxy.Start(endpoint)
+		framework.ExpectNoError(err)
+
+		// Point the kubelet started below at the proxy instead of the real
+		// runtime endpoint.
+		framework.TestContext.ContainerRuntimeEndpoint = endpoint
+	}
+
 	if *startServices {
 		// If the services are expected to stop after test, they should monitor the test process.
 		// If the services are expected to keep running after test, they should not monitor the test process.
@@ -256,6 +281,11 @@ var _ = ginkgo.SynchronizedBeforeSuite(func(ctx context.Context) []byte {
 		waitForNodeReady(ctx)
 	}
 
+	if framework.TestContext.RuntimeHandler != "" && !framework.TestContext.StandaloneMode {
+		klog.Infof("Creating RuntimeClass for runtime handler %q", framework.TestContext.RuntimeHandler)
+		gomega.Expect(createRuntimeClassForHandler(ctx, framework.TestContext.RuntimeHandler)).To(gomega.Succeed(), "should be able to create the RuntimeClass for the selected runtime handler.")
+	}
+
 	// Reference common test to make the import valid.
 	commontest.CurrentSuite = commontest.NodeE2E
 
@@ -285,6 +315,11 @@ var _ = ginkgo.SynchronizedAfterSuite(func() {}, func() {
 		}
 	}
 
+	if e2eCriProxy != nil {
+		klog.Infof("Stopping cri proxy")
+		e2eCriProxy.Stop()
+	}
+
 	klog.Infof("Tests Finished")
 })
 
@@ -363,6 +398,24 @@ func updateTestContext(ctx context.Context) error {
 	return nil
 }
 
+// createRuntimeClassForHandler creates a RuntimeClass named after the
+// given runtime handler, so that pods created through the pod client can
+// select it via spec.runtimeClassName.
+func createRuntimeClassForHandler(ctx context.Context, handler string) error {
+	client, err := getAPIServerClient()
+	if err != nil {
+		return fmt.Errorf("failed to get apiserver client: %w", err)
+	}
+	rc := &nodev1.RuntimeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: handler},
+		Handler:    handler,
+	}
+	if _, err := client.NodeV1().RuntimeClasses().Create(ctx, rc, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create RuntimeClass %q: %w", handler, err)
+	}
+	return nil
+}
+
 // getNode gets node object from the apiserver.
 func getNode(c *clientset.Clientset) (*v1.Node, error) {
 	nodes, err := c.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
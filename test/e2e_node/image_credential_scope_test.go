@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	e2eregistry "k8s.io/kubernetes/test/e2e/framework/registry"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+const (
+	// credentialScopeUser is the only user the protected registry accepts,
+	// and the only one the node is configured with.
+	credentialScopeUser     = "e2e-scope-user"
+	credentialScopePassword = "e2e-scope-password"
+
+	// nodeDockerConfigPath is where the kubelet keyring looks for node-level
+	// registry credentials.
+	nodeDockerConfigPath = "/var/lib/kubelet/config.json"
+)
+
+// This test guards against regressions of a bug where the node's default
+// credentials were attached to every PullImage request, leaking internal
+// usernames to registries that never asked for authentication.
+var _ = SIGDescribe("Image pull credential scoping", framework.WithSerial(), func() {
+	f := framework.NewDefaultFramework("image-credential-scope")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	ginkgo.It("should not send credentials to registries the node has no credentials for", func(ctx context.Context) {
+		ginkgo.By("deploying an anonymous registry")
+		anonRegistry, err := e2eregistry.SetupRegistry(ctx, f.ClientSet, f.Namespace.Name, e2eregistry.Options{
+			NodeAccess: e2eregistry.LocalNodeAccess{},
+		})
+		framework.ExpectNoError(err, "setting up anonymous registry")
+		ginkgo.DeferCleanup(anonRegistry.Teardown)
+
+		ginkgo.By("deploying a registry protected by basic auth")
+		protectedNamespace, err := f.CreateNamespace(ctx, "image-credential-scope-auth", nil)
+		framework.ExpectNoError(err, "creating namespace for the protected registry")
+		protectedRegistry, err := e2eregistry.SetupRegistry(ctx, f.ClientSet, protectedNamespace.Name, e2eregistry.Options{
+			NodeAccess: e2eregistry.LocalNodeAccess{},
+			Auth: &e2eregistry.BasicAuthOptions{
+				Username: credentialScopeUser,
+				Password: credentialScopePassword,
+			},
+		})
+		framework.ExpectNoError(err, "setting up protected registry")
+		ginkgo.DeferCleanup(protectedRegistry.Teardown)
+
+		// The decoy registry accepts exactly the credentials the node has
+		// configured for the protected registry's host. A pull from it can
+		// only succeed if the kubelet leaks those credentials beyond their
+		// host, so its failure is the scoping assertion.
+		ginkgo.By("deploying a decoy registry accepting the same credentials")
+		decoyNamespace, err := f.CreateNamespace(ctx, "image-credential-scope-decoy", nil)
+		framework.ExpectNoError(err, "creating namespace for the decoy registry")
+		decoyRegistry, err := e2eregistry.SetupRegistry(ctx, f.ClientSet, decoyNamespace.Name, e2eregistry.Options{
+			NodeAccess: e2eregistry.LocalNodeAccess{},
+			Auth: &e2eregistry.BasicAuthOptions{
+				Username: credentialScopeUser,
+				Password: credentialScopePassword,
+			},
+		})
+		framework.ExpectNoError(err, "setting up decoy registry")
+		ginkgo.DeferCleanup(decoyRegistry.Teardown)
+
+		ginkgo.By("configuring node-level credentials for the protected registry only")
+		access := e2eregistry.LocalNodeAccess{}
+		err = e2eregistry.WriteNodeDockerConfig(ctx, access, nodeDockerConfigPath,
+			protectedRegistry.Host(), credentialScopeUser, credentialScopePassword)
+		framework.ExpectNoError(err, "writing node docker config")
+		ginkgo.DeferCleanup(func(ctx context.Context) {
+			framework.ExpectNoError(access.RemoveFile(ctx, nodeDockerConfigPath))
+		})
+
+		ginkgo.By("pulling the preloaded image from each registry")
+		podClient := e2epod.NewPodClient(f)
+		for i, registry := range []*e2eregistry.Registry{anonRegistry, protectedRegistry} {
+			pod := podClient.Create(ctx, &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("credential-scope-puller-%d", i),
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:            "pause",
+							Image:           registry.ImageRef("pause:testing"),
+							ImagePullPolicy: v1.PullAlways,
+						},
+					},
+				},
+			})
+			framework.ExpectNoError(e2epod.WaitTimeoutForPodRunningInNamespace(
+				ctx, f.ClientSet, pod.Name, f.Namespace.Name, framework.PodStartTimeout),
+				"pulling %s", registry.ImageRef("pause:testing"))
+		}
+
+		ginkgo.By("verifying both registries served the pulls")
+		for _, registry := range []*e2eregistry.Registry{anonRegistry, protectedRegistry} {
+			entries, err := registry.AccessLog(ctx)
+			framework.ExpectNoError(err, "reading access log of %s", registry.Host())
+			gomega.Expect(e2eregistry.EntriesForPath(entries, "/pause/")).NotTo(gomega.BeEmpty(),
+				"expected registry %s to have served the pull", registry.Host())
+		}
+
+		// The registry's own log never records authorization details, so
+		// the leak check is behavioral: the decoy would accept the
+		// protected registry's credentials, and only a kubelet attaching
+		// them to the wrong host could pull from it.
+		ginkgo.By("verifying a pull from the decoy registry fails unauthenticated")
+		decoyPod := podClient.Create(ctx, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "credential-scope-decoy-puller",
+			},
+			Spec: v1.PodSpec{
+				RestartPolicy: v1.RestartPolicyNever,
+				Containers: []v1.Container{
+					{
+						Name:            "pause",
+						Image:           decoyRegistry.ImageRef("pause:testing"),
+						ImagePullPolicy: v1.PullAlways,
+					},
+				},
+			},
+		})
+		gomega.Eventually(ctx, func() (string, error) {
+			pod, err := podClient.Get(ctx, decoyPod.Name, metav1.GetOptions{})
+			if err != nil {
+				return "", err
+			}
+			status := e2epod.FindContainerStatusInPod(pod, "pause")
+			if status == nil || status.State.Waiting == nil {
+				return "", nil
+			}
+			return status.State.Waiting.Reason, nil
+		}, framework.PodStartTimeout, framework.Poll).Should(gomega.Or(
+			gomega.Equal("ErrImagePull"), gomega.Equal("ImagePullBackOff")),
+			"expected the pull from the decoy registry %s to fail without credentials", decoyRegistry.Host())
+	})
+})
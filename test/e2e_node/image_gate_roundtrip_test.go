@@ -0,0 +1,161 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/component-base/featuregate"
+	kubefeatures "k8s.io/kubernetes/pkg/features"
+	"k8s.io/kubernetes/pkg/kubelet/images/pullreport"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	"k8s.io/kubernetes/test/e2e_node/services"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+// imageGateState describes an image-related feature gate which persists
+// state on disk, together with hooks to generate that state and to validate
+// the kubelet's behavior while the gate is flipped off and back on. New
+// gated image features with persisted state should add an entry here so the
+// disable/re-enable round trip is covered.
+type imageGateState struct {
+	gate featuregate.Feature
+	// stateFiles are the files the feature persists, relative to the
+	// kubelet root directory.
+	stateFiles []string
+	// generate produces on-disk state while the gate is enabled.
+	generate func(ctx context.Context, f *framework.Framework)
+	// validateDegraded asserts sensible degraded behavior while the gate is
+	// disabled with the state still on disk.
+	validateDegraded func(ctx context.Context, f *framework.Framework)
+	// validateReenabled asserts the persisted state is safely reused or
+	// discarded once the gate is enabled again.
+	validateReenabled func(ctx context.Context, f *framework.Framework)
+}
+
+// pullReportRecordsSince returns the pull report records written after the
+// given time.
+func pullReportRecordsSince(start time.Time) ([]pullreport.Record, error) {
+	records, err := pullreport.ReadDir(services.KubeletRootDirectory)
+	if err != nil {
+		return nil, err
+	}
+	var recent []pullreport.Record
+	for _, record := range records {
+		if record.Timestamp.Time.After(start) {
+			recent = append(recent, record)
+		}
+	}
+	return recent, nil
+}
+
+// runRoundTripPullPod runs a short-lived always-pull pod so the kubelet
+// performs a fresh image pull.
+func runRoundTripPullPod(ctx context.Context, f *framework.Framework, name string) {
+	pod := e2epod.NewPodClient(f).Create(ctx, newPullReportPod(name, f.Namespace.Name, imageutils.GetE2EImage(imageutils.Httpd)))
+	framework.ExpectNoError(e2epod.WaitTimeoutForPodRunningInNamespace(ctx, f.ClientSet, pod.Name, f.Namespace.Name, 5*time.Minute))
+	framework.ExpectNoError(e2epod.DeletePodWithWait(ctx, f.ClientSet, pod))
+}
+
+func imageGateStates() []imageGateState {
+	return []imageGateState{
+		{
+			gate:       kubefeatures.KubeletImagePullReport,
+			stateFiles: []string{pullreport.ReportFileName, pullreport.ReportFileName + ".1"},
+			generate: func(ctx context.Context, f *framework.Framework) {
+				start := time.Now()
+				runRoundTripPullPod(ctx, f, "pull-report-roundtrip-generate")
+				gomega.Eventually(ctx, func() ([]pullreport.Record, error) {
+					return pullReportRecordsSince(start)
+				}, 2*time.Minute, 10*time.Second).ShouldNot(gomega.BeEmpty(), "expected the enabled gate to produce report records")
+			},
+			validateDegraded: func(ctx context.Context, f *framework.Framework) {
+				// With the gate off, pulls must still work and the stale
+				// report must be left alone rather than appended to.
+				start := time.Now()
+				runRoundTripPullPod(ctx, f, "pull-report-roundtrip-degraded")
+				records, err := pullReportRecordsSince(start)
+				framework.ExpectNoError(err)
+				gomega.Expect(records).To(gomega.BeEmpty(), "expected no report records while the gate is disabled")
+			},
+			validateReenabled: func(ctx context.Context, f *framework.Framework) {
+				// The pre-existing report is reused: new records are
+				// appended and the old ones remain parseable.
+				start := time.Now()
+				runRoundTripPullPod(ctx, f, "pull-report-roundtrip-reenabled")
+				gomega.Eventually(ctx, func() ([]pullreport.Record, error) {
+					return pullReportRecordsSince(start)
+				}, 2*time.Minute, 10*time.Second).ShouldNot(gomega.BeEmpty(), "expected report records after re-enabling the gate")
+				records, err := pullreport.ReadDir(services.KubeletRootDirectory)
+				framework.ExpectNoError(err)
+				gomega.Expect(len(records)).To(gomega.BeNumerically(">", 1), "expected the stale records to remain readable alongside the new ones")
+			},
+		},
+	}
+}
+
+var _ = SIGDescribe("Image feature gate round trip", framework.WithSerial(), framework.WithDisruptive(), func() {
+	f := framework.NewDefaultFramework("image-gate-roundtrip")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	for _, state := range imageGateStates() {
+		state := state
+		ginkgo.It(fmt.Sprintf("should disable and re-enable %s cleanly with stale state on disk", state.gate), func(ctx context.Context) {
+			oldCfg, err := getCurrentKubeletConfig(ctx)
+			framework.ExpectNoError(err)
+			ginkgo.DeferCleanup(func(ctx context.Context) {
+				updateKubeletConfig(ctx, f, oldCfg, false)
+			})
+			setGate := func(enabled bool) {
+				cfg := oldCfg.DeepCopy()
+				if cfg.FeatureGates == nil {
+					cfg.FeatureGates = map[string]bool{}
+				}
+				cfg.FeatureGates[string(state.gate)] = enabled
+				// The state files are deliberately kept across restarts;
+				// surviving them without a crash is the point of the test.
+				updateKubeletConfig(ctx, f, cfg, false)
+			}
+
+			ginkgo.By(fmt.Sprintf("enabling %s and generating state", state.gate))
+			setGate(true)
+			state.generate(ctx, f)
+			for _, file := range state.stateFiles {
+				if _, err := os.Stat(filepath.Join(services.KubeletRootDirectory, file)); err == nil {
+					framework.Logf("State file %s present before disabling %s", file, state.gate)
+				}
+			}
+
+			ginkgo.By(fmt.Sprintf("restarting the kubelet with %s disabled", state.gate))
+			setGate(false)
+			state.validateDegraded(ctx, f)
+
+			ginkgo.By(fmt.Sprintf("restarting the kubelet with %s re-enabled", state.gate))
+			setGate(true)
+			state.validateReenabled(ctx, f)
+		})
+	}
+})
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/kubernetes/pkg/kubelet/events"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+const (
+	// clockJumpBackoffWindow bounds how long the kubelet may take to retry a
+	// pull after the clock jumps backward: the maximum image pull backoff is
+	// 5 minutes, plus leeway for the sync loop to notice.
+	clockJumpBackoffWindow = 6 * time.Minute
+	clockJumpStep          = 30 * time.Minute
+)
+
+// stepSystemClockBackward steps the node's wall clock backward by the given
+// duration and returns a function restoring the original time. NTP
+// synchronization is suspended while the clock is stepped so the test
+// observes a stable offset.
+func stepSystemClockBackward(d time.Duration) (restore func(), err error) {
+	// Best effort: not all images ship timedatectl.
+	_ = exec.Command("timedatectl", "set-ntp", "false").Run()
+
+	target := time.Now().Add(-d)
+	if out, err := exec.Command("date", "-s", fmt.Sprintf("@%d", target.Unix())).CombinedOutput(); err != nil {
+		_ = exec.Command("timedatectl", "set-ntp", "true").Run()
+		return nil, fmt.Errorf("stepping system clock backward: %v, output: %s", err, out)
+	}
+
+	return func() {
+		forward := time.Now().Add(d)
+		if out, err := exec.Command("date", "-s", fmt.Sprintf("@%d", forward.Unix())).CombinedOutput(); err != nil {
+			framework.Logf("failed to step system clock forward: %v, output: %s", err, out)
+		}
+		_ = exec.Command("timedatectl", "set-ntp", "true").Run()
+	}, nil
+}
+
+var _ = SIGDescribe("Image pull backoff across clock jumps", framework.WithSerial(), framework.WithDisruptive(), func() {
+	f := framework.NewDefaultFramework("image-pull-clock-jump")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	ginkgo.It("should retry a pull within the capped backoff window after the clock jumps backward", func(ctx context.Context) {
+		podClient := e2epod.NewPodClient(f)
+		pod := podClient.Create(ctx, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "image-pull-clock-jump",
+			},
+			Spec: v1.PodSpec{
+				RestartPolicy: v1.RestartPolicyNever,
+				Containers: []v1.Container{
+					{
+						Name:            "puller",
+						Image:           imageutils.GetE2EImage(imageutils.InvalidRegistryImage),
+						ImagePullPolicy: v1.PullAlways,
+					},
+				},
+			},
+		})
+
+		ginkgo.By("waiting for the pod to enter ImagePullBackOff")
+		gomega.Eventually(ctx, func() (string, error) {
+			pod, err := podClient.Get(ctx, pod.Name, metav1.GetOptions{})
+			if err != nil {
+				return "", err
+			}
+			status := e2epod.FindContainerStatusInPod(pod, "puller")
+			if status == nil || status.State.Waiting == nil {
+				return "", nil
+			}
+			return status.State.Waiting.Reason, nil
+		}, framework.PodStartTimeout, framework.Poll).Should(gomega.Equal("ImagePullBackOff"))
+
+		countPullAttempts := func() int {
+			selector := fields.Set{
+				"involvedObject.kind":      "Pod",
+				"involvedObject.name":      pod.Name,
+				"involvedObject.namespace": f.Namespace.Name,
+				"reason":                   events.PullingImage,
+			}.AsSelector().String()
+			eventList, err := f.ClientSet.CoreV1().Events(f.Namespace.Name).List(ctx, metav1.ListOptions{
+				FieldSelector: selector,
+			})
+			framework.ExpectNoError(err, "listing pull events for pod %s", pod.Name)
+			attempts := 0
+			for _, event := range eventList.Items {
+				count := int(event.Count)
+				if count == 0 {
+					count = 1
+				}
+				attempts += count
+			}
+			return attempts
+		}
+		attemptsBeforeJump := countPullAttempts()
+
+		ginkgo.By(fmt.Sprintf("stepping the system clock backward by %v", clockJumpStep))
+		restore, err := stepSystemClockBackward(clockJumpStep)
+		framework.ExpectNoError(err)
+		ginkgo.DeferCleanup(restore)
+
+		ginkgo.By("verifying the next pull retry is not deferred until the wall clock catches up")
+		gomega.Eventually(ctx, func() int {
+			return countPullAttempts()
+		}, clockJumpBackoffWindow, 10*time.Second).Should(gomega.BeNumerically(">", attemptsBeforeJump),
+			"expected a pull retry within %v despite the backward clock jump", clockJumpBackoffWindow)
+	})
+})
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	kubeletconfig "k8s.io/kubernetes/pkg/kubelet/apis/config"
+	evictionapi "k8s.io/kubernetes/pkg/kubelet/eviction/api"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	e2eskipper "k8s.io/kubernetes/test/e2e/framework/skipper"
+	"k8s.io/kubernetes/test/e2e/nodefeature"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+// This test fills the image filesystem until the node reports disk pressure,
+// then verifies that the kubelet's image garbage collection reclaims space and
+// that image pulls work again once the pressure is relieved.
+var _ = SIGDescribe("ImagePullDiskPressure", framework.WithSlow(), framework.WithSerial(), framework.WithDisruptive(), nodefeature.Eviction, func() {
+	f := framework.NewDefaultFramework("image-pull-disk-pressure-test")
+	f.NamespacePodSecurityLevel = admissionapi.LevelPrivileged
+	pressureTimeout := 10 * time.Minute
+	ballastSize := resource.MustParse("4Gi")
+
+	var is internalapi.ImageManagerService
+	ginkgo.BeforeEach(func() {
+		var err error
+		_, is, err = getCRIClient()
+		framework.ExpectNoError(err)
+	})
+	ginkgo.AfterEach(func() {
+		framework.ExpectNoError(PrePullAllImages())
+	})
+
+	ginkgo.Context("when the image filesystem runs low on space", func() {
+		tempSetCurrentKubeletConfig(f, func(ctx context.Context, initialConfig *kubeletconfig.KubeletConfiguration) {
+			summary := eventuallyGetSummary(ctx)
+			availableBytes := *(summary.Node.Fs.AvailableBytes)
+			if availableBytes <= uint64(ballastSize.Value()) {
+				e2eskipper.Skipf("Too little disk free on the node to run this test: %d bytes", availableBytes)
+			}
+			// Pick a threshold half a ballast below the current headroom, so
+			// allocating the ballast reliably crosses it without the risk of
+			// the node starting out under pressure.
+			initialConfig.EvictionHard = map[string]string{string(evictionapi.SignalNodeFsAvailable): fmt.Sprintf("%d", availableBytes-uint64(ballastSize.Value()/2))}
+			initialConfig.EvictionMinimumReclaim = map[string]string{}
+			initialConfig.ImageMinimumGCAge = metav1.Duration{Duration: time.Second}
+		})
+		ginkgo.It("should reclaim images under pressure and resume pulls once it is relieved", func(ctx context.Context) {
+			ginkgo.By("pulling an image so garbage collection has something to reclaim")
+			_, err := is.PullImage(ctx, &runtimeapi.ImageSpec{Image: agnhostImage}, nil, nil)
+			framework.ExpectNoError(err)
+			allImages, err := is.ListImages(ctx, &runtimeapi.ImageFilter{})
+			framework.ExpectNoError(err)
+
+			ginkgo.By("consuming image filesystem space until the eviction threshold is crossed")
+			ballast, err := createImageFsBallast(ctx, ballastSize.Value())
+			framework.ExpectNoError(err)
+			ginkgo.DeferCleanup(ballast.Release)
+
+			ginkgo.By("waiting for the node to report disk pressure")
+			gomega.Eventually(ctx, func(ctx context.Context) bool {
+				return hasNodeCondition(ctx, f, v1.NodeDiskPressure)
+			}, pressureTimeout, evictionPollInterval).Should(gomega.BeTrue())
+
+			ginkgo.By("waiting for image garbage collection to reclaim images")
+			gomega.Eventually(ctx, func() int {
+				gcdImageList, err := is.ListImages(ctx, &runtimeapi.ImageFilter{})
+				framework.ExpectNoError(err)
+				return len(gcdImageList)
+			}, checkGCUntil, checkGCFreq).Should(gomega.BeNumerically("<", len(allImages)))
+
+			ginkgo.By("releasing the consumed space and waiting for disk pressure to clear")
+			framework.ExpectNoError(ballast.Release())
+			gomega.Eventually(ctx, func(ctx context.Context) bool {
+				return hasNodeCondition(ctx, f, v1.NodeDiskPressure)
+			}, pressureDisappearTimeout, evictionPollInterval).Should(gomega.BeFalse())
+
+			ginkgo.By("verifying that image pulls succeed again")
+			// PullAlways forces a fresh pull even when garbage collection
+			// spared the image, so reaching Running proves the pull path
+			// recovered and the pod is not stuck in ImagePullBackOff.
+			e2epod.NewPodClient(f).CreateSync(ctx, &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "image-pull-after-pressure"},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:            "image-pull-after-pressure",
+							Image:           agnhostImage,
+							ImagePullPolicy: v1.PullAlways,
+							Args:            []string{"pause"},
+						},
+					},
+				},
+			})
+		})
+	})
+})
@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	e2eregistry "k8s.io/kubernetes/test/e2e/framework/registry"
+	"k8s.io/kubernetes/test/e2e_node/services"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+const (
+	proxyTestUser     = "e2e-proxy-user"
+	proxyTestPassword = "e2e-proxy-password"
+)
+
+// blockDirectRegistryAccess rejects node-level traffic to the registry's
+// pod IP, so that only pulls routed through the forward proxy (which runs
+// in its own pod network namespace) can reach it. It returns a function
+// removing the rule again.
+func blockDirectRegistryAccess(registryIP string, port int) (unblock func(), err error) {
+	rule := []string{"OUTPUT", "-d", registryIP, "-p", "tcp", "--dport", fmt.Sprintf("%d", port), "-j", "REJECT"}
+	if out, err := exec.Command("sudo", append([]string{"iptables", "-I"}, rule...)...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("blocking direct registry access: %v, output: %s", err, out)
+	}
+	return func() {
+		if out, err := exec.Command("sudo", append([]string{"iptables", "-D"}, rule...)...).CombinedOutput(); err != nil {
+			framework.Logf("failed to remove registry block rule: %v, output: %s", err, out)
+		}
+	}, nil
+}
+
+var _ = SIGDescribe("Image pulls through an authenticating forward proxy", framework.WithSerial(), framework.WithDisruptive(), func() {
+	f := framework.NewDefaultFramework("image-pull-proxy")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	ginkgo.It("should pull through the proxy with credentials and bypass it for NO_PROXY registries", func(ctx context.Context) {
+		ginkgo.By("deploying the local registry and the external registry")
+		localRegistry, err := e2eregistry.SetupRegistry(ctx, f.ClientSet, f.Namespace.Name, e2eregistry.Options{
+			NodeAccess: e2eregistry.LocalNodeAccess{},
+		})
+		framework.ExpectNoError(err, "setting up local registry")
+		ginkgo.DeferCleanup(localRegistry.Teardown)
+
+		externalNamespace, err := f.CreateNamespace(ctx, "image-pull-proxy-ext", nil)
+		framework.ExpectNoError(err, "creating namespace for the external registry")
+		externalRegistry, err := e2eregistry.SetupRegistry(ctx, f.ClientSet, externalNamespace.Name, e2eregistry.Options{
+			NodeAccess: e2eregistry.LocalNodeAccess{},
+		})
+		framework.ExpectNoError(err, "setting up external registry")
+		ginkgo.DeferCleanup(externalRegistry.Teardown)
+
+		ginkgo.By("deploying the authenticating forward proxy")
+		proxy, err := e2eregistry.SetupProxy(ctx, f.ClientSet, f.Namespace.Name, e2eregistry.ProxyOptions{
+			Auth: &e2eregistry.BasicAuthOptions{
+				Username: proxyTestUser,
+				Password: proxyTestPassword,
+			},
+		})
+		framework.ExpectNoError(err, "setting up forward proxy")
+		ginkgo.DeferCleanup(proxy.Teardown)
+
+		ginkgo.By("blocking direct node access to the external registry")
+		unblock, err := blockDirectRegistryAccess(externalRegistry.Pod.Status.PodIP, 5000)
+		framework.ExpectNoError(err)
+		ginkgo.DeferCleanup(unblock)
+
+		ginkgo.By("pointing the container runtime and the kubelet at the proxy")
+		proxyConfig := services.ProxyConfig{
+			HTTPProxy:  proxy.URL(),
+			HTTPSProxy: proxy.URL(),
+			NoProxy:    localRegistry.Host(),
+		}
+		runtimeUnit, err := findContainerRuntimeServiceName()
+		framework.ExpectNoError(err, "finding container runtime unit")
+		restoreRuntime, err := services.ApplyProxyToUnit(runtimeUnit, proxyConfig)
+		framework.ExpectNoError(err, "applying proxy environment to the container runtime")
+		ginkgo.DeferCleanup(func() {
+			framework.ExpectNoError(restoreRuntime(), "restoring the container runtime environment")
+		})
+		restoreKubelet, err := services.ApplyProxyToUnit(findKubeletServiceName(true), proxyConfig)
+		framework.ExpectNoError(err, "applying proxy environment to the kubelet")
+		ginkgo.DeferCleanup(func() {
+			framework.ExpectNoError(restoreKubelet(), "restoring the kubelet environment")
+		})
+
+		ginkgo.By("pulling from the external registry through the proxy")
+		podClient := e2epod.NewPodClient(f)
+		externalPod := podClient.Create(ctx, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "proxy-pull-external",
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{
+					{
+						Name:            "pause",
+						Image:           externalRegistry.ImageRef("pause:testing"),
+						ImagePullPolicy: v1.PullAlways,
+					},
+				},
+			},
+		})
+		framework.ExpectNoError(e2epod.WaitTimeoutForPodRunningInNamespace(
+			ctx, f.ClientSet, externalPod.Name, f.Namespace.Name, framework.PodStartTimeout),
+			"pulling through the proxy from %s", externalRegistry.Host())
+
+		ginkgo.By("pulling from the NO_PROXY'd local registry")
+		localPod := podClient.Create(ctx, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "proxy-pull-local",
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{
+					{
+						Name:            "pause",
+						Image:           localRegistry.ImageRef("pause:testing"),
+						ImagePullPolicy: v1.PullAlways,
+					},
+				},
+			},
+		})
+		framework.ExpectNoError(e2epod.WaitTimeoutForPodRunningInNamespace(
+			ctx, f.ClientSet, localPod.Name, f.Namespace.Name, framework.PodStartTimeout),
+			"pulling from the NO_PROXY'd registry %s", localRegistry.Host())
+
+		ginkgo.By("verifying the proxy served the external pull but not the local one")
+		entries, err := proxy.AccessLog(ctx)
+		framework.ExpectNoError(err, "reading proxy access log")
+		gomega.Expect(e2eregistry.EntriesForPath(entries, externalRegistry.Host())).NotTo(gomega.BeEmpty(),
+			"expected the pull from the external registry to go through the proxy")
+		gomega.Expect(e2eregistry.EntriesForPath(entries, localRegistry.Host())).To(gomega.BeEmpty(),
+			"expected the pull from the NO_PROXY'd registry to bypass the proxy")
+	})
+})
@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefeatures "k8s.io/kubernetes/pkg/features"
+	kubeletconfig "k8s.io/kubernetes/pkg/kubelet/apis/config"
+	"k8s.io/kubernetes/pkg/kubelet/images/pullreport"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	"k8s.io/kubernetes/test/e2e_node/services"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = SIGDescribe("ImagePullReport", framework.WithSerial(), func() {
+	f := framework.NewDefaultFramework("image-pull-report")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	ginkgo.Context("when the KubeletImagePullReport feature gate is enabled", func() {
+		tempSetCurrentKubeletConfig(f, func(ctx context.Context, initialConfig *kubeletconfig.KubeletConfiguration) {
+			if initialConfig.FeatureGates == nil {
+				initialConfig.FeatureGates = map[string]bool{}
+			}
+			initialConfig.FeatureGates[string(kubefeatures.KubeletImagePullReport)] = true
+		})
+
+		ginkgo.It("should record successful and failed pulls in the report", func(ctx context.Context) {
+			goodImage := imageutils.GetE2EImage(imageutils.Httpd)
+			const badImage = "registry.invalid/nonexistent/image:1.0"
+			start := time.Now()
+
+			ginkgo.By("running a pod whose image pull succeeds")
+			goodPod := e2epod.NewPodClient(f).Create(ctx, newPullReportPod("pull-report-good", f.Namespace.Name, goodImage))
+			framework.ExpectNoError(e2epod.WaitTimeoutForPodRunningInNamespace(ctx, f.ClientSet, goodPod.Name, f.Namespace.Name, 5*time.Minute))
+
+			ginkgo.By("creating a pod whose image pull fails")
+			e2epod.NewPodClient(f).Create(ctx, newPullReportPod("pull-report-bad", f.Namespace.Name, badImage))
+
+			ginkgo.By("waiting for the report to cover both pulls")
+			gomega.Eventually(ctx, func() error {
+				records, err := pullreport.ReadDir(services.KubeletRootDirectory)
+				if err != nil {
+					return err
+				}
+				var sawSuccess, sawFailure bool
+				for _, record := range records {
+					// The report survives kubelet restarts; only consider
+					// records written by this test.
+					if record.Timestamp.Time.Before(start) {
+						continue
+					}
+					if record.SchemaVersion != pullreport.SchemaVersion {
+						return fmt.Errorf("unexpected schema version in record %+v", record)
+					}
+					switch record.Image {
+					case goodImage:
+						if !record.Succeeded {
+							return fmt.Errorf("expected a successful record for %q, got %+v", goodImage, record)
+						}
+						if record.Registry != pullreport.RegistryOf(goodImage) {
+							return fmt.Errorf("unexpected registry in record %+v", record)
+						}
+						sawSuccess = true
+					case badImage:
+						if record.Succeeded || record.Reason == "" {
+							return fmt.Errorf("expected a classified failure record for %q, got %+v", badImage, record)
+						}
+						if record.Registry != "registry.invalid" {
+							return fmt.Errorf("unexpected registry in record %+v", record)
+						}
+						sawFailure = true
+					}
+				}
+				if !sawSuccess || !sawFailure {
+					return fmt.Errorf("report does not cover both pulls yet (success=%t, failure=%t) in %d records", sawSuccess, sawFailure, len(records))
+				}
+				return nil
+			}, 3*time.Minute, 10*time.Second).Should(gomega.Succeed())
+		})
+	})
+})
+
+// newPullReportPod returns a pod which always pulls the given image so every
+// run produces fresh pull activity for the report.
+func newPullReportPod(name, namespace, image string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyAlways,
+			Containers: []v1.Container{
+				{
+					Name:            name,
+					Image:           image,
+					ImagePullPolicy: v1.PullAlways,
+				},
+			},
+		},
+	}
+}
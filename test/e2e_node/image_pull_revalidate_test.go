@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	kubeletconfig "k8s.io/kubernetes/pkg/kubelet/apis/config"
+	kubeletevents "k8s.io/kubernetes/pkg/kubelet/events"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+// revalidateInterval is the tag revalidation interval configured for the
+// test; short enough that the second pod start falls into a fresh interval.
+const revalidateInterval = 30 * time.Second
+
+var _ = SIGDescribe("ImagePullRevalidation", framework.WithSerial(), func() {
+	f := framework.NewDefaultFramework("image-pull-revalidate")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	ginkgo.Context("when imagePullRevalidateInterval is configured", func() {
+		tempSetCurrentKubeletConfig(f, func(ctx context.Context, initialConfig *kubeletconfig.KubeletConfiguration) {
+			initialConfig.ImagePullRevalidateInterval = metav1.Duration{Duration: revalidateInterval}
+		})
+
+		ginkgo.It("should not pull again while the registry digest is unchanged", func(ctx context.Context) {
+			image := imageutils.GetE2EImage(imageutils.Httpd)
+
+			ginkgo.By("running a first pod so the image is present on the node")
+			first := e2epod.NewPodClient(f).Create(ctx, newRevalidatePod("revalidate-first", f.Namespace.Name, image))
+			framework.ExpectNoError(e2epod.WaitTimeoutForPodRunningInNamespace(ctx, f.ClientSet, first.Name, f.Namespace.Name, 5*time.Minute))
+			e2epod.NewPodClient(f).DeleteSync(ctx, first.Name, metav1.DeleteOptions{}, e2epod.DefaultPodDeletionTimeout)
+
+			ginkgo.By("waiting out the revalidation interval")
+			time.Sleep(revalidateInterval + 5*time.Second)
+
+			ginkgo.By("running a second pod with the same image and PullIfNotPresent")
+			second := e2epod.NewPodClient(f).Create(ctx, newRevalidatePod("revalidate-second", f.Namespace.Name, image))
+			framework.ExpectNoError(e2epod.WaitTimeoutForPodRunningInNamespace(ctx, f.ClientSet, second.Name, f.Namespace.Name, 5*time.Minute))
+
+			ginkgo.By("verifying revalidation kept the local image instead of pulling")
+			pullingEvents := fields.Set{
+				"involvedObject.kind":      "Pod",
+				"involvedObject.name":      second.Name,
+				"involvedObject.namespace": f.Namespace.Name,
+				"reason":                   kubeletevents.PullingImage,
+			}.AsSelector().String()
+			eventList, err := f.ClientSet.CoreV1().Events(f.Namespace.Name).List(ctx, metav1.ListOptions{
+				FieldSelector: pullingEvents,
+			})
+			framework.ExpectNoError(err, "listing pull events for the second pod")
+			gomega.Expect(eventList.Items).To(gomega.BeEmpty(),
+				"expected no pull for an unchanged digest, got %d %s events", len(eventList.Items), kubeletevents.PullingImage)
+
+			pulledEvents := fields.Set{
+				"involvedObject.kind":      "Pod",
+				"involvedObject.name":      second.Name,
+				"involvedObject.namespace": f.Namespace.Name,
+				"reason":                   kubeletevents.PulledImage,
+			}.AsSelector().String()
+			eventList, err = f.ClientSet.CoreV1().Events(f.Namespace.Name).List(ctx, metav1.ListOptions{
+				FieldSelector: pulledEvents,
+			})
+			framework.ExpectNoError(err, "listing pulled events for the second pod")
+			gomega.Expect(eventList.Items).ToNot(gomega.BeEmpty(), "expected a %s event for the second pod", kubeletevents.PulledImage)
+			for _, event := range eventList.Items {
+				gomega.Expect(event.Message).To(gomega.ContainSubstring("already present on machine"),
+					"expected the image to be reused, got: %s", event.Message)
+			}
+		})
+	})
+})
+
+// newRevalidatePod returns a pod using PullIfNotPresent so image reuse is
+// left to the kubelet's revalidation.
+func newRevalidatePod(name, namespace, image string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyAlways,
+			Containers: []v1.Container{
+				{
+					Name:            name,
+					Image:           image,
+					ImagePullPolicy: v1.PullIfNotPresent,
+				},
+			},
+		},
+	}
+}
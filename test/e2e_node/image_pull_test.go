@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	"k8s.io/kubernetes/pkg/kubelet/events"
+	"k8s.io/kubernetes/test/e2e/feature"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	"k8s.io/kubernetes/test/e2e_node/criproxy"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = SIGDescribe("Pull Image", feature.CriProxy, framework.WithSerial(), func() {
+	f := framework.NewDefaultFramework("cri-proxy-image-pull")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	var is internalapi.ImageManagerService
+	ginkgo.BeforeEach(func() {
+		if err := resetCRIProxyInjector(e2eCriProxy); err != nil {
+			ginkgo.Skip("Skip the test since the CRI Proxy is undefined.")
+		}
+		var err error
+		_, is, err = getCRIClient()
+		framework.ExpectNoError(err)
+	})
+	ginkgo.AfterEach(func() {
+		err := resetCRIProxyInjector(e2eCriProxy)
+		framework.ExpectNoError(err)
+	})
+
+	ginkgo.It("should re-pull the image when it is removed between pull completion and container creation", func(ctx context.Context) {
+		image := imageutils.GetE2EImage(imageutils.Httpd)
+
+		// While the kubelet's CreateContainer call is held back in the
+		// proxy, remove the freshly pulled image. This narrows the race
+		// between image garbage collection and container creation into a
+		// deterministic failure: creation always starts with the image gone.
+		var once sync.Once
+		err := addCRIProxyInjector(e2eCriProxy, func(apiName string) error {
+			if apiName == criproxy.CreateContainer {
+				once.Do(func() {
+					framework.Logf("Removing image %q while CreateContainer is held back", image)
+					framework.ExpectNoError(is.RemoveImage(ctx, &runtimeapi.ImageSpec{Image: image}))
+				})
+			}
+			return nil
+		})
+		framework.ExpectNoError(err)
+
+		pod := e2epod.NewPodClient(f).Create(ctx, newImageRemovedTestPod(f.Namespace.Name, image))
+
+		ginkgo.By("waiting for the kubelet to re-pull the image and run the pod")
+		err = e2epod.WaitTimeoutForPodRunningInNamespace(ctx, f.ClientSet, pod.Name, f.Namespace.Name, 5*time.Minute)
+		framework.ExpectNoError(err)
+
+		ginkgo.By("verifying the events reflect the removed image and the retried pull")
+		eventList, err := f.ClientSet.CoreV1().Events(f.Namespace.Name).List(ctx, metav1.ListOptions{})
+		framework.ExpectNoError(err)
+		sawRetry := false
+		pullCount := 0
+		for _, event := range eventList.Items {
+			if event.InvolvedObject.Name != pod.Name {
+				continue
+			}
+			switch {
+			case event.Reason == events.FailedToCreateContainer && strings.Contains(event.Message, "re-pulling"):
+				sawRetry = true
+			case event.Reason == events.PulledImage:
+				pullCount++
+			}
+		}
+		gomega.Expect(sawRetry).To(gomega.BeTrueBecause("expected an event recording the removed image, got %+v", eventList.Items))
+		gomega.Expect(pullCount).To(gomega.BeNumerically(">=", 2), "expected the image to be pulled again after its removal")
+	})
+})
+
+// newImageRemovedTestPod returns a pod which always pulls the given image, so
+// the test does not depend on the image being absent from the node beforehand.
+func newImageRemovedTestPod(namespace, image string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "image-removed-test-pod",
+			Namespace: namespace,
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyAlways,
+			Containers: []v1.Container{
+				{
+					Name:            "image-removed-test",
+					Image:           image,
+					ImagePullPolicy: v1.PullAlways,
+				},
+			},
+		},
+	}
+}
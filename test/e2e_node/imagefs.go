@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// imageFsBallast is space artificially consumed on the image filesystem, so
+// tests can drive the node into disk pressure without pulling huge images.
+// Release frees the space again; it is registered as a cleanup by the
+// creating test, so the node recovers even when the test fails mid-way.
+type imageFsBallast struct {
+	dir string
+}
+
+// createImageFsBallast allocates size bytes on the filesystem the container
+// runtime stores images on. The space is consumed by a preallocated file in
+// a temporary directory on the imagefs mountpoint, so it is reclaimed by a
+// simple removal and can never be confused with real image content.
+func createImageFsBallast(ctx context.Context, size int64) (*imageFsBallast, error) {
+	mountpoint, err := imageFsMountpoint(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("locating image filesystem: %w", err)
+	}
+	dir, err := os.MkdirTemp(mountpoint, "e2e-imagefs-ballast-")
+	if err != nil {
+		return nil, fmt.Errorf("creating ballast directory on %s: %w", mountpoint, err)
+	}
+	// fallocate reserves the blocks without writing them, so consuming
+	// several GiB is near-instant and never produces sparse files.
+	file := filepath.Join(dir, "ballast")
+	if out, err := exec.Command("fallocate", "-l", strconv.FormatInt(size, 10), file).CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("allocating %d bytes in %s: %v: %s", size, file, err, string(out))
+	}
+	return &imageFsBallast{dir: dir}, nil
+}
+
+// Release frees the consumed space. It is safe to call more than once.
+func (b *imageFsBallast) Release() error {
+	if b.dir == "" {
+		return nil
+	}
+	dir := b.dir
+	b.dir = ""
+	return os.RemoveAll(dir)
+}
+
+// imageFsMountpoint returns the mountpoint of the filesystem the container
+// runtime stores images on, as reported over CRI.
+func imageFsMountpoint(ctx context.Context) (string, error) {
+	_, is, err := getCRIClient()
+	if err != nil {
+		return "", err
+	}
+	resp, err := is.ImageFsInfo(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, fs := range resp.GetImageFilesystems() {
+		if mountpoint := fs.GetFsId().GetMountpoint(); mountpoint != "" {
+			return mountpoint, nil
+		}
+	}
+	return "", fmt.Errorf("container runtime reported no image filesystem mountpoint")
+}
@@ -41,6 +41,7 @@ var deleteInstances = flag.Bool("delete-instances", true, "If true, delete any i
 var buildOnly = flag.Bool("build-only", false, "If true, build e2e_node_test.tar.gz and exit.")
 var gubernator = flag.Bool("gubernator", false, "If true, output Gubernator link to view logs")
 var ginkgoFlags = flag.String("ginkgo-flags", "", "Passed to ginkgo to specify additional flags such as --skip=.")
+var runtimes = flag.String("runtimes", "", "Comma-separated list of runtime[/handler] entries to run the suite against, e.g. containerd/runc,crio/crun. Empty runs once against the host's default runtime.")
 var (
 	arc Archive
 )
@@ -87,6 +88,11 @@ func RunRemoteTestSuite(testSuite TestSuite) {
 	allGinkgoFlags := fmt.Sprintf("%s --no-color -v", *ginkgoFlags)
 	fmt.Printf("Will use ginkgo flags as: %s", allGinkgoFlags)
 
+	runtimeMatrix, err := ParseRuntimeMatrix(*runtimes)
+	if err != nil {
+		klog.Fatalf("parsing --runtimes: %v", err)
+	}
+
 	var runner Runner
 	cfg := Config{
 		InstanceNamePrefix: *instanceNamePrefix,
@@ -101,6 +107,7 @@ func RunRemoteTestSuite(testSuite TestSuite) {
 		ExtraEnvs:          getFlag("extra-envs"),
 		RuntimeConfig:      getFlag("runtime-config"),
 		SystemSpecName:     getFlag("system-spec-name"),
+		Runtimes:           runtimeMatrix,
 	}
 
 	var sshRunner Runner
@@ -151,6 +158,9 @@ func RunRemoteTestSuite(testSuite TestSuite) {
 	for i := 0; i < running; i++ {
 		tr := <-results
 		host := tr.Host
+		if tr.Runtime != "" {
+			host = fmt.Sprintf("%s (runtime %s)", host, tr.Runtime)
+		}
 		fmt.Println() // Print an empty line
 		fmt.Printf("%s>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>%s\n", blue, noColour)
 		fmt.Printf("%s>                              START TEST                                >%s\n", blue, noColour)
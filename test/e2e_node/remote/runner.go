@@ -34,6 +34,9 @@ type Config struct {
 	RuntimeConfig      string
 	SystemSpecName     string
 	Hosts              []string
+	// Runtimes is the runtime/handler matrix to run the suite against. An
+	// empty matrix means a single run against the host's default runtime.
+	Runtimes []RuntimeVariant
 }
 
 // TestResult contains some information about the test results.
@@ -41,5 +44,8 @@ type TestResult struct {
 	Output string
 	Err    error
 	Host   string
-	ExitOK bool
+	// Runtime is the tag of the runtime variant the suite ran against, or
+	// empty when running against the host's default runtime.
+	Runtime string
+	ExitOK  bool
 }
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RuntimeVariant selects the container runtime and, optionally, the
+// runtime handler (RuntimeClass handler name) one invocation of the test
+// suite runs against. The runner expands the configured variants into
+// one suite run per host and variant, so a single invocation can cover a
+// containerd/CRI-O and runc/crun/kata matrix.
+type RuntimeVariant struct {
+	// Runtime is the container runtime name, one of "containerd" or "crio".
+	Runtime string
+	// Handler is the runtime handler to run test pods under, one of
+	// "runc", "crun" or "kata". Empty means the runtime's default handler.
+	Handler string
+}
+
+// runtimeEndpoints maps the supported runtimes to the CRI endpoint and
+// process name the node e2e suite needs to talk to them. The endpoints
+// match the runtimes' packaged defaults.
+var runtimeEndpoints = map[string]struct {
+	endpoint    string
+	processName string
+}{
+	"containerd": {
+		endpoint:    "unix:///run/containerd/containerd.sock",
+		processName: "containerd",
+	},
+	"crio": {
+		endpoint:    "unix:///var/run/crio/crio.sock",
+		processName: "crio",
+	},
+}
+
+var knownRuntimeHandlers = map[string]bool{
+	"runc": true,
+	"crun": true,
+	"kata": true,
+}
+
+// ParseRuntimeMatrix parses a comma-separated list of runtime[/handler]
+// entries, e.g. "containerd/runc,crio/crun,crio". An empty string yields
+// an empty matrix, which the runner treats as a single run against the
+// host's default runtime.
+func ParseRuntimeMatrix(s string) ([]RuntimeVariant, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	var variants []RuntimeVariant
+	for _, entry := range strings.Split(s, ",") {
+		segs := strings.Split(entry, "/")
+		if len(segs) > 2 {
+			return nil, fmt.Errorf("invalid runtime entry %q, expected runtime[/handler]", entry)
+		}
+		variant := RuntimeVariant{Runtime: segs[0]}
+		if len(segs) == 2 {
+			variant.Handler = segs[1]
+		}
+		if _, ok := runtimeEndpoints[variant.Runtime]; !ok {
+			return nil, fmt.Errorf("unknown runtime %q, supported runtimes are containerd and crio", variant.Runtime)
+		}
+		if variant.Handler != "" && !knownRuntimeHandlers[variant.Handler] {
+			return nil, fmt.Errorf("unknown runtime handler %q, supported handlers are runc, crun and kata", variant.Handler)
+		}
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+// Tag returns a short identifier for the variant, e.g. "containerd-runc"
+// or "crio", used to tag junit files and test output per runtime.
+func (v RuntimeVariant) Tag() string {
+	if v.Handler == "" {
+		return v.Runtime
+	}
+	return v.Runtime + "-" + v.Handler
+}
+
+// TestArgs appends the test arguments selecting this variant's runtime
+// endpoint and handler to the given base test arguments.
+func (v RuntimeVariant) TestArgs(base string) string {
+	if v.Runtime == "" {
+		return base
+	}
+	rt := runtimeEndpoints[v.Runtime]
+	args := fmt.Sprintf("%s --container-runtime-endpoint=%s --container-runtime-process-name=%s", base, rt.endpoint, rt.processName)
+	if v.Handler != "" {
+		args += fmt.Sprintf(" --runtime-handler=%s", v.Handler)
+	}
+	return args
+}
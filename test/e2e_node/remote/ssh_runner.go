@@ -27,30 +27,48 @@ type SSHRunner struct {
 }
 
 func (s *SSHRunner) StartTests(suite TestSuite, archivePath string, results chan *TestResult) (numTests int) {
+	// An empty runtime matrix means a single run against the host's
+	// default runtime, expressed as a single zero-value variant.
+	variants := s.cfg.Runtimes
+	if len(variants) == 0 {
+		variants = []RuntimeVariant{{}}
+	}
+	numTests = len(s.cfg.Hosts) * len(variants)
 	for _, host := range s.cfg.Hosts {
-		fmt.Printf("Initializing e2e tests using host %s.\n", host)
-		numTests++
-		go func(host string, junitFileName string) {
-			output, exitOk, err := RunRemote(RunRemoteConfig{
-				Suite:          suite,
-				Archive:        archivePath,
-				Host:           host,
-				Cleanup:        s.cfg.Cleanup,
-				ImageDesc:      "",
-				JunitFileName:  junitFileName,
-				TestArgs:       s.cfg.TestArgs,
-				GinkgoArgs:     s.cfg.GinkgoFlags,
-				SystemSpecName: s.cfg.SystemSpecName,
-				ExtraEnvs:      s.cfg.ExtraEnvs,
-				RuntimeConfig:  s.cfg.RuntimeConfig,
-			})
-			results <- &TestResult{
-				Output: output,
-				Err:    err,
-				Host:   host,
-				ExitOK: exitOk,
+		// The variants for one host run serially inside its goroutine:
+		// they share the host's kubelet port and runtime services, so
+		// only runs on different hosts may overlap.
+		go func(host string) {
+			for _, variant := range variants {
+				junitFileName := host
+				if tag := variant.Tag(); tag != "" {
+					junitFileName = host + "-" + tag
+					fmt.Printf("Initializing e2e tests using host %s with runtime %s.\n", host, tag)
+				} else {
+					fmt.Printf("Initializing e2e tests using host %s.\n", host)
+				}
+				output, exitOk, err := RunRemote(RunRemoteConfig{
+					Suite:          suite,
+					Archive:        archivePath,
+					Host:           host,
+					Cleanup:        s.cfg.Cleanup,
+					ImageDesc:      "",
+					JunitFileName:  junitFileName,
+					TestArgs:       variant.TestArgs(s.cfg.TestArgs),
+					GinkgoArgs:     s.cfg.GinkgoFlags,
+					SystemSpecName: s.cfg.SystemSpecName,
+					ExtraEnvs:      s.cfg.ExtraEnvs,
+					RuntimeConfig:  s.cfg.RuntimeConfig,
+				})
+				results <- &TestResult{
+					Output:  output,
+					Err:     err,
+					Host:    host,
+					Runtime: variant.Tag(),
+					ExitOK:  exitOk,
+				}
 			}
-		}(host, host)
+		}(host)
 	}
 	return
 }
@@ -19,8 +19,6 @@ package e2enode
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -34,15 +32,11 @@ import (
 	"github.com/onsi/ginkgo/v2"
 )
 
-var _ = SIGDescribe("Container Runtime Conformance Test", func() {
-	f := framework.NewDefaultFramework("runtime-conformance")
-	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
-
-	ginkgo.Describe("container runtime conformance blackbox test", func() {
-
-		ginkgo.Context("when running a container with a new image", func() {
-			// The service account only has pull permission
-			auth := `
+// authenticatedImagePullingDockerConfig is a docker config.json granting
+// pull-only access to the gcr.io/authenticated-image-pulling project; the
+// service account behind it only has pull permission. It is shared with the
+// standalone-mode image pull tests.
+const authenticatedImagePullingDockerConfig = `
 {
 	"auths": {
 		"https://gcr.io": {
@@ -51,6 +45,14 @@ var _ = SIGDescribe("Container Runtime Conformance Test", func() {
 		}
 	}
 }`
+
+var _ = SIGDescribe("Container Runtime Conformance Test", func() {
+	f := framework.NewDefaultFramework("runtime-conformance")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	ginkgo.Describe("container runtime conformance blackbox test", func() {
+
+		ginkgo.Context("when running a container with a new image", func() {
 			// The following images are not added into NodePrePullImageList, because this test is
 			// testing image pulling, these images don't need to be prepulled. The ImagePullPolicy
 			// is v1.PullAlways, so it won't be blocked by framework image pre-pull list check.
@@ -83,10 +85,9 @@ var _ = SIGDescribe("Container Runtime Conformance Test", func() {
 						RestartPolicy: v1.RestartPolicyNever,
 					}
 
-					configFile := filepath.Join(services.KubeletRootDirectory, "config.json")
-					err := os.WriteFile(configFile, []byte(auth), 0644)
+					err := services.WriteStaticDockerConfig([]byte(authenticatedImagePullingDockerConfig))
 					framework.ExpectNoError(err)
-					defer os.Remove(configFile)
+					defer services.RemoveStaticDockerConfig()
 
 					// checkContainerStatus checks whether the container status matches expectation.
 					checkContainerStatus := func(ctx context.Context) error {
@@ -125,10 +126,9 @@ var _ = SIGDescribe("Container Runtime Conformance Test", func() {
 						}
 						return nil
 					}
-					// The image registry is not stable, which sometimes causes the test to fail. Add retry mechanism to make this
-					// less flaky.
-					const flakeRetry = 3
-					for i := 1; i <= flakeRetry; i++ {
+					// The image registry is not stable, which sometimes causes the test to fail.
+					// Retry any failure through the framework's flake policy to make this less flaky.
+					err = framework.RetryOnFlake(ctx, 3, nil, func(ctx context.Context) error {
 						var err error
 						ginkgo.By("create the container")
 						container.Create(ctx)
@@ -140,15 +140,9 @@ var _ = SIGDescribe("Container Runtime Conformance Test", func() {
 						}
 						ginkgo.By("delete the container")
 						_ = container.Delete(ctx)
-						if err == nil {
-							break
-						}
-						if i < flakeRetry {
-							framework.Logf("No.%d attempt failed: %v, retrying...", i, err)
-						} else {
-							framework.Failf("All %d attempts failed: %v", flakeRetry, err)
-						}
-					}
+						return err
+					})
+					framework.ExpectNoError(err, "checking container status")
 				})
 			}
 		})
@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// The kubelet under test is always started with the exec credential provider
+// flags pointing at a directory this package manages, so tests can install a
+// plugin binary and rewrite the provider config, then restart the kubelet to
+// pick them up. Between tests the config is a placeholder with a no-op
+// provider that never matches a real image, keeping default behavior
+// unchanged.
+
+const (
+	// credentialProviderDirName is the directory under the kubelet root
+	// directory holding the provider binaries and config.
+	credentialProviderDirName = "credential-provider"
+
+	// credentialProviderConfigFileName is the name of the
+	// CredentialProviderConfig file inside the credential provider
+	// directory.
+	credentialProviderConfigFileName = "config.yaml"
+
+	// noopCredentialProviderName is the name of the placeholder plugin the
+	// kubelet is registered with when no test has installed one.
+	noopCredentialProviderName = "e2e-noop-provider"
+)
+
+// noopCredentialProviderScript drains the CredentialProviderRequest and
+// returns no credentials.
+const noopCredentialProviderScript = `#!/bin/sh
+cat > /dev/null
+echo '{"kind":"CredentialProviderResponse","apiVersion":"credentialprovider.kubelet.k8s.io/v1","cacheKeyType":"Registry","auth":{}}'
+`
+
+// noopCredentialProviderConfig registers only the no-op plugin, for a match
+// pattern no real image reference resolves to.
+const noopCredentialProviderConfig = `apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+  - name: ` + noopCredentialProviderName + `
+    matchImages:
+      - "*.invalid"
+    defaultCacheDuration: "1m"
+    apiVersion: credentialprovider.kubelet.k8s.io/v1
+`
+
+// CredentialProviderBinDir returns the directory the kubelet under test
+// looks up credential provider plugin binaries in.
+func CredentialProviderBinDir() string {
+	return filepath.Join(KubeletRootDirectory, credentialProviderDirName)
+}
+
+// CredentialProviderConfigPath returns the path of the
+// CredentialProviderConfig the kubelet under test is started with.
+func CredentialProviderConfigPath() string {
+	return filepath.Join(CredentialProviderBinDir(), credentialProviderConfigFileName)
+}
+
+// setupCredentialProvider prepares the credential provider directory the
+// kubelet is pointed at: the no-op plugin is always (re)installed, and a
+// placeholder config is written if none exists yet.
+func setupCredentialProvider() error {
+	if err := InstallCredentialProviderPlugin(noopCredentialProviderName, []byte(noopCredentialProviderScript)); err != nil {
+		return err
+	}
+	if _, err := os.Stat(CredentialProviderConfigPath()); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return ResetCredentialProviderConfig()
+}
+
+// InstallCredentialProviderPlugin installs an executable credential provider
+// plugin under the given name into the bin dir the kubelet reads. The
+// kubelet registers plugins at startup, so a restart is needed before it is
+// used.
+func InstallCredentialProviderPlugin(name string, contents []byte) error {
+	if err := os.MkdirAll(CredentialProviderBinDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create credential provider directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(CredentialProviderBinDir(), name), contents, 0755); err != nil {
+		return fmt.Errorf("failed to install credential provider plugin %s: %w", name, err)
+	}
+	return nil
+}
+
+// RemoveCredentialProviderPlugin removes a plugin previously installed with
+// InstallCredentialProviderPlugin. Removing a plugin that does not exist is
+// not an error.
+func RemoveCredentialProviderPlugin(name string) error {
+	if err := os.Remove(filepath.Join(CredentialProviderBinDir(), name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// WriteCredentialProviderConfig replaces the CredentialProviderConfig the
+// kubelet is started with. The kubelet reads it at startup, so a restart is
+// needed before it takes effect. Tests must restore the placeholder with
+// ResetCredentialProviderConfig when they are done.
+func WriteCredentialProviderConfig(config []byte) error {
+	if err := os.MkdirAll(CredentialProviderBinDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create credential provider directory: %w", err)
+	}
+	if err := os.WriteFile(CredentialProviderConfigPath(), config, 0644); err != nil {
+		return fmt.Errorf("failed to write credential provider config: %w", err)
+	}
+	return nil
+}
+
+// ResetCredentialProviderConfig restores the placeholder config with the
+// no-op provider.
+func ResetCredentialProviderConfig() error {
+	return WriteCredentialProviderConfig([]byte(noopCredentialProviderConfig))
+}
+
+// staticDockerConfigPath is the config.json keyring file in the kubelet root
+// directory. The kubelet picks it up on every pull without a restart, which
+// makes it the credential source of choice in standalone mode where no API
+// server is available to carry pull secrets.
+func staticDockerConfigPath() string {
+	return filepath.Join(KubeletRootDirectory, "config.json")
+}
+
+// WriteStaticDockerConfig writes a docker config.json into the kubelet root
+// directory so the kubelet's keyring can authenticate pulls with it. Tests
+// must remove it with RemoveStaticDockerConfig when they are done.
+func WriteStaticDockerConfig(auth []byte) error {
+	if err := os.WriteFile(staticDockerConfigPath(), auth, 0644); err != nil {
+		return fmt.Errorf("failed to write static docker config: %w", err)
+	}
+	return nil
+}
+
+// RemoveStaticDockerConfig removes the config.json written by
+// WriteStaticDockerConfig. Removing a config that does not exist is not an
+// error.
+func RemoveStaticDockerConfig() error {
+	if err := os.Remove(staticDockerConfigPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -304,6 +304,17 @@ func (e *E2EServices) startKubelet(featureGates map[string]bool) (*server, error
 		cmdArgs = append(cmdArgs, "--image-service-endpoint", framework.TestContext.ImageServiceEndpoint)
 	}
 
+	// Point the kubelet at the managed credential provider directory, so
+	// tests can swap in an exec plugin and restart the kubelet. The
+	// placeholder registered here never matches a real image.
+	if err := setupCredentialProvider(); err != nil {
+		return nil, err
+	}
+	cmdArgs = append(cmdArgs,
+		"--image-credential-provider-config", CredentialProviderConfigPath(),
+		"--image-credential-provider-bin-dir", CredentialProviderBinDir(),
+	)
+
 	if err := WriteKubeletConfigFile(kc, kubeletConfigPath); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"os/exec"
+	"time"
+
+	kubeletconfig "k8s.io/kubernetes/pkg/kubelet/apis/config"
+	e2enodekubelet "k8s.io/kubernetes/test/e2e_node/kubeletconfig"
+)
+
+// kubeletRestartTimeout bounds how long WithKubeletConfig waits for the
+// kubelet to become healthy again after a restart.
+const kubeletRestartTimeout = 2 * time.Minute
+
+// WithKubeletConfig restarts the kubelet unit with a configuration modified
+// by mutate and returns a function that restores the previous configuration
+// with another restart. Callers register the restore function via
+// DeferCleanup, mirroring ApplyProxyToUnit. unit is the kubelet systemd
+// unit under test. Both restarts block until the kubelet reports healthy
+// again, so callers can proceed immediately.
+func WithKubeletConfig(unit string, mutate func(*kubeletconfig.KubeletConfiguration)) (restore func() error, err error) {
+	oldCfg, err := e2enodekubelet.GetCurrentKubeletConfigFromFile()
+	if err != nil {
+		return nil, fmt.Errorf("reading current kubelet config: %w", err)
+	}
+
+	newCfg := oldCfg.DeepCopy()
+	mutate(newCfg)
+	if err := e2enodekubelet.WriteKubeletConfigFile(newCfg); err != nil {
+		return nil, fmt.Errorf("writing mutated kubelet config: %w", err)
+	}
+	if err := restartKubeletUnit(unit); err != nil {
+		// Leave no half-applied configuration behind.
+		if writeErr := e2enodekubelet.WriteKubeletConfigFile(oldCfg); writeErr == nil {
+			_ = restartKubeletUnit(unit)
+		}
+		return nil, err
+	}
+
+	return func() error {
+		if err := e2enodekubelet.WriteKubeletConfigFile(oldCfg); err != nil {
+			return fmt.Errorf("restoring kubelet config: %w", err)
+		}
+		return restartKubeletUnit(unit)
+	}, nil
+}
+
+// restartKubeletUnit restarts the kubelet service unit, resetting its
+// start-limit-hit counter first so repeated restarts within one test do not
+// leave the unit failed, and waits for the kubelet to report healthy.
+func restartKubeletUnit(unit string) error {
+	if out, err := exec.Command("sudo", "systemctl", "reset-failed", unit).CombinedOutput(); err != nil {
+		return fmt.Errorf("resetting start limit of %s: %v, output: %s", unit, err, out)
+	}
+	if out, err := exec.Command("sudo", "systemctl", "restart", unit).CombinedOutput(); err != nil {
+		return fmt.Errorf("restarting %s: %v, output: %s", unit, err, out)
+	}
+	deadline := time.Now().Add(kubeletRestartTimeout)
+	for time.Now().Before(deadline) {
+		if healthCheck(http.DefaultClient, kubeletHealthCheckURL) {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+	return fmt.Errorf("kubelet did not become healthy within %v of restarting %s", kubeletRestartTimeout, unit)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ProxyConfig is the forward proxy environment applied to node components
+// (kubelet and container runtime) by tests that force image pulls through an
+// HTTP proxy.
+type ProxyConfig struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+}
+
+// environment returns the environment assignments for the config, skipping
+// unset fields.
+func (p ProxyConfig) environment() []string {
+	var env []string
+	if p.HTTPProxy != "" {
+		env = append(env, "HTTP_PROXY="+p.HTTPProxy)
+	}
+	if p.HTTPSProxy != "" {
+		env = append(env, "HTTPS_PROXY="+p.HTTPSProxy)
+	}
+	if p.NoProxy != "" {
+		env = append(env, "NO_PROXY="+p.NoProxy)
+	}
+	return env
+}
+
+// proxyDropInName is the systemd drop-in file installed by ApplyProxyToUnit.
+const proxyDropInName = "99-e2e-proxy.conf"
+
+// ApplyProxyToUnit installs a systemd drop-in setting the proxy environment
+// for the given unit and restarts it so the environment takes effect. The
+// returned restore function removes the drop-in and restarts the unit again;
+// callers must run it on cleanup so the node is returned to its original
+// environment.
+func ApplyProxyToUnit(unit string, cfg ProxyConfig) (restore func() error, err error) {
+	dropInDir := filepath.Join("/etc/systemd/system", unit+".d")
+	dropInFile := filepath.Join(dropInDir, proxyDropInName)
+
+	var lines []string
+	lines = append(lines, "[Service]")
+	for _, assignment := range cfg.environment() {
+		lines = append(lines, fmt.Sprintf("Environment=%q", assignment))
+	}
+	if err := os.MkdirAll(dropInDir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating drop-in directory for %s: %w", unit, err)
+	}
+	if err := os.WriteFile(dropInFile, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		return nil, fmt.Errorf("writing proxy drop-in for %s: %w", unit, err)
+	}
+	if err := reloadAndRestartUnit(unit); err != nil {
+		// Leave no half-applied configuration behind.
+		os.Remove(dropInFile)
+		_ = reloadAndRestartUnit(unit)
+		return nil, err
+	}
+
+	return func() error {
+		if err := os.Remove(dropInFile); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing proxy drop-in for %s: %w", unit, err)
+		}
+		// Best effort: the directory may hold other drop-ins.
+		_ = os.Remove(dropInDir)
+		return reloadAndRestartUnit(unit)
+	}, nil
+}
+
+func reloadAndRestartUnit(unit string) error {
+	if out, err := exec.Command("sudo", "systemctl", "daemon-reload").CombinedOutput(); err != nil {
+		return fmt.Errorf("reloading systemd: %v, output: %s", err, out)
+	}
+	if out, err := exec.Command("sudo", "systemctl", "restart", unit).CombinedOutput(); err != nil {
+		return fmt.Errorf("restarting %s: %v, output: %s", unit, err, out)
+	}
+	return nil
+}
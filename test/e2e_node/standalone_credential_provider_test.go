@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/kubernetes/pkg/kubelet/images"
+	"k8s.io/kubernetes/test/e2e/feature"
+	"k8s.io/kubernetes/test/e2e/framework"
+	"k8s.io/kubernetes/test/e2e_node/services"
+	testutils "k8s.io/kubernetes/test/utils"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+// A reduced conformance subset for standalone kubelets: with no API server
+// there are no pull secrets, so private registry pulls depend entirely on the
+// node-local credential sources (the config.json keyring in the kubelet root
+// directory and exec credential provider plugins). The tests drive the kubelet
+// through static pods and observe status through the read-only /pods endpoint,
+// mirroring how an edge deployment would.
+var _ = SIGDescribe(feature.StandaloneMode, "Private registry pulls", func() {
+	f := framework.NewDefaultFramework("standalone-private-pull")
+	f.NamespacePodSecurityLevel = admissionapi.LevelBaseline
+
+	// privateImage is not in NodePrePullImageList; the test is about pulling
+	// it, and PullAlways keeps any present copy from masking a failure.
+	const privateImage = "gcr.io/authenticated-image-pulling/alpine:3.7"
+
+	ginkgo.Context("when pulling an image from a private registry", func() {
+		var ns, podPath, staticPodName string
+
+		ginkgo.BeforeEach(func() {
+			ns = f.Namespace.Name
+			podPath = kubeletCfg.StaticPodPath
+		})
+
+		ginkgo.It("the pod should run with credentials from config.json", func(ctx context.Context) {
+			staticPodName = "static-private-pull-" + string(uuid.NewUUID())
+
+			err := services.WriteStaticDockerConfig([]byte(authenticatedImagePullingDockerConfig))
+			framework.ExpectNoError(err)
+			ginkgo.DeferCleanup(services.RemoveStaticDockerConfig)
+
+			err = createImagePullStaticPod(podPath, staticPodName, ns, privateImage)
+			framework.ExpectNoError(err)
+
+			gomega.Eventually(ctx, func(ctx context.Context) error {
+				pod, err := getPodFromStandaloneKubelet(ctx, ns, staticPodName)
+				if err != nil {
+					return fmt.Errorf("error getting pod(%v/%v) from standalone kubelet: %v", ns, staticPodName, err)
+				}
+
+				isReady, err := testutils.PodRunningReady(pod)
+				if err != nil {
+					return fmt.Errorf("error checking if pod (%v/%v) is running ready: %v", ns, staticPodName, err)
+				}
+				if !isReady {
+					return fmt.Errorf("pod (%v/%v) is not running", ns, staticPodName)
+				}
+				return nil
+			}, f.Timeouts.PodStart, time.Second*5).Should(gomega.BeNil())
+		})
+
+		ginkgo.It("the pod should report a pull failure without credentials", func(ctx context.Context) {
+			staticPodName = "static-private-pull-denied-" + string(uuid.NewUUID())
+
+			// Make sure no earlier test left credentials behind.
+			framework.ExpectNoError(services.RemoveStaticDockerConfig())
+
+			err := createImagePullStaticPod(podPath, staticPodName, ns, privateImage)
+			framework.ExpectNoError(err)
+
+			gomega.Eventually(ctx, func(ctx context.Context) error {
+				pod, err := getPodFromStandaloneKubelet(ctx, ns, staticPodName)
+				if err != nil {
+					return fmt.Errorf("error getting pod(%v/%v) from standalone kubelet: %v", ns, staticPodName, err)
+				}
+
+				if len(pod.Status.ContainerStatuses) == 0 {
+					return fmt.Errorf("pod (%v/%v) has no container status yet", ns, staticPodName)
+				}
+				waiting := pod.Status.ContainerStatuses[0].State.Waiting
+				if waiting == nil {
+					return fmt.Errorf("expected container of pod (%v/%v) to be waiting", ns, staticPodName)
+				}
+				if waiting.Reason != images.ErrImagePull.Error() &&
+					waiting.Reason != images.ErrImagePullBackOff.Error() {
+					return fmt.Errorf("unexpected waiting reason: %q", waiting.Reason)
+				}
+				return nil
+			}, f.Timeouts.PodStart, time.Second*5).Should(gomega.BeNil())
+		})
+
+		ginkgo.AfterEach(func(ctx context.Context) {
+			ginkgo.By(fmt.Sprintf("delete the static pod (%v/%v)", ns, staticPodName))
+			err := deleteStaticPod(podPath, staticPodName, ns)
+			framework.ExpectNoError(err)
+
+			ginkgo.By(fmt.Sprintf("wait for pod to disappear (%v/%v)", ns, staticPodName))
+			gomega.Eventually(ctx, func(ctx context.Context) error {
+				_, err := getPodFromStandaloneKubelet(ctx, ns, staticPodName)
+
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return fmt.Errorf("pod (%v/%v) still exists", ns, staticPodName)
+			}).Should(gomega.Succeed())
+		})
+	})
+})
+
+// createImagePullStaticPod writes a static pod manifest that always pulls the
+// given image, so the pull path is exercised even when the image is present.
+func createImagePullStaticPod(dir, name, namespace, image string) error {
+	podSpec := &v1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyAlways,
+			Containers: []v1.Container{
+				{
+					Name:            "image-pull",
+					Image:           image,
+					ImagePullPolicy: v1.PullAlways,
+					Command: []string{
+						"/bin/sh", "-c", "while true; do sleep 1; done",
+					},
+				},
+			},
+		},
+	}
+
+	file := staticPodPath(dir, name, namespace)
+	f, err := os.OpenFile(file, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	y := printers.YAMLPrinter{}
+	return y.PrintObj(podSpec, f)
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2enode
+
+import (
+	"context"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/test/e2e/feature"
+	"k8s.io/kubernetes/test/e2e/framework"
+	e2epod "k8s.io/kubernetes/test/e2e/framework/pod"
+	e2eregistry "k8s.io/kubernetes/test/e2e/framework/registry"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+const (
+	// usernsRunAsUser is the in-namespace UID the matrix pods run as. With
+	// hostUsers=false the container must still observe this UID even though
+	// the host-side UID is shifted into the pod's mapping range.
+	usernsRunAsUser = int64(1000)
+
+	usernsRegistryUser     = "e2e-userns-user"
+	usernsRegistryPassword = "e2e-userns-password"
+)
+
+// These tests re-run basic runtime and image pull scenarios with
+// pod.spec.hostUsers=false, so regressions specific to user-namespaced pods
+// (broken idmapped mounts, wrong UID mappings, pulls failing under userns) do
+// not hide behind the host-user-namespace test matrix.
+var _ = SIGDescribe("User namespaces runtime and image matrix", feature.UserNamespacesSupport, framework.WithSerial(), func() {
+	f := framework.NewDefaultFramework("userns-matrix")
+	f.NamespacePodSecurityLevel = admissionapi.LevelPrivileged
+
+	ginkgo.BeforeEach(func(ctx context.Context) {
+		e2epod.SkipIfUserNamespacesUnsupported(ctx, f)
+	})
+
+	newUserNSPod := func(name string, container v1.Container) *v1.Pod {
+		hostUsers := false
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: v1.PodSpec{
+				RestartPolicy: v1.RestartPolicyNever,
+				HostUsers:     &hostUsers,
+				Containers:    []v1.Container{container},
+			},
+		}
+	}
+
+	ginkgo.It("should run the container as the configured UID inside the user namespace", func(ctx context.Context) {
+		podClient := e2epod.NewPodClient(f)
+		runAsUser := usernsRunAsUser
+		pod := podClient.Create(ctx, newUserNSPod("userns-uid-mapping", v1.Container{
+			Name:    "uid-mapping",
+			Image:   imageutils.GetE2EImage(imageutils.BusyBox),
+			Command: []string{"/bin/sh", "-c", "id -u && cat /proc/self/uid_map"},
+			SecurityContext: &v1.SecurityContext{
+				RunAsUser: &runAsUser,
+			},
+		}))
+		ginkgo.DeferCleanup(func(ctx context.Context) {
+			podClient.DeleteSync(ctx, pod.Name, metav1.DeleteOptions{}, e2epod.DefaultPodDeletionTimeout)
+		})
+		podClient.WaitForSuccess(ctx, pod.Name, f.Timeouts.PodStart)
+
+		logs, err := e2epod.GetPodLogs(ctx, f.ClientSet, f.Namespace.Name, pod.Name, "uid-mapping")
+		framework.ExpectNoError(err, "reading the uid mapping output")
+		lines := strings.Split(strings.TrimSpace(logs), "\n")
+		gomega.Expect(lines).ToNot(gomega.BeEmpty(), "expected output from the pod")
+		gomega.Expect(strings.TrimSpace(lines[0])).To(gomega.Equal("1000"),
+			"expected the container to observe the in-namespace UID, got output:\n%s", logs)
+		// The uid_map of a user-namespaced pod covers the default 65536 IDs;
+		// a host-user-namespace pod would show the identity mapping instead.
+		gomega.Expect(logs).To(gomega.ContainSubstring("65536"),
+			"expected a user namespace mapping, got:\n%s", logs)
+
+		// When the runtime reports the identity of the container's first
+		// process, it must be the in-namespace UID, not the shifted host UID.
+		pod, err = podClient.Get(ctx, pod.Name, metav1.GetOptions{})
+		framework.ExpectNoError(err, "getting the pod after it finished")
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.User != nil && status.User.Linux != nil {
+				gomega.Expect(status.User.Linux.UID).To(gomega.Equal(usernsRunAsUser),
+					"container status reports a UID outside the pod's user namespace")
+			}
+		}
+	})
+
+	ginkgo.It("should report container exit codes for user-namespaced pods", func(ctx context.Context) {
+		podClient := e2epod.NewPodClient(f)
+		pod := podClient.Create(ctx, newUserNSPod("userns-exit-code", v1.Container{
+			Name:    "exit-code",
+			Image:   imageutils.GetE2EImage(imageutils.BusyBox),
+			Command: []string{"/bin/sh", "-c", "exit 42"},
+		}))
+		ginkgo.DeferCleanup(func(ctx context.Context) {
+			podClient.DeleteSync(ctx, pod.Name, metav1.DeleteOptions{}, e2epod.DefaultPodDeletionTimeout)
+		})
+		podClient.WaitForFinish(ctx, pod.Name, f.Timeouts.PodStart)
+
+		pod, err := podClient.Get(ctx, pod.Name, metav1.GetOptions{})
+		framework.ExpectNoError(err, "getting the pod after it finished")
+		gomega.Expect(pod.Status.ContainerStatuses).To(gomega.HaveLen(1))
+		terminated := pod.Status.ContainerStatuses[0].State.Terminated
+		if terminated == nil {
+			framework.Failf("expected the container to be terminated, status: %+v", pod.Status.ContainerStatuses[0].State)
+		}
+		gomega.Expect(terminated.ExitCode).To(gomega.Equal(int32(42)),
+			"expected the user-namespaced container's exit code to be reported")
+	})
+
+	ginkgo.It("should pull from an authenticated registry into a user-namespaced pod", func(ctx context.Context) {
+		ginkgo.By("deploying a registry protected by basic auth")
+		reg, err := e2eregistry.SetupRegistry(ctx, f.ClientSet, f.Namespace.Name, e2eregistry.Options{
+			NodeAccess: e2eregistry.LocalNodeAccess{},
+			Auth: &e2eregistry.BasicAuthOptions{
+				Username: usernsRegistryUser,
+				Password: usernsRegistryPassword,
+			},
+		})
+		framework.ExpectNoError(err, "setting up the registry")
+		ginkgo.DeferCleanup(reg.Teardown)
+
+		ginkgo.By("creating a pull secret for the registry")
+		data, err := e2eregistry.DockerConfigJSON(reg.Host(), usernsRegistryUser, usernsRegistryPassword)
+		framework.ExpectNoError(err, "building docker config")
+		secret, err := f.ClientSet.CoreV1().Secrets(f.Namespace.Name).Create(ctx, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "userns-pull-secret"},
+			Type:       v1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{v1.DockerConfigJsonKey: data},
+		}, metav1.CreateOptions{})
+		framework.ExpectNoError(err, "creating pull secret")
+
+		ginkgo.By("running a user-namespaced pod from the private image")
+		podClient := e2epod.NewPodClient(f)
+		pod := newUserNSPod("userns-private-pull", v1.Container{
+			Name:            "pause",
+			Image:           reg.ImageRef("pause:testing"),
+			ImagePullPolicy: v1.PullAlways,
+		})
+		pod.Spec.ImagePullSecrets = []v1.LocalObjectReference{{Name: secret.Name}}
+		pod = podClient.Create(ctx, pod)
+		ginkgo.DeferCleanup(func(ctx context.Context) {
+			podClient.DeleteSync(ctx, pod.Name, metav1.DeleteOptions{}, e2epod.DefaultPodDeletionTimeout)
+		})
+		framework.ExpectNoError(e2epod.WaitTimeoutForPodRunningInNamespace(
+			ctx, f.ClientSet, pod.Name, f.Namespace.Name, framework.PodStartTimeout),
+			"pulling %s into a user-namespaced pod", reg.ImageRef("pause:testing"))
+	})
+})
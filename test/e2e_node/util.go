@@ -64,6 +64,7 @@ import (
 	"k8s.io/kubernetes/test/e2e/framework"
 	e2emetrics "k8s.io/kubernetes/test/e2e/framework/metrics"
 	e2enode "k8s.io/kubernetes/test/e2e/framework/node"
+	"k8s.io/kubernetes/test/e2e_node/criproxy"
 	e2enodekubelet "k8s.io/kubernetes/test/e2e_node/kubeletconfig"
 	imageutils "k8s.io/kubernetes/test/utils/image"
 
@@ -338,6 +339,26 @@ func getCRIClient() (internalapi.RuntimeService, internalapi.ImageManagerService
 	return r, i, nil
 }
 
+// addCRIProxyInjector registers an injector on the suite CRI proxy. It
+// returns an error when the suite is not running with the CRI proxy enabled,
+// which tests should treat as a reason to skip.
+func addCRIProxyInjector(proxy *criproxy.RemoteRuntime, injector func(apiName string) error) error {
+	if proxy == nil {
+		return fmt.Errorf("failed to add injector because the CRI Proxy is undefined")
+	}
+	proxy.AddInjector(injector)
+	return nil
+}
+
+// resetCRIProxyInjector removes all injectors from the suite CRI proxy.
+func resetCRIProxyInjector(proxy *criproxy.RemoteRuntime) error {
+	if proxy == nil {
+		return fmt.Errorf("failed to reset injector because the CRI Proxy is undefined")
+	}
+	proxy.ResetInjectors()
+	return nil
+}
+
 // findKubeletServiceName searches the unit name among the services known to systemd.
 // if the `running` parameter is true, restricts the search among currently running services;
 // otherwise, also stopped, failed, exited (non-running in general) services are also considered.
@@ -28,6 +28,7 @@ import (
 	"k8s.io/kubernetes/test/images/agnhost/dns"
 	"k8s.io/kubernetes/test/images/agnhost/entrypoint-tester"
 	"k8s.io/kubernetes/test/images/agnhost/fakegitserver"
+	forwardproxy "k8s.io/kubernetes/test/images/agnhost/forward-proxy"
 	grpchealthchecking "k8s.io/kubernetes/test/images/agnhost/grpc-health-checking"
 	"k8s.io/kubernetes/test/images/agnhost/guestbook"
 	"k8s.io/kubernetes/test/images/agnhost/inclusterclient"
@@ -43,6 +44,8 @@ import (
 	"k8s.io/kubernetes/test/images/agnhost/pause"
 	portforwardtester "k8s.io/kubernetes/test/images/agnhost/port-forward-tester"
 	"k8s.io/kubernetes/test/images/agnhost/porter"
+	registryauthproxy "k8s.io/kubernetes/test/images/agnhost/registry-auth-proxy"
+	registryfaultproxy "k8s.io/kubernetes/test/images/agnhost/registry-fault-proxy"
 	resconsumerctrl "k8s.io/kubernetes/test/images/agnhost/resource-consumer-controller"
 	servehostname "k8s.io/kubernetes/test/images/agnhost/serve-hostname"
 	tcpreset "k8s.io/kubernetes/test/images/agnhost/tcp-reset"
@@ -67,6 +70,7 @@ func main() {
 	rootCmd.AddCommand(dns.CmdEtcHosts)
 	rootCmd.AddCommand(entrypoint.CmdEntrypointTester)
 	rootCmd.AddCommand(fakegitserver.CmdFakeGitServer)
+	rootCmd.AddCommand(forwardproxy.CmdForwardProxy)
 	rootCmd.AddCommand(guestbook.CmdGuestbook)
 	rootCmd.AddCommand(inclusterclient.CmdInClusterClient)
 	rootCmd.AddCommand(liveness.CmdLiveness)
@@ -80,6 +84,8 @@ func main() {
 	rootCmd.AddCommand(pause.CmdPause)
 	rootCmd.AddCommand(porter.CmdPorter)
 	rootCmd.AddCommand(portforwardtester.CmdPortForwardTester)
+	rootCmd.AddCommand(registryauthproxy.CmdRegistryAuthProxy)
+	rootCmd.AddCommand(registryfaultproxy.CmdRegistryFaultProxy)
 	rootCmd.AddCommand(resconsumerctrl.CmdResourceConsumerController)
 	rootCmd.AddCommand(servehostname.CmdServeHostname)
 	rootCmd.AddCommand(testwebserver.CmdTestWebserver)
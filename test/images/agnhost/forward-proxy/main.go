@@ -0,0 +1,198 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package forwardproxy implements a forward HTTP proxy. The e2e registry
+// framework (test/e2e/framework/registry) deploys it to verify that node
+// components honor HTTP(S)_PROXY and NO_PROXY when pulling images.
+package forwardproxy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// CmdForwardProxy is used by agnhost Cobra.
+var CmdForwardProxy = &cobra.Command{
+	Use:   "forward-proxy",
+	Short: "Forward HTTP proxy supporting CONNECT and optional basic auth",
+	Long: `Serves a forward HTTP proxy handling both plain absolute-URI requests and
+CONNECT tunnels. When PROXY_AUTH_USER and PROXY_AUTH_PASSWORD are set, requests
+must carry matching basic proxy credentials and are answered with 407
+otherwise. Every request is logged to stdout as one JSON line whose path field
+carries the full request target (the CONNECT host or absolute URI).`,
+	Args: cobra.MaximumNArgs(0),
+	Run:  main,
+}
+
+var port int
+
+func init() {
+	CmdForwardProxy.Flags().IntVar(&port, "port", 3128, "Port to listen on.")
+}
+
+// accessLogEntry is the JSON line logged per request. The field names match
+// what ParseAccessLog of the e2e registry framework expects; the path field
+// carries the request target.
+type accessLogEntry struct {
+	Method           string `json:"method"`
+	Path             string `json:"path"`
+	HasAuthorization bool   `json:"hasAuthorization"`
+	BasicAuthUser    string `json:"basicAuthUser"`
+}
+
+// proxy is the forward proxy handler with its optional credentials.
+type proxy struct {
+	authUser     string
+	authPassword string
+	transport    *http.Transport
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	target := req.URL.String()
+	if req.Method == http.MethodConnect {
+		target = req.Host
+	}
+	p.logRequest(req, target)
+
+	if !p.authorized(req) {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="e2e-forward-proxy"`)
+		http.Error(w, "proxy authentication required", http.StatusProxyAuthRequired)
+		return
+	}
+	if req.Method == http.MethodConnect {
+		p.tunnel(w, req)
+		return
+	}
+	p.forward(w, req)
+}
+
+// logRequest writes the access log line for one request to stdout. The
+// credentials are reduced to header presence and the proxy basic-auth
+// username, so secrets are never logged.
+func (p *proxy) logRequest(req *http.Request, target string) {
+	entry := accessLogEntry{
+		Method:           req.Method,
+		Path:             target,
+		HasAuthorization: req.Header.Get("Proxy-Authorization") != "",
+	}
+	if user, _, ok := proxyBasicAuth(req); ok {
+		entry.BasicAuthUser = user
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(os.Stdout, string(line))
+}
+
+// authorized reports whether the request may use the proxy.
+func (p *proxy) authorized(req *http.Request) bool {
+	if p.authUser == "" {
+		return true
+	}
+	user, password, ok := proxyBasicAuth(req)
+	return ok && user == p.authUser && password == p.authPassword
+}
+
+// proxyBasicAuth parses the Proxy-Authorization header, which
+// http.Request.BasicAuth does not cover.
+func proxyBasicAuth(req *http.Request) (user, password string, ok bool) {
+	const prefix = "Basic "
+	value := req.Header.Get("Proxy-Authorization")
+	if !strings.HasPrefix(value, prefix) {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(value[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	user, password, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return "", "", false
+	}
+	return user, password, true
+}
+
+// tunnel serves a CONNECT request by dialing the target and splicing the
+// client connection to it.
+func (p *proxy) tunnel(w http.ResponseWriter, req *http.Request) {
+	upstream, err := net.Dial("tcp", req.Host)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("dialing %s: %v", req.Host, err), http.StatusBadGateway)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "connection cannot be hijacked", http.StatusInternalServerError)
+		return
+	}
+	client, _, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	fmt.Fprintf(client, "HTTP/1.1 200 Connection Established\r\n\r\n")
+	go splice(upstream, client)
+	go splice(client, upstream)
+}
+
+func splice(dst io.WriteCloser, src io.ReadCloser) {
+	defer dst.Close()
+	defer src.Close()
+	_, _ = io.Copy(dst, src)
+}
+
+// forward serves a plain absolute-URI proxy request by performing it
+// against the origin and copying the response back.
+func (p *proxy) forward(w http.ResponseWriter, req *http.Request) {
+	outbound := req.Clone(req.Context())
+	outbound.RequestURI = ""
+	outbound.Header.Del("Proxy-Authorization")
+	resp, err := p.transport.RoundTrip(outbound)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("forwarding to %s: %v", req.URL.Host, err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for name, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+func main(cmd *cobra.Command, args []string) {
+	p := &proxy{
+		authUser:     os.Getenv("PROXY_AUTH_USER"),
+		authPassword: os.Getenv("PROXY_AUTH_PASSWORD"),
+		transport:    &http.Transport{Proxy: nil},
+	}
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), p); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
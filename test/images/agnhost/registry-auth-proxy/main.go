@@ -0,0 +1,270 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registryauthproxy implements an authorizing reverse proxy for a
+// container image registry. The e2e registry framework
+// (test/e2e/framework/registry) runs it next to a registry container to
+// serve multiple users with per-repository scopes under both basic auth
+// and the Docker registry bearer-token flow.
+package registryauthproxy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// CmdRegistryAuthProxy is used by agnhost Cobra.
+var CmdRegistryAuthProxy = &cobra.Command{
+	Use:   "registry-auth-proxy",
+	Short: "Authorizing reverse proxy for a container image registry",
+	Long: `Fronts a container image registry and authorizes every request against a set of
+users with per-repository scopes. AUTH_UPSTREAM_URL (required) is the registry
+to proxy to; AUTH_USERS (required) is a JSON array of users of the form
+{"name":...,"password":...,"scopes":[{"repository":...,"access":"pull"}]},
+where access is "none", "pull" or "push,pull" and repository "*" matches every
+repository. Requests authenticate with basic auth or with bearer tokens from
+the /token endpoint, whose URL is advertised as the realm of the proxy's
+WWW-Authenticate challenges; both are checked against the same scopes. Every
+request is logged to stdout as one JSON line.`,
+	Args: cobra.MaximumNArgs(0),
+	Run:  main,
+}
+
+var port int
+
+func init() {
+	CmdRegistryAuthProxy.Flags().IntVar(&port, "port", 5002, "Port to listen on.")
+}
+
+// tokenEndpointPath is the path of the bearer-token endpoint.
+const tokenEndpointPath = "/token"
+
+// user mirrors the User type of the e2e registry framework.
+type user struct {
+	Name     string  `json:"name"`
+	Password string  `json:"password"`
+	Scopes   []scope `json:"scopes,omitempty"`
+}
+
+// scope grants one access level on one repository.
+type scope struct {
+	Repository string `json:"repository"`
+	Access     string `json:"access"`
+}
+
+// accessLogEntry is the JSON line logged per request. The field names match
+// what ParseAccessLog of the e2e registry framework expects.
+type accessLogEntry struct {
+	Method           string `json:"method"`
+	Path             string `json:"path"`
+	HasAuthorization bool   `json:"hasAuthorization"`
+	BasicAuthUser    string `json:"basicAuthUser"`
+}
+
+func logRequest(req *http.Request, basicUser string) {
+	entry := accessLogEntry{
+		Method:           req.Method,
+		Path:             req.URL.Path,
+		HasAuthorization: req.Header.Get("Authorization") != "",
+		BasicAuthUser:    basicUser,
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(os.Stdout, string(line))
+}
+
+// authProxy authorizes registry requests against the configured users and
+// proxies permitted ones to the upstream registry.
+type authProxy struct {
+	users   map[string]user
+	forward *httputil.ReverseProxy
+
+	// mu guards tokens, the bearer tokens issued by the token endpoint,
+	// keyed by token value.
+	mu     sync.Mutex
+	tokens map[string]user
+}
+
+func newAuthProxy(users []user, upstream *url.URL) *authProxy {
+	p := &authProxy{
+		users:   map[string]user{},
+		tokens:  map[string]user{},
+		forward: httputil.NewSingleHostReverseProxy(upstream),
+	}
+	for _, u := range users {
+		p.users[u.Name] = u
+	}
+	return p
+}
+
+func (p *authProxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	basicUser, _, _ := req.BasicAuth()
+	logRequest(req, basicUser)
+
+	if req.URL.Path == tokenEndpointPath {
+		p.serveToken(w, req)
+		return
+	}
+	account, ok := p.authenticate(req)
+	if !ok {
+		p.challenge(w, req)
+		return
+	}
+	repository := repositoryForPath(req.URL.Path)
+	if repository != "" && !allows(account, repository, actionForMethod(req.Method)) {
+		http.Error(w, fmt.Sprintf("user %q is not authorized for repository %q", account.Name, repository), http.StatusForbidden)
+		return
+	}
+	p.forward.ServeHTTP(w, req)
+}
+
+// serveToken implements the Docker registry token flow: it authenticates
+// the user with basic auth and issues an opaque bearer token carrying the
+// user's scopes.
+func (p *authProxy) serveToken(w http.ResponseWriter, req *http.Request) {
+	account, ok := p.authenticateBasic(req)
+	if !ok {
+		p.challenge(w, req)
+		return
+	}
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		http.Error(w, "generating token", http.StatusInternalServerError)
+		return
+	}
+	token := hex.EncodeToString(raw)
+	p.mu.Lock()
+	p.tokens[token] = account
+	p.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"token": token})
+}
+
+// authenticate resolves the request's credentials, either basic auth or a
+// bearer token issued by the token endpoint.
+func (p *authProxy) authenticate(req *http.Request) (user, bool) {
+	if account, ok := p.authenticateBasic(req); ok {
+		return account, true
+	}
+	value := req.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(value, prefix) {
+		return user{}, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	account, ok := p.tokens[value[len(prefix):]]
+	return account, ok
+}
+
+func (p *authProxy) authenticateBasic(req *http.Request) (user, bool) {
+	name, password, ok := req.BasicAuth()
+	if !ok {
+		return user{}, false
+	}
+	account, ok := p.users[name]
+	if !ok || account.Password != password {
+		return user{}, false
+	}
+	return account, true
+}
+
+// challenge answers an unauthenticated request with both auth schemes the
+// proxy serves. The bearer realm is the proxy's own token endpoint, derived
+// from the request so it works under whatever endpoint the proxy is
+// reached through.
+func (p *authProxy) challenge(w http.ResponseWriter, req *http.Request) {
+	realm := fmt.Sprintf("http://%s%s", req.Host, tokenEndpointPath)
+	w.Header().Add("WWW-Authenticate", fmt.Sprintf("Bearer realm=%q,service=%q", realm, req.Host))
+	w.Header().Add("WWW-Authenticate", `Basic realm="e2e-registry"`)
+	http.Error(w, "authentication required", http.StatusUnauthorized)
+}
+
+// repositoryForPath extracts the repository name from a registry API path,
+// e.g. "/v2/team-a/app/manifests/v1" yields "team-a/app". The version check
+// endpoint "/v2/" has no repository; it only requires authentication.
+func repositoryForPath(path string) string {
+	trimmed := strings.TrimPrefix(path, "/v2/")
+	if trimmed == path || trimmed == "" {
+		return ""
+	}
+	for _, marker := range []string{"/manifests/", "/blobs/", "/tags/", "/referrers/"} {
+		if idx := strings.Index(trimmed, marker); idx >= 0 {
+			return trimmed[:idx]
+		}
+	}
+	return ""
+}
+
+// actionForMethod maps an HTTP method to the registry action it performs.
+func actionForMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return "pull"
+	default:
+		return "push"
+	}
+}
+
+// allows reports whether the user's scopes grant the action on the
+// repository. Repositories without a scope are denied.
+func allows(account user, repository, action string) bool {
+	for _, s := range account.Scopes {
+		if s.Repository != "*" && s.Repository != repository {
+			continue
+		}
+		for _, granted := range strings.Split(s.Access, ",") {
+			if granted == action {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func main(cmd *cobra.Command, args []string) {
+	rawUpstream := os.Getenv("AUTH_UPSTREAM_URL")
+	if rawUpstream == "" {
+		fmt.Fprintln(os.Stderr, "AUTH_UPSTREAM_URL must be set")
+		os.Exit(1)
+	}
+	upstream, err := url.Parse(rawUpstream)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid AUTH_UPSTREAM_URL %q: %v\n", rawUpstream, err)
+		os.Exit(1)
+	}
+	var users []user
+	if err := json.Unmarshal([]byte(os.Getenv("AUTH_USERS")), &users); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid AUTH_USERS: %v\n", err)
+		os.Exit(1)
+	}
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), newAuthProxy(users, upstream)); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registryfaultproxy implements a fault-injecting reverse proxy for
+// a container image registry. The e2e registry framework
+// (test/e2e/framework/registry) runs it next to a registry container to
+// drive image pull error paths deterministically.
+package registryfaultproxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// CmdRegistryFaultProxy is used by agnhost Cobra.
+var CmdRegistryFaultProxy = &cobra.Command{
+	Use:   "registry-fault-proxy",
+	Short: "Fault-injecting reverse proxy for a container image registry",
+	Long: `Fronts a container image registry and applies the faults configured through the
+FAULT_* environment variables: FAULT_UPSTREAM_URL (required) is the registry to
+proxy to; FAULT_DROP_REQUESTS drops the first N matching requests by closing the
+connection; FAULT_FAIL_STATUS and FAULT_FAIL_COUNT answer the next N matching
+requests with the given status; FAULT_RATE_LIMIT_AFTER lets N matching requests
+through and answers later ones with 429, with FAULT_RETRY_AFTER_SECONDS as the
+Retry-After header; FAULT_BANDWIDTH_BYTES_PER_SEC throttles response bodies;
+FAULT_CORRUPT_BLOBS flips a byte in every blob response body; FAULT_PATH_PREFIX
+restricts all faults to requests whose path starts with the prefix. Every
+request is logged to stdout as one JSON line with its method, path and
+credential presence.`,
+	Args: cobra.MaximumNArgs(0),
+	Run:  main,
+}
+
+var port int
+
+func init() {
+	CmdRegistryFaultProxy.Flags().IntVar(&port, "port", 5001, "Port to listen on.")
+}
+
+// faultConfig is the fault schedule read from the FAULT_* environment. It
+// mirrors FaultOptions of the e2e registry framework.
+type faultConfig struct {
+	dropRequests         int
+	failStatus           int
+	failCount            int
+	bandwidthBytesPerSec int64
+	corruptBlobs         bool
+	rateLimitAfter       int
+	retryAfterSeconds    int
+	pathPrefix           string
+}
+
+func configFromEnv() (faultConfig, error) {
+	cfg := faultConfig{pathPrefix: os.Getenv("FAULT_PATH_PREFIX")}
+	for _, entry := range []struct {
+		name  string
+		value *int
+	}{
+		{"FAULT_DROP_REQUESTS", &cfg.dropRequests},
+		{"FAULT_FAIL_STATUS", &cfg.failStatus},
+		{"FAULT_FAIL_COUNT", &cfg.failCount},
+		{"FAULT_RATE_LIMIT_AFTER", &cfg.rateLimitAfter},
+		{"FAULT_RETRY_AFTER_SECONDS", &cfg.retryAfterSeconds},
+	} {
+		raw := os.Getenv(entry.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid %s %q: %w", entry.name, raw, err)
+		}
+		*entry.value = value
+	}
+	if raw := os.Getenv("FAULT_BANDWIDTH_BYTES_PER_SEC"); raw != "" {
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid FAULT_BANDWIDTH_BYTES_PER_SEC %q: %w", raw, err)
+		}
+		cfg.bandwidthBytesPerSec = value
+	}
+	cfg.corruptBlobs = os.Getenv("FAULT_CORRUPT_BLOBS") == "true"
+	return cfg, nil
+}
+
+// accessLogEntry is the JSON line logged per request. The field names match
+// what ParseAccessLog of the e2e registry framework expects.
+type accessLogEntry struct {
+	Method           string `json:"method"`
+	Path             string `json:"path"`
+	HasAuthorization bool   `json:"hasAuthorization"`
+	BasicAuthUser    string `json:"basicAuthUser"`
+}
+
+// logRequest writes the access log line for one request to stdout.
+// Credential material is reduced to header presence and the basic-auth
+// username, so secrets are never logged.
+func logRequest(req *http.Request, target string) {
+	entry := accessLogEntry{
+		Method:           req.Method,
+		Path:             target,
+		HasAuthorization: req.Header.Get("Authorization") != "",
+	}
+	if user, _, ok := req.BasicAuth(); ok {
+		entry.BasicAuthUser = user
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(os.Stdout, string(line))
+}
+
+// faultProxy applies the configured fault schedule to matching requests and
+// proxies everything else through to the upstream registry.
+type faultProxy struct {
+	cfg     faultConfig
+	forward *httputil.ReverseProxy
+
+	// mu guards the per-fault counters below.
+	mu      sync.Mutex
+	dropped int
+	failed  int
+	allowed int
+}
+
+func newFaultProxy(cfg faultConfig, upstream *url.URL) *faultProxy {
+	p := &faultProxy{cfg: cfg}
+	p.forward = httputil.NewSingleHostReverseProxy(upstream)
+	p.forward.ModifyResponse = p.modifyResponse
+	return p
+}
+
+func (p *faultProxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	logRequest(req, req.URL.Path)
+	if p.cfg.pathPrefix != "" && !strings.HasPrefix(req.URL.Path, p.cfg.pathPrefix) {
+		p.forward.ServeHTTP(w, req)
+		return
+	}
+	switch p.next() {
+	case faultDrop:
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			// Fall back to an empty reply when the connection cannot be
+			// taken over; the pull still fails.
+			return
+		}
+		if conn, _, err := hijacker.Hijack(); err == nil {
+			conn.Close()
+		}
+	case faultFail:
+		http.Error(w, fmt.Sprintf("injected fault: status %d", p.cfg.failStatus), p.cfg.failStatus)
+	case faultRateLimit:
+		if p.cfg.retryAfterSeconds > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(p.cfg.retryAfterSeconds))
+		}
+		http.Error(w, "injected fault: rate limited", http.StatusTooManyRequests)
+	default:
+		p.forward.ServeHTTP(w, req)
+	}
+}
+
+type faultAction int
+
+const (
+	faultNone faultAction = iota
+	faultDrop
+	faultFail
+	faultRateLimit
+)
+
+// next consumes the fault schedule for one matching request: the first
+// DropRequests are dropped, the next FailCount answered with FailStatus,
+// and once RateLimitAfter requests passed through every later one is rate
+// limited.
+func (p *faultProxy) next() faultAction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dropped < p.cfg.dropRequests {
+		p.dropped++
+		return faultDrop
+	}
+	if p.cfg.failStatus != 0 && p.failed < p.cfg.failCount {
+		p.failed++
+		return faultFail
+	}
+	if p.cfg.rateLimitAfter > 0 && p.allowed >= p.cfg.rateLimitAfter {
+		return faultRateLimit
+	}
+	p.allowed++
+	return faultNone
+}
+
+// modifyResponse wraps proxied response bodies for bandwidth throttling and
+// blob corruption.
+func (p *faultProxy) modifyResponse(resp *http.Response) error {
+	body := resp.Body
+	if p.cfg.corruptBlobs && strings.Contains(resp.Request.URL.Path, "/blobs/") {
+		body = &corruptingReader{body: body}
+	}
+	if p.cfg.bandwidthBytesPerSec > 0 {
+		body = &throttledReader{body: body, bytesPerSec: p.cfg.bandwidthBytesPerSec}
+	}
+	resp.Body = body
+	return nil
+}
+
+// corruptingReader flips a bit in the first byte it reads, so the blob
+// fails digest verification after the transfer completes.
+type corruptingReader struct {
+	body      io.ReadCloser
+	corrupted bool
+}
+
+func (r *corruptingReader) Read(b []byte) (int, error) {
+	n, err := r.body.Read(b)
+	if n > 0 && !r.corrupted {
+		b[0] ^= 0x01
+		r.corrupted = true
+	}
+	return n, err
+}
+
+func (r *corruptingReader) Close() error {
+	return r.body.Close()
+}
+
+// throttledReader limits reads to roughly bytesPerSec by sleeping after
+// each chunk.
+type throttledReader struct {
+	body        io.ReadCloser
+	bytesPerSec int64
+}
+
+func (r *throttledReader) Read(b []byte) (int, error) {
+	chunk := r.bytesPerSec / 10
+	if chunk < 1 {
+		chunk = 1
+	}
+	if int64(len(b)) > chunk {
+		b = b[:chunk]
+	}
+	n, err := r.body.Read(b)
+	if n > 0 {
+		time.Sleep(time.Duration(float64(n) / float64(r.bytesPerSec) * float64(time.Second)))
+	}
+	return n, err
+}
+
+func (r *throttledReader) Close() error {
+	return r.body.Close()
+}
+
+func main(cmd *cobra.Command, args []string) {
+	rawUpstream := os.Getenv("FAULT_UPSTREAM_URL")
+	if rawUpstream == "" {
+		fmt.Fprintln(os.Stderr, "FAULT_UPSTREAM_URL must be set")
+		os.Exit(1)
+	}
+	upstream, err := url.Parse(rawUpstream)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid FAULT_UPSTREAM_URL %q: %v\n", rawUpstream, err)
+		os.Exit(1)
+	}
+	cfg, err := configFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), newFaultProxy(cfg, upstream)); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}